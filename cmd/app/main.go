@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	_ "time/tzdata" // Doctors' IANA time zones must resolve in images without zoneinfo.
 
 	"github.com/dostonshernazarov/doctor-appointment/config"
 	"github.com/dostonshernazarov/doctor-appointment/internal/app"
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -9,13 +10,18 @@ import (
 type (
 	// Config -.
 	Config struct {
-		App     App
-		HTTP    HTTP
-		Log     Log
-		PG      PG
-		Metrics Metrics
-		Swagger Swagger
-		Jwt     Jwt
+		App       App
+		HTTP      HTTP
+		Log       Log
+		PG        PG
+		Metrics   Metrics
+		Swagger   Swagger
+		Jwt       Jwt
+		Waitlist  Waitlist
+		Mail      Mail
+		Login     Login
+		TwoFactor TwoFactor
+		OIDC      OIDC
 	}
 
 	// App -.
@@ -28,6 +34,10 @@ type (
 	HTTP struct {
 		Port           string `env:"HTTP_PORT,required"`
 		UsePreforkMode bool   `env:"HTTP_USE_PREFORK_MODE" envDefault:"false"`
+		// ProxyHeader holds the client IP set by a reverse proxy. It is only read from requests
+		// coming from TrustedProxies, addresses or CIDR ranges; others count by their own address.
+		ProxyHeader    string   `env:"HTTP_PROXY_HEADER" envDefault:"X-Real-IP"`
+		TrustedProxies []string `env:"HTTP_TRUSTED_PROXIES"`
 	}
 
 	// Log -.
@@ -50,10 +60,73 @@ type (
 	}
 
 	Jwt struct {
-		Admin     string `env:"ROLE_ADMIN,required"`
-		User      string `env:"ROLE_USER,required"`
-		Secret    string `env:"JWT_SECRET,required"`
-		ExpiresAt int    `env:"JWT_EXPIRES_AT,required"`
+		// Keys maps key ids to PEM files of RSA or Ed25519 keys, e.g. "2026-01=/keys/2026-01.pem".
+		// The signing key needs its private key; the others may be public keys only verifying
+		// tokens they signed before a rotation.
+		Keys         map[string]string `env:"JWT_KEYS" envKeyValSeparator:"="`
+		SigningKeyID string            `env:"JWT_SIGNING_KEY_ID"`
+		// Secret signs with HS256 when no Keys are configured, for local development.
+		Secret    string `env:"JWT_SECRET"`
+		ExpiresAt int    `env:"JWT_EXPIRES_AT,required"` // access token lifetime, in seconds
+		// RefreshExpiresAt is how long a session lasts without its refresh token being used, in seconds.
+		RefreshExpiresAt int `env:"JWT_REFRESH_EXPIRES_AT" envDefault:"2592000"`
+	}
+
+	// Waitlist -.
+	Waitlist struct {
+		Hold          time.Duration `env:"WAITLIST_HOLD" envDefault:"30m"`
+		SweepInterval time.Duration `env:"WAITLIST_SWEEP_INTERVAL" envDefault:"1m"`
+	}
+
+	// Mail - where password reset and verification mail goes. Driver is smtp, file or log.
+	Mail struct {
+		Driver       string `env:"MAIL_DRIVER" envDefault:"log"`
+		From         string `env:"MAIL_FROM" envDefault:"no-reply@localhost"`
+		SMTPHost     string `env:"MAIL_SMTP_HOST"`
+		SMTPPort     string `env:"MAIL_SMTP_PORT" envDefault:"587"`
+		SMTPUsername string `env:"MAIL_SMTP_USERNAME"`
+		SMTPPassword string `env:"MAIL_SMTP_PASSWORD"`
+		FilePath     string `env:"MAIL_FILE_PATH" envDefault:"mail.log"`
+		// LinkBaseURL is the address of this API, where email verification links point.
+		// PasswordResetURL is the frontend page reset links point to; the API has no page to
+		// choose a password on. It gets the token query parameter and posts it with the new
+		// password to /v1/auth/reset-password.
+		LinkBaseURL          string        `env:"MAIL_LINK_BASE_URL" envDefault:"http://localhost:8070"`
+		PasswordResetURL     string        `env:"MAIL_PASSWORD_RESET_URL" envDefault:"http://localhost:3000/reset-password"`
+		PasswordResetTTL     time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+		EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"48h"`
+	}
+
+	// Login - failed sign-ins per account and per client IP. After the n-th failure the next
+	// attempt waits BaseDelay·2^(n-1), up to MaxDelay; a threshold of failures within
+	// FailureWindow locks the account or IP for LockoutDuration.
+	Login struct {
+		AccountThreshold int           `env:"LOGIN_ACCOUNT_THRESHOLD" envDefault:"5"`
+		IPThreshold      int           `env:"LOGIN_IP_THRESHOLD" envDefault:"20"`
+		FailureWindow    time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+		BaseDelay        time.Duration `env:"LOGIN_BASE_DELAY" envDefault:"1s"`
+		MaxDelay         time.Duration `env:"LOGIN_MAX_DELAY" envDefault:"30s"`
+		LockoutDuration  time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+	}
+
+	// TwoFactor - Issuer is the name authenticator apps list codes under; APP_NAME if empty.
+	TwoFactor struct {
+		Issuer       string        `env:"TWO_FACTOR_ISSUER"`
+		ChallengeTTL time.Duration `env:"TWO_FACTOR_CHALLENGE_TTL" envDefault:"5m"`
+	}
+
+	// OIDC - single sign-on through an OpenID Connect provider, off while Issuer is empty.
+	// GroupRoles maps provider groups to roles as "group=role", most privileged first; users in
+	// none of the groups get DefaultRole, or can't sign in if it is empty.
+	OIDC struct {
+		Issuer       string   `env:"OIDC_ISSUER"`
+		ClientID     string   `env:"OIDC_CLIENT_ID"`
+		ClientSecret string   `env:"OIDC_CLIENT_SECRET"`
+		RedirectURL  string   `env:"OIDC_REDIRECT_URL" envDefault:"http://localhost:8070/v1/auth/oidc/callback"`
+		Scopes       []string `env:"OIDC_SCOPES" envDefault:"openid,email,profile"`
+		GroupsClaim  string   `env:"OIDC_GROUPS_CLAIM" envDefault:"groups"`
+		GroupRoles   []string `env:"OIDC_GROUP_ROLES"`
+		DefaultRole  string   `env:"OIDC_DEFAULT_ROLE"`
 	}
 )
 
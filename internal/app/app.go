@@ -1,18 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/config"
 	v1 "github.com/dostonshernazarov/doctor-appointment/internal/controller/http"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/internal/repo/persistent"
 	"github.com/dostonshernazarov/doctor-appointment/internal/usecase/common"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/httpserver"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/mailer"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
 )
 
 func Run(cfg *config.Config) {
@@ -25,14 +32,74 @@ func Run(cfg *config.Config) {
 	}
 	defer pg.Close()
 
+	// Access tokens
+	keys, err := signingKeys(cfg.Jwt, l)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - signingKeys: %w", err))
+	}
+
+	// Mail
+	var mail mailer.Mailer
+
+	switch cfg.Mail.Driver {
+	case "smtp":
+		mail = mailer.NewSMTP(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.From, mailer.Auth(cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword))
+	case "file":
+		mail = mailer.NewFile(cfg.Mail.FilePath)
+	default:
+		mail = mailer.NewLog(l)
+	}
+
+	// Single sign-on
+	sso, err := ssoOption(cfg.OIDC)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - ssoOption: %w", err))
+	}
+
 	// Use case
 	usecaseCommon := common.NewUseCase(
 		persistent.NewUser(pg),
 		persistent.NewDoctor(pg),
 		persistent.NewAppointment(pg),
+		persistent.NewSchedule(pg),
+		persistent.NewWaitlist(pg),
+		persistent.NewRole(pg),
+		persistent.NewSession(pg),
+		persistent.NewLogin(pg),
+		persistent.NewTwoFactor(pg),
+		persistent.NewIdentity(pg),
+		persistent.NewAPIKey(pg),
+		persistent.NewPatient(pg),
+		persistent.NewSpecialization(pg),
+		persistent.NewAppointmentType(pg),
+		persistent.NewClinic(pg),
+		common.WaitlistHold(cfg.Waitlist.Hold),
+		common.RefreshTokenTTL(time.Duration(cfg.Jwt.RefreshExpiresAt)*time.Second),
+		common.Mailer(mail),
+		common.LinkBaseURL(cfg.Mail.LinkBaseURL),
+		common.PasswordResetURL(cfg.Mail.PasswordResetURL),
+		common.UserTokenTTLs(cfg.Mail.PasswordResetTTL, cfg.Mail.EmailVerificationTTL),
+		common.LoginPolicies(
+			loginPolicy(cfg.Login, cfg.Login.AccountThreshold),
+			loginPolicy(cfg.Login, cfg.Login.IPThreshold),
+		),
+		common.TwoFactor(twoFactorIssuer(cfg), cfg.TwoFactor.ChallengeTTL),
+		sso,
+		common.Logger(l),
 	)
 
-	httpServer := httpserver.New(httpserver.Port(cfg.HTTP.Port), httpserver.Prefork(cfg.HTTP.UsePreforkMode))
+	// Waitlist offers that ran out move on to the next patient
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go usecaseCommon.RunWaitlistExpiry(ctx, cfg.Waitlist.SweepInterval)
+
+	httpServer := httpserver.New(
+		httpserver.Port(cfg.HTTP.Port),
+		httpserver.Prefork(cfg.HTTP.UsePreforkMode),
+		httpserver.ProxyHeader(cfg.HTTP.ProxyHeader),
+		httpserver.TrustedProxies(cfg.HTTP.TrustedProxies),
+	)
 	v1.NewRouter(v1.NewRouterConfig(
 		httpServer.App,
 		cfg,
@@ -40,6 +107,19 @@ func Run(cfg *config.Config) {
 		usecaseCommon,
 		usecaseCommon,
 		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		usecaseCommon,
+		keys,
 	))
 
 	httpServer.Start()
@@ -50,7 +130,7 @@ func Run(cfg *config.Config) {
 
 	select {
 	case s := <-interrupt:
-		l.Info("app - Run - signal: " + s.String())
+		l.Info("app - Run - signal: %s", s.String())
 	case err = <-httpServer.Notify():
 		l.Error(fmt.Errorf("app - Run - httpServer.Notify: %w", err))
 	}
@@ -61,3 +141,61 @@ func Run(cfg *config.Config) {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
 }
+
+// signingKeys loads the keys in cfg.Keys. Without any, tokens are signed with the shared
+// cfg.Secret, which other services can't verify through the JWKS.
+func signingKeys(cfg config.Jwt, l logger.Interface) (*tokens.KeySet, error) {
+	if len(cfg.Keys) > 0 {
+		return tokens.LoadKeySet(cfg.SigningKeyID, cfg.Keys)
+	}
+
+	if cfg.Secret == "" {
+		return nil, errors.New("one of JWT_KEYS and JWT_SECRET is required")
+	}
+
+	l.Warn("JWT_KEYS is not set, signing access tokens with JWT_SECRET (HS256)")
+
+	return tokens.NewKeySet("default", tokens.NewHMACKey("default", []byte(cfg.Secret)))
+}
+
+// loginPolicy is the policy in cfg with the given lockout threshold.
+func loginPolicy(cfg config.Login, threshold int) entity.LoginPolicy {
+	return entity.LoginPolicy{
+		Threshold: threshold,
+		Window:    cfg.FailureWindow,
+		BaseDelay: cfg.BaseDelay,
+		MaxDelay:  cfg.MaxDelay,
+		Lockout:   cfg.LockoutDuration,
+	}
+}
+
+func twoFactorIssuer(cfg *config.Config) string {
+	if cfg.TwoFactor.Issuer != "" {
+		return cfg.TwoFactor.Issuer
+	}
+
+	return cfg.App.Name
+}
+
+// ssoOption turns on single sign-on if cfg has an issuer.
+func ssoOption(cfg config.OIDC) (common.Option, error) {
+	if cfg.Issuer == "" {
+		return common.OIDC(nil, nil, ""), nil
+	}
+
+	if cfg.ClientID == "" {
+		return nil, errors.New("OIDC_CLIENT_ID is required with OIDC_ISSUER")
+	}
+
+	groupRoles, err := entity.ParseGroupRoles(cfg.GroupRoles)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC_GROUP_ROLES: %w", err)
+	}
+
+	provider := oidc.New(cfg.Issuer, cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL,
+		oidc.Scopes(cfg.Scopes...),
+		oidc.GroupsClaim(cfg.GroupsClaim),
+	)
+
+	return common.OIDC(provider, groupRoles, entity.Role(cfg.DefaultRole)), nil
+}
@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/response"
@@ -11,23 +13,65 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// Keys of the authenticated user's values, both in c.Locals and in the user context.
 const (
-	UserKey   = "user"
-	RoleKey   = "role"
-	ClaimsKey = "claims"
+	UserKey        = "user"
+	UserIDKey      = "userID"
+	SessionIDKey   = "sessionID"
+	RoleKey        = "role"
+	PermissionsKey = "permissions"
+	ClaimsKey      = "claims"
+	APIKeyIDKey    = "apiKeyID"
 )
 
+// APIKeyHeader carries the API key of a request made by another system.
+const APIKeyHeader = "X-API-Key"
+
+// AccessFunc returns the user's current role and the permissions it grants.
+type AccessFunc func(ctx context.Context, userID int) (entity.Role, []entity.Permission, error)
+
+// SessionFunc returns an error unless the session belongs to the user and is still active.
+type SessionFunc func(ctx context.Context, userID, sessionID int) error
+
+// APIKeyFunc returns the active API key a request presented, or an error.
+type APIKeyFunc func(ctx context.Context, key string) (entity.APIKey, error)
+
 type AuthConfig struct {
-	Skipper   func(c *fiber.Ctx) bool
-	JWTSecret string
+	Skipper func(c *fiber.Ctx) bool
+	Keys    *tokens.KeySet
+	Access  AccessFunc
+	Session SessionFunc
+	APIKey  APIKeyFunc
 }
 
+// Authentication verifies the bearer token and stores its claims, the user and session ids (int),
+// and the user's current role (entity.Role) and permissions ([]entity.Permission) in c.Locals for
+// the handlers that follow. The session is checked through config.Session and the role and
+// permissions read through config.Access on every request, so logging out, role changes and
+// deactivation apply before the token expires.
+//
+// If config.APIKey is set, a request may instead present an API key in the X-API-Key header. It
+// then acts with the key's permissions and has no user: the key's id (int) and permissions are
+// stored in c.Locals instead, so routes only the signed-in user may reach turn it away.
 func Authentication(config AuthConfig) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		if config.Skipper != nil && config.Skipper(c) {
 			return c.Next()
 		}
 
+		if key := c.Get(APIKeyHeader); key != "" && config.APIKey != nil {
+			apiKey, err := config.APIKey(c.Context(), key)
+			if err != nil {
+				return response.ErrorResponse(c, http.StatusUnauthorized, "invalid API key")
+			}
+
+			c.Locals(APIKeyIDKey, apiKey.ID)
+			c.Locals(PermissionsKey, apiKey.Permissions)
+			c.SetUserContext(context.WithValue(c.Context(), APIKeyIDKey, apiKey.ID))
+
+			return c.Next()
+		}
+
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
 			return response.ErrorResponse(c, http.StatusUnauthorized, "missing authorization header")
@@ -38,30 +82,52 @@ func Authentication(config AuthConfig) fiber.Handler {
 			return response.ErrorResponse(c, http.StatusUnauthorized, "invalid authorization format")
 		}
 
-		claims, err := tokens.ParseToken(tokenParts[1], config.JWTSecret)
+		claims, err := config.Keys.ParseToken(tokenParts[1])
+		if err != nil || claims.UserID == 0 || claims.SessionID == 0 {
+			return response.ErrorResponse(c, http.StatusUnauthorized, "invalid token")
+		}
+
+		if err = config.Session(c.Context(), claims.UserID, claims.SessionID); err != nil {
+			return response.ErrorResponse(c, http.StatusUnauthorized, "session has ended")
+		}
+
+		role, permissions, err := config.Access(c.Context(), claims.UserID)
+		if errors.Is(err, entity.ErrUserDeactivated) {
+			return response.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		}
+
 		if err != nil {
 			return response.ErrorResponse(c, http.StatusUnauthorized, "invalid token")
 		}
 
-		// Add claims to context
 		c.Locals(ClaimsKey, claims)
+		c.Locals(UserIDKey, claims.UserID)
+		c.Locals(SessionIDKey, claims.SessionID)
+		c.Locals(RoleKey, role)
+		c.Locals(PermissionsKey, permissions)
 
 		// Create custom context
 		ctx := context.WithValue(c.Context(), UserKey, claims.Email)
-		ctx = context.WithValue(ctx, RoleKey, claims.Role)
+		ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, RoleKey, role)
 		c.SetUserContext(ctx)
 
 		return c.Next()
 	}
 }
 
-// Role-based access control middleware
-func RequireRole(requiredRole entity.Role) fiber.Handler {
+// RequirePermission lets the request through only if Authentication ran before it and the
+// user's role, or the API key, grants the permission.
+func RequirePermission(permission entity.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx := c.Context()
-		userRole := ctx.Value(RoleKey).(entity.Role)
+		_, isUser := c.Locals(UserIDKey).(int)
+		_, isAPIKey := c.Locals(APIKeyIDKey).(int)
 
-		if userRole != requiredRole {
+		if !isUser && !isAPIKey {
+			return response.ErrorResponse(c, http.StatusUnauthorized, "authentication required")
+		}
+
+		if !HasPermission(c, permission) {
 			return response.ErrorResponse(c, http.StatusForbidden,
 				"you don't have permission to access this resource")
 		}
@@ -69,3 +135,11 @@ func RequireRole(requiredRole entity.Role) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// HasPermission reports whether the authenticated user's role, or the API key, grants the
+// permission.
+func HasPermission(c *fiber.Ctx, permission entity.Permission) bool {
+	permissions, _ := c.Locals(PermissionsKey).([]entity.Permission)
+
+	return slices.Contains(permissions, permission)
+}
@@ -7,22 +7,108 @@ import (
 )
 
 type Appointment struct {
-	DoctorID        int           `json:"doctor_id"`
-	UserID          int           `json:"user_id"`
-	AppointmentTime time.Time     `json:"appointment_time"`
-	Duration        time.Duration `json:"duration"`
-	Status          string        `json:"status"`
+	DoctorID          int       `json:"doctor_id"`
+	PatientID         int       `json:"patient_id"` // the signed-in user's own patient when empty
+	AppointmentTypeID int       `json:"appointment_type_id" validate:"required"`
+	AppointmentTime   time.Time `json:"appointment_time"`
+}
+
+type AppointmentUpdate struct {
+	DoctorID        int       `json:"doctor_id"`
+	PatientID       int       `json:"patient_id"`
+	AppointmentTime time.Time `json:"appointment_time"`
+	Duration        int       `json:"duration"` // in minutes, 0 keeps the current duration; a type's duration can't change
+	Status          string    `json:"status"`
 }
 
 type AppointmentResponse struct {
 	ID              int       `json:"id"`
 	DoctorID        int       `json:"doctor_id"`
-	UserID          int       `json:"user_id"`
-	AppointmentTime time.Time `json:"appointment_time"`
+	PatientID       int       `json:"patient_id"`
+	AppointmentTime time.Time `json:"appointment_time"` // UTC
 	Duration        int       `json:"duration"`
 	Status          string    `json:"status"`
+	// AppointmentTypeID is empty for appointments booked before types existed. Buffers and capacity
+	// are those of the type when the appointment was booked.
+	AppointmentTypeID *int   `json:"appointment_type_id,omitempty"`
+	BufferBefore      int    `json:"buffer_before"`
+	BufferAfter       int    `json:"buffer_after"`
+	Capacity          int    `json:"capacity"`
+	LocationID        *int   `json:"location_id,omitempty"`
+	RoomID            *int   `json:"room_id,omitempty"`
+	TimeZone          string `json:"time_zone,omitempty" example:"Europe/Berlin"`
+	LocalTime         string `json:"local_time,omitempty" example:"2026-03-02T10:00:00+01:00"`
+	UTCOffset         string `json:"utc_offset,omitempty" example:"+01:00"`
+	SeriesID          *int   `json:"series_id,omitempty"`
 }
 
 type AppointmentsResponse struct {
-	Appointments []entity.Appointment `json:"appointments"`
+	Appointments []AppointmentResponse `json:"appointments"`
+}
+
+type StatusChangeRequest struct {
+	Reason string `json:"reason"`
+}
+
+type StatusHistoryResponse struct {
+	Changes []entity.AppointmentStatusChange `json:"changes"`
+}
+
+type RescheduleRequest struct {
+	AppointmentTime time.Time `json:"appointment_time" validate:"required"`
+	Duration        int       `json:"duration"` // in minutes, 0 keeps the current duration; a type's duration can't change
+	DoctorID        int       `json:"doctor_id"`
+	Reason          string    `json:"reason"`
+}
+
+type ReschedulesResponse struct {
+	Reschedules []entity.AppointmentReschedule `json:"reschedules"`
+}
+
+type AppointmentSeriesRequest struct {
+	DoctorID          int       `json:"doctor_id" validate:"required"`
+	PatientID         int       `json:"patient_id"`                           // the signed-in user's own patient when empty
+	AppointmentTime   time.Time `json:"appointment_time" validate:"required"` // the first occurrence
+	AppointmentTypeID int       `json:"appointment_type_id" validate:"required"`
+	Rule              string    `json:"rule" validate:"required" example:"FREQ=WEEKLY;COUNT=12"`
+	SkipConflicts     bool      `json:"skip_conflicts"`
+}
+
+type SeriesConflictResponse struct {
+	Error       string                    `json:"error"`
+	Occurrences []entity.SeriesOccurrence `json:"occurrences"`
+}
+
+type AppointmentSeriesResponse struct {
+	Series       entity.AppointmentSeries `json:"series"`
+	Appointments []AppointmentResponse    `json:"appointments"`
+}
+
+type SeriesCancelRequest struct {
+	Scope  string `json:"scope" validate:"required,oneof=this following all"`
+	Reason string `json:"reason"`
+}
+
+type SeriesRescheduleRequest struct {
+	Scope           string    `json:"scope" validate:"required,oneof=this following all"`
+	AppointmentTime time.Time `json:"appointment_time" validate:"required"`
+	Duration        int       `json:"duration"` // in minutes, 0 keeps the current duration; a type's duration can't change
+	DoctorID        int       `json:"doctor_id"`
+	Reason          string    `json:"reason"`
+}
+
+type WaitlistRequest struct {
+	PatientID      int       `json:"patient_id"` // the signed-in user's own patient when empty
+	DoctorID       *int      `json:"doctor_id"`
+	Specialization string    `json:"specialization"`
+	From           time.Time `json:"from" validate:"required"`
+	To             time.Time `json:"to" validate:"required"`
+}
+
+type WaitlistEntriesResponse struct {
+	Entries []entity.WaitlistEntry `json:"entries"`
+}
+
+type WaitlistOffersResponse struct {
+	Offers []entity.WaitlistOffer `json:"offers"`
 }
@@ -7,24 +7,49 @@ import (
 )
 
 type Doctor struct {
-	Name           string   `json:"name"`
-	Specialization string   `json:"specialization"`
-	Schedule       Schedule `json:"schedule"`
+	Name              string   `json:"name"`
+	UserID            *int     `json:"user_id"`
+	Specializations   []string `json:"specializations" example:"cardiology"` // codes, the main one first
+	Schedule          Schedule `json:"schedule"`
+	HolidayCalendarID *int     `json:"holiday_calendar_id"`
+	TimeZone          string   `json:"time_zone" example:"Europe/Berlin"`
 }
 
 type Schedule struct {
-	Days  []string `json:"days"`
-	Start string   `json:"start"`
-	End   string   `json:"end"`
+	Version int            `json:"version,omitempty"`
+	Rules   []ScheduleRule `json:"rules"`
+}
+
+type ScheduleRule struct {
+	Weekday    string      `json:"weekday" example:"mon"`
+	Ranges     []TimeRange `json:"ranges"`
+	Breaks     []TimeRange `json:"breaks,omitempty"`
+	LocationID *int        `json:"location_id,omitempty"` // where the doctor works that day
+	RoomID     *int        `json:"room_id,omitempty"`     // a room of location_id
+}
+
+type TimeRange struct {
+	Start string `json:"start" example:"09:00"`
+	End   string `json:"end" example:"13:00"`
 }
 
 type DoctorResponse struct {
-	ID             int       `json:"id"`
-	Name           string    `json:"name"`
-	Specialization string    `json:"specialization"`
-	Schedule       Schedule  `json:"schedule"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                int                     `json:"id"`
+	Name              string                  `json:"name"`
+	UserID            *int                    `json:"user_id"`
+	Specializations   []entity.Specialization `json:"specializations"`
+	Schedule          Schedule                `json:"schedule"`
+	HolidayCalendarID *int                    `json:"holiday_calendar_id"`
+	TimeZone          string                  `json:"time_zone"`
+	CreatedAt         time.Time               `json:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+type DoctorAgendaResponse struct {
+	DoctorID     int                   `json:"doctor_id"`
+	Date         string                `json:"date" example:"2025-03-14"`
+	TimeZone     string                `json:"time_zone"`
+	Appointments []AppointmentResponse `json:"appointments"`
 }
 
 type SuccessResponse struct {
@@ -35,10 +60,114 @@ type AllDoctorsResponse struct {
 	Doctors []entity.Doctor `json:"doctors"`
 }
 
+type SpecializationRequest struct {
+	Code            string            `json:"code" validate:"required" example:"cardiology"`
+	Names           map[string]string `json:"names" validate:"required"` // by language tag, "en" required
+	Description     string            `json:"description"`
+	DefaultDuration int               `json:"default_duration" example:"30"` // minutes, 30 when empty
+}
+
 type SpecializationResponse struct {
-	Specializations []string `json:"specializations"`
+	entity.Specialization
+	Name string `json:"name"` // in the language asked for with Accept-Language
+}
+
+type SpecializationsResponse struct {
+	Specializations []SpecializationResponse `json:"specializations"`
+}
+
+type AppointmentTypeRequest struct {
+	Name             string `json:"name" validate:"required,max=100" example:"First consultation"`
+	DoctorID         *int   `json:"doctor_id"`                                      // either doctor_id or specialization_id
+	SpecializationID *int   `json:"specialization_id"`                              // for every doctor holding it
+	Duration         int    `json:"duration" validate:"required,gt=0" example:"40"` // minutes
+	BufferBefore     int    `json:"buffer_before" validate:"gte=0" example:"5"`     // minutes
+	BufferAfter      int    `json:"buffer_after" validate:"gte=0" example:"10"`     // minutes
+	Capacity         int    `json:"capacity" validate:"gte=0" example:"1"`          // patients per slot, 1 when empty
+}
+
+type AppointmentTypesResponse struct {
+	AppointmentTypes []entity.AppointmentType `json:"appointment_types"`
+}
+
+type ClinicRequest struct {
+	Name string `json:"name" validate:"required,max=100" example:"City Health"`
+}
+
+type ClinicsResponse struct {
+	Clinics []entity.Clinic `json:"clinics"`
+}
+
+type LocationRequest struct {
+	Name    string `json:"name" validate:"required,max=100" example:"Downtown"`
+	Address string `json:"address" validate:"max=500" example:"1 Main Street"`
+}
+
+type LocationsResponse struct {
+	Locations []entity.Location `json:"locations"`
+}
+
+type RoomRequest struct {
+	Name string `json:"name" validate:"required,max=100" example:"Room 101"`
+}
+
+type RoomsResponse struct {
+	Rooms []entity.Room `json:"rooms"`
 }
 
 type BookedSchedulesResponse struct {
 	BookedSchedules []entity.Schedule `json:"booked_schedules"`
 }
+
+type AvailabilityResponse struct {
+	DoctorID          int            `json:"doctor_id"`
+	TimeZone          string         `json:"time_zone"`
+	From              time.Time      `json:"from"`
+	To                time.Time      `json:"to"`
+	Duration          int            `json:"duration"` // in minutes
+	AppointmentTypeID *int           `json:"appointment_type_id,omitempty"`
+	Slots             []SlotResponse `json:"slots"`
+}
+
+type SlotResponse struct {
+	Start      time.Time `json:"start"` // UTC
+	End        time.Time `json:"end"`   // UTC
+	LocalStart string    `json:"local_start" example:"2026-03-02T10:00:00+01:00"`
+	LocalEnd   string    `json:"local_end" example:"2026-03-02T10:30:00+01:00"`
+	UTCOffset  string    `json:"utc_offset" example:"+01:00"`
+	Remaining  int       `json:"remaining,omitempty"` // places left in a slot several patients share
+}
+
+type ScheduleExceptionRequest struct {
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required"`
+	Reason   string    `json:"reason"`
+}
+
+type TimeOffRequest struct {
+	StartsAt        time.Time `json:"starts_at" validate:"required"`
+	EndsAt          time.Time `json:"ends_at" validate:"required"`
+	Reason          string    `json:"reason"`
+	CancelConflicts bool      `json:"cancel_conflicts"`
+}
+
+type ScheduleExceptionsResponse struct {
+	Exceptions []entity.ScheduleException `json:"exceptions"`
+}
+
+type HolidayCalendarRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type HolidayCalendarsResponse struct {
+	Calendars []entity.HolidayCalendar `json:"calendars"`
+}
+
+type HolidayRequest struct {
+	Date string `json:"date" validate:"required" example:"2026-12-25"`
+	Name string `json:"name" validate:"required"`
+}
+
+type HolidaysResponse struct {
+	Holidays []entity.Holiday `json:"holidays"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+type PatientRequest struct {
+	FullName              string `json:"full_name" validate:"required"`
+	DateOfBirth           string `json:"date_of_birth" example:"2019-05-14"` // empty when unknown
+	Sex                   string `json:"sex" example:"female"`               // female, male, other or empty
+	EmergencyContactName  string `json:"emergency_contact_name"`
+	EmergencyContactPhone string `json:"emergency_contact_phone"`
+	PreferredLanguage     string `json:"preferred_language" example:"en"`
+}
+
+type CreatePatientRequest struct {
+	PatientRequest
+	// Relationship of the user to the patient, such as parent. Staff may leave it empty to create
+	// a patient no user is linked to yet.
+	Relationship string `json:"relationship" example:"parent"`
+	UserID       int    `json:"user_id"` // the signed-in user when empty; staff only otherwise
+}
+
+type PatientResponse struct {
+	Patient entity.Patient       `json:"patient"`
+	Users   []entity.PatientLink `json:"users"`
+}
+
+type PatientsResponse struct {
+	Patients []entity.LinkedPatient `json:"patients"`
+}
+
+type PatientLinkRequest struct {
+	UserID       int    `json:"user_id" validate:"required"`
+	Relationship string `json:"relationship" validate:"required" example:"guardian"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "github.com/dostonshernazarov/doctor-appointment/internal/entity"
+import (
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
 
 type SignUpUserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -9,10 +13,12 @@ type SignUpUserRequest struct {
 }
 
 type UserResponse struct {
-	ID       int    `json:"id"`
-	Email    string `json:"email"`
-	FullName string `json:"full_name"`
-	Phone    string `json:"phone"`
+	ID            int         `json:"id"`
+	Email         string      `json:"email"`
+	FullName      string      `json:"full_name"`
+	Phone         string      `json:"phone"`
+	Role          entity.Role `json:"role,omitempty"`
+	DeactivatedAt *time.Time  `json:"deactivated_at,omitempty"`
 }
 
 type SignInUserRequest struct {
@@ -20,16 +26,76 @@ type SignInUserRequest struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
-type CreateUserRequest struct {
-	Email    string `json:"email" validate:"required,email"`
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
 	Password string `json:"password" validate:"required,min=8"`
-	FullName string `json:"full_name" validate:"required"`
-	Phone    string `json:"phone"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse - a new access token and the refresh token to get the next one with.
+type TokenResponse struct {
+	Message      string `json:"message,omitempty"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until the access token expires
+	// RecoveryCodes are returned once, when signing in turned on two-factor authentication.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorChallengeResponse - the password was right, now answer the challenge at /auth/2fa/verify.
+type TwoFactorChallengeResponse struct {
+	Message        string `json:"message"`
+	ChallengeToken string `json:"challenge_token"`
+	// Method is totp, or enroll when the user's role requires TOTP they haven't set up yet:
+	// get a secret from /auth/2fa/enroll first.
+	Method    string `json:"method"`
+	ExpiresIn int    `json:"expires_in"` // seconds until the challenge expires
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled  bool `json:"enabled"`
+	Required bool `json:"required"` // by the user's role
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type CreateUserRequest struct {
+	Email    string      `json:"email" validate:"required,email"`
+	Password string      `json:"password" validate:"required,min=8"`
+	FullName string      `json:"full_name" validate:"required"`
+	Phone    string      `json:"phone"`
+	Role     entity.Role `json:"role"` // user when empty
 }
 
 type UpdateUserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
 	FullName string `json:"full_name" validate:"required"`
 	Phone    string `json:"phone"`
 }
@@ -46,3 +112,47 @@ type PingResponse struct {
 type ListUsersResponse struct {
 	Users []entity.User `json:"users"`
 }
+
+type RoleRequest struct {
+	Name             entity.Role         `json:"name" validate:"required"`
+	Description      string              `json:"description"`
+	Permissions      []entity.Permission `json:"permissions"`
+	RequireTwoFactor bool                `json:"require_two_factor"`
+}
+
+type UpdateRoleRequest struct {
+	Description string              `json:"description"`
+	Permissions []entity.Permission `json:"permissions"`
+}
+
+type RolesResponse struct {
+	Roles []entity.RoleDefinition `json:"roles"`
+}
+
+type PermissionsResponse struct {
+	Permissions []entity.PermissionInfo `json:"permissions"`
+}
+
+type RoleTwoFactorRequest struct {
+	Required *bool `json:"required" validate:"required"`
+}
+
+type AssignRoleRequest struct {
+	Role entity.Role `json:"role" validate:"required"`
+}
+
+type APIKeyRequest struct {
+	Name        string              `json:"name" validate:"required"`
+	Permissions []entity.Permission `json:"permissions" validate:"required"`
+	ExpiresAt   time.Time           `json:"expires_at" validate:"required"`
+}
+
+// APIKeyResponse - a key just created. Key is shown this once.
+type APIKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey entity.APIKey `json:"api_key"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []entity.APIKey `json:"api_keys"`
+}
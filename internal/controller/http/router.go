@@ -7,6 +7,7 @@ import (
 	v1 "github.com/dostonshernazarov/doctor-appointment/internal/controller/http/v1"
 	"github.com/dostonshernazarov/doctor-appointment/internal/usecase"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -14,23 +15,49 @@ import (
 )
 
 type Router struct {
-	app         *fiber.App
-	cfg         *config.Config
-	l           logger.Interface
-	user        usecase.UserUsecase
-	doctor      usecase.DoctorUsecase
-	appointment usecase.AppointmentUsecase
+	app             *fiber.App
+	cfg             *config.Config
+	l               logger.Interface
+	user            usecase.UserUsecase
+	doctor          usecase.DoctorUsecase
+	appointment     usecase.AppointmentUsecase
+	schedule        usecase.ScheduleUsecase
+	waitlist        usecase.WaitlistUsecase
+	role            usecase.RoleUsecase
+	session         usecase.SessionUsecase
+	login           usecase.LoginUsecase
+	twoFactor       usecase.TwoFactorUsecase
+	sso             usecase.SSOUsecase
+	apiKey          usecase.APIKeyUsecase
+	patient         usecase.PatientUsecase
+	specialization  usecase.SpecializationUsecase
+	appointmentType usecase.AppointmentTypeUsecase
+	clinic          usecase.ClinicUsecase
+	keys            *tokens.KeySet
 }
 
 // NewRouterConfig creates a new Router configuration
-func NewRouterConfig(app *fiber.App, cfg *config.Config, l logger.Interface, user usecase.UserUsecase, doctor usecase.DoctorUsecase, appointment usecase.AppointmentUsecase) *Router {
+func NewRouterConfig(app *fiber.App, cfg *config.Config, l logger.Interface, user usecase.UserUsecase, doctor usecase.DoctorUsecase, appointment usecase.AppointmentUsecase, schedule usecase.ScheduleUsecase, waitlist usecase.WaitlistUsecase, role usecase.RoleUsecase, session usecase.SessionUsecase, login usecase.LoginUsecase, twoFactor usecase.TwoFactorUsecase, sso usecase.SSOUsecase, apiKey usecase.APIKeyUsecase, patient usecase.PatientUsecase, specialization usecase.SpecializationUsecase, appointmentType usecase.AppointmentTypeUsecase, clinic usecase.ClinicUsecase, keys *tokens.KeySet) *Router {
 	return &Router{
-		app:         app,
-		cfg:         cfg,
-		l:           l,
-		user:        user,
-		doctor:      doctor,
-		appointment: appointment,
+		app:             app,
+		cfg:             cfg,
+		l:               l,
+		user:            user,
+		doctor:          doctor,
+		appointment:     appointment,
+		schedule:        schedule,
+		waitlist:        waitlist,
+		role:            role,
+		session:         session,
+		login:           login,
+		twoFactor:       twoFactor,
+		sso:             sso,
+		apiKey:          apiKey,
+		patient:         patient,
+		specialization:  specialization,
+		appointmentType: appointmentType,
+		clinic:          clinic,
+		keys:            keys,
 	}
 }
 
@@ -45,12 +72,18 @@ func NewRouterConfig(app *fiber.App, cfg *config.Config, l logger.Interface, use
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 func NewRouter(r *Router) {
 	r.app.Use(middleware.Logger(r.l))
 
 	// Swagger
 	r.app.Get("/swagger/*", swagger.HandlerDefault)
 
+	// Public keys other services verify our access tokens with
+	r.app.Get("/.well-known/jwks.json", v1.JWKS(r.keys))
+
 	// Configure CORS middleware
 	r.app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:8070, http://127.0.0.1:8070",
@@ -64,11 +97,26 @@ func NewRouter(r *Router) {
 	apiV1Group := r.app.Group("/v1")
 	{
 		v1.NewUserRoutes(v1.HandlerV1Config{
-			Config:     r.cfg,
-			Logger:     r.l,
-			Validation: validator.New(),
-			User:       r.user,
-			Router:     apiV1Group,
+			Config:          r.cfg,
+			Logger:          r.l,
+			Validation:      validator.New(),
+			User:            r.user,
+			Doctor:          r.doctor,
+			Appointment:     r.appointment,
+			Schedule:        r.schedule,
+			Waitlist:        r.waitlist,
+			Role:            r.role,
+			Session:         r.session,
+			Login:           r.login,
+			TwoFactor:       r.twoFactor,
+			SSO:             r.sso,
+			APIKey:          r.apiKey,
+			Patient:         r.patient,
+			Specialization:  r.specialization,
+			AppointmentType: r.appointmentType,
+			Clinic:          r.clinic,
+			Keys:            r.keys,
+			Router:          apiV1Group,
 		})
 	}
 
@@ -0,0 +1,228 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/middleware"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errPatientRequired = errors.New("patient_id is required")
+	errNotLinked       = errors.New("user is not linked to the patient")
+)
+
+// canAccessUser reports whether the authenticated user may act for userID: always for
+// themselves, and for anyone else if their role grants the permission.
+func canAccessUser(c *fiber.Ctx, userID int, permission entity.Permission) bool {
+	if middleware.HasPermission(c, permission) {
+		return true
+	}
+
+	currentID, ok := currentUserID(c)
+
+	return ok && currentID == userID
+}
+
+func forbidden(c *fiber.Ctx) error {
+	return errorResponse(c, fiber.StatusForbidden, "you don't have permission to access this resource")
+}
+
+// requireSelf only lets the user named by the path parameter through, or a user whose role
+// grants the permission.
+func requireSelf(param string, permission entity.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := strconv.Atoi(c.Params(param))
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "invalid user ID")
+		}
+
+		if !canAccessUser(c, userID, permission) {
+			return forbidden(c)
+		}
+
+		return c.Next()
+	}
+}
+
+// canAccessPatient reports whether the authenticated user may act for the patient: if they are
+// linked to the patient, and for any patient if their role grants the permission.
+func (h *HandlerV1) canAccessPatient(c *fiber.Ctx, patientID int, permission entity.Permission) (bool, error) {
+	if middleware.HasPermission(c, permission) {
+		return true, nil
+	}
+
+	currentID, ok := currentUserID(c)
+	if !ok {
+		return false, nil
+	}
+
+	links, err := h.Patient.GetPatientLinks(c.Context(), patientID)
+	if err != nil {
+		return false, err
+	}
+
+	return entity.LinksUser(links, currentID), nil
+}
+
+// requirePatient only lets users linked to the patient named by the path parameter through, or a
+// user whose role grants the permission.
+func (h *HandlerV1) requirePatient(param string, permission entity.Permission) fiber.Handler {
+	return h.requireOwner(param, "patient", permission, func(_ context.Context, id int) (int, error) {
+		return id, nil
+	})
+}
+
+// requireOwner only lets users linked to the patient owning the resource named by the path
+// parameter through, or a user whose role grants the permission. owner looks the patient up by
+// the resource id.
+func (h *HandlerV1) requireOwner(param, name string, permission entity.Permission, owner func(ctx context.Context, id int) (int, error)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if middleware.HasPermission(c, permission) {
+			return c.Next()
+		}
+
+		id, err := strconv.Atoi(c.Params(param))
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "invalid "+name+" ID")
+		}
+
+		patientID, err := owner(c.Context(), id)
+		if err != nil {
+			return h.accessErrorResponse(c, err, "http - v1 - requireOwner - owner")
+		}
+
+		ok, err := h.canAccessPatient(c, patientID, permission)
+		if err != nil {
+			return h.accessErrorResponse(c, err, "http - v1 - requireOwner - h.canAccessPatient")
+		}
+
+		if !ok {
+			return forbidden(c)
+		}
+
+		return c.Next()
+	}
+}
+
+// requireAppointmentOwner guards routes on :appointment_id.
+func (h *HandlerV1) requireAppointmentOwner(permission entity.Permission) fiber.Handler {
+	return h.requireOwner("appointment_id", "appointment", permission, func(ctx context.Context, id int) (int, error) {
+		appointment, err := h.Appointment.GetAppointmentByID(ctx, id)
+
+		return appointment.PatientID, err
+	})
+}
+
+// requireSeriesOwner guards routes on :series_id.
+func (h *HandlerV1) requireSeriesOwner(permission entity.Permission) fiber.Handler {
+	return h.requireOwner("series_id", "series", permission, func(ctx context.Context, id int) (int, error) {
+		series, _, err := h.Appointment.GetAppointmentSeries(ctx, id)
+
+		return series.PatientID, err
+	})
+}
+
+// requireWaitlistEntryOwner guards routes on :entry_id.
+func (h *HandlerV1) requireWaitlistEntryOwner(permission entity.Permission) fiber.Handler {
+	return h.requireOwner("entry_id", "entry", permission, func(ctx context.Context, id int) (int, error) {
+		entry, err := h.Waitlist.GetWaitlistEntry(ctx, id)
+
+		return entry.PatientID, err
+	})
+}
+
+// requireOfferOwner guards routes on :offer_id.
+func (h *HandlerV1) requireOfferOwner(permission entity.Permission) fiber.Handler {
+	return h.requireOwner("offer_id", "offer", permission, func(ctx context.Context, id int) (int, error) {
+		offer, err := h.Waitlist.GetWaitlistOffer(ctx, id)
+
+		return offer.PatientID, err
+	})
+}
+
+// bookingPatient answers which patient a booking is for: patientID, or the caller themselves if
+// it is 0. It returns errPatientRequired if there is none and errNotLinked if the caller may
+// not book for them.
+func (h *HandlerV1) bookingPatient(c *fiber.Ctx, patientID int) (int, error) {
+	if userID, ok := currentUserID(c); ok && patientID == 0 {
+		patient, err := h.Patient.GetSelfPatient(c.Context(), userID)
+		if err != nil && !errors.Is(err, entity.ErrPatientNotFound) {
+			return 0, err
+		}
+
+		patientID = patient.ID
+	}
+
+	if patientID == 0 {
+		return 0, errPatientRequired
+	}
+
+	ok, err := h.canAccessPatient(c, patientID, entity.PermAppointmentWriteAny)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, errNotLinked
+	}
+
+	return patientID, nil
+}
+
+// bookingPatientErrorResponse answers a request bookingPatient refused.
+func bookingPatientErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errPatientRequired):
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, errNotLinked):
+		return forbidden(c)
+	}
+
+	return errorResponse(c, fiber.StatusInternalServerError, err.Error())
+}
+
+// requireVerifiedEmail keeps users who haven't verified their email address from booking. Staff
+// whose role lets them book for any patient, and API keys that may, aren't held up by an address.
+func (h *HandlerV1) requireVerifiedEmail(c *fiber.Ctx) error {
+	if middleware.HasPermission(c, entity.PermAppointmentWriteAny) {
+		return c.Next()
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return forbidden(c)
+	}
+
+	user, err := h.User.GetUserByID(c.Context(), userID)
+	if err != nil {
+		return h.accessErrorResponse(c, err, "http - v1 - requireVerifiedEmail - h.User.GetUserByID")
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"code":  "email_not_verified",
+			"error": entity.ErrEmailNotVerified.Error(),
+		})
+	}
+
+	return c.Next()
+}
+
+// accessErrorResponse answers a request an access check couldn't decide: 404 when what it names
+// doesn't exist, a 500 without the details, which are logged, otherwise.
+func (h *HandlerV1) accessErrorResponse(c *fiber.Ctx, err error, where string) error {
+	switch {
+	case errors.Is(err, entity.ErrAppointmentNotFound), errors.Is(err, entity.ErrSeriesNotFound),
+		errors.Is(err, entity.ErrWaitlistEntryNotFound), errors.Is(err, entity.ErrWaitlistOfferNotFound),
+		errors.Is(err, entity.ErrPatientNotFound), errors.Is(err, entity.ErrUserNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err.Error())
+	}
+
+	h.Logger.Error(err, where)
+
+	return errorResponse(c, fiber.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create API key
+// @Description Create a key another system can call the API with in the X-API-Key header. It acts with the given permissions, which must be ones your role has, until it expires or is revoked. The key is only shown in this response.
+// @Accept json
+// @Produce json
+// @Tags api-key
+// @Param request body models.APIKeyRequest true "API key"
+// @Success 201 {object} models.APIKeyResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /api-keys [post]
+func (h *HandlerV1) CreateAPIKey(c *fiber.Ctx) error {
+	req := models.APIKeyRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID, _ := currentUserID(c)
+
+	key, secret, err := h.APIKey.CreateAPIKey(c.Context(), entity.APIKey{
+		Name:        req.Name,
+		Permissions: req.Permissions,
+		ExpiresAt:   req.ExpiresAt,
+	}, userID)
+	if errors.Is(err, entity.ErrInvalidAPIKey) || errors.Is(err, entity.ErrUnknownPermission) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIKeyResponse{Key: secret, APIKey: key})
+}
+
+// @Summary List API keys
+// @Description List every API key, revoked and expired ones included, newest first. The keys themselves aren't stored, only their prefix.
+// @Accept json
+// @Produce json
+// @Tags api-key
+// @Success 200 {object} models.APIKeysResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /api-keys [get]
+func (h *HandlerV1) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.APIKey.ListAPIKeys(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.APIKeysResponse{APIKeys: keys})
+}
+
+// @Summary Revoke API key
+// @Description Stop an API key from working. It stays listed.
+// @Accept json
+// @Produce json
+// @Tags api-key
+// @Param key_id path int true "API key ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /api-keys/{key_id} [delete]
+func (h *HandlerV1) RevokeAPIKey(c *fiber.Ctx) error {
+	keyID, err := strconv.Atoi(c.Params("key_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid API key ID"})
+	}
+
+	userID, _ := currentUserID(c)
+
+	err = h.APIKey.RevokeAPIKey(c.Context(), keyID, userID)
+	if errors.Is(err, entity.ErrAPIKeyNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "API key revoked successfully",
+	})
+}
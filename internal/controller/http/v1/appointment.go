@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
@@ -9,14 +11,18 @@ import (
 )
 
 // @Summary Create appointment
-// @Description Create appointment
+// @Description Book an appointment with an appointment type the doctor offers. The type sets its duration, buffers and capacity.
 // @Accept json
 // @Produce json
 // @Tags appointment
 // @Param appointment body models.Appointment true "Appointment"
 // @Success 201 {object} models.AppointmentResponse
 // @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 422 {object} entity.BookingError
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments [post]
 func (h *HandlerV1) CreateAppointment(c *fiber.Ctx) error {
 	appointment := models.Appointment{}
@@ -24,23 +30,32 @@ func (h *HandlerV1) CreateAppointment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	appointment.Status = "scheduled"
+	if err := h.Validation.Struct(appointment); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	err := h.Appointment.CreateAppointment(c.Context(), entity.Appointment{
-		DoctorID:        appointment.DoctorID,
-		UserID:          appointment.UserID,
-		AppointmentTime: appointment.AppointmentTime,
-		Duration:        int(appointment.Duration.Minutes()),
-		Status:          appointment.Status,
+	patientID, err := h.bookingPatient(c, appointment.PatientID)
+	if err != nil {
+		return bookingPatientErrorResponse(c, err)
+	}
+
+	id, err := h.Appointment.CreateAppointment(c.Context(), entity.Appointment{
+		DoctorID:          appointment.DoctorID,
+		PatientID:         patientID,
+		AppointmentTypeID: &appointment.AppointmentTypeID,
+		AppointmentTime:   appointment.AppointmentTime,
+		Status:            entity.StatusScheduled,
 	})
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+
+	created, err := h.Appointment.GetAppointmentByID(c.Context(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(models.AppointmentResponse{
-		DoctorID: appointment.DoctorID,
-		UserID:   appointment.UserID,
-	})
+	return c.Status(fiber.StatusCreated).JSON(appointmentResponse(created))
 }
 
 // @Summary Get appointments by doctor id
@@ -52,6 +67,7 @@ func (h *HandlerV1) CreateAppointment(c *fiber.Ctx) error {
 // @Success 200 {object} models.AppointmentResponse
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments/doctor/{doctor_id} [get]
 func (h *HandlerV1) GetAppointmentsByDoctorID(c *fiber.Ctx) error {
 	doctorID := c.Params("doctor_id")
@@ -65,9 +81,7 @@ func (h *HandlerV1) GetAppointmentsByDoctorID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.AppointmentsResponse{
-		Appointments: appointments,
-	})
+	return c.Status(fiber.StatusOK).JSON(appointmentsResponse(appointments))
 }
 
 // @Summary Get appointments by user id
@@ -79,6 +93,7 @@ func (h *HandlerV1) GetAppointmentsByDoctorID(c *fiber.Ctx) error {
 // @Success 200 {object} models.AppointmentResponse
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments/user/{user_id} [get]
 func (h *HandlerV1) GetAppointmentsByUserID(c *fiber.Ctx) error {
 	userID := c.Params("user_id")
@@ -92,9 +107,7 @@ func (h *HandlerV1) GetAppointmentsByUserID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.AppointmentsResponse{
-		Appointments: appointments,
-	})
+	return c.Status(fiber.StatusOK).JSON(appointmentsResponse(appointments))
 }
 
 // @Summary Update appointment
@@ -103,11 +116,14 @@ func (h *HandlerV1) GetAppointmentsByUserID(c *fiber.Ctx) error {
 // @Produce json
 // @Tags appointment
 // @Param appointment_id path int true "Appointment ID"
-// @Param appointment body models.Appointment true "Appointment"
+// @Param appointment body models.AppointmentUpdate true "Appointment"
 // @Success 200 {object} models.AppointmentResponse
 // @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 422 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Router /appointments [put]
+// @Security BearerAuth
+// @Router /appointments/{appointment_id} [put]
 func (h *HandlerV1) UpdateAppointment(c *fiber.Ctx) error {
 	appointmentID := c.Params("appointment_id")
 	appointmentIDInt, err := strconv.Atoi(appointmentID)
@@ -115,7 +131,7 @@ func (h *HandlerV1) UpdateAppointment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
 	}
 
-	appointment := models.Appointment{}
+	appointment := models.AppointmentUpdate{}
 	if err := c.BodyParser(&appointment); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -123,22 +139,21 @@ func (h *HandlerV1) UpdateAppointment(c *fiber.Ctx) error {
 	err = h.Appointment.UpdateAppointment(c.Context(), entity.Appointment{
 		ID:              appointmentIDInt,
 		DoctorID:        appointment.DoctorID,
-		UserID:          appointment.UserID,
+		PatientID:       appointment.PatientID,
 		AppointmentTime: appointment.AppointmentTime,
-		Duration:        int(appointment.Duration.Minutes()),
+		Duration:        appointment.Duration,
 		Status:          appointment.Status,
 	})
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+
+	updated, err := h.Appointment.GetAppointmentByID(c.Context(), appointmentIDInt)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.AppointmentResponse{
-		DoctorID:        appointment.DoctorID,
-		UserID:          appointment.UserID,
-		AppointmentTime: appointment.AppointmentTime,
-		Duration:        int(appointment.Duration.Minutes()),
-		Status:          appointment.Status,
-	})
+	return c.Status(fiber.StatusOK).JSON(appointmentResponse(updated))
 }
 
 // @Summary Delete appointment
@@ -149,7 +164,9 @@ func (h *HandlerV1) UpdateAppointment(c *fiber.Ctx) error {
 // @Param appointment_id path int true "Appointment ID"
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments/{appointment_id} [delete]
 func (h *HandlerV1) DeleteAppointment(c *fiber.Ctx) error {
 	appointmentID := c.Params("appointment_id")
@@ -160,7 +177,7 @@ func (h *HandlerV1) DeleteAppointment(c *fiber.Ctx) error {
 
 	err = h.Appointment.DeleteAppointment(c.Context(), appointmentIDInt)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return appointmentErrorResponse(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
@@ -177,6 +194,7 @@ func (h *HandlerV1) DeleteAppointment(c *fiber.Ctx) error {
 // @Success 200 {object} models.AppointmentsResponse
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments/doctor/{doctor_id}/booked-schedules [get]
 func (h *HandlerV1) GetBookedSchedulesByDoctorID(c *fiber.Ctx) error {
 	doctorID := c.Params("doctor_id")
@@ -190,9 +208,7 @@ func (h *HandlerV1) GetBookedSchedulesByDoctorID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.AppointmentsResponse{
-		Appointments: bookedSchedules,
-	})
+	return c.Status(fiber.StatusOK).JSON(appointmentsResponse(bookedSchedules))
 }
 
 // @Summary Get booked schedules by user id
@@ -204,6 +220,7 @@ func (h *HandlerV1) GetBookedSchedulesByDoctorID(c *fiber.Ctx) error {
 // @Success 200 {object} models.AppointmentsResponse
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments/user/{user_id}/booked-schedules [get]
 func (h *HandlerV1) GetBookedSchedulesByUserID(c *fiber.Ctx) error {
 	userID := c.Params("user_id")
@@ -217,9 +234,7 @@ func (h *HandlerV1) GetBookedSchedulesByUserID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.AppointmentsResponse{
-		Appointments: bookedSchedules,
-	})
+	return c.Status(fiber.StatusOK).JSON(appointmentsResponse(bookedSchedules))
 }
 
 // Get appointment by id
@@ -232,6 +247,7 @@ func (h *HandlerV1) GetBookedSchedulesByUserID(c *fiber.Ctx) error {
 // @Success 200 {object} models.AppointmentResponse
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /appointments/{appointment_id} [get]
 func (h *HandlerV1) GetAppointmentByID(c *fiber.Ctx) error {
 	appointmentID := c.Params("appointment_id")
@@ -245,12 +261,319 @@ func (h *HandlerV1) GetAppointmentByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.AppointmentResponse{
-		ID:              appointment.ID,
-		DoctorID:        appointment.DoctorID,
-		UserID:          appointment.UserID,
-		AppointmentTime: appointment.AppointmentTime,
-		Duration:        appointment.Duration,
-		Status:          appointment.Status,
+	return c.Status(fiber.StatusOK).JSON(appointmentResponse(appointment))
+}
+
+// @Summary Cancel appointment
+// @Description Cancel a scheduled, confirmed or checked-in appointment
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/cancel [post]
+func (h *HandlerV1) CancelAppointment(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusCancelled)
+}
+
+// @Summary Confirm appointment
+// @Description Confirm a scheduled appointment
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/confirm [post]
+// @Router /me/doctor/appointments/{appointment_id}/confirm [post]
+func (h *HandlerV1) ConfirmAppointment(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusConfirmed)
+}
+
+// @Summary Check in appointment
+// @Description Mark the patient as arrived
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/check-in [post]
+func (h *HandlerV1) CheckInAppointment(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusCheckedIn)
+}
+
+// @Summary Start appointment
+// @Description Mark a checked-in appointment as in progress
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/start [post]
+// @Router /me/doctor/appointments/{appointment_id}/start [post]
+func (h *HandlerV1) StartAppointment(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusInProgress)
+}
+
+// @Summary Complete appointment
+// @Description Mark a checked-in or in-progress appointment as completed
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/complete [post]
+// @Router /me/doctor/appointments/{appointment_id}/complete [post]
+func (h *HandlerV1) CompleteAppointment(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusCompleted)
+}
+
+// @Summary Mark appointment as no-show
+// @Description Mark a scheduled or confirmed appointment as missed by the patient
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/no-show [post]
+// @Router /me/doctor/appointments/{appointment_id}/no-show [post]
+func (h *HandlerV1) MarkAppointmentNoShow(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusNoShow)
+}
+
+func (h *HandlerV1) changeAppointmentStatus(c *fiber.Ctx, status string) error {
+	appointmentID := c.Params("appointment_id")
+	appointmentIDInt, err := strconv.Atoi(appointmentID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
+	}
+
+	req := models.StatusChangeRequest{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	var changedBy *int
+	if userID, ok := currentUserID(c); ok {
+		changedBy = &userID
+	}
+
+	appointment, err := h.Appointment.ChangeAppointmentStatus(c.Context(), appointmentIDInt, status, changedBy, req.Reason)
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(appointmentResponse(appointment))
+}
+
+// @Summary Get appointment status history
+// @Description Get every status change of an appointment with its time and author
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Success 200 {object} models.StatusHistoryResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/history [get]
+func (h *HandlerV1) GetAppointmentStatusHistory(c *fiber.Ctx) error {
+	appointmentID := c.Params("appointment_id")
+	appointmentIDInt, err := strconv.Atoi(appointmentID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
+	}
+
+	changes, err := h.Appointment.GetAppointmentStatusHistory(c.Context(), appointmentIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.StatusHistoryResponse{
+		Changes: changes,
 	})
 }
+
+// @Summary Reschedule appointment
+// @Description Move a scheduled or confirmed appointment to a new time, optionally with a new duration or doctor
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.RescheduleRequest true "New time"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/reschedule [post]
+func (h *HandlerV1) RescheduleAppointment(c *fiber.Ctx) error {
+	appointmentID := c.Params("appointment_id")
+	appointmentIDInt, err := strconv.Atoi(appointmentID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
+	}
+
+	req := models.RescheduleRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var changedBy *int
+	if userID, ok := currentUserID(c); ok {
+		changedBy = &userID
+	}
+
+	appointment, err := h.Appointment.RescheduleAppointment(c.Context(), entity.AppointmentReschedule{
+		AppointmentID:   appointmentIDInt,
+		DoctorID:        req.DoctorID,
+		AppointmentTime: req.AppointmentTime,
+		Duration:        req.Duration,
+		ChangedBy:       changedBy,
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(appointmentResponse(appointment))
+}
+
+// @Summary Get appointment reschedules
+// @Description Get every earlier time, duration and doctor of an appointment
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Success 200 {object} models.ReschedulesResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/reschedules [get]
+func (h *HandlerV1) GetAppointmentReschedules(c *fiber.Ctx) error {
+	appointmentID := c.Params("appointment_id")
+	appointmentIDInt, err := strconv.Atoi(appointmentID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
+	}
+
+	reschedules, err := h.Appointment.GetAppointmentReschedules(c.Context(), appointmentIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.ReschedulesResponse{
+		Reschedules: reschedules,
+	})
+}
+
+// appointmentResponse returns the appointment with its time in UTC and in the doctor's time zone.
+func appointmentResponse(appointment entity.Appointment) models.AppointmentResponse {
+	response := models.AppointmentResponse{
+		ID:                appointment.ID,
+		DoctorID:          appointment.DoctorID,
+		PatientID:         appointment.PatientID,
+		AppointmentTime:   appointment.AppointmentTime.UTC(),
+		Duration:          appointment.Duration,
+		Status:            appointment.Status,
+		AppointmentTypeID: appointment.AppointmentTypeID,
+		BufferBefore:      appointment.BufferBefore,
+		BufferAfter:       appointment.BufferAfter,
+		Capacity:          appointment.Capacity,
+		LocationID:        appointment.LocationID,
+		RoomID:            appointment.RoomID,
+		SeriesID:          appointment.SeriesID,
+	}
+
+	if loc, err := entity.LoadLocation(appointment.TimeZone); err == nil {
+		response.TimeZone = loc.String()
+		response.LocalTime, response.UTCOffset = localTime(appointment.AppointmentTime, loc)
+	}
+
+	return response
+}
+
+func appointmentsResponse(appointments []entity.Appointment) models.AppointmentsResponse {
+	response := models.AppointmentsResponse{Appointments: make([]models.AppointmentResponse, 0, len(appointments))}
+	for _, appointment := range appointments {
+		response.Appointments = append(response.Appointments, appointmentResponse(appointment))
+	}
+
+	return response
+}
+
+// localTime formats t as RFC3339 in loc and returns it with loc's UTC offset at that instant, e.g. "+02:00".
+func localTime(t time.Time, loc *time.Location) (string, string) {
+	local := t.In(loc)
+
+	return local.Format(time.RFC3339), local.Format("-07:00")
+}
+
+// appointmentErrorResponse maps booking and lifecycle errors to their HTTP status.
+func appointmentErrorResponse(c *fiber.Ctx, err error) error {
+	var bookingErr *entity.BookingError
+
+	switch {
+	case errors.As(err, &bookingErr):
+		return c.Status(bookingErrorStatus(bookingErr)).JSON(bookingErr)
+	case errors.Is(err, entity.ErrInvalidTransition):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrAppointmentTypeRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrAppointmentTypeNotFound), errors.Is(err, entity.ErrAppointmentNotFound),
+		errors.Is(err, entity.ErrSeriesNotFound), errors.Is(err, entity.ErrWaitlistEntryNotFound),
+		errors.Is(err, entity.ErrWaitlistOfferNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrAppointmentConflict), errors.Is(err, entity.ErrAppointmentModified):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// bookingErrorStatus - 409 for a booking refused because others already hold its time, 422 for
+// one that can't be made at all.
+func bookingErrorStatus(err *entity.BookingError) int {
+	switch err {
+	case entity.ErrSlotFull, entity.ErrRoomTaken:
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusUnprocessableEntity
+	}
+}
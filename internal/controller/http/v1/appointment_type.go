@@ -0,0 +1,206 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create appointment type
+// @Description Add an appointment type for a doctor or for every doctor of a specialization. It sets the duration, the buffers kept free before and after and how many patients share a slot.
+// @Accept json
+// @Produce json
+// @Tags appointment-type
+// @Param appointment_type body models.AppointmentTypeRequest true "Appointment type"
+// @Success 201 {object} entity.AppointmentType
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-types [post]
+func (h *HandlerV1) CreateAppointmentType(c *fiber.Ctx) error {
+	req := models.AppointmentTypeRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	appointmentType, err := h.AppointmentType.CreateAppointmentType(c.Context(), appointmentTypeFromRequest(req))
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(appointmentType)
+}
+
+// @Summary List appointment types
+// @Description List every appointment type
+// @Accept json
+// @Produce json
+// @Tags appointment-type
+// @Success 200 {object} models.AppointmentTypesResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-types [get]
+func (h *HandlerV1) ListAppointmentTypes(c *fiber.Ctx) error {
+	appointmentTypes, err := h.AppointmentType.ListAppointmentTypes(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(models.AppointmentTypesResponse{AppointmentTypes: appointmentTypes})
+}
+
+// @Summary Get appointment type
+// @Description Get an appointment type
+// @Accept json
+// @Produce json
+// @Tags appointment-type
+// @Param type_id path int true "Appointment type ID"
+// @Success 200 {object} entity.AppointmentType
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-types/{type_id} [get]
+func (h *HandlerV1) GetAppointmentType(c *fiber.Ctx) error {
+	typeID, err := strconv.Atoi(c.Params("type_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment type ID"})
+	}
+
+	appointmentType, err := h.AppointmentType.GetAppointmentType(c.Context(), typeID)
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+
+	return c.JSON(appointmentType)
+}
+
+// @Summary Get doctor appointment types
+// @Description List the appointment types a doctor can be booked with: their own and those of the specializations they hold
+// @Accept json
+// @Produce json
+// @Tags doctor
+// @Param id path int true "Doctor ID"
+// @Success 200 {object} models.AppointmentTypesResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /doctors/{id}/appointment-types [get]
+func (h *HandlerV1) GetDoctorAppointmentTypes(c *fiber.Ctx) error {
+	doctorID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid doctor ID"})
+	}
+
+	appointmentTypes, err := h.AppointmentType.GetAppointmentTypesByDoctorID(c.Context(), doctorID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(models.AppointmentTypesResponse{AppointmentTypes: appointmentTypes})
+}
+
+// @Summary Update appointment type
+// @Description Replace an appointment type. Appointments already booked keep the duration, buffers and capacity they were booked with.
+// @Accept json
+// @Produce json
+// @Tags appointment-type
+// @Param type_id path int true "Appointment type ID"
+// @Param appointment_type body models.AppointmentTypeRequest true "Appointment type"
+// @Success 200 {object} entity.AppointmentType
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-types/{type_id} [put]
+func (h *HandlerV1) UpdateAppointmentType(c *fiber.Ctx) error {
+	typeID, err := strconv.Atoi(c.Params("type_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment type ID"})
+	}
+
+	req := models.AppointmentTypeRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	appointmentType := appointmentTypeFromRequest(req)
+	appointmentType.ID = typeID
+
+	appointmentType, err = h.AppointmentType.UpdateAppointmentType(c.Context(), appointmentType)
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+
+	return c.JSON(appointmentType)
+}
+
+// @Summary Delete appointment type
+// @Description Remove an appointment type. One that active appointments are booked with can't be removed.
+// @Accept json
+// @Produce json
+// @Tags appointment-type
+// @Param type_id path int true "Appointment type ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-types/{type_id} [delete]
+func (h *HandlerV1) DeleteAppointmentType(c *fiber.Ctx) error {
+	typeID, err := strconv.Atoi(c.Params("type_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment type ID"})
+	}
+
+	err = h.AppointmentType.DeleteAppointmentType(c.Context(), typeID)
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "appointment type deleted successfully",
+	})
+}
+
+func appointmentTypeFromRequest(req models.AppointmentTypeRequest) entity.AppointmentType {
+	return entity.AppointmentType{
+		Name:             req.Name,
+		DoctorID:         req.DoctorID,
+		SpecializationID: req.SpecializationID,
+		Duration:         req.Duration,
+		BufferBefore:     req.BufferBefore,
+		BufferAfter:      req.BufferAfter,
+		Capacity:         req.Capacity,
+	}
+}
+
+func appointmentTypeErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidAppointmentType):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrAppointmentTypeNotFound), errors.Is(err, entity.ErrDoctorNotFound),
+		errors.Is(err, entity.ErrSpecializationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrAppointmentTypeInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -1,24 +1,27 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/middleware"
 	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/etc"
-	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
 	"github.com/gofiber/fiber/v2"
 )
 
 // @Summary Sign up user
-// @Description Sign up a user
+// @Description Sign up a user and mail them a link to verify their email address, which booking needs
 // @Accept json
 // @Produce json
 // @Tags auth
 // @Param user body models.SignUpUserRequest true "User"
-// @Success 201 {object} models.UserResponse
+// @Success 201 {object} models.TokenResponse
 // @Failure 400 {object} models.Error
 // @Router /auth/signup [post]
 func (r *HandlerV1) SignUpUser(c *fiber.Ctx) error {
@@ -42,19 +45,11 @@ func (r *HandlerV1) SignUpUser(c *fiber.Ctx) error {
 		return errorResponse(c, http.StatusInternalServerError, "failed to hash password")
 	}
 
-	token, err := tokens.GenerateJWTToken(req.Email, string(entity.RoleUser), r.Config.Jwt.Secret, time.Duration(r.Config.Jwt.ExpiresAt)*time.Second)
-	if err != nil {
-		r.Logger.Error(err, "http - v1 - sign up user")
-
-		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
-	}
-
-	_, err = r.User.CreateUser(c.Context(), entity.User{
+	id, err := r.User.CreateUser(c.Context(), entity.User{
 		Email:    req.Email,
 		Password: hashedPassword,
 		FullName: req.FullName,
 		Role:     entity.RoleUser,
-		Token:    token,
 	})
 
 	if err != nil {
@@ -63,18 +58,46 @@ func (r *HandlerV1) SignUpUser(c *fiber.Ctx) error {
 		return errorResponse(c, http.StatusInternalServerError, "failed to create user")
 	}
 
-	return c.Status(http.StatusCreated).JSON(fiber.Map{"message": "User created successfully", "token": token})
+	// The account works without a verified address, only booking waits for it.
+	err = r.User.SendEmailVerification(c.Context(), id)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - sign up user")
+	}
+
+	session, refreshToken, err := r.Session.CreateSession(c.Context(), id, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - sign up user")
 
+		return errorResponse(c, http.StatusInternalServerError, "failed to create session")
+	}
+
+	resp, err := r.tokenResponse(session, req.Email, entity.RoleUser, refreshToken)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - sign up user")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
+	}
+
+	resp.Message = "User created successfully"
+
+	return c.Status(http.StatusCreated).JSON(resp)
 }
 
 // @Summary Sign in user
-// @Description Sign in a user
+// @Description Sign in a user. Failed attempts slow down further ones for the account and the client IP,
+// @Description and too many lock them out for a while (429 with Retry-After). Users with two-factor
+// @Description authentication, or whose role requires it, get a challenge (202) to answer at /auth/2fa/verify
+// @Description instead of tokens. Deactivated users get 403.
 // @Accept json
 // @Produce json
 // @Tags auth
 // @Param user body models.SignInUserRequest true "User"
-// @Success 200 {object} models.UserResponse
+// @Success 200 {object} models.TokenResponse
+// @Success 202 {object} models.TwoFactorChallengeResponse
 // @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 429 {object} models.Error
 // @Router /auth/signin [post]
 func (r *HandlerV1) SignInUser(c *fiber.Ctx) error {
 	var req models.SignInUserRequest
@@ -84,34 +107,345 @@ func (r *HandlerV1) SignInUser(c *fiber.Ctx) error {
 		return errorResponse(c, http.StatusBadRequest, "invalid request body")
 	}
 
-	user, err := r.User.GetPasswordHash(c.Context(), req.Email)
+	var throttled *entity.LoginThrottledError
+
+	err := r.Login.CheckLogin(c.Context(), req.Email, c.IP())
+	if errors.As(err, &throttled) {
+		return throttledResponse(c, throttled)
+	}
+
 	if err != nil {
 		r.Logger.Error(err, "http - v1 - sign in user")
 
-		return errorResponse(c, http.StatusInternalServerError, "failed to get password hash")
+		return errorResponse(c, http.StatusInternalServerError, "failed to check sign-in attempts")
 	}
 
-	fmt.Println("password hash", user.PasswordHash, "Request password", req.Password)
+	user, err := r.User.GetPasswordHash(c.Context(), req.Email)
+	if err != nil && !errors.Is(err, entity.ErrUserNotFound) {
+		r.Logger.Error(err, "http - v1 - sign in user")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to get password hash")
+	}
 
-	if !etc.CheckPasswordHash(req.Password, user.PasswordHash) {
-		r.Logger.Error("invalid credentials", "http - v1 - sign in user")
+	// An unknown email counts as a failure too, so it can't be told apart from a wrong password.
+	if err != nil || !etc.CheckPasswordHash(req.Password, user.PasswordHash) {
+		if err = r.Login.RecordLoginFailure(c.Context(), req.Email, c.IP()); err != nil {
+			r.Logger.Error(err, "http - v1 - sign in user")
+		}
 
 		return errorResponse(c, http.StatusUnauthorized, "invalid credentials")
 	}
 
-	token, err := tokens.GenerateJWTToken(req.Email, string(entity.RoleUser), r.Config.Jwt.Secret, time.Duration(r.Config.Jwt.ExpiresAt)*time.Second)
+	if user.DeactivatedAt != nil {
+		return errorResponse(c, http.StatusForbidden, entity.ErrUserDeactivated.Error())
+	}
+
+	challenge, err := r.TwoFactor.StartTwoFactor(c.Context(), user.ID)
 	if err != nil {
 		r.Logger.Error(err, "http - v1 - sign in user")
 
-		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
+		return errorResponse(c, http.StatusInternalServerError, "failed to start two-factor authentication")
 	}
 
-	err = r.User.UpdateToken(c.Context(), user.ID, token)
+	if challenge.Token != "" {
+		return c.Status(http.StatusAccepted).JSON(models.TwoFactorChallengeResponse{
+			Message:        "Two-factor authentication required",
+			ChallengeToken: challenge.Token,
+			Method:         challenge.Method,
+			ExpiresIn:      int(math.Ceil(time.Until(challenge.ExpiresAt).Seconds())),
+		})
+	}
+
+	// With two factors, the failed sign-ins are only forgotten once the code is right too.
+	if err = r.Login.RecordLoginSuccess(c.Context(), req.Email); err != nil {
+		r.Logger.Error(err, "http - v1 - sign in user")
+	}
+
+	session, refreshToken, err := r.Session.CreateSession(c.Context(), user.ID, c.Get(fiber.HeaderUserAgent), c.IP())
 	if err != nil {
 		r.Logger.Error(err, "http - v1 - sign in user")
 
-		return errorResponse(c, http.StatusInternalServerError, "failed to update token")
+		return errorResponse(c, http.StatusInternalServerError, "failed to create session")
+	}
+
+	resp, err := r.tokenResponse(session, req.Email, user.Role, refreshToken)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - sign in user")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
+	}
+
+	resp.Message = "User signed in successfully"
+
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token. Each refresh token works once;
+// @Description presenting a used one again ends its session.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Router /auth/refresh [post]
+func (r *HandlerV1) RefreshToken(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	session, refreshToken, err := r.Session.RefreshSession(c.Context(), req.RefreshToken)
+	if errors.Is(err, entity.ErrInvalidRefreshToken) || errors.Is(err, entity.ErrRefreshTokenReused) {
+		return errorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - refresh token")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to refresh session")
+	}
+
+	user, err := r.User.GetUserByID(c.Context(), session.UserID)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - refresh token")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to get user")
+	}
+
+	if user.DeactivatedAt != nil {
+		return errorResponse(c, http.StatusForbidden, entity.ErrUserDeactivated.Error())
+	}
+
+	resp, err := r.tokenResponse(session, user.Email, user.Role, refreshToken)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - refresh token")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
 	}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User signed in successfully", "token": token})
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// @Summary Log out
+// @Description End the current session. Its access and refresh tokens stop working.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func (r *HandlerV1) Logout(c *fiber.Ctx) error {
+	userID, _ := currentUserID(c)
+	sessionID, _ := c.Locals(middleware.SessionIDKey).(int)
+
+	err := r.Session.Logout(c.Context(), userID, sessionID)
+	if errors.Is(err, entity.ErrSessionRevoked) {
+		return errorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - logout")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to log out")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{Message: "Logged out successfully"})
+}
+
+// @Summary Log out everywhere
+// @Description End every session of the current user, on all devices.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/logout-all [post]
+func (r *HandlerV1) LogoutAll(c *fiber.Ctx) error {
+	userID, _ := currentUserID(c)
+
+	revoked, err := r.Session.LogoutAll(c.Context(), userID)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - logout all")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to log out")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{
+		Message: fmt.Sprintf("Logged out of %d sessions", revoked),
+	})
+}
+
+// @Summary Forgot password
+// @Description Mail a password reset link to the address, if an account uses it. The response is the same either way.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.ForgotPasswordRequest true "Email"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Router /auth/forgot-password [post]
+func (r *HandlerV1) ForgotPassword(c *fiber.Ctx) error {
+	var req models.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	// Failures are only logged so the response doesn't tell whether the account exists.
+	err := r.User.RequestPasswordReset(c.Context(), req.Email)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - forgot password")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{
+		Message: "If an account uses this email, a password reset link has been sent to it",
+	})
+}
+
+// @Summary Reset password
+// @Description Set a new password with the token from a reset link. Every session of the user is ended.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.ResetPasswordRequest true "Token and new password"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /auth/reset-password [post]
+func (r *HandlerV1) ResetPassword(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	hashedPassword, err := etc.HashPassword(req.Password)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - reset password")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to hash password")
+	}
+
+	err = r.User.ResetPassword(c.Context(), req.Token, hashedPassword)
+	if errors.Is(err, entity.ErrInvalidUserToken) {
+		return errorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - reset password")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to reset password")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{Message: "Password reset successfully"})
+}
+
+// @Summary Verify email
+// @Description Verify an email address with the token from a verification link, sent either in the body or as ?token=
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.VerifyEmailRequest false "Token"
+// @Param token query string false "Token"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /auth/verify-email [get]
+// @Router /auth/verify-email [post]
+func (r *HandlerV1) VerifyEmail(c *fiber.Ctx) error {
+	req := models.VerifyEmailRequest{Token: c.Query("token")}
+	if req.Token == "" && c.Method() == fiber.MethodPost {
+		if err := c.BodyParser(&req); err != nil {
+			return errorResponse(c, http.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "token is required")
+	}
+
+	err := r.User.VerifyEmail(c.Context(), req.Token)
+	if errors.Is(err, entity.ErrInvalidUserToken) {
+		return errorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - verify email")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to verify email")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{Message: "Email verified successfully"})
+}
+
+// @Summary Resend verification email
+// @Description Mail the current user a new email verification link. Earlier links stop working.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/verify-email/resend [post]
+func (r *HandlerV1) ResendEmailVerification(c *fiber.Ctx) error {
+	userID, _ := currentUserID(c)
+
+	err := r.User.SendEmailVerification(c.Context(), userID)
+	if errors.Is(err, entity.ErrEmailAlreadyVerified) {
+		return errorResponse(c, http.StatusConflict, err.Error())
+	}
+
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - resend email verification")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to send verification email")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{Message: "Verification email sent"})
+}
+
+// tokenResponse signs an access token for the session and pairs it with the refresh token.
+func (r *HandlerV1) tokenResponse(session entity.Session, email string, role entity.Role, refreshToken string) (models.TokenResponse, error) {
+	expiresIn := r.Config.Jwt.ExpiresAt
+
+	accessToken, err := r.Keys.GenerateJWTToken(session.UserID, session.ID, email, string(role), time.Duration(expiresIn)*time.Second)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn,
+	}, nil
+}
+
+// throttledResponse tells the client how many seconds to wait before signing in again.
+func throttledResponse(c *fiber.Ctx, throttled *entity.LoginThrottledError) error {
+	retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       throttled.Error(),
+		"retry_after": retryAfter,
+	})
 }
@@ -0,0 +1,476 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create clinic
+// @Description Add a clinic. Its locations and their rooms are added under it.
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param clinic body models.ClinicRequest true "Clinic"
+// @Success 201 {object} entity.Clinic
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics [post]
+func (h *HandlerV1) CreateClinic(c *fiber.Ctx) error {
+	req := models.ClinicRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	clinic, err := h.Clinic.CreateClinic(c.Context(), entity.Clinic{Name: req.Name})
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(clinic)
+}
+
+// @Summary List clinics
+// @Description List the clinics
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Success 200 {object} models.ClinicsResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics [get]
+func (h *HandlerV1) ListClinics(c *fiber.Ctx) error {
+	clinics, err := h.Clinic.ListClinics(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(models.ClinicsResponse{Clinics: clinics})
+}
+
+// @Summary Get clinic
+// @Description Get a clinic
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param clinic_id path int true "Clinic ID"
+// @Success 200 {object} entity.Clinic
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics/{clinic_id} [get]
+func (h *HandlerV1) GetClinic(c *fiber.Ctx) error {
+	clinicID, err := strconv.Atoi(c.Params("clinic_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid clinic ID"})
+	}
+
+	clinic, err := h.Clinic.GetClinic(c.Context(), clinicID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(clinic)
+}
+
+// @Summary Update clinic
+// @Description Rename a clinic
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param clinic_id path int true "Clinic ID"
+// @Param clinic body models.ClinicRequest true "Clinic"
+// @Success 200 {object} entity.Clinic
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics/{clinic_id} [put]
+func (h *HandlerV1) UpdateClinic(c *fiber.Ctx) error {
+	clinicID, err := strconv.Atoi(c.Params("clinic_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid clinic ID"})
+	}
+
+	req := models.ClinicRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	clinic, err := h.Clinic.UpdateClinic(c.Context(), entity.Clinic{ID: clinicID, Name: req.Name})
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(clinic)
+}
+
+// @Summary Delete clinic
+// @Description Remove a clinic. One that still has locations can't be removed.
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param clinic_id path int true "Clinic ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics/{clinic_id} [delete]
+func (h *HandlerV1) DeleteClinic(c *fiber.Ctx) error {
+	clinicID, err := strconv.Atoi(c.Params("clinic_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid clinic ID"})
+	}
+
+	err = h.Clinic.DeleteClinic(c.Context(), clinicID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "clinic deleted successfully",
+	})
+}
+
+// @Summary Create location
+// @Description Add a location to a clinic
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param clinic_id path int true "Clinic ID"
+// @Param location body models.LocationRequest true "Location"
+// @Success 201 {object} entity.Location
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics/{clinic_id}/locations [post]
+func (h *HandlerV1) CreateLocation(c *fiber.Ctx) error {
+	clinicID, err := strconv.Atoi(c.Params("clinic_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid clinic ID"})
+	}
+
+	req := models.LocationRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	location, err := h.Clinic.CreateLocation(c.Context(), entity.Location{ClinicID: clinicID, Name: req.Name, Address: req.Address})
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(location)
+}
+
+// @Summary List clinic locations
+// @Description List the locations of a clinic
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param clinic_id path int true "Clinic ID"
+// @Success 200 {object} models.LocationsResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /clinics/{clinic_id}/locations [get]
+func (h *HandlerV1) GetClinicLocations(c *fiber.Ctx) error {
+	clinicID, err := strconv.Atoi(c.Params("clinic_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid clinic ID"})
+	}
+
+	locations, err := h.Clinic.GetLocationsByClinicID(c.Context(), clinicID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(models.LocationsResponse{Locations: locations})
+}
+
+// @Summary Get location
+// @Description Get a location
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param location_id path int true "Location ID"
+// @Success 200 {object} entity.Location
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /locations/{location_id} [get]
+func (h *HandlerV1) GetLocation(c *fiber.Ctx) error {
+	locationID, err := strconv.Atoi(c.Params("location_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	location, err := h.Clinic.GetLocation(c.Context(), locationID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(location)
+}
+
+// @Summary Update location
+// @Description Rename a location or change its address. It stays with its clinic.
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param location_id path int true "Location ID"
+// @Param location body models.LocationRequest true "Location"
+// @Success 200 {object} entity.Location
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /locations/{location_id} [put]
+func (h *HandlerV1) UpdateLocation(c *fiber.Ctx) error {
+	locationID, err := strconv.Atoi(c.Params("location_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	req := models.LocationRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	location, err := h.Clinic.UpdateLocation(c.Context(), entity.Location{ID: locationID, Name: req.Name, Address: req.Address})
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(location)
+}
+
+// @Summary Delete location
+// @Description Remove a location and its rooms. One that doctors' current schedules or active appointments are at can't be removed.
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param location_id path int true "Location ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /locations/{location_id} [delete]
+func (h *HandlerV1) DeleteLocation(c *fiber.Ctx) error {
+	locationID, err := strconv.Atoi(c.Params("location_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	err = h.Clinic.DeleteLocation(c.Context(), locationID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "location deleted successfully",
+	})
+}
+
+// @Summary Create room
+// @Description Add a room to a location
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param location_id path int true "Location ID"
+// @Param room body models.RoomRequest true "Room"
+// @Success 201 {object} entity.Room
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /locations/{location_id}/rooms [post]
+func (h *HandlerV1) CreateRoom(c *fiber.Ctx) error {
+	locationID, err := strconv.Atoi(c.Params("location_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	req := models.RoomRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	room, err := h.Clinic.CreateRoom(c.Context(), entity.Room{LocationID: locationID, Name: req.Name})
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(room)
+}
+
+// @Summary List location rooms
+// @Description List the rooms of a location
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param location_id path int true "Location ID"
+// @Success 200 {object} models.RoomsResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /locations/{location_id}/rooms [get]
+func (h *HandlerV1) GetLocationRooms(c *fiber.Ctx) error {
+	locationID, err := strconv.Atoi(c.Params("location_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	rooms, err := h.Clinic.GetRoomsByLocationID(c.Context(), locationID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(models.RoomsResponse{Rooms: rooms})
+}
+
+// @Summary Get room
+// @Description Get a room
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param room_id path int true "Room ID"
+// @Success 200 {object} entity.Room
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /rooms/{room_id} [get]
+func (h *HandlerV1) GetRoom(c *fiber.Ctx) error {
+	roomID, err := strconv.Atoi(c.Params("room_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room ID"})
+	}
+
+	room, err := h.Clinic.GetRoom(c.Context(), roomID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(room)
+}
+
+// @Summary Update room
+// @Description Rename a room. It stays at its location.
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param room_id path int true "Room ID"
+// @Param room body models.RoomRequest true "Room"
+// @Success 200 {object} entity.Room
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /rooms/{room_id} [put]
+func (h *HandlerV1) UpdateRoom(c *fiber.Ctx) error {
+	roomID, err := strconv.Atoi(c.Params("room_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room ID"})
+	}
+
+	req := models.RoomRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	room, err := h.Clinic.UpdateRoom(c.Context(), entity.Room{ID: roomID, Name: req.Name})
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.JSON(room)
+}
+
+// @Summary Delete room
+// @Description Remove a room. One that doctors' current schedules or active appointments are in can't be removed.
+// @Accept json
+// @Produce json
+// @Tags clinic
+// @Param room_id path int true "Room ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /rooms/{room_id} [delete]
+func (h *HandlerV1) DeleteRoom(c *fiber.Ctx) error {
+	roomID, err := strconv.Atoi(c.Params("room_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid room ID"})
+	}
+
+	err = h.Clinic.DeleteRoom(c.Context(), roomID)
+	if err != nil {
+		return clinicErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "room deleted successfully",
+	})
+}
+
+func clinicErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidClinic), errors.Is(err, entity.ErrInvalidLocation), errors.Is(err, entity.ErrInvalidRoom):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrClinicNotFound), errors.Is(err, entity.ErrLocationNotFound), errors.Is(err, entity.ErrRoomNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrClinicInUse), errors.Is(err, entity.ErrLocationInUse), errors.Is(err, entity.ErrRoomInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"strconv"
 	"time"
 
@@ -10,14 +11,17 @@ import (
 )
 
 // @Summary Create doctor
-// @Description Create doctor
+// @Description Create doctor. Specializations are catalogue codes, the main one first. Setting user_id lets that user, whose role must grant doctor:self, sign in as the doctor.
 // @Accept json
 // @Produce json
 // @Tags doctor
 // @Param doctor body models.Doctor true "Doctor"
 // @Success 201 {object} models.DoctorResponse
 // @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /doctors [post]
 func (h *HandlerV1) CreateDoctor(c *fiber.Ctx) error {
 	doctor := models.Doctor{}
@@ -25,29 +29,41 @@ func (h *HandlerV1) CreateDoctor(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	schedule := scheduleToEntity(doctor.Schedule)
+	if err := schedule.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	loc, err := entity.LoadLocation(doctor.TimeZone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	timeNow := time.Now()
 
-	err := h.Doctor.CreateDoctor(c.Context(), entity.Doctor{
-		Name:           doctor.Name,
-		Specialization: doctor.Specialization,
-		Schedule: entity.Schedule{
-			Days:  doctor.Schedule.Days,
-			Start: doctor.Schedule.Start,
-			End:   doctor.Schedule.End,
-		},
-		CreatedAt: timeNow,
-		UpdatedAt: timeNow,
+	err = h.Doctor.CreateDoctor(c.Context(), entity.Doctor{
+		Name:              doctor.Name,
+		UserID:            doctor.UserID,
+		Specializations:   specializationsFromCodes(doctor.Specializations),
+		Schedule:          schedule,
+		HolidayCalendarID: doctor.HolidayCalendarID,
+		TimeZone:          loc.String(),
+		CreatedAt:         timeNow,
+		UpdatedAt:         timeNow,
 	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return doctorErrorResponse(c, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(models.DoctorResponse{
-		Name:           doctor.Name,
-		Specialization: doctor.Specialization,
-		Schedule:       doctor.Schedule,
-		CreatedAt:      timeNow,
-		UpdatedAt:      timeNow,
+		Name:              doctor.Name,
+		UserID:            doctor.UserID,
+		Specializations:   specializationsFromCodes(doctor.Specializations),
+		Schedule:          doctor.Schedule,
+		HolidayCalendarID: doctor.HolidayCalendarID,
+		TimeZone:          loc.String(),
+		CreatedAt:         timeNow,
+		UpdatedAt:         timeNow,
 	})
 }
 
@@ -61,6 +77,7 @@ func (h *HandlerV1) CreateDoctor(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /doctors/{id} [get]
 func (h *HandlerV1) GetDoctorByID(c *fiber.Ctx) error {
 	doctorID := c.Params("id")
@@ -74,31 +91,22 @@ func (h *HandlerV1) GetDoctorByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(models.DoctorResponse{
-		ID:             doctor.ID,
-		Name:           doctor.Name,
-		Specialization: doctor.Specialization,
-		Schedule: models.Schedule{
-			Days:  doctor.Schedule.Days,
-			Start: doctor.Schedule.Start,
-			End:   doctor.Schedule.End,
-		},
-		CreatedAt: doctor.CreatedAt,
-		UpdatedAt: doctor.UpdatedAt,
-	})
+	return c.JSON(doctorResponse(doctor))
 }
 
 // @Summary Update doctor
-// @Description Update doctor
+// @Description Update doctor. Specializations are catalogue codes, the main one first. Setting user_id lets that user, whose role must grant doctor:self, sign in as the doctor.
 // @Accept json
 // @Produce json
 // @Tags doctor
 // @Param id path int true "Doctor ID"
 // @Param doctor body models.Doctor true "Doctor"
-// @Success 200 {object} models.Doctor
+// @Success 200 {object} models.DoctorResponse
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /doctors/{id} [put]
 func (h *HandlerV1) UpdateDoctor(c *fiber.Ctx) error {
 	doctorID := c.Params("id")
@@ -112,6 +120,16 @@ func (h *HandlerV1) UpdateDoctor(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	schedule := scheduleToEntity(doctor.Schedule)
+	if err := schedule.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	loc, err := entity.LoadLocation(doctor.TimeZone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Get doctor by id
 	doctorGet, err := h.Doctor.GetDoctorByID(c.Context(), doctorIDInt)
 	if err != nil {
@@ -121,29 +139,26 @@ func (h *HandlerV1) UpdateDoctor(c *fiber.Ctx) error {
 	timeNow := time.Now()
 
 	err = h.Doctor.UpdateDoctor(c.Context(), entity.Doctor{
-		ID:             doctorIDInt,
-		Name:           doctor.Name,
-		Specialization: doctor.Specialization,
-		Schedule: entity.Schedule{
-			Days:  doctor.Schedule.Days,
-			Start: doctor.Schedule.Start,
-			End:   doctor.Schedule.End,
-		},
-		CreatedAt: doctorGet.CreatedAt,
-		UpdatedAt: timeNow,
+		ID:                doctorIDInt,
+		Name:              doctor.Name,
+		UserID:            doctor.UserID,
+		Specializations:   specializationsFromCodes(doctor.Specializations),
+		Schedule:          schedule,
+		HolidayCalendarID: doctor.HolidayCalendarID,
+		TimeZone:          loc.String(),
+		CreatedAt:         doctorGet.CreatedAt,
+		UpdatedAt:         timeNow,
 	})
+	if err != nil {
+		return doctorErrorResponse(c, err)
+	}
+
+	updated, err := h.Doctor.GetDoctorByID(c.Context(), doctorIDInt)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(models.DoctorResponse{
-		ID:             doctorIDInt,
-		Name:           doctor.Name,
-		Specialization: doctor.Specialization,
-		Schedule:       doctor.Schedule,
-		CreatedAt:      doctorGet.CreatedAt,
-		UpdatedAt:      timeNow,
-	})
+	return c.JSON(doctorResponse(updated))
 }
 
 // @Summary Delete doctor
@@ -156,6 +171,7 @@ func (h *HandlerV1) UpdateDoctor(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /doctors/{id} [delete]
 func (h *HandlerV1) DeleteDoctor(c *fiber.Ctx) error {
 	doctorID := c.Params("id")
@@ -175,17 +191,33 @@ func (h *HandlerV1) DeleteDoctor(c *fiber.Ctx) error {
 }
 
 // @Summary Get all doctors
-// @Description Get all doctors
+// @Description Get all doctors, or with location_id those whose current schedule has them working at the location
 // @Accept json
 // @Produce json
 // @Tags doctor
+// @Param location_id query int false "Location ID"
 // @Success 200 {object} models.AllDoctorsResponse
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /doctors [get]
 func (h *HandlerV1) GetAllDoctors(c *fiber.Ctx) error {
-	doctors, err := h.Doctor.GetDoctors(c.Context())
+	locationID, err := optionalQueryID(c, "location_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	var doctors []entity.Doctor
+	if locationID != nil {
+		doctors, err = h.Doctor.GetDoctorsByLocationID(c.Context(), *locationID)
+		if errors.Is(err, entity.ErrLocationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+	} else {
+		doctors, err = h.Doctor.GetDoctors(c.Context())
+	}
+
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -196,15 +228,16 @@ func (h *HandlerV1) GetAllDoctors(c *fiber.Ctx) error {
 }
 
 // @Summary Get doctors by specialization
-// @Description Get doctors by specialization
+// @Description Get the doctors holding a specialization from the catalogue
 // @Accept json
 // @Produce json
 // @Tags doctor
-// @Param specialization path string true "Specialization"
+// @Param specialization path string true "Specialization code"
 // @Success 200 {object} models.AllDoctorsResponse
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /doctors/specialization/{specialization} [get]
 func (h *HandlerV1) GetDoctorsBySpecialization(c *fiber.Ctx) error {
 	specialization := c.Params("specialization")
@@ -219,25 +252,232 @@ func (h *HandlerV1) GetDoctorsBySpecialization(c *fiber.Ctx) error {
 	})
 }
 
-// @Summary List specializations
-// @Description List specializations
+// @Summary Get doctor availability
+// @Description Get free appointment slots of a doctor between from and to
 // @Accept json
 // @Produce json
 // @Tags doctor
-// @Success 200 {object} models.SpecializationResponse
+// @Param id path int true "Doctor ID"
+// @Param from query string true "Range start (YYYY-MM-DD in the doctor's time zone, or RFC3339)"
+// @Param to query string true "Range end (YYYY-MM-DD in the doctor's time zone, inclusive, or RFC3339)"
+// @Param duration query int false "Slot length in minutes, the default visit length of the doctor's main specialization by default"
+// @Param appointment_type_id query int false "Appointment type the slots are for; sets their length, spacing and places instead of duration"
+// @Param location_id query int false "Only slots on days the doctor works at this location"
+// @Success 200 {object} models.AvailabilityResponse
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
+// @Failure 422 {object} entity.BookingError
 // @Failure 500 {object} models.Error
-// @Router /doctors/specializations [get]
-func (h *HandlerV1) ListSpecializations(c *fiber.Ctx) error {
-	specializations, err := h.Doctor.ListSpecializations(c.Context())
+// @Security BearerAuth
+// @Router /doctors/{id}/availability [get]
+func (h *HandlerV1) GetDoctorAvailability(c *fiber.Ctx) error {
+	doctorID := c.Params("id")
+	doctorIDInt, err := strconv.Atoi(doctorID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid doctor ID"})
+	}
+
+	doctor, err := h.Doctor.GetDoctorByID(c.Context(), doctorIDInt)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(models.SpecializationResponse{
-		Specializations: specializations,
-	})
+	loc, err := doctor.Location()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	from, to, err := parseRange(c, loc)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response := models.AvailabilityResponse{
+		DoctorID: doctorIDInt,
+		TimeZone: loc.String(),
+		From:     from.UTC(),
+		To:       to.UTC(),
+	}
+
+	locationID, err := optionalQueryID(c, "location_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid location ID"})
+	}
+
+	var slots []entity.Slot
+
+	if c.Query("appointment_type_id") != "" {
+		appointmentTypeID, err := strconv.Atoi(c.Query("appointment_type_id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment type ID"})
+		}
+
+		appointmentType, err := h.AppointmentType.GetAppointmentType(c.Context(), appointmentTypeID)
+		if err != nil {
+			return appointmentErrorResponse(c, err)
+		}
+
+		response.Duration = appointmentType.Duration
+		response.AppointmentTypeID = &appointmentType.ID
+
+		slots, err = h.Doctor.GetAppointmentTypeAvailability(c.Context(), doctorIDInt, appointmentTypeID, from, to, locationID)
+		if err != nil {
+			return availabilityErrorResponse(c, err)
+		}
+	} else {
+		response.Duration = c.QueryInt("duration", doctor.VisitMinutes())
+		if response.Duration <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid duration"})
+		}
+
+		slots, err = h.Doctor.GetAvailability(c.Context(), doctorIDInt, from, to, time.Duration(response.Duration)*time.Minute, locationID)
+		if err != nil {
+			return availabilityErrorResponse(c, err)
+		}
+	}
+
+	response.Slots = slotsResponse(slots, loc)
+
+	return c.JSON(response)
+}
+
+func availabilityErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, entity.ErrInvalidTimeRange) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return appointmentErrorResponse(c, err)
+}
+
+func doctorResponse(doctor entity.Doctor) models.DoctorResponse {
+	return models.DoctorResponse{
+		ID:                doctor.ID,
+		Name:              doctor.Name,
+		UserID:            doctor.UserID,
+		Specializations:   doctor.Specializations,
+		Schedule:          scheduleFromEntity(doctor.Schedule),
+		HolidayCalendarID: doctor.HolidayCalendarID,
+		TimeZone:          doctor.TimeZone,
+		CreatedAt:         doctor.CreatedAt,
+		UpdatedAt:         doctor.UpdatedAt,
+	}
+}
+
+func doctorErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidDoctorAccount), errors.Is(err, entity.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrUserNotFound), errors.Is(err, entity.ErrSpecializationNotFound),
+		errors.Is(err, entity.ErrLocationNotFound), errors.Is(err, entity.ErrRoomNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrDoctorAccountTaken):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// specializationsFromCodes turns the codes a request names into specializations to be looked up
+// in the catalogue.
+func specializationsFromCodes(codes []string) []entity.Specialization {
+	specializations := make([]entity.Specialization, 0, len(codes))
+	for _, code := range codes {
+		specializations = append(specializations, entity.Specialization{Code: code})
+	}
+
+	return specializations
+}
+
+func scheduleToEntity(schedule models.Schedule) entity.Schedule {
+	rules := make([]entity.ScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		rules = append(rules, entity.ScheduleRule{
+			Weekday:    rule.Weekday,
+			Ranges:     timeRangesToEntity(rule.Ranges),
+			Breaks:     timeRangesToEntity(rule.Breaks),
+			LocationID: rule.LocationID,
+			RoomID:     rule.RoomID,
+		})
+	}
+
+	return entity.Schedule{Rules: rules}
+}
+
+func timeRangesToEntity(ranges []models.TimeRange) []entity.TimeRange {
+	result := make([]entity.TimeRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, entity.TimeRange{Start: r.Start, End: r.End})
+	}
+
+	return result
+}
+
+func scheduleFromEntity(schedule entity.Schedule) models.Schedule {
+	rules := make([]models.ScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		rules = append(rules, models.ScheduleRule{
+			Weekday:    rule.Weekday,
+			Ranges:     timeRangesFromEntity(rule.Ranges),
+			Breaks:     timeRangesFromEntity(rule.Breaks),
+			LocationID: rule.LocationID,
+			RoomID:     rule.RoomID,
+		})
+	}
+
+	return models.Schedule{Version: schedule.Version, Rules: rules}
+}
+
+func timeRangesFromEntity(ranges []entity.TimeRange) []models.TimeRange {
+	result := make([]models.TimeRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, models.TimeRange{Start: r.Start, End: r.End})
+	}
+
+	return result
+}
+
+func slotsResponse(slots []entity.Slot, loc *time.Location) []models.SlotResponse {
+	response := make([]models.SlotResponse, 0, len(slots))
+	for _, slot := range slots {
+		localStart, offset := localTime(slot.Start, loc)
+		localEnd, _ := localTime(slot.End, loc)
+
+		response = append(response, models.SlotResponse{
+			Start:      slot.Start.UTC(),
+			End:        slot.End.UTC(),
+			LocalStart: localStart,
+			LocalEnd:   localEnd,
+			UTCOffset:  offset,
+			Remaining:  slot.Remaining,
+		})
+	}
+
+	return response
+}
+
+// optionalQueryID returns the ID in the query parameter, or nil when it's empty.
+func optionalQueryID(c *fiber.Ctx, key string) (*int, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// parseRangeBound accepts either a date (YYYY-MM-DD), read as midnight in loc, or an RFC3339 timestamp.
+func parseRangeBound(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	return t, false, err
 }
 
 // @Summary Get doctor by specialization
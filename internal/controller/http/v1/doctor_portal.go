@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// _doctorKey holds the doctor the signed-in user works as, set by requireDoctorAccount.
+const _doctorKey = "doctor"
+
+// requireDoctorAccount only lets users linked to a doctor through and keeps that doctor for the
+// handlers after it.
+func (h *HandlerV1) requireDoctorAccount(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return forbidden(c)
+	}
+
+	doctor, err := h.Doctor.GetDoctorByUserID(c.Context(), userID)
+	if errors.Is(err, entity.ErrDoctorNotFound) {
+		return errorResponse(c, fiber.StatusForbidden, "your account isn't linked to a doctor")
+	}
+
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Locals(_doctorKey, doctor)
+
+	return c.Next()
+}
+
+// requireOwnAppointment only lets the doctor through to their own appointments on :appointment_id.
+func (h *HandlerV1) requireOwnAppointment(c *fiber.Ctx) error {
+	appointmentID, err := strconv.Atoi(c.Params("appointment_id"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "invalid appointment ID")
+	}
+
+	appointment, err := h.Appointment.GetAppointmentByID(c.Context(), appointmentID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if appointment.DoctorID != currentDoctor(c).ID {
+		return forbidden(c)
+	}
+
+	return c.Next()
+}
+
+// @Summary Get my doctor
+// @Description Get the doctor the signed-in user works as
+// @Accept json
+// @Produce json
+// @Tags doctor portal
+// @Success 200 {object} models.DoctorResponse
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /me/doctor [get]
+func (h *HandlerV1) GetMyDoctor(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(doctorResponse(currentDoctor(c)))
+}
+
+// @Summary Get my agenda
+// @Description Get the signed-in doctor's appointments on a day in their time zone, cancelled ones left out
+// @Accept json
+// @Produce json
+// @Tags doctor portal
+// @Param date query string false "Day (YYYY-MM-DD), today by default"
+// @Success 200 {object} models.DoctorAgendaResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /me/doctor/agenda [get]
+func (h *HandlerV1) GetMyAgenda(c *fiber.Ctx) error {
+	doctor := currentDoctor(c)
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	day := time.Now().In(loc)
+	if date := c.Query("date"); date != "" {
+		day, err = time.ParseInLocation(time.DateOnly, date, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "date must be YYYY-MM-DD"})
+		}
+	}
+
+	appointments, err := h.Appointment.GetDoctorAgenda(c.Context(), doctor.ID, day)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.DoctorAgendaResponse{
+		DoctorID:     doctor.ID,
+		Date:         day.Format(time.DateOnly),
+		TimeZone:     loc.String(),
+		Appointments: appointmentsResponse(appointments).Appointments,
+	})
+}
+
+// @Summary Decline appointment
+// @Description Decline a booking with the signed-in doctor; the appointment is cancelled
+// @Accept json
+// @Produce json
+// @Tags doctor portal
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.StatusChangeRequest false "Reason"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /me/doctor/appointments/{appointment_id}/decline [post]
+func (h *HandlerV1) DeclineAppointment(c *fiber.Ctx) error {
+	return h.changeAppointmentStatus(c, entity.StatusCancelled)
+}
+
+// @Summary Add my time off
+// @Description Block a period of the signed-in doctor's schedule. Active appointments inside it are reported and, with cancel_conflicts, cancelled
+// @Accept json
+// @Produce json
+// @Tags doctor portal
+// @Param request body models.TimeOffRequest true "Time off"
+// @Success 201 {object} entity.TimeOffResult
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /me/doctor/time-off [post]
+func (h *HandlerV1) AddMyTimeOff(c *fiber.Ctx) error {
+	return h.addTimeOff(c, currentDoctor(c).ID)
+}
+
+// currentDoctor returns the doctor requireDoctorAccount found for the signed-in user.
+func currentDoctor(c *fiber.Ctx) entity.Doctor {
+	doctor, _ := c.Locals(_doctorKey).(entity.Doctor)
+
+	return doctor
+}
@@ -4,86 +4,312 @@ import (
 	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/config"
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/middleware"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/internal/usecase"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
 )
 
 type HandlerV1 struct {
-	Config         *config.Config
-	Logger         logger.Interface
-	Validation     *validator.Validate
-	ContextTimeout time.Duration
-	User           usecase.UserUsecase
-	Doctor         usecase.DoctorUsecase
-	Appointment    usecase.AppointmentUsecase
-	Router         fiber.Router
+	Config          *config.Config
+	Logger          logger.Interface
+	Validation      *validator.Validate
+	ContextTimeout  time.Duration
+	User            usecase.UserUsecase
+	Doctor          usecase.DoctorUsecase
+	Appointment     usecase.AppointmentUsecase
+	Schedule        usecase.ScheduleUsecase
+	Waitlist        usecase.WaitlistUsecase
+	Role            usecase.RoleUsecase
+	Session         usecase.SessionUsecase
+	Login           usecase.LoginUsecase
+	TwoFactor       usecase.TwoFactorUsecase
+	SSO             usecase.SSOUsecase
+	APIKey          usecase.APIKeyUsecase
+	Patient         usecase.PatientUsecase
+	Specialization  usecase.SpecializationUsecase
+	AppointmentType usecase.AppointmentTypeUsecase
+	Clinic          usecase.ClinicUsecase
+	Keys            *tokens.KeySet
+	Router          fiber.Router
 }
 
 type HandlerV1Config struct {
-	Config         *config.Config
-	Logger         logger.Interface
-	Validation     *validator.Validate
-	ContextTimeout time.Duration
-	User           usecase.UserUsecase
-	Doctor         usecase.DoctorUsecase
-	Appointment    usecase.AppointmentUsecase
-	Router         fiber.Router
+	Config          *config.Config
+	Logger          logger.Interface
+	Validation      *validator.Validate
+	ContextTimeout  time.Duration
+	User            usecase.UserUsecase
+	Doctor          usecase.DoctorUsecase
+	Appointment     usecase.AppointmentUsecase
+	Schedule        usecase.ScheduleUsecase
+	Waitlist        usecase.WaitlistUsecase
+	Role            usecase.RoleUsecase
+	Session         usecase.SessionUsecase
+	Login           usecase.LoginUsecase
+	TwoFactor       usecase.TwoFactorUsecase
+	SSO             usecase.SSOUsecase
+	APIKey          usecase.APIKeyUsecase
+	Patient         usecase.PatientUsecase
+	Specialization  usecase.SpecializationUsecase
+	AppointmentType usecase.AppointmentTypeUsecase
+	Clinic          usecase.ClinicUsecase
+	Keys            *tokens.KeySet
+	Router          fiber.Router
 }
 
 func NewUserRoutes(c HandlerV1Config) {
 	r := &HandlerV1{
-		Config:         c.Config,
-		Logger:         c.Logger,
-		Validation:     c.Validation,
-		ContextTimeout: c.ContextTimeout,
-		User:           c.User,
-		Doctor:         c.Doctor,
-		Appointment:    c.Appointment,
-		Router:         c.Router,
+		Config:          c.Config,
+		Logger:          c.Logger,
+		Validation:      c.Validation,
+		ContextTimeout:  c.ContextTimeout,
+		User:            c.User,
+		Doctor:          c.Doctor,
+		Appointment:     c.Appointment,
+		Schedule:        c.Schedule,
+		Waitlist:        c.Waitlist,
+		Role:            c.Role,
+		Session:         c.Session,
+		Login:           c.Login,
+		TwoFactor:       c.TwoFactor,
+		SSO:             c.SSO,
+		APIKey:          c.APIKey,
+		Patient:         c.Patient,
+		Specialization:  c.Specialization,
+		AppointmentType: c.AppointmentType,
+		Clinic:          c.Clinic,
+		Keys:            c.Keys,
+		Router:          c.Router,
 	}
 
-	// Should not be role required in signup
+	// Everything but signing up, in (two-factor challenges and single sign-on included), refreshing
+	// and the mailed links needs a signed-in user with an open session; booking also needs a
+	// verified email address.
+	// What else a route needs is a permission granted through the user's role; owner-scoped routes
+	// also let the users linked to a patient - the patient themselves, a parent or a carer - reach
+	// the patient's appointments, series and waitlist entries without it.
+	// Other systems may call the API with an API key instead, acting with the key's permissions;
+	// a user's own account, sessions and API keys are only reachable by a signed-in user.
+	auth := middleware.Authentication(middleware.AuthConfig{
+		Keys:    r.Keys,
+		Access:  r.Role.GetUserAccess,
+		Session: r.Session.CheckSession,
+		APIKey:  r.APIKey.AuthenticateAPIKey,
+	})
+	userAuth := middleware.Authentication(middleware.AuthConfig{
+		Keys:    r.Keys,
+		Access:  r.Role.GetUserAccess,
+		Session: r.Session.CheckSession,
+	})
+	can := middleware.RequirePermission
+
 	authGroup := r.Router.Group("/auth")
 	{
 		authGroup.Post("/signup", r.SignUpUser)
 		authGroup.Post("/signin", r.SignInUser)
+		authGroup.Get("/oidc/login", r.SignInWithOIDC)
+		authGroup.Get("/oidc/callback", r.OIDCCallback)
+		authGroup.Post("/refresh", r.RefreshToken)
+		authGroup.Post("/forgot-password", r.ForgotPassword)
+		authGroup.Post("/reset-password", r.ResetPassword)
+		authGroup.Get("/verify-email", r.VerifyEmail)
+		authGroup.Post("/verify-email", r.VerifyEmail)
+		authGroup.Post("/verify-email/resend", userAuth, r.ResendEmailVerification)
+		authGroup.Post("/logout", userAuth, r.Logout)
+		authGroup.Post("/logout-all", userAuth, r.LogoutAll)
+		authGroup.Post("/2fa/verify", r.VerifyTwoFactor)
+		authGroup.Post("/2fa/enroll", r.EnrollTwoFactor)
+		authGroup.Get("/2fa", userAuth, r.GetTwoFactorStatus)
+		authGroup.Post("/2fa/setup", userAuth, r.SetupTwoFactor)
+		authGroup.Post("/2fa/confirm", userAuth, r.ConfirmTwoFactor)
+		authGroup.Post("/2fa/disable", userAuth, r.DisableTwoFactor)
+		authGroup.Post("/2fa/recovery-codes", userAuth, r.RegenerateRecoveryCodes)
+	}
+
+	userGroup := r.Router.Group("/users", auth)
+	{
+		userGroup.Post("/", can(entity.PermUserManage), r.CreateUser)
+		userGroup.Get("/", can(entity.PermUserReadAny), r.GetAllUsers)
+		userGroup.Get("/:id", requireSelf("id", entity.PermUserReadAny), r.GetUser)
+		userGroup.Put("/:id", requireSelf("id", entity.PermUserManage), r.UpdateUser)
+		userGroup.Post("/:id/unlock", can(entity.PermUserManage), r.UnlockUser)
+		userGroup.Post("/:id/deactivate", can(entity.PermUserManage), r.DeactivateUser)
+		userGroup.Post("/:id/reactivate", can(entity.PermUserManage), r.ReactivateUser)
+		userGroup.Delete("/:id", can(entity.PermUserManage), r.DeleteUser)
+		userGroup.Put("/:id/role", can(entity.PermRoleManage), r.AssignRole)
+		userGroup.Get("/:id/patients", requireSelf("id", entity.PermUserReadAny), r.GetUserPatients)
 	}
 
-	userGroup := r.Router.Group("/users")
+	patientGroup := r.Router.Group("/patients", auth)
+	{
+		patientGroup.Post("/", r.CreatePatient)
+		patientGroup.Get("/:patient_id", r.requirePatient("patient_id", entity.PermAppointmentReadAny), r.GetPatient)
+		patientGroup.Put("/:patient_id", r.requirePatient("patient_id", entity.PermPatientManage), r.UpdatePatient)
+		patientGroup.Post("/:patient_id/users", can(entity.PermPatientManage), r.LinkPatientUser)
+		patientGroup.Delete("/:patient_id/users/:user_id", can(entity.PermPatientManage), r.UnlinkPatientUser)
+	}
+
+	roleGroup := r.Router.Group("/roles", auth, can(entity.PermRoleManage))
+	{
+		roleGroup.Get("/", r.ListRoles)
+		roleGroup.Post("/", r.CreateRole)
+		roleGroup.Get("/:name", r.GetRole)
+		roleGroup.Put("/:name", r.UpdateRole)
+		roleGroup.Put("/:name/two-factor", r.SetRoleTwoFactor)
+		roleGroup.Delete("/:name", r.DeleteRole)
+	}
+
+	r.Router.Get("/permissions", auth, can(entity.PermRoleManage), r.ListPermissions)
+
+	apiKeyGroup := r.Router.Group("/api-keys", userAuth, can(entity.PermAPIKeyManage))
 	{
-		userGroup.Post("/", r.CreateUser)
-		userGroup.Get("/", r.GetAllUsers)
-		userGroup.Get("/:id", r.GetUser)
-		userGroup.Put("/:id", r.UpdateUser)
-		userGroup.Delete("/:id", r.DeleteUser)
+		apiKeyGroup.Post("/", r.CreateAPIKey)
+		apiKeyGroup.Get("/", r.ListAPIKeys)
+		apiKeyGroup.Delete("/:key_id", r.RevokeAPIKey)
 	}
 
-	doctorGroup := r.Router.Group("/doctors")
+	doctorGroup := r.Router.Group("/doctors", auth)
 	{
-		doctorGroup.Post("/", r.CreateDoctor)
+		doctorGroup.Post("/", can(entity.PermDoctorManage), r.CreateDoctor)
 		doctorGroup.Get("/", r.GetAllDoctors)
-		doctorGroup.Get("/:id", r.GetDoctorByID)
-		doctorGroup.Put("/:id", r.UpdateDoctor)
-		doctorGroup.Delete("/:id", r.DeleteDoctor)
 		doctorGroup.Get("/specializations", r.ListSpecializations)
 		doctorGroup.Get("/specialization/:specialization", r.GetDoctorsBySpecialization)
+		doctorGroup.Get("/:id", r.GetDoctorByID)
+		doctorGroup.Get("/:id/availability", r.GetDoctorAvailability)
+		doctorGroup.Get("/:id/appointment-types", r.GetDoctorAppointmentTypes)
+		doctorGroup.Get("/:id/exceptions", r.GetScheduleExceptions)
+		doctorGroup.Post("/:id/time-off", can(entity.PermScheduleManage), r.AddTimeOff)
+		doctorGroup.Post("/:id/extra-hours", can(entity.PermScheduleManage), r.AddExtraHours)
+		doctorGroup.Delete("/:id/exceptions/:exception_id", can(entity.PermScheduleManage), r.DeleteScheduleException)
+		doctorGroup.Put("/:id", can(entity.PermDoctorManage), r.UpdateDoctor)
+		doctorGroup.Delete("/:id", can(entity.PermDoctorManage), r.DeleteDoctor)
+	}
+
+	specializationGroup := r.Router.Group("/specializations", auth)
+	{
+		specializationGroup.Post("/", can(entity.PermDoctorManage), r.CreateSpecialization)
+		specializationGroup.Get("/", r.ListSpecializations)
+		specializationGroup.Get("/:specialization_id", r.GetSpecialization)
+		specializationGroup.Put("/:specialization_id", can(entity.PermDoctorManage), r.UpdateSpecialization)
+		specializationGroup.Delete("/:specialization_id", can(entity.PermDoctorManage), r.DeleteSpecialization)
+	}
+
+	appointmentTypeGroup := r.Router.Group("/appointment-types", auth)
+	{
+		appointmentTypeGroup.Post("/", can(entity.PermDoctorManage), r.CreateAppointmentType)
+		appointmentTypeGroup.Get("/", r.ListAppointmentTypes)
+		appointmentTypeGroup.Get("/:type_id", r.GetAppointmentType)
+		appointmentTypeGroup.Put("/:type_id", can(entity.PermDoctorManage), r.UpdateAppointmentType)
+		appointmentTypeGroup.Delete("/:type_id", can(entity.PermDoctorManage), r.DeleteAppointmentType)
+	}
+
+	clinicGroup := r.Router.Group("/clinics", auth)
+	{
+		clinicGroup.Post("/", can(entity.PermDoctorManage), r.CreateClinic)
+		clinicGroup.Get("/", r.ListClinics)
+		clinicGroup.Get("/:clinic_id", r.GetClinic)
+		clinicGroup.Put("/:clinic_id", can(entity.PermDoctorManage), r.UpdateClinic)
+		clinicGroup.Delete("/:clinic_id", can(entity.PermDoctorManage), r.DeleteClinic)
+		clinicGroup.Post("/:clinic_id/locations", can(entity.PermDoctorManage), r.CreateLocation)
+		clinicGroup.Get("/:clinic_id/locations", r.GetClinicLocations)
 	}
 
-	appointmentGroup := r.Router.Group("/appointments")
+	locationGroup := r.Router.Group("/locations", auth)
 	{
-		appointmentGroup.Post("/", r.CreateAppointment)
-		appointmentGroup.Get("/:id", r.GetAppointmentByID)
-		appointmentGroup.Put("/:id", r.UpdateAppointment)
-		appointmentGroup.Delete("/:id", r.DeleteAppointment)
-		appointmentGroup.Get("/doctor/:doctor_id", r.GetAppointmentsByDoctorID)
-		appointmentGroup.Get("/user/:user_id", r.GetAppointmentsByUserID)
-		appointmentGroup.Get("/doctor/:doctor_id/booked-schedules", r.GetBookedSchedulesByDoctorID)
-		appointmentGroup.Get("/user/:user_id/booked-schedules", r.GetBookedSchedulesByUserID)
+		locationGroup.Get("/:location_id", r.GetLocation)
+		locationGroup.Put("/:location_id", can(entity.PermDoctorManage), r.UpdateLocation)
+		locationGroup.Delete("/:location_id", can(entity.PermDoctorManage), r.DeleteLocation)
+		locationGroup.Post("/:location_id/rooms", can(entity.PermDoctorManage), r.CreateRoom)
+		locationGroup.Get("/:location_id/rooms", r.GetLocationRooms)
+	}
+
+	roomGroup := r.Router.Group("/rooms", auth)
+	{
+		roomGroup.Get("/:room_id", r.GetRoom)
+		roomGroup.Put("/:room_id", can(entity.PermDoctorManage), r.UpdateRoom)
+		roomGroup.Delete("/:room_id", can(entity.PermDoctorManage), r.DeleteRoom)
+	}
+
+	// A doctor works their own agenda here; the appointment routes below only reach their own
+	// appointments through requireOwnAppointment.
+	myDoctorGroup := r.Router.Group("/me/doctor", userAuth, can(entity.PermDoctorSelf), r.requireDoctorAccount)
+	{
+		myDoctorGroup.Get("/", r.GetMyDoctor)
+		myDoctorGroup.Get("/agenda", r.GetMyAgenda)
+		myDoctorGroup.Post("/appointments/:appointment_id/confirm", r.requireOwnAppointment, r.ConfirmAppointment)
+		myDoctorGroup.Post("/appointments/:appointment_id/decline", r.requireOwnAppointment, r.DeclineAppointment)
+		myDoctorGroup.Post("/appointments/:appointment_id/start", r.requireOwnAppointment, r.StartAppointment)
+		myDoctorGroup.Post("/appointments/:appointment_id/complete", r.requireOwnAppointment, r.CompleteAppointment)
+		myDoctorGroup.Post("/appointments/:appointment_id/no-show", r.requireOwnAppointment, r.MarkAppointmentNoShow)
+		myDoctorGroup.Post("/time-off", r.AddMyTimeOff)
+	}
+
+	readAppointment := r.requireAppointmentOwner(entity.PermAppointmentReadAny)
+	writeAppointment := r.requireAppointmentOwner(entity.PermAppointmentWriteAny)
+	setStatus := can(entity.PermAppointmentStatus)
+
+	appointmentGroup := r.Router.Group("/appointments", auth)
+	{
+		appointmentGroup.Post("/", r.requireVerifiedEmail, r.CreateAppointment)
+		appointmentGroup.Get("/doctor/:doctor_id", can(entity.PermAppointmentReadAny), r.GetAppointmentsByDoctorID)
+		appointmentGroup.Get("/doctor/:doctor_id/booked-schedules", can(entity.PermAppointmentReadAny), r.GetBookedSchedulesByDoctorID)
+		appointmentGroup.Get("/user/:user_id", requireSelf("user_id", entity.PermAppointmentReadAny), r.GetAppointmentsByUserID)
+		appointmentGroup.Get("/user/:user_id/booked-schedules", requireSelf("user_id", entity.PermAppointmentReadAny), r.GetBookedSchedulesByUserID)
+		appointmentGroup.Get("/:appointment_id", readAppointment, r.GetAppointmentByID)
+		appointmentGroup.Put("/:appointment_id", can(entity.PermAppointmentWriteAny), r.UpdateAppointment)
+		appointmentGroup.Delete("/:appointment_id", can(entity.PermAppointmentDelete), r.DeleteAppointment)
+		appointmentGroup.Get("/:appointment_id/history", readAppointment, r.GetAppointmentStatusHistory)
+		appointmentGroup.Get("/:appointment_id/reschedules", readAppointment, r.GetAppointmentReschedules)
+		appointmentGroup.Post("/:appointment_id/reschedule", writeAppointment, r.RescheduleAppointment)
+		appointmentGroup.Post("/:appointment_id/series/cancel", writeAppointment, r.CancelSeriesAppointments)
+		appointmentGroup.Post("/:appointment_id/series/reschedule", writeAppointment, r.RescheduleSeriesAppointments)
+		appointmentGroup.Post("/:appointment_id/cancel", writeAppointment, r.CancelAppointment)
+		appointmentGroup.Post("/:appointment_id/confirm", setStatus, r.ConfirmAppointment)
+		appointmentGroup.Post("/:appointment_id/check-in", setStatus, r.CheckInAppointment)
+		appointmentGroup.Post("/:appointment_id/start", setStatus, r.StartAppointment)
+		appointmentGroup.Post("/:appointment_id/complete", setStatus, r.CompleteAppointment)
+		appointmentGroup.Post("/:appointment_id/no-show", setStatus, r.MarkAppointmentNoShow)
+	}
+
+	seriesGroup := r.Router.Group("/appointment-series", auth)
+	{
+		seriesGroup.Post("/", r.requireVerifiedEmail, r.CreateAppointmentSeries)
+		seriesGroup.Get("/:series_id", r.requireSeriesOwner(entity.PermAppointmentReadAny), r.GetAppointmentSeries)
+	}
+
+	holidayGroup := r.Router.Group("/holiday-calendars", auth)
+	{
+		holidayGroup.Post("/", can(entity.PermScheduleManage), r.CreateHolidayCalendar)
+		holidayGroup.Get("/", r.ListHolidayCalendars)
+		holidayGroup.Get("/:calendar_id/holidays", r.GetHolidays)
+		holidayGroup.Post("/:calendar_id/holidays", can(entity.PermScheduleManage), r.AddHoliday)
+		holidayGroup.Delete("/:calendar_id/holidays/:holiday_id", can(entity.PermScheduleManage), r.DeleteHoliday)
+	}
+
+	waitlistGroup := r.Router.Group("/waitlist", auth)
+	{
+		waitlistGroup.Post("/", r.requireVerifiedEmail, r.JoinWaitlist)
+		waitlistGroup.Get("/user/:user_id", requireSelf("user_id", entity.PermAppointmentReadAny), r.GetWaitlistEntriesByUserID)
+		waitlistGroup.Get("/user/:user_id/offers", requireSelf("user_id", entity.PermAppointmentReadAny), r.GetWaitlistOffersByUserID)
+		waitlistGroup.Get("/offers/:offer_id", r.requireOfferOwner(entity.PermAppointmentReadAny), r.GetWaitlistOffer)
+		waitlistGroup.Post("/offers/:offer_id/accept", r.requireOfferOwner(entity.PermAppointmentWriteAny), r.requireVerifiedEmail, r.AcceptWaitlistOffer)
+		waitlistGroup.Post("/offers/:offer_id/decline", r.requireOfferOwner(entity.PermAppointmentWriteAny), r.DeclineWaitlistOffer)
+		waitlistGroup.Get("/:entry_id", r.requireWaitlistEntryOwner(entity.PermAppointmentReadAny), r.GetWaitlistEntry)
+		waitlistGroup.Delete("/:entry_id", r.requireWaitlistEntryOwner(entity.PermAppointmentWriteAny), r.LeaveWaitlist)
 	}
 
 	// Ping
 	r.Router.Get("/ping", r.Ping)
 
 }
+
+// currentUserID returns the id of the authenticated user, if any.
+func currentUserID(c *fiber.Ctx) (int, bool) {
+	userID, ok := c.Locals(middleware.UserIDKey).(int)
+
+	return userID, ok
+}
@@ -0,0 +1,1099 @@
+package v1
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/config"
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/usecase"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/httpserver"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	_patientID      = 1
+	_otherID        = 2
+	_adminID        = 3
+	_receptionistID = 4
+	_auditorID      = 5
+	_doctorID       = 6
+	_unverifiedID   = 7
+	_deactivatedID  = 8
+	_userManagerID  = 9
+)
+
+// Every user is the patient with their id. _patientID is also the parent of _childID.
+const _childID = 30
+
+// No appointment or waitlist offer has _missingID.
+const _missingID = 99
+
+// stubUseCase owns appointment, series, entry and offer 10 for _patientID, 30 for _childID and 20
+// for _otherID. _doctorID signs in as doctor 5, who has every appointment but 20.
+// Calls the access checks don't need fall through to the nil interfaces and panic, which the
+// recover middleware turns into a 500: the request got past authorization.
+type stubUseCase struct {
+	usecase.UserUsecase
+	usecase.DoctorUsecase
+	usecase.AppointmentUsecase
+	usecase.ScheduleUsecase
+	usecase.WaitlistUsecase
+	usecase.RoleUsecase
+	usecase.SessionUsecase
+	usecase.LoginUsecase
+	usecase.TwoFactorUsecase
+	usecase.SSOUsecase
+	usecase.APIKeyUsecase
+	usecase.PatientUsecase
+	usecase.SpecializationUsecase
+	usecase.AppointmentTypeUsecase
+	usecase.ClinicUsecase
+}
+
+// _access mirrors the roles seeded by the migrations; everyone else is a patient.
+var _access = map[int]struct {
+	role        entity.Role
+	permissions []entity.Permission
+}{
+	_adminID: {entity.RoleAdmin, []entity.Permission{
+		entity.PermUserReadAny, entity.PermUserManage, entity.PermRoleManage, entity.PermDoctorManage,
+		entity.PermScheduleManage, entity.PermAppointmentReadAny, entity.PermAppointmentWriteAny,
+		entity.PermAppointmentStatus, entity.PermAppointmentDelete, entity.PermAPIKeyManage, entity.PermPatientManage,
+		entity.PermDoctorSelf,
+	}},
+	_receptionistID: {entity.RoleReceptionist, []entity.Permission{
+		entity.PermUserReadAny, entity.PermScheduleManage, entity.PermAppointmentReadAny,
+		entity.PermAppointmentWriteAny, entity.PermAppointmentStatus, entity.PermPatientManage,
+	}},
+	_auditorID: {entity.RoleAuditor, []entity.Permission{entity.PermUserReadAny, entity.PermAppointmentReadAny}},
+	_doctorID:  {entity.RoleDoctor, []entity.Permission{entity.PermDoctorSelf}},
+	// A custom role that may manage users but not hand out roles.
+	_userManagerID: {"user-manager", []entity.Permission{entity.PermUserReadAny, entity.PermUserManage}},
+}
+
+func (stubUseCase) GetUserAccess(_ context.Context, userID int) (entity.Role, []entity.Permission, error) {
+	if userID == _deactivatedID {
+		return "", nil, entity.ErrUserDeactivated
+	}
+
+	access, ok := _access[userID]
+	if !ok {
+		return entity.RoleUser, nil, nil
+	}
+
+	return access.role, access.permissions, nil
+}
+
+func ownerOf(id int) int {
+	switch id {
+	case 10:
+		return _patientID
+	case 30:
+		return _childID
+	default:
+		return _otherID
+	}
+}
+
+func (stubUseCase) GetSelfPatient(_ context.Context, userID int) (entity.Patient, error) {
+	return entity.Patient{ID: userID}, nil
+}
+
+func (stubUseCase) GetPatientLinks(_ context.Context, patientID int) ([]entity.PatientLink, error) {
+	if patientID == _childID {
+		return []entity.PatientLink{{PatientID: patientID, UserID: _patientID, Relationship: entity.RelationshipParent}}, nil
+	}
+
+	return []entity.PatientLink{{PatientID: patientID, UserID: patientID, Relationship: entity.RelationshipSelf}}, nil
+}
+
+func (stubUseCase) GetAppointmentByID(_ context.Context, id int) (entity.Appointment, error) {
+	if id == _missingID {
+		return entity.Appointment{}, entity.ErrAppointmentNotFound
+	}
+
+	doctorID := 5
+	if id == 20 {
+		doctorID = 9
+	}
+
+	return entity.Appointment{ID: id, PatientID: ownerOf(id), DoctorID: doctorID}, nil
+}
+
+func (stubUseCase) GetDoctorByUserID(_ context.Context, userID int) (entity.Doctor, error) {
+	if userID != _doctorID {
+		return entity.Doctor{}, entity.ErrDoctorNotFound
+	}
+
+	return entity.Doctor{ID: 5, UserID: &userID}, nil
+}
+
+func (stubUseCase) ListSpecializations(context.Context) ([]entity.Specialization, error) {
+	return []entity.Specialization{
+		{ID: 1, Code: "cardiology", Names: map[string]string{"en": "Cardiology", "de": "Kardiologie"}, DefaultDuration: 30},
+	}, nil
+}
+
+func (stubUseCase) GetAppointmentSeries(_ context.Context, id int) (entity.AppointmentSeries, []entity.Appointment, error) {
+	return entity.AppointmentSeries{ID: id, PatientID: ownerOf(id)}, nil, nil
+}
+
+func (stubUseCase) GetWaitlistEntry(_ context.Context, id int) (entity.WaitlistEntry, error) {
+	return entity.WaitlistEntry{ID: id, PatientID: ownerOf(id)}, nil
+}
+
+func (stubUseCase) GetWaitlistOffer(_ context.Context, id int) (entity.WaitlistOffer, error) {
+	if id == _missingID {
+		return entity.WaitlistOffer{}, entity.ErrWaitlistOfferNotFound
+	}
+
+	return entity.WaitlistOffer{ID: id, PatientID: ownerOf(id)}, nil
+}
+
+// Every user but _unverifiedID has verified their email address. There is no _missingID.
+func (stubUseCase) GetUserByID(_ context.Context, id int) (entity.User, error) {
+	if id == _missingID {
+		return entity.User{}, entity.ErrUserNotFound
+	}
+
+	user := entity.User{ID: id}
+	if id != _unverifiedID {
+		verifiedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		user.EmailVerifiedAt = &verifiedAt
+	}
+
+	return user, nil
+}
+
+// sessionOf is the open session of each user; any other session has ended.
+func sessionOf(userID int) int {
+	return userID * 10
+}
+
+func (stubUseCase) CheckSession(_ context.Context, userID, sessionID int) error {
+	if sessionID != sessionOf(userID) {
+		return entity.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// _lockedEmail is locked out for a minute after too many failed sign-ins.
+const _lockedEmail = "locked@example.com"
+
+func (stubUseCase) CheckLogin(_ context.Context, email, _ string) error {
+	if email == _lockedEmail {
+		return &entity.LoginThrottledError{RetryAfter: time.Minute - time.Millisecond, Locked: true}
+	}
+
+	return nil
+}
+
+// _adminEmail signs in with _adminPassword and has TOTP turned on. _deactivatedEmail has the same
+// password but was deactivated.
+const (
+	_adminEmail       = "admin@example.com"
+	_deactivatedEmail = "former@example.com"
+	_adminPassword    = "correct horse"
+)
+
+var _adminPasswordHash, _ = bcrypt.GenerateFromPassword([]byte(_adminPassword), bcrypt.MinCost)
+
+func (stubUseCase) GetPasswordHash(_ context.Context, email string) (entity.GetPasswordHash, error) {
+	switch email {
+	case _adminEmail:
+		return entity.GetPasswordHash{ID: _adminID, Role: entity.RoleAdmin, PasswordHash: string(_adminPasswordHash)}, nil
+	case _deactivatedEmail:
+		deactivatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		return entity.GetPasswordHash{ID: _deactivatedID, Role: entity.RoleReceptionist, PasswordHash: string(_adminPasswordHash), DeactivatedAt: &deactivatedAt}, nil
+	default:
+		return entity.GetPasswordHash{}, entity.ErrUserNotFound
+	}
+}
+
+func (stubUseCase) RecordLoginSuccess(context.Context, string) error {
+	return nil
+}
+
+func (stubUseCase) RecordLoginFailure(context.Context, string, string) error {
+	return nil
+}
+
+func (stubUseCase) StartTwoFactor(_ context.Context, userID int) (entity.TwoFactorChallenge, error) {
+	if userID != _adminID {
+		return entity.TwoFactorChallenge{}, nil
+	}
+
+	return entity.TwoFactorChallenge{Token: "challenge", Method: entity.TwoFactorTOTP, ExpiresAt: time.Now().Add(5 * time.Minute)}, nil
+}
+
+func (stubUseCase) StartOIDCLogin(context.Context) (string, error) {
+	return "https://idp.example/authorize?state=state", nil
+}
+
+func (stubUseCase) FinishOIDCLogin(_ context.Context, _, state string) (entity.User, error) {
+	if state == "unmapped" {
+		return entity.User{}, entity.ErrNoRoleForGroups
+	}
+
+	return entity.User{}, entity.ErrInvalidSSOState
+}
+
+// _labKey is the only active API key. It may read and book appointments for any patient.
+const _labKey = entity.APIKeyPrefix + "lab"
+
+func (stubUseCase) AuthenticateAPIKey(_ context.Context, key string) (entity.APIKey, error) {
+	if key != _labKey {
+		return entity.APIKey{}, entity.ErrAPIKeyRejected
+	}
+
+	return entity.APIKey{ID: 1, Name: "Lab system", Permissions: []entity.Permission{
+		entity.PermAppointmentReadAny, entity.PermAppointmentWriteAny,
+	}}, nil
+}
+
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	return withTestRoutes(fiber.New(), stubUseCase{})
+}
+
+// withTestRoutes serves the routes on app from the stubs, with login in place of their sign-in
+// throttling.
+func withTestRoutes(app *fiber.App, login usecase.LoginUsecase) *fiber.App {
+	app.Use(recover.New())
+
+	uc := stubUseCase{}
+	NewUserRoutes(HandlerV1Config{
+		Config:          &config.Config{},
+		Logger:          logger.New("error"),
+		Validation:      validator.New(),
+		User:            uc,
+		Doctor:          uc,
+		Appointment:     uc,
+		Schedule:        uc,
+		Waitlist:        uc,
+		Role:            uc,
+		Session:         uc,
+		Login:           login,
+		TwoFactor:       uc,
+		SSO:             uc,
+		APIKey:          uc,
+		Patient:         uc,
+		Specialization:  uc,
+		AppointmentType: uc,
+		Clinic:          uc,
+		Keys:            _keys,
+		Router:          app.Group("/v1"),
+	})
+
+	return app
+}
+
+// _keys signs with a current Ed25519 key and still verifies the RSA key it replaced, which
+// _previousKeys signs with.
+var _keys, _previousKeys = newTestKeys()
+
+func newTestKeys() (*tokens.KeySet, *tokens.KeySet) {
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	currentKey := mustKey(tokens.NewKey("current", current))
+	keys, err := tokens.NewKeySet("current", currentKey, mustKey(tokens.NewKey("previous", &previous.PublicKey)))
+	if err != nil {
+		panic(err)
+	}
+
+	previousKeys, err := tokens.NewKeySet("previous", mustKey(tokens.NewKey("previous", previous)))
+	if err != nil {
+		panic(err)
+	}
+
+	return keys, previousKeys
+}
+
+func mustKey(key tokens.Key, err error) tokens.Key {
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+func signToken(t *testing.T, keys *tokens.KeySet, userID, sessionID int, role entity.Role, expiration time.Duration) string {
+	t.Helper()
+
+	token, err := keys.GenerateJWTToken(userID, sessionID, "user@example.com", string(role), expiration)
+	require.NoError(t, err)
+
+	return token
+}
+
+func testToken(t *testing.T, userID int, role entity.Role) string {
+	t.Helper()
+
+	return signToken(t, _keys, userID, sessionOf(userID), role, time.Hour)
+}
+
+func TestRouteAccess(t *testing.T) {
+	app := newTestApp(t)
+
+	const (
+		anonymous    = "anonymous"
+		patient      = "patient"
+		admin        = "admin"
+		receptionist = "receptionist"
+		auditor      = "auditor"
+		doctor       = "doctor"
+		unverified   = "unverified"
+		labSystem    = "lab system"
+	)
+
+	tokensByCaller := map[string]string{
+		patient:      testToken(t, _patientID, entity.RoleUser),
+		admin:        testToken(t, _adminID, entity.RoleAdmin),
+		receptionist: testToken(t, _receptionistID, entity.RoleReceptionist),
+		auditor:      testToken(t, _auditorID, entity.RoleAuditor),
+		doctor:       testToken(t, _doctorID, entity.RoleDoctor),
+		unverified:   testToken(t, _unverifiedID, entity.RoleUser),
+	}
+
+	tests := []struct {
+		method, path string
+		caller       string
+		want         int // 0 means authorized, whatever the handler then returns
+	}{
+		{http.MethodGet, "/v1/ping", anonymous, 0},
+		{http.MethodPost, "/v1/auth/signin", anonymous, 0},
+		{http.MethodPost, "/v1/auth/refresh", anonymous, 0},
+		{http.MethodGet, "/v1/auth/oidc/login", anonymous, 0},
+		{http.MethodGet, "/v1/auth/oidc/callback?code=code&state=state", anonymous, 0},
+		{http.MethodPost, "/v1/auth/logout", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/auth/logout", patient, 0},
+		{http.MethodPost, "/v1/auth/logout-all", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/auth/logout-all", patient, 0},
+		{http.MethodPost, "/v1/auth/forgot-password", anonymous, 0},
+		{http.MethodPost, "/v1/auth/reset-password", anonymous, 0},
+		{http.MethodGet, "/v1/auth/verify-email", anonymous, 0},
+		{http.MethodPost, "/v1/auth/verify-email/resend", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/auth/verify-email/resend", unverified, 0},
+		{http.MethodPost, "/v1/auth/2fa/verify", anonymous, 0},
+		{http.MethodPost, "/v1/auth/2fa/enroll", anonymous, 0},
+		{http.MethodGet, "/v1/auth/2fa", anonymous, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/auth/2fa", patient, 0},
+		{http.MethodPost, "/v1/auth/2fa/setup", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/auth/2fa/setup", patient, 0},
+		{http.MethodPost, "/v1/auth/2fa/confirm", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/auth/2fa/disable", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/auth/2fa/recovery-codes", anonymous, http.StatusUnauthorized},
+
+		{http.MethodPost, "/v1/appointments", unverified, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointment-series", unverified, http.StatusForbidden},
+		{http.MethodPost, "/v1/waitlist", unverified, http.StatusForbidden},
+		{http.MethodGet, "/v1/doctors", unverified, 0},
+		{http.MethodGet, "/v1/users/7", unverified, 0},
+
+		{http.MethodGet, "/v1/doctors", anonymous, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/doctors", patient, 0},
+		{http.MethodGet, "/v1/doctors/specializations", patient, 0},
+		{http.MethodGet, "/v1/specializations", patient, 0},
+		{http.MethodGet, "/v1/specializations/1", patient, 0},
+		{http.MethodPost, "/v1/specializations", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/specializations", admin, 0},
+		{http.MethodPut, "/v1/specializations/1", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/specializations/1", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/specializations/1", admin, 0},
+		{http.MethodGet, "/v1/appointment-types", patient, 0},
+		{http.MethodGet, "/v1/appointment-types/1", patient, 0},
+		{http.MethodGet, "/v1/doctors/5/appointment-types", patient, 0},
+		{http.MethodPost, "/v1/appointment-types", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointment-types", admin, 0},
+		{http.MethodPut, "/v1/appointment-types/1", receptionist, http.StatusForbidden},
+		{http.MethodDelete, "/v1/appointment-types/1", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/appointment-types/1", admin, 0},
+		{http.MethodGet, "/v1/clinics", patient, 0},
+		{http.MethodGet, "/v1/clinics/1/locations", patient, 0},
+		{http.MethodPost, "/v1/clinics", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/clinics", admin, 0},
+		{http.MethodPost, "/v1/clinics/1/locations", receptionist, http.StatusForbidden},
+		{http.MethodDelete, "/v1/clinics/1", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/locations/1/rooms", patient, 0},
+		{http.MethodPut, "/v1/locations/1", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/locations/1/rooms", admin, 0},
+		{http.MethodGet, "/v1/rooms/1", patient, 0},
+		{http.MethodDelete, "/v1/rooms/1", receptionist, http.StatusForbidden},
+		{http.MethodDelete, "/v1/rooms/1", admin, 0},
+		{http.MethodGet, "/v1/doctors/5/availability", patient, 0},
+		{http.MethodPost, "/v1/doctors", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/doctors", admin, 0},
+		{http.MethodPut, "/v1/doctors/5", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/doctors/5", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/doctors/5/time-off", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/doctors/5/time-off", admin, 0},
+		{http.MethodPost, "/v1/doctors/5/extra-hours", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/doctors/5/exceptions/1", patient, http.StatusForbidden},
+
+		{http.MethodGet, "/v1/users", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/users", admin, 0},
+		{http.MethodPost, "/v1/users", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/users/1", patient, 0},
+		{http.MethodGet, "/v1/users/2", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/users/2", admin, 0},
+		{http.MethodGet, "/v1/users/99", admin, http.StatusNotFound},
+		{http.MethodPut, "/v1/users/2", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/users/1", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/users/1", admin, 0},
+		{http.MethodPost, "/v1/users/2/unlock", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/users/2/unlock", admin, 0},
+		{http.MethodPost, "/v1/users/2/deactivate", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/users/2/deactivate", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/users/2/deactivate", admin, 0},
+		{http.MethodPost, "/v1/users/2/reactivate", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/users/2/reactivate", admin, 0},
+
+		{http.MethodPost, "/v1/appointments", anonymous, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/appointments/10", patient, 0},
+		{http.MethodGet, "/v1/appointments/20", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/20", admin, 0},
+		{http.MethodGet, "/v1/appointments/99", patient, http.StatusNotFound},
+		{http.MethodGet, "/v1/appointments/20/history", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/10/cancel", patient, 0},
+		{http.MethodPost, "/v1/appointments/20/cancel", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/10/reschedule", patient, 0},
+		{http.MethodPost, "/v1/appointments/20/series/cancel", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/10/confirm", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/10/complete", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/10/check-in", admin, 0},
+		{http.MethodPut, "/v1/appointments/10", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/appointments/10", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/user/1", patient, 0},
+		{http.MethodGet, "/v1/appointments/user/2", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/doctor/5", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/doctor/5", admin, 0},
+
+		{http.MethodGet, "/v1/appointments/30", patient, 0},
+		{http.MethodPost, "/v1/appointments/30/cancel", patient, 0},
+		{http.MethodGet, "/v1/appointments/30", receptionist, 0},
+		{http.MethodGet, "/v1/appointments/30", labSystem, 0},
+
+		{http.MethodGet, "/v1/appointment-series/10", patient, 0},
+		{http.MethodGet, "/v1/appointment-series/20", patient, http.StatusForbidden},
+
+		{http.MethodGet, "/v1/holiday-calendars", patient, 0},
+		{http.MethodPost, "/v1/holiday-calendars", patient, http.StatusForbidden},
+
+		{http.MethodGet, "/v1/waitlist/10", patient, 0},
+		{http.MethodDelete, "/v1/waitlist/20", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/waitlist/user/2/offers", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/waitlist/offers/10/accept", patient, 0},
+		{http.MethodPost, "/v1/waitlist/offers/20/accept", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/waitlist/offers/99/accept", patient, http.StatusNotFound},
+		{http.MethodPost, "/v1/waitlist/offers/20/decline", admin, 0},
+
+		{http.MethodPost, "/v1/patients", anonymous, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/patients", patient, 0},
+		{http.MethodGet, "/v1/patients/1", patient, 0},
+		{http.MethodGet, "/v1/patients/30", patient, 0},
+		{http.MethodGet, "/v1/patients/2", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/patients/2", auditor, 0},
+		{http.MethodPut, "/v1/patients/30", patient, 0},
+		{http.MethodPut, "/v1/patients/2", patient, http.StatusForbidden},
+		{http.MethodPut, "/v1/patients/2", auditor, http.StatusForbidden},
+		{http.MethodPut, "/v1/patients/2", receptionist, 0},
+		{http.MethodPost, "/v1/patients/30/users", patient, http.StatusForbidden},
+		{http.MethodPost, "/v1/patients/30/users", receptionist, 0},
+		{http.MethodDelete, "/v1/patients/30/users/1", patient, http.StatusForbidden},
+		{http.MethodDelete, "/v1/patients/30/users/1", admin, 0},
+		{http.MethodGet, "/v1/users/1/patients", patient, 0},
+		{http.MethodGet, "/v1/users/2/patients", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/users/2/patients", receptionist, 0},
+
+		{http.MethodGet, "/v1/roles", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/roles", receptionist, http.StatusForbidden},
+		{http.MethodGet, "/v1/roles", admin, 0},
+		{http.MethodPost, "/v1/roles", admin, 0},
+		{http.MethodDelete, "/v1/roles/auditor", auditor, http.StatusForbidden},
+		{http.MethodGet, "/v1/permissions", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/permissions", admin, 0},
+		{http.MethodPut, "/v1/users/1/role", receptionist, http.StatusForbidden},
+		{http.MethodPut, "/v1/users/1/role", admin, 0},
+		{http.MethodPut, "/v1/roles/admin/two-factor", receptionist, http.StatusForbidden},
+		{http.MethodPut, "/v1/roles/admin/two-factor", admin, 0},
+
+		{http.MethodGet, "/v1/users", receptionist, 0},
+		{http.MethodPost, "/v1/users", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/users/2/unlock", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/doctors", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/doctors/5/time-off", receptionist, 0},
+		{http.MethodPost, "/v1/holiday-calendars", receptionist, 0},
+		{http.MethodPost, "/v1/appointments/20/cancel", receptionist, 0},
+		{http.MethodPost, "/v1/appointments/20/check-in", receptionist, 0},
+		{http.MethodDelete, "/v1/appointments/20", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/waitlist/offers/20/accept", receptionist, 0},
+
+		{http.MethodGet, "/v1/users/2", auditor, 0},
+		{http.MethodPut, "/v1/users/2", auditor, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/20", auditor, 0},
+		{http.MethodGet, "/v1/appointments/doctor/5", auditor, 0},
+		{http.MethodGet, "/v1/appointment-series/20", auditor, 0},
+		{http.MethodGet, "/v1/waitlist/user/2/offers", auditor, 0},
+		{http.MethodPost, "/v1/appointments/20/cancel", auditor, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/20/confirm", auditor, http.StatusForbidden},
+		{http.MethodDelete, "/v1/waitlist/20", auditor, http.StatusForbidden},
+
+		{http.MethodPost, "/v1/appointments/20/start", doctor, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/20/history", doctor, http.StatusForbidden},
+		{http.MethodPost, "/v1/appointments/20/reschedule", doctor, http.StatusForbidden},
+		{http.MethodGet, "/v1/appointments/doctor/5", doctor, http.StatusForbidden},
+		{http.MethodPost, "/v1/doctors/5/time-off", doctor, http.StatusForbidden},
+		{http.MethodGet, "/v1/users", doctor, http.StatusForbidden},
+
+		{http.MethodGet, "/v1/me/doctor", anonymous, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/me/doctor", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/me/doctor", admin, http.StatusForbidden},
+		{http.MethodGet, "/v1/me/doctor", labSystem, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/me/doctor", doctor, 0},
+		{http.MethodGet, "/v1/me/doctor/agenda", doctor, 0},
+		{http.MethodGet, "/v1/me/doctor/agenda", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/me/doctor/appointments/10/confirm", doctor, 0},
+		{http.MethodPost, "/v1/me/doctor/appointments/10/decline", doctor, 0},
+		{http.MethodPost, "/v1/me/doctor/appointments/10/start", doctor, 0},
+		{http.MethodPost, "/v1/me/doctor/appointments/10/complete", doctor, 0},
+		{http.MethodPost, "/v1/me/doctor/appointments/10/no-show", doctor, 0},
+		{http.MethodPost, "/v1/me/doctor/appointments/20/confirm", doctor, http.StatusForbidden},
+		{http.MethodPost, "/v1/me/doctor/appointments/20/decline", doctor, http.StatusForbidden},
+		{http.MethodPost, "/v1/me/doctor/appointments/20/no-show", doctor, http.StatusForbidden},
+		{http.MethodPost, "/v1/me/doctor/time-off", doctor, 0},
+
+		{http.MethodGet, "/v1/doctors", labSystem, 0},
+		{http.MethodPost, "/v1/appointments", labSystem, 0},
+		{http.MethodGet, "/v1/appointments/20", labSystem, 0},
+		{http.MethodGet, "/v1/appointments/user/2", labSystem, 0},
+		{http.MethodPost, "/v1/appointments/20/cancel", labSystem, 0},
+		{http.MethodPost, "/v1/appointments/20/confirm", labSystem, http.StatusForbidden},
+		{http.MethodGet, "/v1/users", labSystem, http.StatusForbidden},
+		{http.MethodGet, "/v1/users/2", labSystem, http.StatusForbidden},
+		{http.MethodPost, "/v1/auth/logout", labSystem, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/auth/2fa", labSystem, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/api-keys", labSystem, http.StatusUnauthorized},
+
+		{http.MethodGet, "/v1/api-keys", anonymous, http.StatusUnauthorized},
+		{http.MethodGet, "/v1/api-keys", receptionist, http.StatusForbidden},
+		{http.MethodPost, "/v1/api-keys", patient, http.StatusForbidden},
+		{http.MethodGet, "/v1/api-keys", admin, 0},
+		{http.MethodPost, "/v1/api-keys", admin, 0},
+		{http.MethodDelete, "/v1/api-keys/1", admin, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.caller+" "+tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if token, ok := tokensByCaller[tt.caller]; ok {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			if tt.caller == labSystem {
+				req.Header.Set("X-API-Key", _labKey)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			if tt.want != 0 {
+				assert.Equal(t, tt.want, resp.StatusCode)
+
+				return
+			}
+
+			assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}, resp.StatusCode)
+		})
+	}
+}
+
+func TestBookingForAnotherPatient(t *testing.T) {
+	app := newTestApp(t)
+
+	body := `{"patient_id": 2, "doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "appointment_type_id": 1,
+		"rule": "FREQ=WEEKLY;COUNT=2", "from": "2030-01-07T00:00:00Z", "to": "2030-01-14T00:00:00Z"}`
+
+	for _, path := range []string{"/v1/appointments", "/v1/appointment-series", "/v1/waitlist"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+testToken(t, _patientID, entity.RoleUser))
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+	}
+}
+
+func TestCreateUserWithRole(t *testing.T) {
+	app := newTestApp(t)
+
+	tests := []struct {
+		name   string
+		caller int
+		role   entity.Role
+		denied bool
+	}{
+		{"user manager, default role", _userManagerID, "", false},
+		{"user manager, user role", _userManagerID, entity.RoleUser, false},
+		{"user manager, admin role", _userManagerID, entity.RoleAdmin, true},
+		{"user manager, receptionist role", _userManagerID, entity.RoleReceptionist, true},
+		{"admin, admin role", _adminID, entity.RoleAdmin, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"email": "staff@example.com", "password": "password1", "full_name": "Staff", "role": "` + string(tt.role) + `"}`
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+testToken(t, tt.caller, _access[tt.caller].role))
+
+			// Allowed requests hash the password, which can outlast the default one second.
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			if tt.denied {
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			} else {
+				assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestBookingForDependant(t *testing.T) {
+	app := newTestApp(t)
+
+	body := `{"patient_id": 30, "doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "appointment_type_id": 1,
+		"rule": "FREQ=WEEKLY;COUNT=2", "from": "2030-01-07T00:00:00Z", "to": "2030-01-14T00:00:00Z"}`
+
+	for _, path := range []string{"/v1/appointments", "/v1/appointment-series", "/v1/waitlist"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+testToken(t, _patientID, entity.RoleUser))
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.NotContains(t, []int{http.StatusBadRequest, http.StatusForbidden}, resp.StatusCode)
+		})
+	}
+}
+
+func TestBookingByReceptionist(t *testing.T) {
+	app := newTestApp(t)
+
+	body := `{"patient_id": 2, "doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "appointment_type_id": 1}`
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/appointments", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testToken(t, _receptionistID, entity.RoleReceptionist))
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.NotEqual(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestBookingNeedsAppointmentType(t *testing.T) {
+	app := newTestApp(t)
+
+	body := `{"doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "duration": 30, "rule": "FREQ=WEEKLY;COUNT=2"}`
+
+	for _, path := range []string{"/v1/appointments", "/v1/appointment-series"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+testToken(t, _patientID, entity.RoleUser))
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestBookingWithAPIKey(t *testing.T) {
+	app := newTestApp(t)
+
+	book := func(key, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/appointments", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	// An API key books for the patient it names; it has no account of its own to book for.
+	assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden},
+		book(_labKey, `{"patient_id": 2, "doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "appointment_type_id": 1}`))
+	assert.Equal(t, http.StatusBadRequest,
+		book(_labKey, `{"doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "appointment_type_id": 1}`))
+	assert.Equal(t, http.StatusUnauthorized,
+		book(entity.APIKeyPrefix+"revoked", `{"patient_id": 2, "doctor_id": 5, "appointment_time": "2030-01-07T09:00:00Z", "appointment_type_id": 1}`))
+}
+
+func TestSignInLockedOut(t *testing.T) {
+	app := newTestApp(t)
+
+	body := `{"email": "` + _lockedEmail + `", "password": "password"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	var got struct {
+		RetryAfter int `json:"retry_after"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, 60, got.RetryAfter)
+}
+
+func TestSignInTwoFactorChallenge(t *testing.T) {
+	app := newTestApp(t)
+
+	signIn := func(password string) *http.Response {
+		body := `{"email": "` + _adminEmail + `", "password": "` + password + `"}`
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	resp := signIn("wrong password")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = signIn(_adminPassword)
+	defer resp.Body.Close()
+
+	var got map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "challenge", got["challenge_token"])
+	assert.Equal(t, entity.TwoFactorTOTP, got["method"])
+	assert.NotContains(t, got, "access_token")
+}
+
+func TestSignInWithOIDC(t *testing.T) {
+	app := newTestApp(t)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/login", http.NoBody))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://idp.example/authorize?state=state", resp.Header.Get(fiber.HeaderLocation))
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?error=access_denied", http.StatusUnauthorized},
+		{"?code=code&state=stale", http.StatusBadRequest},
+		{"?code=code&state=unmapped", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/callback"+tt.query, http.NoBody))
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, tt.want, resp.StatusCode, tt.query)
+	}
+}
+
+func TestDeactivatedUser(t *testing.T) {
+	app := newTestApp(t)
+
+	body := `{"email": "` + _deactivatedEmail + `", "password": "` + _adminPassword + `"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// A token issued before the user was deactivated stops working.
+	req = httptest.NewRequest(http.MethodGet, "/v1/doctors", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, _deactivatedID, entity.RoleReceptionist))
+
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+// The role in the token is informational: permissions come from the user's current role.
+func TestRoleClaimIsNotTrusted(t *testing.T) {
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, _patientID, entity.RoleAdmin))
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestInvalidToken(t *testing.T) {
+	app := newTestApp(t)
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	unknownKeys, err := tokens.NewKeySet("other", mustKey(tokens.NewKey("other", otherKey)))
+	require.NoError(t, err)
+
+	impostorKeys, err := tokens.NewKeySet("current", mustKey(tokens.NewKey("current", otherKey)))
+	require.NoError(t, err)
+
+	// HS256 keyed with the published public key must not pass for the EdDSA key.
+	jwk := _keys.JWKS().Keys[0]
+	require.Equal(t, "current", jwk.KeyID)
+	confusedKeys, err := tokens.NewKeySet("current", tokens.NewHMACKey("current", []byte(jwk.X)))
+	require.NoError(t, err)
+
+	forged := signToken(t, impostorKeys, _patientID, sessionOf(_patientID), entity.RoleAdmin, time.Hour)
+	unknownKey := signToken(t, unknownKeys, _patientID, sessionOf(_patientID), entity.RoleUser, time.Hour)
+	confused := signToken(t, confusedKeys, _patientID, sessionOf(_patientID), entity.RoleUser, time.Hour)
+	expired := signToken(t, _keys, _patientID, sessionOf(_patientID), entity.RoleUser, -time.Hour)
+	revoked := signToken(t, _keys, _patientID, sessionOf(_patientID)+1, entity.RoleUser, time.Hour)
+	othersSession := signToken(t, _keys, _patientID, sessionOf(_otherID), entity.RoleUser, time.Hour)
+	noSession := signToken(t, _keys, _patientID, 0, entity.RoleUser, time.Hour)
+
+	for name, header := range map[string]string{
+		"forged":                 "Bearer " + forged,
+		"unknown key":            "Bearer " + unknownKey,
+		"algorithm confusion":    "Bearer " + confused,
+		"expired":                "Bearer " + expired,
+		"no bearer":              forged,
+		"revoked session":        "Bearer " + revoked,
+		"another user's session": "Bearer " + othersSession,
+		"no session":             "Bearer " + noSession,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/doctors", nil)
+			req.Header.Set("Authorization", header)
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+}
+
+func TestRotatedKey(t *testing.T) {
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/doctors/specializations", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, _previousKeys, _patientID, sessionOf(_patientID), entity.RoleUser, time.Hour))
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.NotEqual(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestSpecializationNames(t *testing.T) {
+	app := newTestApp(t)
+	token := signToken(t, _keys, _patientID, sessionOf(_patientID), entity.RoleUser, time.Hour)
+
+	for language, want := range map[string]string{
+		"":               "Cardiology",
+		"de":             "Kardiologie",
+		"fr, de;q=0.5":   "Kardiologie",
+		"fr":             "Cardiology",
+		"en-US, de;q=.9": "Cardiology",
+	} {
+		t.Run(language, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/specializations", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Accept-Language", language)
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var body models.SpecializationsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			require.Len(t, body.Specializations, 1)
+
+			assert.Equal(t, want, body.Specializations[0].Name)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	app := fiber.New()
+	app.Get("/.well-known/jwks.json", JWKS(_keys))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var jwks tokens.JWKS
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+	require.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, tokens.JWK{KeyType: "OKP", KeyID: "current", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+	assert.Len(t, jwks.Keys[0].X, 43)
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "previous", jwks.Keys[1].KeyID)
+	assert.Equal(t, "RS256", jwks.Keys[1].Algorithm)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+}
+
+func TestBookingErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusConflict, bookingErrorStatus(entity.ErrSlotFull))
+	assert.Equal(t, http.StatusConflict, bookingErrorStatus(entity.ErrRoomTaken))
+	assert.Equal(t, http.StatusUnprocessableEntity, bookingErrorStatus(entity.ErrTypeNotOffered))
+}
+
+// _realIPHeader is where nginx puts the client IP.
+const _realIPHeader = "X-Real-IP"
+
+// ipThrottle throttles a client IP after one failed sign-in.
+type ipThrottle struct {
+	stubUseCase
+	failures map[string]int
+}
+
+func (l *ipThrottle) CheckLogin(_ context.Context, _, ip string) error {
+	if l.failures[ip] > 0 {
+		return &entity.LoginThrottledError{RetryAfter: time.Minute}
+	}
+
+	return nil
+}
+
+func (l *ipThrottle) RecordLoginFailure(_ context.Context, _, ip string) error {
+	// The IP points into the request, which fiber reuses.
+	l.failures[strings.Clone(ip)]++
+
+	return nil
+}
+
+func TestSignInThrottlesForwardedIPs(t *testing.T) {
+	login := &ipThrottle{failures: map[string]int{}}
+
+	// app.Test connects from 0.0.0.0, which stands in for the proxy.
+	server := httpserver.New(httpserver.ProxyHeader(_realIPHeader), httpserver.TrustedProxies([]string{"0.0.0.0"}))
+	app := withTestRoutes(server.App, login)
+
+	signIn := func(ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(`{"email":"`+_adminEmail+`","password":"wrong password"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(_realIPHeader, ip)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, signIn("203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, signIn("203.0.113.1"))
+	assert.Equal(t, http.StatusUnauthorized, signIn("203.0.113.2"), "another client behind the proxy has its own count")
+	assert.Equal(t, map[string]int{"203.0.113.1": 1, "203.0.113.2": 1}, login.failures)
+}
+
+func TestSignInIgnoresForwardedIPsFromUntrustedPeers(t *testing.T) {
+	login := &ipThrottle{failures: map[string]int{}}
+
+	server := httpserver.New(httpserver.ProxyHeader(_realIPHeader))
+	app := withTestRoutes(server.App, login)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(`{"email":"`+_adminEmail+`","password":"wrong password"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(_realIPHeader, "203.0.113.1")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, map[string]int{"0.0.0.0": 1}, login.failures)
+}
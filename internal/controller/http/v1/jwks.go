@@ -0,0 +1,18 @@
+package v1
+
+import (
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKS serves the public keys access tokens are signed with, so other services can verify them
+// without sharing a secret. The key set only changes on restart, so clients may cache it briefly.
+func JWKS(keys *tokens.KeySet) fiber.Handler {
+	jwks := keys.JWKS()
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+		return c.JSON(jwks)
+	}
+}
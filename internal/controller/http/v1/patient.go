@@ -0,0 +1,283 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/middleware"
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create patient
+// @Description Create a patient, such as a child or a relative in your care, and link them to your account with the given relationship. Staff may link another user instead or, by leaving the relationship empty, nobody yet.
+// @Accept json
+// @Produce json
+// @Tags patient
+// @Param patient body models.CreatePatientRequest true "Patient"
+// @Success 201 {object} models.PatientResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /patients [post]
+func (h *HandlerV1) CreatePatient(c *fiber.Ctx) error {
+	req := models.CreatePatientRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	patient, err := patientFromRequest(req.PatientRequest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	staff := middleware.HasPermission(c, entity.PermPatientManage)
+	currentID, _ := currentUserID(c)
+
+	if req.UserID == 0 {
+		req.UserID = currentID
+	}
+
+	if !staff && (req.UserID == 0 || req.UserID != currentID) {
+		return forbidden(c)
+	}
+
+	var links []entity.PatientLink
+
+	switch {
+	case req.Relationship != "":
+		links = append(links, entity.PatientLink{UserID: req.UserID, Relationship: req.Relationship})
+	case !staff:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "relationship is required"})
+	}
+
+	patient, err = h.Patient.CreatePatient(c.Context(), patient, links)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+
+	for i := range links {
+		links[i].PatientID = patient.ID
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.PatientResponse{Patient: patient, Users: links})
+}
+
+// @Summary Get patient
+// @Description Get a patient and the users linked to them
+// @Accept json
+// @Produce json
+// @Tags patient
+// @Param patient_id path int true "Patient ID"
+// @Success 200 {object} models.PatientResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /patients/{patient_id} [get]
+func (h *HandlerV1) GetPatient(c *fiber.Ctx) error {
+	patientID, err := strconv.Atoi(c.Params("patient_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid patient ID"})
+	}
+
+	patient, err := h.Patient.GetPatient(c.Context(), patientID)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+
+	links, err := h.Patient.GetPatientLinks(c.Context(), patientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.PatientResponse{Patient: patient, Users: links})
+}
+
+// @Summary Update patient
+// @Description Replace a patient's details
+// @Accept json
+// @Produce json
+// @Tags patient
+// @Param patient_id path int true "Patient ID"
+// @Param patient body models.PatientRequest true "Patient"
+// @Success 200 {object} entity.Patient
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /patients/{patient_id} [put]
+func (h *HandlerV1) UpdatePatient(c *fiber.Ctx) error {
+	patientID, err := strconv.Atoi(c.Params("patient_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid patient ID"})
+	}
+
+	req := models.PatientRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	patient, err := patientFromRequest(req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	patient.ID = patientID
+
+	patient, err = h.Patient.UpdatePatient(c.Context(), patient)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(patient)
+}
+
+// @Summary Get user's patients
+// @Description Get the patients a user may book for: themselves first, then their dependants
+// @Accept json
+// @Produce json
+// @Tags patient
+// @Param id path int true "User ID"
+// @Success 200 {object} models.PatientsResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /users/{id}/patients [get]
+func (h *HandlerV1) GetUserPatients(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	patients, err := h.Patient.GetUserPatients(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.PatientsResponse{Patients: patients})
+}
+
+// @Summary Link user to patient
+// @Description Let another user act for the patient, such as a second parent or a carer
+// @Accept json
+// @Produce json
+// @Tags patient
+// @Param patient_id path int true "Patient ID"
+// @Param link body models.PatientLinkRequest true "Link"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /patients/{patient_id}/users [post]
+func (h *HandlerV1) LinkPatientUser(c *fiber.Ctx) error {
+	patientID, err := strconv.Atoi(c.Params("patient_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid patient ID"})
+	}
+
+	req := models.PatientLinkRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err = h.Patient.LinkPatientUser(c.Context(), entity.PatientLink{
+		PatientID:    patientID,
+		UserID:       req.UserID,
+		Relationship: req.Relationship,
+	})
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse{
+		Message: "user linked to patient successfully",
+	})
+}
+
+// @Summary Unlink user from patient
+// @Description Stop a user from acting for the patient. The patient and their appointments are kept.
+// @Accept json
+// @Produce json
+// @Tags patient
+// @Param patient_id path int true "Patient ID"
+// @Param user_id path int true "User ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /patients/{patient_id}/users/{user_id} [delete]
+func (h *HandlerV1) UnlinkPatientUser(c *fiber.Ctx) error {
+	patientID, err := strconv.Atoi(c.Params("patient_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid patient ID"})
+	}
+
+	userID, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	err = h.Patient.UnlinkPatientUser(c.Context(), patientID, userID)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "user unlinked from patient successfully",
+	})
+}
+
+func patientFromRequest(req models.PatientRequest) (entity.Patient, error) {
+	patient := entity.Patient{
+		FullName:              req.FullName,
+		Sex:                   req.Sex,
+		EmergencyContactName:  req.EmergencyContactName,
+		EmergencyContactPhone: req.EmergencyContactPhone,
+		PreferredLanguage:     req.PreferredLanguage,
+	}
+
+	if req.DateOfBirth != "" {
+		dateOfBirth, err := time.Parse(time.DateOnly, req.DateOfBirth)
+		if err != nil {
+			return entity.Patient{}, errors.New("invalid date of birth")
+		}
+
+		patient.DateOfBirth = &dateOfBirth
+	}
+
+	return patient, nil
+}
+
+func patientErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidPatient):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrPatientNotFound), errors.Is(err, entity.ErrUserNotFound), errors.Is(err, entity.ErrPatientLinkNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrPatientLinkExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
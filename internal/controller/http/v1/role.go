@@ -0,0 +1,260 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary List roles
+// @Description List roles with the permissions they grant
+// @Accept json
+// @Produce json
+// @Tags role
+// @Success 200 {object} models.RolesResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /roles [get]
+func (h *HandlerV1) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.Role.ListRoles(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.RolesResponse{Roles: roles})
+}
+
+// @Summary Get role
+// @Description Get a role with the permissions it grants
+// @Accept json
+// @Produce json
+// @Tags role
+// @Param name path string true "Role name"
+// @Success 200 {object} entity.RoleDefinition
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /roles/{name} [get]
+func (h *HandlerV1) GetRole(c *fiber.Ctx) error {
+	role, err := h.Role.GetRole(c.Context(), entity.Role(c.Params("name")))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(role)
+}
+
+// @Summary Create role
+// @Description Create a role granting the given permissions
+// @Accept json
+// @Produce json
+// @Tags role
+// @Param request body models.RoleRequest true "Role"
+// @Success 201 {object} entity.RoleDefinition
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /roles [post]
+func (h *HandlerV1) CreateRole(c *fiber.Ctx) error {
+	req := models.RoleRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err := h.Role.CreateRole(c.Context(), entity.RoleDefinition{
+		Name:             req.Name,
+		Description:      req.Description,
+		Permissions:      req.Permissions,
+		RequireTwoFactor: req.RequireTwoFactor,
+	})
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	role, err := h.Role.GetRole(c.Context(), req.Name)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(role)
+}
+
+// @Summary Update role
+// @Description Replace the description and permissions of a role. The admin role can't be changed.
+// @Accept json
+// @Produce json
+// @Tags role
+// @Param name path string true "Role name"
+// @Param request body models.UpdateRoleRequest true "Role"
+// @Success 200 {object} entity.RoleDefinition
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /roles/{name} [put]
+func (h *HandlerV1) UpdateRole(c *fiber.Ctx) error {
+	name := entity.Role(c.Params("name"))
+
+	req := models.UpdateRoleRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err := h.Role.UpdateRole(c.Context(), entity.RoleDefinition{
+		Name:        name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	role, err := h.Role.GetRole(c.Context(), name)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(role)
+}
+
+// @Summary Delete role
+// @Description Delete a role no user has. Built-in roles can't be deleted.
+// @Accept json
+// @Produce json
+// @Tags role
+// @Param name path string true "Role name"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /roles/{name} [delete]
+func (h *HandlerV1) DeleteRole(c *fiber.Ctx) error {
+	err := h.Role.DeleteRole(c.Context(), entity.Role(c.Params("name")))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "Role deleted successfully",
+	})
+}
+
+// @Summary List permissions
+// @Description List the permissions roles can grant
+// @Accept json
+// @Produce json
+// @Tags role
+// @Success 200 {object} models.PermissionsResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /permissions [get]
+func (h *HandlerV1) ListPermissions(c *fiber.Ctx) error {
+	permissions, err := h.Role.ListPermissions(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.PermissionsResponse{Permissions: permissions})
+}
+
+// @Summary Assign role
+// @Description Give a user a role. It applies from the user's next request.
+// @Accept json
+// @Produce json
+// @Tags role
+// @Param id path int true "User ID"
+// @Param request body models.AssignRoleRequest true "Role"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /users/{id}/role [put]
+func (h *HandlerV1) AssignRole(c *fiber.Ctx) error {
+	userIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	req := models.AssignRoleRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err = h.Role.AssignRole(c.Context(), userIDInt, req.Role)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "Role assigned successfully",
+	})
+}
+
+// @Summary Require two-factor authentication for a role
+// @Description Set whether users with the role need TOTP. Requiring it signs the role's users out everywhere;
+// @Description those who haven't set it up are asked to on their next sign-in.
+// @Accept json
+// @Produce json
+// @Tags role
+// @Param name path string true "Role name"
+// @Param request body models.RoleTwoFactorRequest true "Required"
+// @Success 200 {object} entity.RoleDefinition
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Security BearerAuth
+// @Router /roles/{name}/two-factor [put]
+func (h *HandlerV1) SetRoleTwoFactor(c *fiber.Ctx) error {
+	name := entity.Role(c.Params("name"))
+
+	req := models.RoleTwoFactorRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err := h.Role.SetRoleTwoFactor(c.Context(), name, *req.Required)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	role, err := h.Role.GetRole(c.Context(), name)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(role)
+}
+
+func roleErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidRole), errors.Is(err, entity.ErrUnknownPermission):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrUnknownRole):
+		if c.Params("name") != "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrRoleExists), errors.Is(err, entity.ErrRoleInUse), errors.Is(err, entity.ErrBuiltinRole):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,372 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Get doctor schedule exceptions
+// @Description Get time off and extra hours of a doctor overlapping the range
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param id path int true "Doctor ID"
+// @Param from query string true "Range start (YYYY-MM-DD in the doctor's time zone, or RFC3339)"
+// @Param to query string true "Range end (YYYY-MM-DD in the doctor's time zone, inclusive, or RFC3339)"
+// @Success 200 {object} models.ScheduleExceptionsResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /doctors/{id}/exceptions [get]
+func (h *HandlerV1) GetScheduleExceptions(c *fiber.Ctx) error {
+	doctorID := c.Params("id")
+	doctorIDInt, err := strconv.Atoi(doctorID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid doctor ID"})
+	}
+
+	doctor, err := h.Doctor.GetDoctorByID(c.Context(), doctorIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	from, to, err := parseRange(c, loc)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	exceptions, err := h.Schedule.GetScheduleExceptions(c.Context(), doctorIDInt, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.ScheduleExceptionsResponse{
+		Exceptions: exceptions,
+	})
+}
+
+// @Summary Add doctor time off
+// @Description Block a period of a doctor's schedule. Active appointments inside it are reported and, with cancel_conflicts, cancelled
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param id path int true "Doctor ID"
+// @Param request body models.TimeOffRequest true "Time off"
+// @Success 201 {object} entity.TimeOffResult
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /doctors/{id}/time-off [post]
+func (h *HandlerV1) AddTimeOff(c *fiber.Ctx) error {
+	doctorID := c.Params("id")
+	doctorIDInt, err := strconv.Atoi(doctorID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid doctor ID"})
+	}
+
+	return h.addTimeOff(c, doctorIDInt)
+}
+
+func (h *HandlerV1) addTimeOff(c *fiber.Ctx, doctorID int) error {
+	req := models.TimeOffRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var changedBy *int
+	if userID, ok := currentUserID(c); ok {
+		changedBy = &userID
+	}
+
+	result, err := h.Schedule.AddTimeOff(c.Context(), entity.ScheduleException{
+		DoctorID: doctorID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	}, req.CancelConflicts, changedBy)
+	if err != nil {
+		return scheduleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// @Summary Add doctor extra hours
+// @Description Add a one-off working period outside the doctor's weekly schedule
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param id path int true "Doctor ID"
+// @Param request body models.ScheduleExceptionRequest true "Extra hours"
+// @Success 201 {object} entity.ScheduleException
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /doctors/{id}/extra-hours [post]
+func (h *HandlerV1) AddExtraHours(c *fiber.Ctx) error {
+	doctorID := c.Params("id")
+	doctorIDInt, err := strconv.Atoi(doctorID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid doctor ID"})
+	}
+
+	req := models.ScheduleExceptionRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	exception, err := h.Schedule.AddScheduleException(c.Context(), entity.ScheduleException{
+		DoctorID: doctorIDInt,
+		Kind:     entity.ExceptionExtraHours,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		return scheduleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(exception)
+}
+
+// @Summary Delete doctor schedule exception
+// @Description Delete time off or extra hours of a doctor
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param id path int true "Doctor ID"
+// @Param exception_id path int true "Exception ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /doctors/{id}/exceptions/{exception_id} [delete]
+func (h *HandlerV1) DeleteScheduleException(c *fiber.Ctx) error {
+	doctorIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid doctor ID"})
+	}
+
+	exceptionIDInt, err := strconv.Atoi(c.Params("exception_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid exception ID"})
+	}
+
+	err = h.Schedule.DeleteScheduleException(c.Context(), doctorIDInt, exceptionIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "Schedule exception deleted successfully",
+	})
+}
+
+// @Summary Create holiday calendar
+// @Description Create a clinic-wide holiday calendar
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param request body models.HolidayCalendarRequest true "Calendar"
+// @Success 201 {object} entity.HolidayCalendar
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /holiday-calendars [post]
+func (h *HandlerV1) CreateHolidayCalendar(c *fiber.Ctx) error {
+	req := models.HolidayCalendarRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	id, err := h.Schedule.CreateHolidayCalendar(c.Context(), entity.HolidayCalendar{Name: req.Name})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(entity.HolidayCalendar{
+		ID:        id,
+		Name:      req.Name,
+		CreatedAt: time.Now(),
+	})
+}
+
+// @Summary List holiday calendars
+// @Description List holiday calendars
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Success 200 {object} models.HolidayCalendarsResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /holiday-calendars [get]
+func (h *HandlerV1) ListHolidayCalendars(c *fiber.Ctx) error {
+	calendars, err := h.Schedule.ListHolidayCalendars(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.HolidayCalendarsResponse{
+		Calendars: calendars,
+	})
+}
+
+// @Summary Get holidays
+// @Description Get holidays of a calendar in the range
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param calendar_id path int true "Calendar ID"
+// @Param from query string true "Range start (YYYY-MM-DD)"
+// @Param to query string true "Range end (YYYY-MM-DD, inclusive)"
+// @Success 200 {object} models.HolidaysResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /holiday-calendars/{calendar_id}/holidays [get]
+func (h *HandlerV1) GetHolidays(c *fiber.Ctx) error {
+	calendarIDInt, err := strconv.Atoi(c.Params("calendar_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid calendar ID"})
+	}
+
+	from, to, err := parseRange(c, time.UTC)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	holidays, err := h.Schedule.GetHolidays(c.Context(), calendarIDInt, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.HolidaysResponse{
+		Holidays: holidays,
+	})
+}
+
+// @Summary Add holiday
+// @Description Add a day off to a holiday calendar
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param calendar_id path int true "Calendar ID"
+// @Param request body models.HolidayRequest true "Holiday"
+// @Success 201 {object} entity.Holiday
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /holiday-calendars/{calendar_id}/holidays [post]
+func (h *HandlerV1) AddHoliday(c *fiber.Ctx) error {
+	calendarIDInt, err := strconv.Atoi(c.Params("calendar_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid calendar ID"})
+	}
+
+	req := models.HolidayRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	date, err := time.Parse(time.DateOnly, req.Date)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid date"})
+	}
+
+	holiday := entity.Holiday{CalendarID: calendarIDInt, Date: date, Name: req.Name}
+
+	holiday.ID, err = h.Schedule.AddHoliday(c.Context(), holiday)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(holiday)
+}
+
+// @Summary Delete holiday
+// @Description Delete a day off from a holiday calendar
+// @Accept json
+// @Produce json
+// @Tags schedule
+// @Param calendar_id path int true "Calendar ID"
+// @Param holiday_id path int true "Holiday ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /holiday-calendars/{calendar_id}/holidays/{holiday_id} [delete]
+func (h *HandlerV1) DeleteHoliday(c *fiber.Ctx) error {
+	calendarIDInt, err := strconv.Atoi(c.Params("calendar_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid calendar ID"})
+	}
+
+	holidayIDInt, err := strconv.Atoi(c.Params("holiday_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid holiday ID"})
+	}
+
+	err = h.Schedule.DeleteHoliday(c.Context(), calendarIDInt, holidayIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "Holiday deleted successfully",
+	})
+}
+
+// parseRange reads the from and to query parameters, reading bare dates in loc.
+// A bare date in "to" includes the whole day.
+func parseRange(c *fiber.Ctx, loc *time.Location) (time.Time, time.Time, error) {
+	from, _, err := parseRangeBound(c.Query("from"), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid from")
+	}
+
+	to, isDate, err := parseRangeBound(c.Query("to"), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid to")
+	}
+
+	if isDate {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+// scheduleErrorResponse maps schedule exception errors to their HTTP status.
+func scheduleErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, entity.ErrInvalidException) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
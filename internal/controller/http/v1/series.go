@@ -0,0 +1,190 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create appointment series
+// @Description Book a recurring appointment. rule is an RRULE subset: FREQ=DAILY or WEEKLY, optional INTERVAL, and COUNT or UNTIL.
+// @Description Occurrences are booked with the appointment type in one transaction; on conflicts nothing is booked unless skip_conflicts is set.
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param request body models.AppointmentSeriesRequest true "Series"
+// @Success 201 {object} entity.SeriesBooking
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.SeriesConflictResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-series [post]
+func (h *HandlerV1) CreateAppointmentSeries(c *fiber.Ctx) error {
+	req := models.AppointmentSeriesRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	patientID, err := h.bookingPatient(c, req.PatientID)
+	if err != nil {
+		return bookingPatientErrorResponse(c, err)
+	}
+
+	booking, err := h.Appointment.CreateAppointmentSeries(c.Context(), entity.AppointmentSeries{
+		PatientID:         patientID,
+		DoctorID:          req.DoctorID,
+		Rule:              req.Rule,
+		StartsAt:          req.AppointmentTime,
+		AppointmentTypeID: &req.AppointmentTypeID,
+	}, req.SkipConflicts)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrSeriesConflict):
+			return c.Status(fiber.StatusConflict).JSON(models.SeriesConflictResponse{
+				Error:       err.Error(),
+				Occurrences: booking.Occurrences,
+			})
+		case errors.Is(err, entity.ErrInvalidRecurrence):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return appointmentErrorResponse(c, err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(booking)
+}
+
+// @Summary Get appointment series
+// @Description Get a series and all of its occurrences
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param series_id path int true "Series ID"
+// @Success 200 {object} models.AppointmentSeriesResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointment-series/{series_id} [get]
+func (h *HandlerV1) GetAppointmentSeries(c *fiber.Ctx) error {
+	seriesIDInt, err := strconv.Atoi(c.Params("series_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid series ID"})
+	}
+
+	series, appointments, err := h.Appointment.GetAppointmentSeries(c.Context(), seriesIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.AppointmentSeriesResponse{
+		Series:       series,
+		Appointments: appointmentsResponse(appointments).Appointments,
+	})
+}
+
+// @Summary Cancel series occurrences
+// @Description Cancel this occurrence, this and the following ones, or all occurrences of its series
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.SeriesCancelRequest true "Scope"
+// @Success 200 {object} models.AppointmentsResponse
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/series/cancel [post]
+func (h *HandlerV1) CancelSeriesAppointments(c *fiber.Ctx) error {
+	appointmentIDInt, err := strconv.Atoi(c.Params("appointment_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
+	}
+
+	req := models.SeriesCancelRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var changedBy *int
+	if userID, ok := currentUserID(c); ok {
+		changedBy = &userID
+	}
+
+	appointments, err := h.Appointment.CancelAppointments(c.Context(), appointmentIDInt, req.Scope, changedBy, req.Reason)
+	if err != nil {
+		return seriesErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(appointmentsResponse(appointments))
+}
+
+// @Summary Reschedule series occurrences
+// @Description Move this occurrence, this and the following ones, or all occurrences of its series.
+// @Description Other occurrences are shifted by the same number of days to the same local time.
+// @Accept json
+// @Produce json
+// @Tags appointment
+// @Param appointment_id path int true "Appointment ID"
+// @Param request body models.SeriesRescheduleRequest true "Scope and new time"
+// @Success 200 {object} models.AppointmentsResponse
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 422 {object} entity.BookingError
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /appointments/{appointment_id}/series/reschedule [post]
+func (h *HandlerV1) RescheduleSeriesAppointments(c *fiber.Ctx) error {
+	appointmentIDInt, err := strconv.Atoi(c.Params("appointment_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid appointment ID"})
+	}
+
+	req := models.SeriesRescheduleRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var changedBy *int
+	if userID, ok := currentUserID(c); ok {
+		changedBy = &userID
+	}
+
+	appointments, err := h.Appointment.RescheduleAppointments(c.Context(), entity.AppointmentReschedule{
+		AppointmentID:   appointmentIDInt,
+		DoctorID:        req.DoctorID,
+		AppointmentTime: req.AppointmentTime,
+		Duration:        req.Duration,
+		ChangedBy:       changedBy,
+		Reason:          req.Reason,
+	}, req.Scope)
+	if err != nil {
+		return seriesErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(appointmentsResponse(appointments))
+}
+
+func seriesErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, entity.ErrInvalidScope) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return appointmentErrorResponse(c, err)
+}
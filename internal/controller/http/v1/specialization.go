@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create specialization
+// @Description Add a specialization to the catalogue. Names are keyed by language tag and need an "en" one.
+// @Accept json
+// @Produce json
+// @Tags specialization
+// @Param specialization body models.SpecializationRequest true "Specialization"
+// @Success 201 {object} models.SpecializationResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /specializations [post]
+func (h *HandlerV1) CreateSpecialization(c *fiber.Ctx) error {
+	req := models.SpecializationRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	specialization, err := h.Specialization.CreateSpecialization(c.Context(), specializationFromRequest(req))
+	if err != nil {
+		return specializationErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(specializationResponse(c, specialization))
+}
+
+// @Summary List specializations
+// @Description List the specializations catalogue, named in the language asked for with Accept-Language
+// @Accept json
+// @Produce json
+// @Tags specialization
+// @Param Accept-Language header string false "Language of the names"
+// @Success 200 {object} models.SpecializationsResponse
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /specializations [get]
+// @Router /doctors/specializations [get]
+func (h *HandlerV1) ListSpecializations(c *fiber.Ctx) error {
+	specializations, err := h.Specialization.ListSpecializations(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response := models.SpecializationsResponse{
+		Specializations: make([]models.SpecializationResponse, 0, len(specializations)),
+	}
+	for _, specialization := range specializations {
+		response.Specializations = append(response.Specializations, specializationResponse(c, specialization))
+	}
+
+	return c.JSON(response)
+}
+
+// @Summary Get specialization
+// @Description Get a specialization of the catalogue
+// @Accept json
+// @Produce json
+// @Tags specialization
+// @Param specialization_id path int true "Specialization ID"
+// @Param Accept-Language header string false "Language of the name"
+// @Success 200 {object} models.SpecializationResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /specializations/{specialization_id} [get]
+func (h *HandlerV1) GetSpecialization(c *fiber.Ctx) error {
+	specializationID, err := strconv.Atoi(c.Params("specialization_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid specialization ID"})
+	}
+
+	specialization, err := h.Specialization.GetSpecialization(c.Context(), specializationID)
+	if err != nil {
+		return specializationErrorResponse(c, err)
+	}
+
+	return c.JSON(specializationResponse(c, specialization))
+}
+
+// @Summary Update specialization
+// @Description Replace a specialization. Doctors keep holding it and waitlist entries follow a new code.
+// @Accept json
+// @Produce json
+// @Tags specialization
+// @Param specialization_id path int true "Specialization ID"
+// @Param specialization body models.SpecializationRequest true "Specialization"
+// @Success 200 {object} models.SpecializationResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /specializations/{specialization_id} [put]
+func (h *HandlerV1) UpdateSpecialization(c *fiber.Ctx) error {
+	specializationID, err := strconv.Atoi(c.Params("specialization_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid specialization ID"})
+	}
+
+	req := models.SpecializationRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	specialization := specializationFromRequest(req)
+	specialization.ID = specializationID
+
+	specialization, err = h.Specialization.UpdateSpecialization(c.Context(), specialization)
+	if err != nil {
+		return specializationErrorResponse(c, err)
+	}
+
+	return c.JSON(specializationResponse(c, specialization))
+}
+
+// @Summary Delete specialization
+// @Description Remove a specialization from the catalogue. One that doctors hold or patients wait for can't be removed.
+// @Accept json
+// @Produce json
+// @Tags specialization
+// @Param specialization_id path int true "Specialization ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /specializations/{specialization_id} [delete]
+func (h *HandlerV1) DeleteSpecialization(c *fiber.Ctx) error {
+	specializationID, err := strconv.Atoi(c.Params("specialization_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid specialization ID"})
+	}
+
+	err = h.Specialization.DeleteSpecialization(c.Context(), specializationID)
+	if err != nil {
+		return specializationErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "specialization deleted successfully",
+	})
+}
+
+func specializationFromRequest(req models.SpecializationRequest) entity.Specialization {
+	return entity.Specialization{
+		Code:            req.Code,
+		Names:           req.Names,
+		Description:     req.Description,
+		DefaultDuration: req.DefaultDuration,
+	}
+}
+
+// specializationResponse names the specialization in the best of its languages for the request's
+// Accept-Language header.
+func specializationResponse(c *fiber.Ctx, specialization entity.Specialization) models.SpecializationResponse {
+	languages := make([]string, 0, len(specialization.Names))
+	for language := range specialization.Names {
+		if language != entity.DefaultLanguage {
+			languages = append(languages, language)
+		}
+	}
+
+	sort.Strings(languages)
+
+	// Without a header the first offer wins.
+	language := c.AcceptsLanguages(append([]string{entity.DefaultLanguage}, languages...)...)
+
+	return models.SpecializationResponse{
+		Specialization: specialization,
+		Name:           specialization.Name(language),
+	}
+}
+
+func specializationErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidSpecialization):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrSpecializationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrSpecializationExists), errors.Is(err, entity.ErrSpecializationInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Sign in with the identity provider
+// @Description Redirect to the clinic's OpenID Connect provider to sign in. It sends the user back to
+// @Description /auth/oidc/callback. 404 if single sign-on isn't configured.
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /auth/oidc/login [get]
+func (r *HandlerV1) SignInWithOIDC(c *fiber.Ctx) error {
+	authURL, err := r.SSO.StartOIDCLogin(c.Context())
+	if errors.Is(err, entity.ErrSSODisabled) {
+		return errorResponse(c, http.StatusNotFound, err.Error())
+	}
+
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - sign in with oidc")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to start single sign-on")
+	}
+
+	return c.Redirect(authURL, http.StatusFound)
+}
+
+// @Summary Finish signing in with the identity provider
+// @Description Where the identity provider sends the user back to. Signs in the user linked to their
+// @Description provider account, linking or creating one on their first sign-in, with the role their
+// @Description provider groups map to. Two-factor authentication is left to the provider.
+// @Produce json
+// @Tags auth
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Router /auth/oidc/callback [get]
+func (r *HandlerV1) OIDCCallback(c *fiber.Ctx) error {
+	if reason := c.Query("error"); reason != "" {
+		return errorResponse(c, http.StatusUnauthorized, "sign-in was refused by the identity provider: "+reason)
+	}
+
+	if c.Query("code") == "" || c.Query("state") == "" {
+		return errorResponse(c, http.StatusBadRequest, "code and state are required")
+	}
+
+	user, err := r.SSO.FinishOIDCLogin(c.Context(), c.Query("code"), c.Query("state"))
+	switch {
+	case errors.Is(err, entity.ErrSSODisabled):
+		return errorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, entity.ErrInvalidSSOState):
+		return errorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, entity.ErrSSOFailed):
+		r.Logger.Error(err, "http - v1 - oidc callback")
+
+		return errorResponse(c, http.StatusUnauthorized, entity.ErrSSOFailed.Error())
+	case errors.Is(err, entity.ErrNoRoleForGroups), errors.Is(err, entity.ErrUserDeactivated):
+		return errorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, entity.ErrIdentityConflict):
+		return errorResponse(c, http.StatusConflict, err.Error())
+	case err != nil:
+		r.Logger.Error(err, "http - v1 - oidc callback")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to sign in")
+	}
+
+	session, refreshToken, err := r.Session.CreateSession(c.Context(), user.ID, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - oidc callback")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to create session")
+	}
+
+	resp, err := r.tokenResponse(session, user.Email, user.Role, refreshToken)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - oidc callback")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
+	}
+
+	resp.Message = "User signed in successfully"
+
+	return c.Status(http.StatusOK).JSON(resp)
+}
@@ -0,0 +1,268 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Answer a two-factor challenge
+// @Description Finish signing in with a TOTP code, or a recovery code, for the challenge sign-in returned.
+// @Description Answering an enroll challenge turns TOTP on and also returns the first recovery codes.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.VerifyTwoFactorRequest true "Challenge and code"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 429 {object} models.Error
+// @Router /auth/2fa/verify [post]
+func (r *HandlerV1) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req models.VerifyTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	userID, recoveryCodes, err := r.TwoFactor.VerifyTwoFactor(c.Context(), req.ChallengeToken, req.Code, c.IP())
+	if errors.Is(err, entity.ErrInvalidTwoFactorCode) {
+		return errorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - verify two factor")
+	}
+
+	user, err := r.User.GetUserByID(c.Context(), userID)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - verify two factor")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to get user")
+	}
+
+	if user.DeactivatedAt != nil {
+		return errorResponse(c, http.StatusForbidden, entity.ErrUserDeactivated.Error())
+	}
+
+	session, refreshToken, err := r.Session.CreateSession(c.Context(), userID, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - verify two factor")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to create session")
+	}
+
+	resp, err := r.tokenResponse(session, user.Email, user.Role, refreshToken)
+	if err != nil {
+		r.Logger.Error(err, "http - v1 - verify two factor")
+
+		return errorResponse(c, http.StatusInternalServerError, "failed to generate token")
+	}
+
+	resp.Message = "User signed in successfully"
+	resp.RecoveryCodes = recoveryCodes
+
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// @Summary Set up TOTP to answer an enroll challenge
+// @Description Get a TOTP secret and its otpauth:// URI, to show as a QR code, for a user whose role requires
+// @Description two-factor authentication they haven't set up. Answer the challenge with a code from it.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.TwoFactorChallengeRequest true "Challenge"
+// @Success 200 {object} entity.TOTPSetup
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Router /auth/2fa/enroll [post]
+func (r *HandlerV1) EnrollTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	userID, err := r.TwoFactor.TwoFactorChallengeUser(c.Context(), req.ChallengeToken)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - enroll two factor")
+	}
+
+	setup, err := r.TwoFactor.SetupTOTP(c.Context(), userID)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - enroll two factor")
+	}
+
+	return c.Status(http.StatusOK).JSON(setup)
+}
+
+// @Summary Two-factor status
+// @Description Whether the current user has TOTP turned on and whether their role requires it
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Success 200 {object} models.TwoFactorStatusResponse
+// @Failure 401 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/2fa [get]
+func (r *HandlerV1) GetTwoFactorStatus(c *fiber.Ctx) error {
+	userID, _ := currentUserID(c)
+
+	twoFactor, err := r.TwoFactor.GetTwoFactor(c.Context(), userID)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - get two factor status")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.TwoFactorStatusResponse{
+		Enabled:  twoFactor.Enabled(),
+		Required: twoFactor.Required,
+	})
+}
+
+// @Summary Set up TOTP
+// @Description Get a new TOTP secret and its otpauth:// URI, to show as a QR code. TOTP is turned on by
+// @Description confirming a code from it.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Success 200 {object} entity.TOTPSetup
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/2fa/setup [post]
+func (r *HandlerV1) SetupTwoFactor(c *fiber.Ctx) error {
+	userID, _ := currentUserID(c)
+
+	setup, err := r.TwoFactor.SetupTOTP(c.Context(), userID)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - setup two factor")
+	}
+
+	return c.Status(http.StatusOK).JSON(setup)
+}
+
+// @Summary Confirm TOTP
+// @Description Turn TOTP on with a code from the secret set up. The recovery codes are shown only this once.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/2fa/confirm [post]
+func (r *HandlerV1) ConfirmTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	userID, _ := currentUserID(c)
+
+	recoveryCodes, err := r.TwoFactor.ConfirmTOTP(c.Context(), userID, req.Code)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - confirm two factor")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+// @Summary Turn TOTP off
+// @Description Turn TOTP off with a current TOTP or recovery code. Not allowed when the user's role requires it.
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/2fa/disable [post]
+func (r *HandlerV1) DisableTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	userID, _ := currentUserID(c)
+
+	err := r.TwoFactor.DisableTOTP(c.Context(), userID, req.Code)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - disable two factor")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.SuccessResponse{Message: "Two-factor authentication disabled"})
+}
+
+// @Summary New recovery codes
+// @Description Replace the recovery codes with new ones, given a current TOTP or recovery code
+// @Accept json
+// @Produce json
+// @Tags auth
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Security BearerAuth
+// @Router /auth/2fa/recovery-codes [post]
+func (r *HandlerV1) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	if err := r.Validation.Struct(req); err != nil {
+		return errorResponse(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	userID, _ := currentUserID(c)
+
+	recoveryCodes, err := r.TwoFactor.RegenerateRecoveryCodes(c.Context(), userID, req.Code)
+	if err != nil {
+		return r.twoFactorErrorResponse(c, err, "http - v1 - regenerate recovery codes")
+	}
+
+	return c.Status(http.StatusOK).JSON(models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (r *HandlerV1) twoFactorErrorResponse(c *fiber.Ctx, err error, op string) error {
+	var throttled *entity.LoginThrottledError
+
+	switch {
+	case errors.As(err, &throttled):
+		return throttledResponse(c, throttled)
+	case errors.Is(err, entity.ErrInvalidUserToken):
+		return errorResponse(c, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, entity.ErrInvalidTwoFactorCode):
+		return errorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, entity.ErrTwoFactorNotSetUp), errors.Is(err, entity.ErrTwoFactorEnabled), errors.Is(err, entity.ErrTwoFactorRequired):
+		return errorResponse(c, http.StatusConflict, err.Error())
+	default:
+		r.Logger.Error(err, op)
+
+		return errorResponse(c, http.StatusInternalServerError, "two-factor authentication failed")
+	}
+}
@@ -1,6 +1,10 @@
 package v1
 
 import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/middleware"
 	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/etc"
@@ -8,14 +12,16 @@ import (
 )
 
 // @Summary Create user
-// @Description Create user
+// @Description Create a user, such as a staff account, with the given role (user when empty)
 // @Accept json
 // @Produce json
 // @Tags user
 // @Param user body models.CreateUserRequest true "User"
 // @Success 201 {object} models.UserResponse
 // @Failure 400 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /users [post]
 func (h *HandlerV1) CreateUser(c *fiber.Ctx) error {
 	user := models.CreateUserRequest{}
@@ -23,6 +29,19 @@ func (h *HandlerV1) CreateUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if err := h.Validation.Struct(user); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if user.Role == "" {
+		user.Role = entity.RoleUser
+	}
+
+	// Any other role carries permissions of its own, so handing it out is role management.
+	if user.Role != entity.RoleUser && !middleware.HasPermission(c, entity.PermRoleManage) {
+		return forbidden(c)
+	}
+
 	hashedPassword, err := etc.HashPassword(user.Password)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -33,16 +52,27 @@ func (h *HandlerV1) CreateUser(c *fiber.Ctx) error {
 		FullName: user.FullName,
 		Phone:    user.Phone,
 		Password: hashedPassword,
+		Role:     user.Role,
 	})
+	if errors.Is(err, entity.ErrUnknownRole) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	err = h.User.SendEmailVerification(c.Context(), id)
+	if err != nil {
+		h.Logger.Error(err, "http - v1 - create user")
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(models.UserResponse{
 		ID:       id,
 		Email:    user.Email,
 		FullName: user.FullName,
 		Phone:    user.Phone,
+		Role:     user.Role,
 	})
 }
 
@@ -56,25 +86,35 @@ func (h *HandlerV1) CreateUser(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /users/{id} [get]
 func (h *HandlerV1) GetUser(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(int)
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
 
 	user, err := h.User.GetUserByID(c.Context(), userID)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(models.UserResponse{
-		ID:       user.ID,
-		Email:    user.Email,
-		FullName: user.FullName,
-		Phone:    user.Phone,
+		ID:            user.ID,
+		Email:         user.Email,
+		FullName:      user.FullName,
+		Phone:         user.Phone,
+		Role:          user.Role,
+		DeactivatedAt: user.DeactivatedAt,
 	})
 }
 
 // @Summary Update user
-// @Description Update user
+// @Description Update a user's name, email and phone. A new email address has to be verified again. The password only changes through a reset.
 // @Accept json
 // @Produce json
 // @Tags user
@@ -84,22 +124,34 @@ func (h *HandlerV1) GetUser(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /users/{id} [put]
 func (h *HandlerV1) UpdateUser(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(int)
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
 
 	user := models.UpdateUserRequest{}
 	if err := c.BodyParser(&user); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	err := h.User.UpdateUser(c.Context(), entity.UserUpdate{
+	if err := h.Validation.Struct(user); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err = h.User.UpdateUser(c.Context(), entity.UserUpdate{
 		ID:       userID,
 		Email:    user.Email,
 		FullName: user.FullName,
 		Phone:    user.Phone,
 	})
 	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -122,11 +174,15 @@ func (h *HandlerV1) UpdateUser(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /users/{id} [delete]
 func (h *HandlerV1) DeleteUser(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(int)
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
 
-	err := h.User.DeleteUser(c.Context(), userID)
+	err = h.User.DeleteUser(c.Context(), userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -145,6 +201,7 @@ func (h *HandlerV1) DeleteUser(c *fiber.Ctx) error {
 // @Failure 400 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
+// @Security BearerAuth
 // @Router /users [get]
 func (h *HandlerV1) GetAllUsers(c *fiber.Ctx) error {
 	users, err := h.User.ListUsers(c.Context())
@@ -156,3 +213,109 @@ func (h *HandlerV1) GetAllUsers(c *fiber.Ctx) error {
 		Users: users,
 	})
 }
+
+// @Summary Unlock user
+// @Description Lift the sign-in lockout of a user's account and forget its failed sign-ins
+// @Accept json
+// @Produce json
+// @Tags user
+// @Param id path int true "User ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /users/{id}/unlock [post]
+func (h *HandlerV1) UnlockUser(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	adminID, _ := currentUserID(c)
+
+	err = h.Login.UnlockAccount(c.Context(), userID, adminID)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "User unlocked successfully",
+	})
+}
+
+// @Summary Deactivate user
+// @Description Keep a user from signing in and end their sessions. Their appointments and history are kept.
+// @Accept json
+// @Produce json
+// @Tags user
+// @Param id path int true "User ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /users/{id}/deactivate [post]
+func (h *HandlerV1) DeactivateUser(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	adminID, _ := currentUserID(c)
+
+	err = h.User.DeactivateUser(c.Context(), userID, adminID)
+	if errors.Is(err, entity.ErrDeactivateSelf) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "User deactivated successfully",
+	})
+}
+
+// @Summary Reactivate user
+// @Description Let a deactivated user sign in again
+// @Accept json
+// @Produce json
+// @Tags user
+// @Param id path int true "User ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /users/{id}/reactivate [post]
+func (h *HandlerV1) ReactivateUser(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	adminID, _ := currentUserID(c)
+
+	err = h.User.ReactivateUser(c.Context(), userID, adminID)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "User reactivated successfully",
+	})
+}
@@ -0,0 +1,249 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/controller/http/models"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Join waitlist
+// @Description Wait for a slot with a doctor, or with any doctor of a specialization, within [from, to).
+// @Description When a matching appointment is cancelled the slot is held for the first patient in line for a limited time.
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param request body models.WaitlistRequest true "Waitlist entry"
+// @Success 201 {object} entity.WaitlistEntry
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist [post]
+func (h *HandlerV1) JoinWaitlist(c *fiber.Ctx) error {
+	req := models.WaitlistRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Validation.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	patientID, err := h.bookingPatient(c, req.PatientID)
+	if err != nil {
+		return bookingPatientErrorResponse(c, err)
+	}
+
+	entry, err := h.Waitlist.JoinWaitlist(c.Context(), entity.WaitlistEntry{
+		PatientID:      patientID,
+		DoctorID:       req.DoctorID,
+		Specialization: req.Specialization,
+		From:           req.From,
+		To:             req.To,
+	})
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// @Summary Get waitlist entry
+// @Description Get waitlist entry
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param entry_id path int true "Entry ID"
+// @Success 200 {object} entity.WaitlistEntry
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/{entry_id} [get]
+func (h *HandlerV1) GetWaitlistEntry(c *fiber.Ctx) error {
+	entryIDInt, err := strconv.Atoi(c.Params("entry_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid entry ID"})
+	}
+
+	entry, err := h.Waitlist.GetWaitlistEntry(c.Context(), entryIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(entry)
+}
+
+// @Summary Leave waitlist
+// @Description Leave the waitlist. A slot currently held for the entry is passed on to the next patient.
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param entry_id path int true "Entry ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/{entry_id} [delete]
+func (h *HandlerV1) LeaveWaitlist(c *fiber.Ctx) error {
+	entryIDInt, err := strconv.Atoi(c.Params("entry_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid entry ID"})
+	}
+
+	err = h.Waitlist.LeaveWaitlist(c.Context(), entryIDInt)
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "Left the waitlist successfully",
+	})
+}
+
+// @Summary Get waitlist entries by user id
+// @Description Get waitlist entries by user id, newest first
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param user_id path int true "User ID"
+// @Success 200 {object} models.WaitlistEntriesResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/user/{user_id} [get]
+func (h *HandlerV1) GetWaitlistEntriesByUserID(c *fiber.Ctx) error {
+	userIDInt, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	entries, err := h.Waitlist.GetWaitlistEntriesByUserID(c.Context(), userIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.WaitlistEntriesResponse{Entries: entries})
+}
+
+// @Summary Get waitlist offers by user id
+// @Description Get the slots offered to a user from the waitlist, newest first
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param user_id path int true "User ID"
+// @Success 200 {object} models.WaitlistOffersResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/user/{user_id}/offers [get]
+func (h *HandlerV1) GetWaitlistOffersByUserID(c *fiber.Ctx) error {
+	userIDInt, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	offers, err := h.Waitlist.GetWaitlistOffersByUserID(c.Context(), userIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.WaitlistOffersResponse{Offers: offers})
+}
+
+// @Summary Get waitlist offer
+// @Description Get waitlist offer
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param offer_id path int true "Offer ID"
+// @Success 200 {object} entity.WaitlistOffer
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/offers/{offer_id} [get]
+func (h *HandlerV1) GetWaitlistOffer(c *fiber.Ctx) error {
+	offerIDInt, err := strconv.Atoi(c.Params("offer_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer ID"})
+	}
+
+	offer, err := h.Waitlist.GetWaitlistOffer(c.Context(), offerIDInt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(offer)
+}
+
+// @Summary Accept waitlist offer
+// @Description Book the held slot. Fails once the offer expired or was answered.
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param offer_id path int true "Offer ID"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/offers/{offer_id}/accept [post]
+func (h *HandlerV1) AcceptWaitlistOffer(c *fiber.Ctx) error {
+	offerIDInt, err := strconv.Atoi(c.Params("offer_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer ID"})
+	}
+
+	var acceptedBy *int
+	if userID, ok := currentUserID(c); ok {
+		acceptedBy = &userID
+	}
+
+	appointment, err := h.Waitlist.AcceptWaitlistOffer(c.Context(), offerIDInt, acceptedBy)
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(appointmentResponse(appointment))
+}
+
+// @Summary Decline waitlist offer
+// @Description Release the held slot to the next patient. The entry stays on the waitlist for other slots.
+// @Accept json
+// @Produce json
+// @Tags waitlist
+// @Param offer_id path int true "Offer ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Security BearerAuth
+// @Router /waitlist/offers/{offer_id}/decline [post]
+func (h *HandlerV1) DeclineWaitlistOffer(c *fiber.Ctx) error {
+	offerIDInt, err := strconv.Atoi(c.Params("offer_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer ID"})
+	}
+
+	err = h.Waitlist.DeclineWaitlistOffer(c.Context(), offerIDInt)
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.SuccessResponse{
+		Message: "Offer declined successfully",
+	})
+}
+
+func waitlistErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidWaitlistEntry):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, entity.ErrOfferUnavailable), errors.Is(err, entity.ErrEntryClosed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return appointmentErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, so leaked keys are easy to recognise.
+const APIKeyPrefix = "dak_"
+
+// APIKey - a key another system calls the API with. It acts with its own permissions rather than
+// as a user, so it never reaches what only the signed-in user may. Only the hash of the key is
+// stored; Prefix is its first characters, to tell keys apart.
+type APIKey struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Prefix      string       `json:"prefix"`
+	Permissions []Permission `json:"permissions"`
+	CreatedBy   *int         `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	LastUsedAt  *time.Time   `json:"last_used_at"`
+	RevokedAt   *time.Time   `json:"revoked_at"`
+}
+
+// Validate checks a key about to be created.
+func (k APIKey) Validate(now time.Time) error {
+	switch {
+	case strings.TrimSpace(k.Name) == "" || len(k.Name) > 100:
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidAPIKey)
+	case len(k.Permissions) == 0:
+		return fmt.Errorf("%w: at least one permission is required", ErrInvalidAPIKey)
+	case !k.ExpiresAt.After(now):
+		return fmt.Errorf("%w: expiry must be in the future", ErrInvalidAPIKey)
+	}
+
+	return nil
+}
+
+// Active reports whether the key may be used.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && k.ExpiresAt.After(now)
+}
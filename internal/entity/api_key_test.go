@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyValidate(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	valid := APIKey{Name: "Lab system", Permissions: []Permission{PermAppointmentWriteAny}, ExpiresAt: now.AddDate(1, 0, 0)}
+
+	assert.NoError(t, valid.Validate(now))
+
+	tests := map[string]func(k *APIKey){
+		"no name":        func(k *APIKey) { k.Name = " " },
+		"no permissions": func(k *APIKey) { k.Permissions = nil },
+		"expired":        func(k *APIKey) { k.ExpiresAt = now },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			k := valid
+			change(&k)
+
+			assert.ErrorIs(t, k.Validate(now), ErrInvalidAPIKey)
+		})
+	}
+}
+
+func TestAPIKeyActive(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Hour)
+
+	assert.True(t, APIKey{ExpiresAt: now.Add(time.Hour)}.Active(now))
+	assert.False(t, APIKey{ExpiresAt: now}.Active(now))
+	assert.False(t, APIKey{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}.Active(now))
+}
@@ -1,16 +1,122 @@
 package entity
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
-const StatusBooked = "scheduled"
+// Appointment statuses.
+const (
+	StatusScheduled  = "scheduled"
+	StatusConfirmed  = "confirmed"
+	StatusCheckedIn  = "checked_in"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusCancelled  = "cancelled"
+	StatusNoShow     = "no_show"
+	// StatusHeld marks a slot reserved for a waitlisted patient while their offer is open.
+	// It is only entered and left through the waitlist.
+	StatusHeld = "held"
+)
+
+// ActiveStatuses - statuses of appointments that still hold the doctor's time.
+var ActiveStatuses = []string{StatusScheduled, StatusConfirmed, StatusCheckedIn, StatusInProgress, StatusHeld}
+
+// _transitions - the allowed status changes of an appointment. Completed, cancelled
+// and no-show appointments are final.
+var _transitions = map[string][]string{
+	StatusScheduled:  {StatusConfirmed, StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusConfirmed:  {StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusCheckedIn:  {StatusInProgress, StatusCompleted, StatusCancelled},
+	StatusInProgress: {StatusCompleted},
+}
+
+// CanTransition reports whether an appointment may move from one status to another.
+func CanTransition(from, to string) bool {
+	return slices.Contains(_transitions[from], to)
+}
+
+// IsActiveStatus -.
+func IsActiveStatus(status string) bool {
+	return slices.Contains(ActiveStatuses, status)
+}
 
 type Appointment struct {
-	ID              int       `json:"id"`
-	UserID          int       `json:"user_id"`
-	DoctorID        int       `json:"doctor_id"`
-	AppointmentTime time.Time `json:"appointment_time"`
-	Duration        int       `json:"duration"` // in minutes
-	Status          string    `json:"status"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                int       `json:"id"`
+	PatientID         int       `json:"patient_id"`
+	DoctorID          int       `json:"doctor_id"`
+	AppointmentTypeID *int      `json:"appointment_type_id,omitempty"`
+	AppointmentTime   time.Time `json:"appointment_time"`
+	Duration          int       `json:"duration"` // in minutes
+	// BufferBefore, BufferAfter and Capacity are the appointment type's as booked.
+	BufferBefore int `json:"buffer_before"` // in minutes
+	BufferAfter  int `json:"buffer_after"`  // in minutes
+	Capacity     int `json:"capacity"`
+	// LocationID and RoomID are those of the doctor's schedule rule for the day as booked.
+	LocationID *int      `json:"location_id,omitempty"`
+	RoomID     *int      `json:"room_id,omitempty"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	TimeZone   string    `json:"time_zone"` // the doctor's, read only
+	SeriesID   *int      `json:"series_id,omitempty"`
+}
+
+// End returns the time the appointment finishes.
+func (a Appointment) End() time.Time {
+	return a.AppointmentTime.Add(time.Duration(a.Duration) * time.Minute)
+}
+
+// Block returns the doctor's time the appointment takes, buffers included.
+func (a Appointment) Block() Slot {
+	return Slot{
+		Start: a.AppointmentTime.Add(-time.Duration(a.BufferBefore) * time.Minute),
+		End:   a.End().Add(time.Duration(a.BufferAfter) * time.Minute),
+	}
+}
+
+// SharesSlotWith reports whether both appointments are places in the same group slot: they are
+// of one type with room for several patients and start at the same time. Such appointments may
+// overlap.
+func (a Appointment) SharesSlotWith(other Appointment) bool {
+	return a.Capacity > 1 && a.AppointmentTypeID != nil && other.AppointmentTypeID != nil &&
+		*a.AppointmentTypeID == *other.AppointmentTypeID && a.AppointmentTime.Equal(other.AppointmentTime)
+}
+
+// SharesRoomWith reports whether the appointments are another doctor's in the same room. Such
+// appointments may not overlap.
+func (a Appointment) SharesRoomWith(other Appointment) bool {
+	return a.DoctorID != other.DoctorID && a.RoomID != nil && other.RoomID != nil && *a.RoomID == *other.RoomID
+}
+
+// AppointmentStatusChange - a recorded transition of an appointment's status.
+type AppointmentStatusChange struct {
+	ID            int       `json:"id"`
+	AppointmentID int       `json:"appointment_id"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	ChangedBy     *int      `json:"changed_by"`
+	Reason        string    `json:"reason,omitempty"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// AppointmentReschedule - a recorded move of an appointment to another time, duration or doctor.
+type AppointmentReschedule struct {
+	ID               int       `json:"id"`
+	AppointmentID    int       `json:"appointment_id"`
+	PreviousDoctorID int       `json:"previous_doctor_id"`
+	PreviousTime     time.Time `json:"previous_time"`
+	PreviousDuration int       `json:"previous_duration"`
+	// PreviousLocationID and PreviousRoomID are where the appointment was; the repo records them
+	// from the appointment as it moves it.
+	PreviousLocationID *int      `json:"previous_location_id,omitempty"`
+	PreviousRoomID     *int      `json:"previous_room_id,omitempty"`
+	DoctorID           int       `json:"doctor_id"`
+	AppointmentTime    time.Time `json:"appointment_time"`
+	Duration           int       `json:"duration"`
+	LocationID         *int      `json:"location_id,omitempty"`
+	RoomID             *int      `json:"room_id,omitempty"`
+	ChangedBy          *int      `json:"changed_by"`
+	Reason             string    `json:"reason,omitempty"`
+	ChangedAt          time.Time `json:"changed_at"`
 }
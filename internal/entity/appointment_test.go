@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusScheduled, StatusConfirmed, true},
+		{StatusScheduled, StatusCheckedIn, true},
+		{StatusScheduled, StatusCancelled, true},
+		{StatusScheduled, StatusCompleted, false},
+		{StatusConfirmed, StatusNoShow, true},
+		{StatusConfirmed, StatusScheduled, false},
+		{StatusCheckedIn, StatusInProgress, true},
+		{StatusCheckedIn, StatusNoShow, false},
+		{StatusInProgress, StatusCompleted, true},
+		{StatusInProgress, StatusCancelled, false},
+		{StatusCompleted, StatusCancelled, false},
+		{StatusCancelled, StatusScheduled, false},
+		{StatusNoShow, StatusCheckedIn, false},
+		{StatusHeld, StatusScheduled, false},
+		{StatusHeld, StatusCancelled, false},
+		{"booked", StatusCancelled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AppointmentType - a kind of visit, such as "first consultation, 40 minutes" or "vaccination,
+// 5 patients per 10 minute slot". It belongs either to one doctor or to every doctor of a
+// specialization, and sets the duration, buffers and capacity of the appointments booked with it.
+type AppointmentType struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	DoctorID         *int   `json:"doctor_id,omitempty"`
+	SpecializationID *int   `json:"specialization_id,omitempty"`
+	Duration         int    `json:"duration"` // minutes
+	// BufferBefore and BufferAfter keep the doctor's time free around the appointment, in minutes.
+	BufferBefore int `json:"buffer_before"`
+	BufferAfter  int `json:"buffer_after"`
+	// Capacity is how many patients share one slot; they are booked at the same time.
+	Capacity  int       `json:"capacity"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks an appointment type about to be stored.
+func (t AppointmentType) Validate() error {
+	switch {
+	case strings.TrimSpace(t.Name) == "" || len([]rune(t.Name)) > 100:
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidAppointmentType)
+	case (t.DoctorID == nil) == (t.SpecializationID == nil):
+		return fmt.Errorf("%w: exactly one of doctor_id and specialization_id is required", ErrInvalidAppointmentType)
+	case t.Duration <= 0:
+		return fmt.Errorf("%w: duration must be positive", ErrInvalidAppointmentType)
+	case t.BufferBefore < 0 || t.BufferAfter < 0:
+		return fmt.Errorf("%w: buffers can't be negative", ErrInvalidAppointmentType)
+	case t.Capacity <= 0:
+		return fmt.Errorf("%w: capacity must be positive", ErrInvalidAppointmentType)
+	}
+
+	return nil
+}
+
+// OfferedBy reports whether the doctor can be booked with the type: it is theirs or belongs to a
+// specialization they hold.
+func (t AppointmentType) OfferedBy(doctor Doctor) bool {
+	if t.DoctorID != nil {
+		return *t.DoctorID == doctor.ID
+	}
+
+	return t.SpecializationID != nil && slices.ContainsFunc(doctor.Specializations, func(s Specialization) bool {
+		return s.ID == *t.SpecializationID
+	})
+}
+
+// Apply returns the appointment booked with the type: its duration, buffers and capacity come
+// from the type.
+func (t AppointmentType) Apply(appointment Appointment) Appointment {
+	id := t.ID
+
+	appointment.AppointmentTypeID = &id
+	appointment.Duration = t.Duration
+	appointment.BufferBefore = t.BufferBefore
+	appointment.BufferAfter = t.BufferAfter
+	appointment.Capacity = t.Capacity
+
+	return appointment
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppointmentTypeValidate(t *testing.T) {
+	doctorID, specializationID := 5, 1
+
+	valid := AppointmentType{Name: "Vaccination", SpecializationID: &specializationID, Duration: 10, BufferAfter: 5, Capacity: 5}
+
+	assert.NoError(t, valid.Validate())
+
+	tests := map[string]func(a *AppointmentType){
+		"no name":           func(a *AppointmentType) { a.Name = " " },
+		"long name":         func(a *AppointmentType) { a.Name = strings.Repeat("a", 101) },
+		"no owner":          func(a *AppointmentType) { a.SpecializationID = nil },
+		"two owners":        func(a *AppointmentType) { a.DoctorID = &doctorID },
+		"no duration":       func(a *AppointmentType) { a.Duration = 0 },
+		"negative buffer":   func(a *AppointmentType) { a.BufferBefore = -5 },
+		"no capacity":       func(a *AppointmentType) { a.Capacity = 0 },
+		"negative capacity": func(a *AppointmentType) { a.Capacity = -1 },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			appointmentType := valid
+			change(&appointmentType)
+
+			assert.ErrorIs(t, appointmentType.Validate(), ErrInvalidAppointmentType)
+		})
+	}
+}
+
+func TestAppointmentTypeOfferedBy(t *testing.T) {
+	doctorID, cardiology, dermatology := 5, 1, 2
+
+	doctor := Doctor{ID: doctorID, Specializations: []Specialization{{ID: cardiology, Code: "cardiology"}}}
+
+	assert.True(t, AppointmentType{DoctorID: &doctorID}.OfferedBy(doctor))
+	assert.True(t, AppointmentType{SpecializationID: &cardiology}.OfferedBy(doctor))
+	assert.False(t, AppointmentType{SpecializationID: &dermatology}.OfferedBy(doctor))
+	assert.False(t, AppointmentType{DoctorID: &doctorID}.OfferedBy(Doctor{ID: 6}))
+	assert.False(t, AppointmentType{}.OfferedBy(doctor))
+}
+
+func TestAppointmentTypeApply(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	appointmentType := AppointmentType{ID: 3, Duration: 40, BufferBefore: 5, BufferAfter: 10, Capacity: 1}
+
+	appointment := appointmentType.Apply(Appointment{DoctorID: 5, AppointmentTime: at, Duration: 15})
+
+	assert.Equal(t, 3, *appointment.AppointmentTypeID)
+	assert.Equal(t, 40, appointment.Duration)
+	assert.Equal(t, Slot{Start: at.Add(-5 * time.Minute), End: at.Add(50 * time.Minute)}, appointment.Block())
+}
+
+func TestAppointmentSharesSlotWith(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	vaccination := AppointmentType{ID: 1, Duration: 10, Capacity: 5}
+	consultation := AppointmentType{ID: 2, Duration: 10, Capacity: 1}
+
+	first := vaccination.Apply(Appointment{AppointmentTime: at})
+
+	assert.True(t, first.SharesSlotWith(vaccination.Apply(Appointment{AppointmentTime: at})))
+	assert.False(t, first.SharesSlotWith(vaccination.Apply(Appointment{AppointmentTime: at.Add(10 * time.Minute)})))
+	assert.False(t, first.SharesSlotWith(consultation.Apply(Appointment{AppointmentTime: at})))
+	assert.False(t, first.SharesSlotWith(Appointment{AppointmentTime: at, Duration: 10}))
+
+	single := consultation.Apply(Appointment{AppointmentTime: at})
+	assert.False(t, single.SharesSlotWith(consultation.Apply(Appointment{AppointmentTime: at})))
+}
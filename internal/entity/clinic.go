@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clinic - an organisation running one or more locations.
+type Clinic struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Location - a branch of a clinic. Doctors work at locations on the days their schedule rules say.
+type Location struct {
+	ID        int       `json:"id"`
+	ClinicID  int       `json:"clinic_id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Room - a consulting room of a location. Two doctors can't be booked into one room at a time.
+type Room struct {
+	ID         int       `json:"id"`
+	LocationID int       `json:"location_id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Validate checks a clinic about to be stored.
+func (c Clinic) Validate() error {
+	if !validPlaceName(c.Name) {
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidClinic)
+	}
+
+	return nil
+}
+
+// Validate checks a location about to be stored.
+func (l Location) Validate() error {
+	switch {
+	case !validPlaceName(l.Name):
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidLocation)
+	case len([]rune(l.Address)) > 500:
+		return fmt.Errorf("%w: address must be at most 500 characters", ErrInvalidLocation)
+	}
+
+	return nil
+}
+
+// Validate checks a room about to be stored.
+func (r Room) Validate() error {
+	if !validPlaceName(r.Name) {
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidRoom)
+	}
+
+	return nil
+}
+
+func validPlaceName(name string) bool {
+	return strings.TrimSpace(name) != "" && len([]rune(name)) <= 100
+}
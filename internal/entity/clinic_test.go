@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClinicValidate(t *testing.T) {
+	assert.NoError(t, Clinic{Name: "City Health"}.Validate())
+	assert.ErrorIs(t, Clinic{Name: " "}.Validate(), ErrInvalidClinic)
+
+	assert.NoError(t, Location{Name: "Downtown", Address: "1 Main Street"}.Validate())
+	assert.ErrorIs(t, Location{Name: strings.Repeat("a", 101)}.Validate(), ErrInvalidLocation)
+	assert.ErrorIs(t, Location{Name: "Downtown", Address: strings.Repeat("a", 501)}.Validate(), ErrInvalidLocation)
+
+	assert.NoError(t, Room{Name: "Room 101"}.Validate())
+	assert.ErrorIs(t, Room{}.Validate(), ErrInvalidRoom)
+}
+
+func TestSchedulePlace(t *testing.T) {
+	downtown, uptown, room := 1, 2, 7
+	schedule := Schedule{Rules: []ScheduleRule{
+		{Weekday: "mon", Ranges: []TimeRange{{Start: "09:00", End: "17:00"}}, LocationID: &downtown, RoomID: &room},
+		{Weekday: "tue", Ranges: []TimeRange{{Start: "09:00", End: "17:00"}}, LocationID: &uptown},
+		{Weekday: "wed", Ranges: []TimeRange{{Start: "09:00", End: "17:00"}}},
+	}}
+
+	assert.NoError(t, schedule.Validate())
+	assert.Equal(t, []int{room}, schedule.Rooms())
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// 2026-03-02 is a Monday; 23:30 UTC on it is already Tuesday in Berlin.
+	monday := schedule.Place(Appointment{AppointmentTime: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}, berlin)
+	assert.Equal(t, &downtown, monday.LocationID)
+	assert.Equal(t, &room, monday.RoomID)
+
+	tuesday := schedule.Place(Appointment{AppointmentTime: time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)}, berlin)
+	assert.Equal(t, &uptown, tuesday.LocationID)
+	assert.Nil(t, tuesday.RoomID)
+
+	wednesday := schedule.Place(Appointment{AppointmentTime: time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC), RoomID: &room}, berlin)
+	assert.Nil(t, wednesday.LocationID)
+	assert.Nil(t, wednesday.RoomID)
+
+	schedule.Rules[2].RoomID = &room
+	assert.ErrorIs(t, schedule.Validate(), ErrInvalidSchedule)
+}
+
+func TestAppointmentSharesRoomWith(t *testing.T) {
+	room, other := 7, 8
+
+	appointment := Appointment{DoctorID: 5, RoomID: &room}
+
+	assert.True(t, appointment.SharesRoomWith(Appointment{DoctorID: 6, RoomID: &room}))
+	assert.False(t, appointment.SharesRoomWith(Appointment{DoctorID: 6, RoomID: &other}))
+	assert.False(t, appointment.SharesRoomWith(Appointment{DoctorID: 6}))
+	assert.False(t, appointment.SharesRoomWith(Appointment{DoctorID: 5, RoomID: &room}))
+}
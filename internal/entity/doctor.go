@@ -1,18 +1,46 @@
 package entity
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Doctor struct {
-	ID             int       `json:"id"`
-	Name           string    `json:"name"`
-	Specialization string    `json:"specialization"`
-	Schedule       Schedule  `json:"schedule"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                int              `json:"id"`
+	Name              string           `json:"name"`
+	UserID            *int             `json:"user_id"`         // the account the doctor signs in with, if any
+	Specializations   []Specialization `json:"specializations"` // the main one first
+	Schedule          Schedule         `json:"schedule"`
+	HolidayCalendarID *int             `json:"holiday_calendar_id"`
+	TimeZone          string           `json:"time_zone"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
 }
 
-type Schedule struct {
-	Days  []string `json:"days"`
-	Start string   `json:"start"`
-	End   string   `json:"end"`
+// VisitMinutes returns the default visit length of the doctor's main specialization.
+func (d Doctor) VisitMinutes() int {
+	if len(d.Specializations) == 0 {
+		return DefaultVisitMinutes
+	}
+
+	return d.Specializations[0].DefaultDuration
+}
+
+// Location returns the doctor's time zone. Weekly schedules and holidays are wall-clock times in it.
+func (d Doctor) Location() (*time.Location, error) {
+	return LoadLocation(d.TimeZone)
+}
+
+// LoadLocation loads an IANA time zone; an empty name means UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, name)
+	}
+
+	return loc, nil
 }
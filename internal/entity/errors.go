@@ -0,0 +1,185 @@
+package entity
+
+import "errors"
+
+var (
+	// ErrInvalidTimeRange -.
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	// ErrInvalidSchedule -.
+	ErrInvalidSchedule = errors.New("invalid doctor schedule")
+	// ErrAppointmentConflict is returned when a booking overlaps another appointment of the same doctor.
+	ErrAppointmentConflict = errors.New("appointment overlaps an existing booking")
+	// ErrAppointmentNotFound -.
+	ErrAppointmentNotFound = errors.New("appointment not found")
+	// ErrSeriesNotFound -.
+	ErrSeriesNotFound = errors.New("appointment series not found")
+	// ErrInvalidTransition is returned when an appointment can't move to the requested status.
+	ErrInvalidTransition = errors.New("invalid appointment status transition")
+	// ErrAppointmentModified is returned when an appointment changed between reading and updating it.
+	ErrAppointmentModified = errors.New("appointment was modified concurrently")
+	// ErrInvalidTimeZone -.
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	// ErrInvalidRecurrence -.
+	ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+	// ErrSeriesConflict is returned when some occurrences of a series can't be booked and conflicts aren't skipped.
+	ErrSeriesConflict = errors.New("some occurrences of the series can't be booked")
+	// ErrInvalidScope -.
+	ErrInvalidScope = errors.New("scope must be this, following or all")
+	// ErrInvalidException -.
+	ErrInvalidException = errors.New("invalid schedule exception")
+	// ErrInvalidWaitlistEntry -.
+	ErrInvalidWaitlistEntry = errors.New("invalid waitlist entry")
+	// ErrWaitlistEntryNotFound -.
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	// ErrWaitlistOfferNotFound -.
+	ErrWaitlistOfferNotFound = errors.New("waitlist offer not found")
+	// ErrOfferUnavailable is returned when a waitlist offer was already answered or has expired.
+	ErrOfferUnavailable = errors.New("waitlist offer is no longer available")
+	// ErrEntryClosed is returned when leaving a waitlist entry that was already booked or left.
+	ErrEntryClosed = errors.New("waitlist entry is closed")
+	// ErrInvalidRole -.
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrUnknownRole -.
+	ErrUnknownRole = errors.New("role does not exist")
+	// ErrRoleExists -.
+	ErrRoleExists = errors.New("role already exists")
+	// ErrUnknownPermission -.
+	ErrUnknownPermission = errors.New("permission does not exist")
+	// ErrRoleInUse is returned when deleting a role that is still assigned to users.
+	ErrRoleInUse = errors.New("role is assigned to users")
+	// ErrBuiltinRole is returned when removing a built-in role or changing the admin role.
+	ErrBuiltinRole = errors.New("built-in role can't be changed")
+	// ErrInvalidRefreshToken is returned for an unknown refresh token or one whose session has ended.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when a rotated refresh token is presented again; its session is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+	// ErrSessionRevoked -.
+	ErrSessionRevoked = errors.New("session has been revoked or has expired")
+	// ErrUserNotFound -.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserDeactivated is returned when a deactivated user signs in or presents a token.
+	ErrUserDeactivated = errors.New("account is deactivated")
+	// ErrDeactivateSelf is returned when an admin deactivates their own account.
+	ErrDeactivateSelf = errors.New("you can't deactivate your own account")
+	// ErrInvalidUserToken is returned for an unknown, used or expired password reset or verification token.
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+	// ErrEmailNotVerified -.
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	// ErrEmailAlreadyVerified -.
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+	// ErrInvalidTwoFactorCode is returned for a wrong, reused or expired TOTP or recovery code.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrTwoFactorNotSetUp is returned when confirming or using TOTP before a secret was set up.
+	ErrTwoFactorNotSetUp = errors.New("two-factor authentication is not set up")
+	// ErrTwoFactorEnabled is returned when setting up TOTP again while it is on.
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorRequired is returned when turning off TOTP that the user's role requires.
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required for this role")
+	// ErrSSODisabled is returned when signing in with the identity provider while it isn't configured.
+	ErrSSODisabled = errors.New("single sign-on is not configured")
+	// ErrInvalidSSOState is returned for a sign-in callback that wasn't started here, was used or has expired.
+	ErrInvalidSSOState = errors.New("invalid or expired single sign-on state")
+	// ErrSSOFailed is returned when the identity provider doesn't hand over a valid ID token for the sign-in.
+	ErrSSOFailed = errors.New("single sign-on failed")
+	// ErrNoRoleForGroups is returned when none of the user's provider groups maps to a role.
+	ErrNoRoleForGroups = errors.New("none of the user's groups is allowed to sign in")
+	// ErrIdentityConflict is returned when the provider's user has the email of an account here
+	// but the provider hasn't verified it, so the accounts can't be linked.
+	ErrIdentityConflict = errors.New("an account with this email exists and can't be linked")
+	// ErrInvalidSpecialization -.
+	ErrInvalidSpecialization = errors.New("invalid specialization")
+	// ErrSpecializationNotFound -.
+	ErrSpecializationNotFound = errors.New("specialization not found")
+	// ErrSpecializationExists is returned when creating a specialization with a code in use.
+	ErrSpecializationExists = errors.New("a specialization with this code already exists")
+	// ErrSpecializationInUse is returned when deleting a specialization doctors hold, appointment
+	// types belong to or patients wait for.
+	ErrSpecializationInUse = errors.New("specialization is held by doctors, has appointment types or is waited for")
+	// ErrInvalidAppointmentType -.
+	ErrInvalidAppointmentType = errors.New("invalid appointment type")
+	// ErrAppointmentTypeRequired is returned when booking without an appointment type.
+	ErrAppointmentTypeRequired = errors.New("appointment type is required")
+	// ErrAppointmentTypeNotFound -.
+	ErrAppointmentTypeNotFound = errors.New("appointment type not found")
+	// ErrAppointmentTypeInUse is returned when deleting an appointment type active appointments are
+	// booked with.
+	ErrAppointmentTypeInUse = errors.New("appointment type has active appointments")
+	// ErrDoctorNotFound -.
+	ErrDoctorNotFound = errors.New("doctor not found")
+	// ErrInvalidDoctorAccount is returned when linking a doctor to a user whose role doesn't let
+	// them work as a doctor.
+	ErrInvalidDoctorAccount = errors.New("user's role doesn't let them work as a doctor")
+	// ErrDoctorAccountTaken is returned when linking a doctor to a user linked to another doctor.
+	ErrDoctorAccountTaken = errors.New("user is already linked to another doctor")
+	// ErrInvalidPatient -.
+	ErrInvalidPatient = errors.New("invalid patient")
+	// ErrPatientNotFound -.
+	ErrPatientNotFound = errors.New("patient not found")
+	// ErrPatientLinkExists is returned when linking a user to a patient they are already linked to,
+	// or a second account as a patient's own.
+	ErrPatientLinkExists = errors.New("user is already linked to the patient")
+	// ErrPatientLinkNotFound -.
+	ErrPatientLinkNotFound = errors.New("user is not linked to the patient")
+	// ErrInvalidAPIKey -.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyNotFound -.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAPIKeyRejected is returned for an unknown, expired or revoked API key.
+	ErrAPIKeyRejected = errors.New("API key is unknown, expired or revoked")
+	// ErrInvalidClinic -.
+	ErrInvalidClinic = errors.New("invalid clinic")
+	// ErrClinicNotFound -.
+	ErrClinicNotFound = errors.New("clinic not found")
+	// ErrClinicInUse is returned when deleting a clinic that still has locations.
+	ErrClinicInUse = errors.New("clinic has locations")
+	// ErrInvalidLocation -.
+	ErrInvalidLocation = errors.New("invalid location")
+	// ErrLocationNotFound -.
+	ErrLocationNotFound = errors.New("location not found")
+	// ErrLocationInUse is returned when deleting a location doctors' current schedules or active
+	// appointments are at.
+	ErrLocationInUse = errors.New("location is in doctors' schedules or has active appointments")
+	// ErrInvalidRoom -.
+	ErrInvalidRoom = errors.New("invalid room")
+	// ErrRoomNotFound -.
+	ErrRoomNotFound = errors.New("room not found")
+	// ErrRoomInUse is returned when deleting a room doctors' current schedules or active
+	// appointments are in.
+	ErrRoomInUse = errors.New("room is in doctors' schedules or has active appointments")
+)
+
+// BookingError - a booking rule violation. Code is stable so clients can map it to their own message.
+type BookingError struct {
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+func (e *BookingError) Error() string {
+	return e.Message
+}
+
+var (
+	// ErrInvalidDuration -.
+	ErrInvalidDuration = &BookingError{Code: "invalid_duration", Message: "appointment duration must be positive"}
+	// ErrBookingInPast -.
+	ErrBookingInPast = &BookingError{Code: "booking_in_past", Message: "appointment time is in the past"}
+	// ErrOutsideWorkingDays -.
+	ErrOutsideWorkingDays = &BookingError{Code: "outside_working_days", Message: "doctor does not work on this day"}
+	// ErrOutsideWorkingHours -.
+	ErrOutsideWorkingHours = &BookingError{Code: "outside_working_hours", Message: "appointment starts outside the doctor's working hours"}
+	// ErrDoctorUnavailable -.
+	ErrDoctorUnavailable = &BookingError{Code: "doctor_unavailable", Message: "doctor is on time off or it is a holiday"}
+	// ErrSlotTaken -.
+	ErrSlotTaken = &BookingError{Code: "slot_taken", Message: "time is already booked"}
+	// ErrSlotFull is returned when every place of a slot several patients share is booked.
+	ErrSlotFull = &BookingError{Code: "slot_full", Message: "every place in this slot is booked"}
+	// ErrTypeNotOffered is returned when booking a doctor with an appointment type that is neither
+	// theirs nor one of their specializations'.
+	ErrTypeNotOffered = &BookingError{Code: "type_not_offered", Message: "doctor doesn't offer this appointment type"}
+	// ErrDurationSetByType is returned when changing the duration of an appointment booked with a type.
+	ErrDurationSetByType = &BookingError{Code: "duration_set_by_type", Message: "the appointment type sets the duration"}
+	// ErrRoomTaken is returned when another doctor is booked into the room at that time.
+	ErrRoomTaken = &BookingError{Code: "room_taken", Message: "the room is booked for another doctor at this time"}
+	// ErrExceedsShiftEnd -.
+	ErrExceedsShiftEnd = &BookingError{Code: "exceeds_shift_end", Message: "appointment ends after the doctor's shift"}
+)
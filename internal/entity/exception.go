@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Kinds of schedule exceptions.
+const (
+	// ExceptionTimeOff blocks the period even if the weekly schedule says the doctor works.
+	ExceptionTimeOff = "time_off"
+	// ExceptionExtraHours adds the period as working time, e.g. an extra working Saturday.
+	ExceptionExtraHours = "extra_hours"
+)
+
+// ScheduleException - a date-specific change to a doctor's weekly schedule, covering [StartsAt, EndsAt).
+type ScheduleException struct {
+	ID        int       `json:"id"`
+	DoctorID  int       `json:"doctor_id"`
+	Kind      string    `json:"kind"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HolidayCalendar - a clinic-wide list of days nobody works. Doctors opt in by referencing a calendar.
+type HolidayCalendar struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Holiday - a day off in a holiday calendar. Only the date part of Date is used.
+type Holiday struct {
+	ID         int       `json:"id"`
+	CalendarID int       `json:"calendar_id"`
+	Date       time.Time `json:"date"`
+	Name       string    `json:"name"`
+}
+
+// TimeOffResult - a created time-off exception and the active appointments it overlaps.
+// Cancelled lists the ones that were cancelled because the caller asked for it.
+type TimeOffResult struct {
+	Exception ScheduleException `json:"exception"`
+	Conflicts []Appointment     `json:"conflicts"`
+	Cancelled []Appointment     `json:"cancelled"`
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Identity - a user's account at an OpenID Connect provider, linked to their account here.
+type Identity struct {
+	UserID int
+	Issuer string
+	// Subject is the provider's stable identifier of the user.
+	Subject string
+	Email   string
+	// RoleFromGroups is set when the user's role came from their provider groups; only then do
+	// the groups keep changing it.
+	RoleFromGroups bool
+	CreatedAt      time.Time
+}
+
+// OIDCLogin - a sign-in sent to the provider and not yet back. Only the hash of the state is kept.
+type OIDCLogin struct {
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// GroupRole - gives users in a provider group a role.
+type GroupRole struct {
+	Group string
+	Role  Role
+}
+
+// ParseGroupRoles parses "group=role" mappings.
+func ParseGroupRoles(mappings []string) ([]GroupRole, error) {
+	groupRoles := make([]GroupRole, 0, len(mappings))
+
+	for _, m := range mappings {
+		group, role, ok := strings.Cut(m, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+
+		if !ok || group == "" || role == "" {
+			return nil, fmt.Errorf("%w: group mapping %q is not group=role", ErrInvalidRole, m)
+		}
+
+		groupRoles = append(groupRoles, GroupRole{Group: group, Role: Role(role)})
+	}
+
+	return groupRoles, nil
+}
+
+// RoleForGroups returns the role of the first mapping whose group is one of the groups, so
+// mappings are listed from the most to the least privileged.
+func RoleForGroups(mappings []GroupRole, groups []string) (Role, bool) {
+	for _, m := range mappings {
+		for _, group := range groups {
+			if group == m.Group {
+				return m.Role, true
+			}
+		}
+	}
+
+	return "", false
+}
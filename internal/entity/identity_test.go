@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupRoles(t *testing.T) {
+	got, err := ParseGroupRoles([]string{"it-admins=admin", " front-desk = receptionist "})
+	require.NoError(t, err)
+	assert.Equal(t, []GroupRole{{"it-admins", RoleAdmin}, {"front-desk", RoleReceptionist}}, got)
+
+	for _, bad := range []string{"admin", "=admin", "it-admins="} {
+		_, err = ParseGroupRoles([]string{bad})
+		assert.ErrorIs(t, err, ErrInvalidRole, bad)
+	}
+}
+
+func TestRoleForGroups(t *testing.T) {
+	mappings := []GroupRole{{"it-admins", RoleAdmin}, {"physicians", RoleDoctor}, {"staff", RoleReceptionist}}
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   Role
+		ok     bool
+	}{
+		{"no groups", nil, "", false},
+		{"unmapped groups", []string{"visitors"}, "", false},
+		{"one match", []string{"visitors", "staff"}, RoleReceptionist, true},
+		{"first mapping wins", []string{"staff", "physicians"}, RoleDoctor, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RoleForGroups(mappings, tt.groups)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// What failed sign-ins are counted against.
+const (
+	LoginKeyAccount = "account"
+	LoginKeyIP      = "ip"
+)
+
+// LoginKey - an account, by normalized email, or a client IP that failed sign-ins are counted for.
+type LoginKey struct {
+	Kind    string
+	Subject string
+}
+
+// AccountLoginKey -.
+func AccountLoginKey(email string) LoginKey {
+	return LoginKey{Kind: LoginKeyAccount, Subject: strings.ToLower(strings.TrimSpace(email))}
+}
+
+// IPLoginKey -.
+func IPLoginKey(ip string) LoginKey {
+	return LoginKey{Kind: LoginKeyIP, Subject: ip}
+}
+
+// LoginPolicy - how failed sign-ins slow down and lock out further attempts. After the n-th
+// failure the next attempt has to wait BaseDelay·2^(n-1), capped at MaxDelay; Threshold failures
+// lock the key for Lockout. Failures older than Window are forgotten.
+type LoginPolicy struct {
+	Threshold int
+	Window    time.Duration
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Lockout   time.Duration
+}
+
+// LoginThrottle - the failed sign-ins counted for a key.
+type LoginThrottle struct {
+	Key          LoginKey
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+// Locked reports whether the key is locked out at now.
+func (t LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// RetryAfter returns how long the next attempt has to wait from now, or 0 if it may go ahead.
+func (t LoginThrottle) RetryAfter(now time.Time, p LoginPolicy) time.Duration {
+	if t.Locked(now) {
+		return t.LockedUntil.Sub(now)
+	}
+
+	t = t.current(now, p)
+	if t.Failures == 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay << (t.Failures - 1)
+	if t.Failures > 32 || delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if wait := t.LastFailedAt.Add(delay).Sub(now); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// Fail returns the throttle after one more failed sign-in at now.
+func (t LoginThrottle) Fail(now time.Time, p LoginPolicy) LoginThrottle {
+	t = t.current(now, p)
+	t.Failures++
+	t.LastFailedAt = now
+
+	if p.Threshold > 0 && t.Failures >= p.Threshold && !t.Locked(now) {
+		lockedUntil := now.Add(p.Lockout)
+		t.LockedUntil = &lockedUntil
+	}
+
+	return t
+}
+
+// current forgets failures that are outside the window or whose lockout has run out.
+func (t LoginThrottle) current(now time.Time, p LoginPolicy) LoginThrottle {
+	if t.LockedUntil != nil && !t.Locked(now) {
+		return LoginThrottle{Key: t.Key}
+	}
+
+	if t.LockedUntil == nil && now.Sub(t.LastFailedAt) >= p.Window {
+		return LoginThrottle{Key: t.Key}
+	}
+
+	return t
+}
+
+// LoginThrottledError is returned when a sign-in is attempted before RetryAfter has passed.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed sign-ins, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+
+	return fmt.Sprintf("too many failed sign-ins, retry in %s", e.RetryAfter.Round(time.Second))
+}
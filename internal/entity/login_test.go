@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _testLoginPolicy = LoginPolicy{
+	Threshold: 3,
+	Window:    15 * time.Minute,
+	BaseDelay: time.Second,
+	MaxDelay:  3 * time.Second,
+	Lockout:   10 * time.Minute,
+}
+
+func TestLoginThrottleRetryAfter(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	lockedUntil := now.Add(5 * time.Minute)
+	expired := now.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		throttle LoginThrottle
+		want     time.Duration
+	}{
+		{"no failures", LoginThrottle{}, 0},
+		{"first failure waits the base delay", LoginThrottle{Failures: 1, LastFailedAt: now}, time.Second},
+		{"delay doubles", LoginThrottle{Failures: 2, LastFailedAt: now.Add(-500 * time.Millisecond)}, 1500 * time.Millisecond},
+		{"delay is capped", LoginThrottle{Failures: 40, LastFailedAt: now}, 3 * time.Second},
+		{"delay has passed", LoginThrottle{Failures: 2, LastFailedAt: now.Add(-2 * time.Second)}, 0},
+		{"outside the window", LoginThrottle{Failures: 2, LastFailedAt: now.Add(-time.Hour)}, 0},
+		{"locked", LoginThrottle{Failures: 3, LastFailedAt: now, LockedUntil: &lockedUntil}, 5 * time.Minute},
+		{"lockout has run out", LoginThrottle{Failures: 3, LastFailedAt: now, LockedUntil: &expired}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.throttle.RetryAfter(now, _testLoginPolicy))
+		})
+	}
+}
+
+func TestLoginThrottleFail(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		throttle LoginThrottle
+		failures int
+		locked   bool
+	}{
+		{"first failure", LoginThrottle{}, 1, false},
+		{"below the threshold", LoginThrottle{Failures: 1, LastFailedAt: now.Add(-time.Minute)}, 2, false},
+		{"reaches the threshold", LoginThrottle{Failures: 2, LastFailedAt: now.Add(-time.Minute)}, 3, true},
+		{"old failures are forgotten", LoginThrottle{Failures: 2, LastFailedAt: now.Add(-time.Hour)}, 1, false},
+		{"starts over after a lockout", LoginThrottle{Failures: 3, LastFailedAt: now.Add(-time.Hour), LockedUntil: &expired}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.throttle.Fail(now, _testLoginPolicy)
+
+			assert.Equal(t, tt.failures, got.Failures)
+			assert.Equal(t, now, got.LastFailedAt)
+			assert.Equal(t, tt.locked, got.Locked(now))
+			if tt.locked {
+				assert.Equal(t, now.Add(_testLoginPolicy.Lockout), *got.LockedUntil)
+			}
+		})
+	}
+}
+
+func TestAccountLoginKey(t *testing.T) {
+	assert.Equal(t, LoginKey{Kind: LoginKeyAccount, Subject: "jane@example.com"}, AccountLoginKey(" Jane@Example.com "))
+}
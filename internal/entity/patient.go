@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Relationships of a user to a patient they are linked to.
+const (
+	RelationshipSelf     = "self"
+	RelationshipParent   = "parent"
+	RelationshipGuardian = "guardian"
+	RelationshipCarer    = "carer"
+	RelationshipSpouse   = "spouse"
+	RelationshipOther    = "other"
+)
+
+var _relationships = []string{RelationshipSelf, RelationshipParent, RelationshipGuardian, RelationshipCarer, RelationshipSpouse, RelationshipOther}
+
+// IsRelationship -.
+func IsRelationship(relationship string) bool {
+	return slices.Contains(_relationships, relationship)
+}
+
+// Sexes a patient may be recorded with. It may also be left empty.
+const (
+	SexFemale = "female"
+	SexMale   = "male"
+	SexOther  = "other"
+)
+
+// Patient - who appointments, series and waitlist entries are for. A patient doesn't sign in;
+// the users linked to them act for them: their own account and, for children or relatives in
+// their care, the accounts of a parent, guardian or carer.
+type Patient struct {
+	ID       int    `json:"id"`
+	FullName string `json:"full_name"`
+	// DateOfBirth is a date only, at midnight UTC.
+	DateOfBirth           *time.Time `json:"date_of_birth"`
+	Sex                   string     `json:"sex"`
+	EmergencyContactName  string     `json:"emergency_contact_name"`
+	EmergencyContactPhone string     `json:"emergency_contact_phone"`
+	PreferredLanguage     string     `json:"preferred_language"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// Validate checks a patient about to be stored.
+func (p Patient) Validate(now time.Time) error {
+	switch {
+	case strings.TrimSpace(p.FullName) == "" || len([]rune(p.FullName)) > 100:
+		return fmt.Errorf("%w: full name must be 1-100 characters", ErrInvalidPatient)
+	case p.DateOfBirth != nil && p.DateOfBirth.After(now):
+		return fmt.Errorf("%w: date of birth is in the future", ErrInvalidPatient)
+	case p.Sex != "" && p.Sex != SexFemale && p.Sex != SexMale && p.Sex != SexOther:
+		return fmt.Errorf("%w: sex must be female, male or other", ErrInvalidPatient)
+	case len([]rune(p.EmergencyContactName)) > 100:
+		return fmt.Errorf("%w: emergency contact name must be at most 100 characters", ErrInvalidPatient)
+	case len(p.EmergencyContactPhone) > 20:
+		return fmt.Errorf("%w: emergency contact phone must be at most 20 characters", ErrInvalidPatient)
+	case len(p.PreferredLanguage) > 35:
+		return fmt.Errorf("%w: preferred language must be at most 35 characters", ErrInvalidPatient)
+	}
+
+	return nil
+}
+
+// PatientLink - a user who may act for a patient, and how they are related.
+type PatientLink struct {
+	PatientID    int       `json:"patient_id"`
+	UserID       int       `json:"user_id"`
+	Relationship string    `json:"relationship"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LinksUser reports whether the user is one of links.
+func LinksUser(links []PatientLink, userID int) bool {
+	return slices.ContainsFunc(links, func(link PatientLink) bool { return link.UserID == userID })
+}
+
+// LinkedPatient - a patient a user is linked to, and how the user is related to them.
+type LinkedPatient struct {
+	Patient
+	Relationship string `json:"relationship"`
+}
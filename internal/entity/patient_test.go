@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatientValidate(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	dateOfBirth := time.Date(2019, 5, 14, 0, 0, 0, 0, time.UTC)
+	valid := Patient{FullName: "Ada Doe", DateOfBirth: &dateOfBirth, Sex: SexFemale, PreferredLanguage: "en"}
+
+	assert.NoError(t, valid.Validate(now))
+	assert.NoError(t, Patient{FullName: "Ada Doe"}.Validate(now), "everything but the name is optional")
+
+	tomorrow := now.AddDate(0, 0, 1)
+
+	tests := map[string]func(p *Patient){
+		"no name":           func(p *Patient) { p.FullName = " " },
+		"long name":         func(p *Patient) { p.FullName = strings.Repeat("a", 101) },
+		"born in future":    func(p *Patient) { p.DateOfBirth = &tomorrow },
+		"unknown sex":       func(p *Patient) { p.Sex = "unknown" },
+		"long phone number": func(p *Patient) { p.EmergencyContactPhone = strings.Repeat("1", 21) },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := valid
+			change(&p)
+
+			assert.ErrorIs(t, p.Validate(now), ErrInvalidPatient)
+		})
+	}
+}
+
+func TestLinksUser(t *testing.T) {
+	links := []PatientLink{{PatientID: 30, UserID: 1, Relationship: RelationshipParent}, {PatientID: 30, UserID: 2, Relationship: RelationshipGuardian}}
+
+	assert.True(t, LinksUser(links, 2))
+	assert.False(t, LinksUser(links, 3))
+	assert.False(t, LinksUser(nil, 1))
+}
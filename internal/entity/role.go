@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Permission - an action a role may take, named resource:action[:scope].
+type Permission string
+
+// Permissions. Acting on one's own account, appointments, series and waitlist entries needs none;
+// the :any permissions extend that to every patient. doctor:self lets a user linked to a doctor
+// work that doctor's agenda, and nobody else's.
+const (
+	PermUserReadAny         Permission = "user:read:any"
+	PermUserManage          Permission = "user:manage"
+	PermRoleManage          Permission = "role:manage"
+	PermDoctorManage        Permission = "doctor:manage"
+	PermScheduleManage      Permission = "schedule:manage"
+	PermAppointmentReadAny  Permission = "appointment:read:any"
+	PermAppointmentWriteAny Permission = "appointment:write:any"
+	PermAppointmentStatus   Permission = "appointment:status"
+	PermAppointmentDelete   Permission = "appointment:delete"
+	PermAPIKeyManage        Permission = "apikey:manage"
+	PermPatientManage       Permission = "patient:manage"
+	PermDoctorSelf          Permission = "doctor:self"
+)
+
+var _roleName = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// RoleDefinition - a role and the permissions it grants.
+type RoleDefinition struct {
+	Name        Role         `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	// RequireTwoFactor makes users with the role set up TOTP before they can sign in.
+	RequireTwoFactor bool      `json:"require_two_factor"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Validate -.
+func (r RoleDefinition) Validate() error {
+	if !_roleName.MatchString(string(r.Name)) {
+		return fmt.Errorf("%w: name must be 2-50 lowercase letters, digits, '-' or '_'", ErrInvalidRole)
+	}
+
+	return nil
+}
+
+// IsBuiltinRole reports whether the role is one the application relies on and can't be removed.
+// The admin role additionally always keeps every permission.
+func IsBuiltinRole(role Role) bool {
+	return role == RoleAdmin || role == RoleUser
+}
+
+// PermissionInfo -.
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name    Role
+		wantErr bool
+	}{
+		{name: "receptionist"},
+		{name: "night-nurse"},
+		{name: "lab_2"},
+		{name: "", wantErr: true},
+		{name: "x", wantErr: true},
+		{name: "Admin", wantErr: true},
+		{name: "2nd-shift", wantErr: true},
+		{name: "front desk", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			err := RoleDefinition{Name: tt.name}.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRole)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
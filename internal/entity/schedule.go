@@ -0,0 +1,243 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schedule - a version of a doctor's weekly working rules. Every change creates a new version.
+type Schedule struct {
+	Version int            `json:"version"`
+	Rules   []ScheduleRule `json:"rules"`
+}
+
+// ScheduleRule - the working hours of one weekday: one or more shifts with optional breaks inside them,
+// and where the doctor works that day.
+type ScheduleRule struct {
+	Weekday string      `json:"weekday"` // mon, tue, wed, thu, fri, sat, sun
+	Ranges  []TimeRange `json:"ranges"`
+	Breaks  []TimeRange `json:"breaks,omitempty"`
+	// LocationID and RoomID are where appointments of the day are booked; a room needs its location.
+	LocationID *int `json:"location_id,omitempty"`
+	RoomID     *int `json:"room_id,omitempty"`
+}
+
+// TimeRange - a period of a day in HH:MM, End exclusive.
+type TimeRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+var _clock = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+var _weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseWeekday accepts short (mon) and long (monday) weekday names in any case.
+func ParseWeekday(name string) (time.Weekday, error) {
+	weekday, ok := _weekdays[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidSchedule, name)
+	}
+
+	return weekday, nil
+}
+
+// WeekdayName returns the short lowercase name used in schedules.
+func WeekdayName(weekday time.Weekday) string {
+	return strings.ToLower(weekday.String()[:3])
+}
+
+// ParseClock converts an HH:MM value to the offset from midnight.
+func ParseClock(value string) (time.Duration, error) {
+	if !_clock.MatchString(value) {
+		return 0, fmt.Errorf("%w: %q is not a valid HH:MM time", ErrInvalidSchedule, value)
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a valid HH:MM time", ErrInvalidSchedule, value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Bounds returns the range as offsets from midnight.
+func (r TimeRange) Bounds() (start, end time.Duration, err error) {
+	start, err = ParseClock(r.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err = ParseClock(r.End)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start >= end {
+		return 0, 0, fmt.Errorf("%w: range %s-%s must start before it ends", ErrInvalidSchedule, r.Start, r.End)
+	}
+
+	return start, end, nil
+}
+
+// Validate checks that weekdays are known and unique, every time is HH:MM, ranges of a day
+// don't overlap, every break lies inside one of its day's ranges, and a day with a room has a
+// location. Whether the room is at the location is checked when the schedule is stored.
+func (s Schedule) Validate() error {
+	seen := make(map[time.Weekday]bool, len(s.Rules))
+
+	for _, rule := range s.Rules {
+		weekday, err := ParseWeekday(rule.Weekday)
+		if err != nil {
+			return err
+		}
+
+		if seen[weekday] {
+			return fmt.Errorf("%w: %s is listed more than once", ErrInvalidSchedule, rule.Weekday)
+		}
+
+		seen[weekday] = true
+
+		if len(rule.Ranges) == 0 {
+			return fmt.Errorf("%w: %s has no working ranges", ErrInvalidSchedule, rule.Weekday)
+		}
+
+		if rule.RoomID != nil && rule.LocationID == nil {
+			return fmt.Errorf("%w: %s has a room but no location", ErrInvalidSchedule, rule.Weekday)
+		}
+
+		ranges, err := sortedBounds(rule.Ranges)
+		if err != nil {
+			return err
+		}
+
+		breaks, err := sortedBounds(rule.Breaks)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range breaks {
+			inside := false
+			for _, r := range ranges {
+				if b[0] >= r[0] && b[1] <= r[1] {
+					inside = true
+
+					break
+				}
+			}
+
+			if !inside {
+				return fmt.Errorf("%w: a break on %s is outside its working ranges", ErrInvalidSchedule, rule.Weekday)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RuleOn returns the rule of the weekday, if the schedule has one.
+func (s Schedule) RuleOn(weekday time.Weekday) (ScheduleRule, bool) {
+	for _, rule := range s.Rules {
+		day, err := ParseWeekday(rule.Weekday)
+		if err == nil && day == weekday {
+			return rule, true
+		}
+	}
+
+	return ScheduleRule{}, false
+}
+
+// Place puts the appointment where the schedule has the doctor working on its day in loc, the
+// doctor's time zone: at the location and in the room of that weekday's rule. Appointments on days
+// without a rule, on extra hours for example, get neither.
+func (s Schedule) Place(appointment Appointment, loc *time.Location) Appointment {
+	rule, _ := s.RuleOn(appointment.AppointmentTime.In(loc).Weekday())
+	appointment.LocationID, appointment.RoomID = rule.LocationID, rule.RoomID
+
+	return appointment
+}
+
+// Rooms returns the rooms the schedule has the doctor working in.
+func (s Schedule) Rooms() []int {
+	var rooms []int
+	for _, rule := range s.Rules {
+		if rule.RoomID != nil && !slices.Contains(rooms, *rule.RoomID) {
+			rooms = append(rooms, *rule.RoomID)
+		}
+	}
+
+	return rooms
+}
+
+// sortedBounds parses the ranges, sorts them by start and rejects overlaps.
+func sortedBounds(ranges []TimeRange) ([][2]time.Duration, error) {
+	bounds := make([][2]time.Duration, 0, len(ranges))
+
+	for _, r := range ranges {
+		start, end, err := r.Bounds()
+		if err != nil {
+			return nil, err
+		}
+
+		bounds = append(bounds, [2]time.Duration{start, end})
+	}
+
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i][0] < bounds[j][0] })
+
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i][0] < bounds[i-1][1] {
+			return nil, fmt.Errorf("%w: ranges overlap", ErrInvalidSchedule)
+		}
+	}
+
+	return bounds, nil
+}
+
+// Windows returns the working periods of the day as offsets from midnight, with breaks cut out.
+// The schedule is assumed to be valid.
+func (r ScheduleRule) Windows() ([][2]time.Duration, error) {
+	ranges, err := sortedBounds(r.Ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	breaks, err := sortedBounds(r.Breaks)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows [][2]time.Duration
+	for _, rng := range ranges {
+		start := rng[0]
+
+		for _, b := range breaks {
+			if b[1] <= start || b[0] >= rng[1] {
+				continue
+			}
+
+			if b[0] > start {
+				windows = append(windows, [2]time.Duration{start, b[0]})
+			}
+
+			start = b[1]
+		}
+
+		if start < rng[1] {
+			windows = append(windows, [2]time.Duration{start, rng[1]})
+		}
+	}
+
+	return windows, nil
+}
@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleValidate(t *testing.T) {
+	day := func(weekday string, ranges []TimeRange, breaks ...TimeRange) ScheduleRule {
+		return ScheduleRule{Weekday: weekday, Ranges: ranges, Breaks: breaks}
+	}
+
+	tests := []struct {
+		name    string
+		rules   []ScheduleRule
+		wantErr bool
+	}{
+		{name: "empty schedule", rules: nil},
+		{
+			name: "two shifts and a break",
+			rules: []ScheduleRule{
+				day("mon", []TimeRange{{"09:00", "13:00"}, {"14:00", "18:00"}}, TimeRange{"11:00", "11:15"}),
+				day("Friday", []TimeRange{{"08:00", "12:00"}}),
+			},
+		},
+		{name: "unknown weekday", rules: []ScheduleRule{day("xyz", []TimeRange{{"09:00", "13:00"}})}, wantErr: true},
+		{
+			name:    "duplicate weekday",
+			rules:   []ScheduleRule{day("mon", []TimeRange{{"09:00", "13:00"}}), day("monday", []TimeRange{{"14:00", "18:00"}})},
+			wantErr: true,
+		},
+		{name: "no ranges", rules: []ScheduleRule{day("mon", nil)}, wantErr: true},
+		{name: "single digit hour", rules: []ScheduleRule{day("mon", []TimeRange{{"9:00", "13:00"}})}, wantErr: true},
+		{name: "hour out of range", rules: []ScheduleRule{day("mon", []TimeRange{{"09:00", "24:00"}})}, wantErr: true},
+		{name: "end before start", rules: []ScheduleRule{day("mon", []TimeRange{{"13:00", "09:00"}})}, wantErr: true},
+		{name: "overlapping ranges", rules: []ScheduleRule{day("mon", []TimeRange{{"09:00", "13:00"}, {"12:00", "18:00"}})}, wantErr: true},
+		{name: "touching ranges", rules: []ScheduleRule{day("mon", []TimeRange{{"09:00", "13:00"}, {"13:00", "18:00"}})}},
+		{
+			name:    "break outside ranges",
+			rules:   []ScheduleRule{day("mon", []TimeRange{{"09:00", "13:00"}, {"14:00", "18:00"}}, TimeRange{"12:30", "14:30"})},
+			wantErr: true,
+		},
+		{
+			name:    "overlapping breaks",
+			rules:   []ScheduleRule{day("mon", []TimeRange{{"09:00", "18:00"}}, TimeRange{"12:00", "13:00"}, TimeRange{"12:30", "13:30"})},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Schedule{Rules: tt.rules}.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSchedule)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
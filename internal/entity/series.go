@@ -0,0 +1,169 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies, named as in RFC 5545.
+const (
+	FrequencyDaily  = "DAILY"
+	FrequencyWeekly = "WEEKLY"
+)
+
+// Scopes of a change to an appointment that belongs to a series.
+const (
+	// ScopeThis changes only the given occurrence.
+	ScopeThis = "this"
+	// ScopeFollowing changes the given occurrence and every later one.
+	ScopeFollowing = "following"
+	// ScopeAll changes every occurrence of the series.
+	ScopeAll = "all"
+)
+
+// MaxSeriesOccurrences caps how many appointments one series may book.
+const MaxSeriesOccurrences = 200
+
+// RecurrenceRule - a subset of the RFC 5545 RRULE: FREQ=DAILY or WEEKLY, an optional INTERVAL
+// and exactly one of COUNT or UNTIL, e.g. "FREQ=WEEKLY;INTERVAL=2;COUNT=10".
+type RecurrenceRule struct {
+	Frequency string
+	Interval  int
+	Count     int
+	// Until is the last date an occurrence may fall on, in the doctor's time zone. Only the date part is used.
+	Until time.Time
+}
+
+// ParseRecurrenceRule parses an RRULE string. UNTIL takes a date as 20060102 or 2006-01-02.
+func ParseRecurrenceRule(value string) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(value), "RRULE:"), ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return RecurrenceRule{}, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+
+		var err error
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.Until, err = time.Parse("20060102", val)
+			if err != nil {
+				rule.Until, err = time.Parse(time.DateOnly, val)
+			}
+		default:
+			return RecurrenceRule{}, fmt.Errorf("%w: unsupported part %s", ErrInvalidRecurrence, key)
+		}
+
+		if err != nil {
+			return RecurrenceRule{}, fmt.Errorf("%w: invalid %s", ErrInvalidRecurrence, key)
+		}
+	}
+
+	return rule, rule.Validate()
+}
+
+// Validate -.
+func (r RecurrenceRule) Validate() error {
+	switch {
+	case r.Frequency != FrequencyDaily && r.Frequency != FrequencyWeekly:
+		return fmt.Errorf("%w: FREQ must be DAILY or WEEKLY", ErrInvalidRecurrence)
+	case r.Interval < 1 || r.Interval > 365:
+		return fmt.Errorf("%w: INTERVAL must be between 1 and 365", ErrInvalidRecurrence)
+	case (r.Count == 0) == r.Until.IsZero():
+		return fmt.Errorf("%w: exactly one of COUNT and UNTIL is required", ErrInvalidRecurrence)
+	case r.Count < 0 || r.Count > MaxSeriesOccurrences:
+		return fmt.Errorf("%w: COUNT must be between 1 and %d", ErrInvalidRecurrence, MaxSeriesOccurrences)
+	}
+
+	return nil
+}
+
+// String formats the rule back into RRULE form.
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Frequency}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	} else {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Occurrences returns the start times of the series beginning at start. Steps are taken in start's
+// location, so every occurrence keeps the same wall-clock time across DST changes.
+func (r RecurrenceRule) Occurrences(start time.Time) ([]time.Time, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	step := r.Interval
+	if r.Frequency == FrequencyWeekly {
+		step *= 7
+	}
+
+	lastDay := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 0, 0, 0, 0, start.Location()).AddDate(0, 0, 1)
+
+	var occurrences []time.Time
+	for i := 0; ; i++ {
+		occurrence := start.AddDate(0, 0, i*step)
+
+		if r.Count > 0 && i == r.Count {
+			break
+		}
+
+		if r.Count == 0 && !occurrence.Before(lastDay) {
+			break
+		}
+
+		if len(occurrences) == MaxSeriesOccurrences {
+			return nil, fmt.Errorf("%w: more than %d occurrences", ErrInvalidRecurrence, MaxSeriesOccurrences)
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, nil
+}
+
+// AppointmentSeries - appointments of one patient with one doctor repeating by Rule from StartsAt.
+type AppointmentSeries struct {
+	ID        int `json:"id"`
+	PatientID int `json:"patient_id"`
+	DoctorID  int `json:"doctor_id"`
+	// AppointmentTypeID sets the duration, buffers and capacity of every occurrence.
+	AppointmentTypeID *int      `json:"appointment_type_id,omitempty"`
+	Rule              string    `json:"rule"`
+	StartsAt          time.Time `json:"starts_at"`
+	Duration          int       `json:"duration"` // in minutes
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// SeriesOccurrence - the outcome of booking one occurrence of a series. Conflict is set when it couldn't be booked.
+type SeriesOccurrence struct {
+	AppointmentTime time.Time     `json:"appointment_time"`
+	AppointmentID   int           `json:"appointment_id,omitempty"`
+	LocationID      *int          `json:"location_id,omitempty"`
+	RoomID          *int          `json:"room_id,omitempty"`
+	Conflict        *BookingError `json:"conflict,omitempty"`
+}
+
+// SeriesBooking - a series and the per-occurrence result of booking it.
+type SeriesBooking struct {
+	Series      AppointmentSeries  `json:"series"`
+	Occurrences []SeriesOccurrence `json:"occurrences"`
+}
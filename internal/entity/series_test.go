@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    RecurrenceRule
+		wantErr bool
+	}{
+		{value: "FREQ=WEEKLY;COUNT=12", want: RecurrenceRule{Frequency: FrequencyWeekly, Interval: 1, Count: 12}},
+		{value: "RRULE:FREQ=daily;INTERVAL=3;COUNT=5", want: RecurrenceRule{Frequency: FrequencyDaily, Interval: 3, Count: 5}},
+		{value: "FREQ=WEEKLY;UNTIL=20260601", want: RecurrenceRule{Frequency: FrequencyWeekly, Interval: 1, Until: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)}},
+		{value: "FREQ=WEEKLY;UNTIL=2026-06-01", want: RecurrenceRule{Frequency: FrequencyWeekly, Interval: 1, Until: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)}},
+		{value: "FREQ=MONTHLY;COUNT=3", wantErr: true},
+		{value: "FREQ=WEEKLY", wantErr: true},
+		{value: "FREQ=WEEKLY;COUNT=3;UNTIL=20260601", wantErr: true},
+		{value: "FREQ=WEEKLY;INTERVAL=0;COUNT=3", wantErr: true},
+		{value: "FREQ=WEEKLY;COUNT=1000", wantErr: true},
+		{value: "FREQ=WEEKLY;BYDAY=MO;COUNT=3", wantErr: true},
+		{value: "FREQ=WEEKLY;COUNT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRecurrence)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+		})
+	}
+}
+
+func TestRecurrenceRuleString(t *testing.T) {
+	for _, value := range []string{"FREQ=WEEKLY;COUNT=12", "FREQ=DAILY;INTERVAL=2;UNTIL=20260601"} {
+		rule, err := ParseRecurrenceRule(value)
+		require.NoError(t, err)
+		assert.Equal(t, value, rule.String())
+	}
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	format := func(times []time.Time) []string {
+		got := make([]string, 0, len(times))
+		for _, tm := range times {
+			got = append(got, tm.Format("2006-01-02 15:04 -07:00"))
+		}
+
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		rule  RecurrenceRule
+		start time.Time
+		want  []string
+	}{
+		{
+			name:  "weekly keeps local time across DST",
+			rule:  RecurrenceRule{Frequency: FrequencyWeekly, Interval: 1, Count: 3},
+			start: time.Date(2026, 3, 23, 10, 0, 0, 0, berlin),
+			want:  []string{"2026-03-23 10:00 +01:00", "2026-03-30 10:00 +02:00", "2026-04-06 10:00 +02:00"},
+		},
+		{
+			name:  "every three days until a date, inclusive",
+			rule:  RecurrenceRule{Frequency: FrequencyDaily, Interval: 3, Until: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+			start: time.Date(2026, 3, 2, 18, 0, 0, 0, berlin),
+			want:  []string{"2026-03-02 18:00 +01:00", "2026-03-05 18:00 +01:00", "2026-03-08 18:00 +01:00"},
+		},
+		{
+			name:  "every other week",
+			rule:  RecurrenceRule{Frequency: FrequencyWeekly, Interval: 2, Until: time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)},
+			start: time.Date(2026, 3, 2, 9, 0, 0, 0, berlin),
+			want:  []string{"2026-03-02 09:00 +01:00", "2026-03-16 09:00 +01:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrences, err := tt.rule.Occurrences(tt.start)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format(occurrences))
+		})
+	}
+}
+
+func TestRecurrenceRuleOccurrencesLimit(t *testing.T) {
+	rule := RecurrenceRule{Frequency: FrequencyDaily, Interval: 1, Until: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	_, err := rule.Occurrences(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrInvalidRecurrence)
+}
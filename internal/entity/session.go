@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Reasons a session was revoked.
+const (
+	RevokedLogout    = "logout"
+	RevokedLogoutAll = "logout_all"
+	// RevokedPasswordReset - the password was reset, which signs the user out everywhere.
+	RevokedPasswordReset = "password_reset"
+	// RevokedDeactivated - an admin deactivated the user.
+	RevokedDeactivated = "deactivated"
+	// RevokedReuse - a refresh token of the session was presented a second time, so it has leaked.
+	RevokedReuse = "reuse"
+	// RevokedTwoFactorRequired - the user's role started requiring two-factor authentication.
+	RevokedTwoFactorRequired = "two_factor_required"
+)
+
+// Session - one sign-in of a user. Access tokens carry its id and stop working once it is revoked;
+// refresh tokens rotate on every use and extend it until ExpiresAt.
+type Session struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	UserAgent     string     `json:"user_agent"`
+	IP            string     `json:"ip"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+}
+
+// Active reports whether the session is neither revoked nor expired at now.
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionActive(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+
+	assert.True(t, Session{ExpiresAt: now.Add(time.Hour)}.Active(now))
+	assert.False(t, Session{ExpiresAt: now}.Active(now))
+	assert.False(t, Session{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}.Active(now))
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// Slot - a bookable period in a doctor's schedule.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Remaining is how many places are left in a slot several patients share.
+	Remaining int `json:"remaining,omitempty"`
+}
+
+// Overlaps reports whether the slot shares any time with [start, end).
+func (s Slot) Overlaps(start, end time.Time) bool {
+	return s.Start.Before(end) && start.Before(s.End)
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultVisitMinutes is the visit length of a specialization that doesn't set one.
+const DefaultVisitMinutes = 30
+
+// DefaultLanguage names a specialization when it has no name in the language asked for.
+const DefaultLanguage = "en"
+
+var _specializationCode = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Specialization - an entry of the specializations catalogue, such as cardiology. Doctors hold
+// one or more; patients on the waitlist may wait for any doctor of one.
+type Specialization struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	// Names are keyed by language tag, such as "en" or "de".
+	Names           map[string]string `json:"names"`
+	Description     string            `json:"description"`
+	DefaultDuration int               `json:"default_duration"` // minutes
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// Validate checks a specialization about to be stored.
+func (s Specialization) Validate() error {
+	switch {
+	case len(s.Code) > 50 || !_specializationCode.MatchString(s.Code):
+		return fmt.Errorf("%w: code must be lower-case words joined by '-', at most 50 characters", ErrInvalidSpecialization)
+	case strings.TrimSpace(s.Names[DefaultLanguage]) == "":
+		return fmt.Errorf("%w: a name in %q is required", ErrInvalidSpecialization, DefaultLanguage)
+	case s.DefaultDuration <= 0:
+		return fmt.Errorf("%w: default duration must be positive", ErrInvalidSpecialization)
+	}
+
+	for language, name := range s.Names {
+		if language == "" || len(language) > 35 || strings.TrimSpace(name) == "" || len([]rune(name)) > 100 {
+			return fmt.Errorf("%w: names must be 1-100 characters under a language tag", ErrInvalidSpecialization)
+		}
+	}
+
+	return nil
+}
+
+// Name returns the specialization's name in the first of the languages it has one in, or in
+// DefaultLanguage.
+func (s Specialization) Name(languages ...string) string {
+	for _, language := range languages {
+		if name, ok := s.Names[language]; ok {
+			return name
+		}
+	}
+
+	if name, ok := s.Names[DefaultLanguage]; ok {
+		return name
+	}
+
+	return s.Code
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecializationValidate(t *testing.T) {
+	valid := Specialization{
+		Code:            "general-practice",
+		Names:           map[string]string{"en": "General practice", "de": "Allgemeinmedizin"},
+		DefaultDuration: 15,
+	}
+
+	assert.NoError(t, valid.Validate())
+
+	tests := map[string]func(s *Specialization){
+		"no code":         func(s *Specialization) { s.Code = "" },
+		"upper-case code": func(s *Specialization) { s.Code = "Cardiology" },
+		"spaced code":     func(s *Specialization) { s.Code = "general practice" },
+		"trailing dash":   func(s *Specialization) { s.Code = "cardiology-" },
+		"long code":       func(s *Specialization) { s.Code = strings.Repeat("a", 51) },
+		"no english name": func(s *Specialization) { s.Names = map[string]string{"de": "Allgemeinmedizin"} },
+		"empty name":      func(s *Specialization) { s.Names = map[string]string{"en": "General practice", "de": " "} },
+		"long name":       func(s *Specialization) { s.Names = map[string]string{"en": strings.Repeat("a", 101)} },
+		"no duration":     func(s *Specialization) { s.DefaultDuration = 0 },
+		"untagged name":   func(s *Specialization) { s.Names = map[string]string{"en": "General practice", "": "GP"} },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := valid
+			s.Names = map[string]string{"en": "General practice", "de": "Allgemeinmedizin"}
+			change(&s)
+
+			assert.ErrorIs(t, s.Validate(), ErrInvalidSpecialization)
+		})
+	}
+}
+
+func TestSpecializationName(t *testing.T) {
+	s := Specialization{Code: "cardiology", Names: map[string]string{"en": "Cardiology", "de": "Kardiologie"}}
+
+	assert.Equal(t, "Kardiologie", s.Name("de"))
+	assert.Equal(t, "Kardiologie", s.Name("fr", "de"))
+	assert.Equal(t, "Cardiology", s.Name("fr"))
+	assert.Equal(t, "Cardiology", s.Name())
+	assert.Equal(t, "cardiology", Specialization{Code: "cardiology"}.Name("de"))
+}
+
+func TestDoctorVisitMinutes(t *testing.T) {
+	assert.Equal(t, DefaultVisitMinutes, Doctor{}.VisitMinutes())
+	assert.Equal(t, 20, Doctor{Specializations: []Specialization{{DefaultDuration: 20}, {DefaultDuration: 45}}}.VisitMinutes())
+}
@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+// Ways a sign-in challenge is answered.
+const (
+	// TwoFactorTOTP - with a code from the user's authenticator app or a recovery code.
+	TwoFactorTOTP = "totp"
+	// TwoFactorEnroll - the user's role requires TOTP they haven't set up; the challenge lets them
+	// set it up and is answered with the first code.
+	TwoFactorEnroll = "enroll"
+)
+
+// TwoFactor - a user's TOTP enrollment and whether their role requires one.
+type TwoFactor struct {
+	UserID int
+	// Secret is base32 encoded; it is empty until the user starts setting up TOTP.
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	Required     bool
+}
+
+// Enabled reports whether sign-ins need a second factor.
+func (t TwoFactor) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// Method returns how the user's sign-in challenge is answered, or "" if they don't get one.
+func (t TwoFactor) Method() string {
+	switch {
+	case t.Enabled():
+		return TwoFactorTOTP
+	case t.Required:
+		return TwoFactorEnroll
+	default:
+		return ""
+	}
+}
+
+// TwoFactorChallenge - handed out after the password, in exchange for a second factor.
+type TwoFactorChallenge struct {
+	Token     string
+	Method    string
+	ExpiresAt time.Time
+}
+
+// TOTPSetup - what an authenticator app needs to start generating codes.
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
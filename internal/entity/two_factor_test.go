@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorMethod(t *testing.T) {
+	confirmedAt := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "", TwoFactor{}.Method())
+	assert.Equal(t, "", TwoFactor{Secret: "JBSWY3DPEHPK3PXP"}.Method(), "set up but not confirmed")
+	assert.Equal(t, TwoFactorEnroll, TwoFactor{Required: true}.Method())
+	assert.Equal(t, TwoFactorTOTP, TwoFactor{ConfirmedAt: &confirmedAt}.Method())
+	assert.Equal(t, TwoFactorTOTP, TwoFactor{ConfirmedAt: &confirmedAt, Required: true}.Method())
+}
@@ -6,9 +6,13 @@ import (
 
 type Role string
 
+// Built-in roles. Further roles can be defined at runtime; see RoleDefinition.
 const (
-	RoleUser  Role = "user"
-	RoleAdmin Role = "admin"
+	RoleUser         Role = "user"
+	RoleAdmin        Role = "admin"
+	RoleReceptionist Role = "receptionist"
+	RoleDoctor       Role = "doctor"
+	RoleAuditor      Role = "auditor"
 )
 
 // User -.
@@ -18,10 +22,28 @@ type User struct {
 	Email     string    `json:"email"`
 	Phone     string    `json:"phone"`
 	Password  string    `json:"-"`
-	Token     string    `json:"token"`
 	Role      Role      `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// EmailVerifiedAt is nil until the user follows the link mailed to them.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// DeactivatedAt is set while an admin has deactivated the user, who can't sign in meanwhile.
+	DeactivatedAt *time.Time `json:"deactivated_at"`
+}
+
+// Purposes of the single-use tokens handed to users.
+const (
+	TokenPasswordReset     = "password_reset"
+	TokenEmailVerification = "email_verification"
+	TokenTwoFactor         = "two_factor_challenge"
+)
+
+// UserToken - a single-use token mailed or handed to a user. Only the hash of the token is stored.
+type UserToken struct {
+	UserID    int
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
 }
 
 type UserRegister struct {
@@ -34,11 +56,12 @@ type UserUpdate struct {
 	ID       int    `json:"id"`
 	FullName string `json:"fullname"`
 	Email    string `json:"email"`
-	Password string `json:"password_hash"`
 	Phone    string `json:"phone"`
 }
 
 type GetPasswordHash struct {
-	PasswordHash string `json:"-"`
-	ID           int    `json:"id"`
+	PasswordHash  string     `json:"-"`
+	ID            int        `json:"id"`
+	Role          Role       `json:"role"`
+	DeactivatedAt *time.Time `json:"deactivated_at"`
 }
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Waitlist entry statuses.
+const (
+	WaitlistWaiting = "waiting"
+	WaitlistOffered = "offered"
+	WaitlistBooked  = "booked"
+	WaitlistLeft    = "left"
+)
+
+// Waitlist offer statuses.
+const (
+	OfferPending  = "pending"
+	OfferAccepted = "accepted"
+	OfferDeclined = "declined"
+	OfferExpired  = "expired"
+)
+
+// WaitlistEntry - a patient waiting for a slot with a specific doctor, or with any doctor of a
+// specialization when DoctorID is nil, that starts and ends within [From, To).
+type WaitlistEntry struct {
+	ID             int       `json:"id"`
+	PatientID      int       `json:"patient_id"`
+	DoctorID       *int      `json:"doctor_id,omitempty"`
+	Specialization string    `json:"specialization,omitempty"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Validate checks the entry can still be matched at now.
+func (e WaitlistEntry) Validate(now time.Time) error {
+	switch {
+	case e.DoctorID == nil && strings.TrimSpace(e.Specialization) == "":
+		return fmt.Errorf("%w: doctor_id or specialization is required", ErrInvalidWaitlistEntry)
+	case !e.From.Before(e.To):
+		return fmt.Errorf("%w: from must be before to", ErrInvalidWaitlistEntry)
+	case !e.To.After(now):
+		return fmt.Errorf("%w: the window has already passed", ErrInvalidWaitlistEntry)
+	}
+
+	return nil
+}
+
+// WaitlistOffer - a freed slot held for a waitlisted patient until ExpiresAt. The hold is an
+// appointment in StatusHeld that becomes scheduled when the offer is accepted.
+type WaitlistOffer struct {
+	ID                int       `json:"id"`
+	EntryID           int       `json:"entry_id"`
+	PatientID         int       `json:"patient_id"`
+	AppointmentID     int       `json:"appointment_id"`
+	DoctorID          int       `json:"doctor_id"`
+	AppointmentTypeID *int      `json:"appointment_type_id,omitempty"`
+	AppointmentTime   time.Time `json:"appointment_time"`
+	Duration          int       `json:"duration"` // in minutes
+	LocationID        *int      `json:"location_id,omitempty"`
+	RoomID            *int      `json:"room_id,omitempty"`
+	// BufferBefore, BufferAfter and Capacity are the held appointment's, kept to pass the slot on.
+	BufferBefore int        `json:"-"`
+	BufferAfter  int        `json:"-"`
+	Capacity     int        `json:"-"`
+	Status       string     `json:"status"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RespondedAt  *time.Time `json:"responded_at,omitempty"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitlistEntryValidate(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	doctorID := 7
+
+	tests := []struct {
+		name    string
+		entry   WaitlistEntry
+		wantErr bool
+	}{
+		{
+			name:  "doctor",
+			entry: WaitlistEntry{DoctorID: &doctorID, From: now, To: now.Add(48 * time.Hour)},
+		},
+		{
+			name:  "specialization",
+			entry: WaitlistEntry{Specialization: "cardiology", From: now, To: now.Add(48 * time.Hour)},
+		},
+		{
+			name:  "window already started",
+			entry: WaitlistEntry{DoctorID: &doctorID, From: now.Add(-time.Hour), To: now.Add(time.Hour)},
+		},
+		{
+			name:    "neither doctor nor specialization",
+			entry:   WaitlistEntry{Specialization: " ", From: now, To: now.Add(time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "empty window",
+			entry:   WaitlistEntry{DoctorID: &doctorID, From: now.Add(time.Hour), To: now.Add(time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "window passed",
+			entry:   WaitlistEntry{DoctorID: &doctorID, From: now.Add(-2 * time.Hour), To: now},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.entry.Validate(now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidWaitlistEntry)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
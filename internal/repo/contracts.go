@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 )
@@ -16,12 +17,16 @@ type (
 		UpdateUser(ctx context.Context, user entity.UserUpdate) error
 		DeleteUser(ctx context.Context, id int) error
 		GetPasswordHash(ctx context.Context, email string) (entity.GetPasswordHash, error)
-		UpdateToken(ctx context.Context, id int, token string) error
+		DeactivateUser(ctx context.Context, id int, now time.Time) error
+		ReactivateUser(ctx context.Context, id int, now time.Time) error
+		CreateUserToken(ctx context.Context, token entity.UserToken) error
+		ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (int, error)
+		VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (int, error)
 	}
 
 	// AppointmentRepo -.
 	AppointmentRepo interface {
-		CreateAppointment(ctx context.Context, appointment entity.Appointment) error
+		CreateAppointment(ctx context.Context, appointment entity.Appointment) (int, error)
 		GetAppointmentsByDoctorID(ctx context.Context, doctorID int) ([]entity.Appointment, error)
 		GetBookedAppointmentsByDoctorId(ctx context.Context, doctorID int) ([]entity.Appointment, error)
 		UpdateAppointment(ctx context.Context, appointment entity.Appointment) error
@@ -30,17 +35,169 @@ type (
 		GetAppointmentByID(ctx context.Context, id int) (entity.Appointment, error)
 		GetAppointmentsByUserID(ctx context.Context, userID int) ([]entity.Appointment, error)
 		GetAllAppointments(ctx context.Context) ([]entity.Appointment, error)
+		TransitionAppointment(ctx context.Context, change entity.AppointmentStatusChange) error
+		GetAppointmentStatusHistory(ctx context.Context, appointmentID int) ([]entity.AppointmentStatusChange, error)
+		RescheduleAppointment(ctx context.Context, change entity.AppointmentReschedule) error
+		GetAppointmentReschedules(ctx context.Context, appointmentID int) ([]entity.AppointmentReschedule, error)
+		GetBookedAppointmentsInRange(ctx context.Context, doctorID int, from, to time.Time) ([]entity.Appointment, error)
+		GetBookedRoomsInRange(ctx context.Context, roomIDs []int, doctorID int, from, to time.Time) ([]entity.Appointment, error)
+		TransitionAppointments(ctx context.Context, changes []entity.AppointmentStatusChange) error
+		RescheduleAppointments(ctx context.Context, changes []entity.AppointmentReschedule) error
+		CreateAppointmentSeries(ctx context.Context, series entity.AppointmentSeries, appointmentType entity.AppointmentType, occurrences []entity.SeriesOccurrence, skipConflicts bool) (entity.SeriesBooking, error)
+		GetAppointmentSeries(ctx context.Context, id int) (entity.AppointmentSeries, error)
+		GetAppointmentsBySeriesID(ctx context.Context, seriesID int) ([]entity.Appointment, error)
+		GetDoctorAgenda(ctx context.Context, doctorID int, from, to time.Time) ([]entity.Appointment, error)
 	}
 
 	// DoctorRepo -.
 	DoctorRepo interface {
 		CreateDoctor(ctx context.Context, doctor entity.Doctor) error
 		GetDoctorByID(ctx context.Context, id int) (entity.Doctor, error)
-		GetDoctorBySpecialization(ctx context.Context, specialization string) ([]entity.Doctor, error)
+		GetDoctorByUserID(ctx context.Context, userID int) (entity.Doctor, error)
+		GetDoctorBySpecialization(ctx context.Context, code string) ([]entity.Doctor, error)
 		GetDoctors(ctx context.Context) ([]entity.Doctor, error)
+		GetDoctorsByLocationID(ctx context.Context, locationID int) ([]entity.Doctor, error)
 		UpdateDoctor(ctx context.Context, doctor entity.Doctor) error
 		DeleteDoctor(ctx context.Context, id int) error
-		ListSpecializations(ctx context.Context) ([]string, error)
 		GetBookedSchedulesByDoctorID(ctx context.Context, doctorID int) ([]entity.Schedule, error)
 	}
+
+	// ScheduleRepo -.
+	ScheduleRepo interface {
+		CreateScheduleException(ctx context.Context, exception entity.ScheduleException) (int, error)
+		GetScheduleExceptions(ctx context.Context, doctorID int, from, to time.Time) ([]entity.ScheduleException, error)
+		DeleteScheduleException(ctx context.Context, doctorID, id int) error
+		CreateHolidayCalendar(ctx context.Context, calendar entity.HolidayCalendar) (int, error)
+		ListHolidayCalendars(ctx context.Context) ([]entity.HolidayCalendar, error)
+		CreateHoliday(ctx context.Context, holiday entity.Holiday) (int, error)
+		GetHolidays(ctx context.Context, calendarID int, from, to time.Time) ([]entity.Holiday, error)
+		DeleteHoliday(ctx context.Context, calendarID, id int) error
+	}
+
+	// WaitlistRepo -.
+	WaitlistRepo interface {
+		CreateWaitlistEntry(ctx context.Context, entry entity.WaitlistEntry) (int, error)
+		GetWaitlistEntry(ctx context.Context, id int) (entity.WaitlistEntry, error)
+		GetWaitlistEntriesByUserID(ctx context.Context, userID int) ([]entity.WaitlistEntry, error)
+		GetWaitingEntries(ctx context.Context, doctor entity.Doctor, slot entity.Appointment) ([]entity.WaitlistEntry, error)
+		LeaveWaitlist(ctx context.Context, id int) (*entity.WaitlistOffer, error)
+		CreateWaitlistOffer(ctx context.Context, entryID int, slot entity.Appointment, expiresAt time.Time) (entity.WaitlistOffer, error)
+		GetWaitlistOffer(ctx context.Context, id int) (entity.WaitlistOffer, error)
+		GetWaitlistOffersByUserID(ctx context.Context, userID int) ([]entity.WaitlistOffer, error)
+		GetExpiredWaitlistOffers(ctx context.Context, now time.Time) ([]int, error)
+		AcceptWaitlistOffer(ctx context.Context, id int, acceptedBy *int, now time.Time) (entity.WaitlistOffer, error)
+		ReleaseWaitlistOffer(ctx context.Context, id int, status string) (entity.WaitlistOffer, error)
+	}
+
+	// RoleRepo -.
+	RoleRepo interface {
+		ListRoles(ctx context.Context) ([]entity.RoleDefinition, error)
+		GetRole(ctx context.Context, name entity.Role) (entity.RoleDefinition, error)
+		CreateRole(ctx context.Context, role entity.RoleDefinition) error
+		UpdateRole(ctx context.Context, role entity.RoleDefinition) error
+		DeleteRole(ctx context.Context, name entity.Role) error
+		ListPermissions(ctx context.Context) ([]entity.PermissionInfo, error)
+		SetRoleTwoFactor(ctx context.Context, name entity.Role, required bool) error
+		AssignRole(ctx context.Context, userID int, role entity.Role) error
+		GetUserAccess(ctx context.Context, userID int) (entity.Role, []entity.Permission, error)
+	}
+
+	// SessionRepo -.
+	SessionRepo interface {
+		CreateSession(ctx context.Context, session entity.Session, tokenHash string) (entity.Session, error)
+		GetSession(ctx context.Context, id int) (entity.Session, error)
+		RotateRefreshToken(ctx context.Context, oldHash, newHash string, now, expiresAt time.Time) (entity.Session, error)
+		RevokeSession(ctx context.Context, userID, id int, reason string, now time.Time) error
+		RevokeUserSessions(ctx context.Context, userID int, reason string, now time.Time) (int, error)
+		RevokeRoleSessions(ctx context.Context, role entity.Role, reason string, now time.Time) (int, error)
+	}
+
+	// LoginRepo -.
+	LoginRepo interface {
+		GetLoginThrottles(ctx context.Context, keys ...entity.LoginKey) ([]entity.LoginThrottle, error)
+		RecordLoginFailure(ctx context.Context, key entity.LoginKey, now time.Time, policy entity.LoginPolicy) (entity.LoginThrottle, entity.LoginThrottle, error)
+		ClearLoginFailures(ctx context.Context, key entity.LoginKey, now time.Time) (bool, error)
+	}
+
+	// TwoFactorRepo -.
+	TwoFactorRepo interface {
+		GetTwoFactor(ctx context.Context, userID int) (entity.TwoFactor, error)
+		SetTOTPSecret(ctx context.Context, userID int, secret string) error
+		EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string, now time.Time) error
+		DisableTOTP(ctx context.Context, userID int) error
+		UseTOTPStep(ctx context.Context, userID int, step int64) error
+		ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+		UseRecoveryCode(ctx context.Context, userID int, codeHash string, now time.Time) error
+		GetTwoFactorChallenge(ctx context.Context, tokenHash string, now time.Time) (int, error)
+		UseTwoFactorChallenge(ctx context.Context, tokenHash string, now time.Time) (int, error)
+	}
+
+	// IdentityRepo -.
+	IdentityRepo interface {
+		CreateOIDCLogin(ctx context.Context, login entity.OIDCLogin, now time.Time) error
+		UseOIDCLogin(ctx context.Context, stateHash string, now time.Time) (entity.OIDCLogin, error)
+		GetIdentityUser(ctx context.Context, identity entity.Identity, now time.Time) (entity.User, entity.Identity, error)
+		AssignIdentityRole(ctx context.Context, identity entity.Identity, role entity.Role) (bool, error)
+		LinkIdentity(ctx context.Context, identity entity.Identity) error
+		CreateIdentityUser(ctx context.Context, user entity.User, identity entity.Identity, now time.Time) (int, error)
+	}
+
+	// PatientRepo -.
+	PatientRepo interface {
+		CreatePatient(ctx context.Context, patient entity.Patient, links []entity.PatientLink) (entity.Patient, error)
+		GetPatient(ctx context.Context, id int) (entity.Patient, error)
+		UpdatePatient(ctx context.Context, patient entity.Patient) (entity.Patient, error)
+		GetSelfPatient(ctx context.Context, userID int) (entity.Patient, error)
+		GetUserPatients(ctx context.Context, userID int) ([]entity.LinkedPatient, error)
+		GetPatientLinks(ctx context.Context, patientID int) ([]entity.PatientLink, error)
+		LinkPatientUser(ctx context.Context, link entity.PatientLink) error
+		UnlinkPatientUser(ctx context.Context, patientID, userID int) error
+	}
+
+	// SpecializationRepo -.
+	SpecializationRepo interface {
+		CreateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error)
+		GetSpecialization(ctx context.Context, id int) (entity.Specialization, error)
+		GetSpecializationsByCode(ctx context.Context, codes []string) ([]entity.Specialization, error)
+		ListSpecializations(ctx context.Context) ([]entity.Specialization, error)
+		UpdateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error)
+		DeleteSpecialization(ctx context.Context, id int) error
+	}
+
+	// AppointmentTypeRepo -.
+	AppointmentTypeRepo interface {
+		CreateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error)
+		GetAppointmentType(ctx context.Context, id int) (entity.AppointmentType, error)
+		ListAppointmentTypes(ctx context.Context) ([]entity.AppointmentType, error)
+		GetAppointmentTypesByDoctorID(ctx context.Context, doctorID int) ([]entity.AppointmentType, error)
+		UpdateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error)
+		DeleteAppointmentType(ctx context.Context, id int) error
+	}
+
+	// ClinicRepo -.
+	ClinicRepo interface {
+		CreateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error)
+		GetClinic(ctx context.Context, id int) (entity.Clinic, error)
+		ListClinics(ctx context.Context) ([]entity.Clinic, error)
+		UpdateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error)
+		DeleteClinic(ctx context.Context, id int) error
+		CreateLocation(ctx context.Context, location entity.Location) (entity.Location, error)
+		GetLocation(ctx context.Context, id int) (entity.Location, error)
+		GetLocationsByClinicID(ctx context.Context, clinicID int) ([]entity.Location, error)
+		UpdateLocation(ctx context.Context, location entity.Location) (entity.Location, error)
+		DeleteLocation(ctx context.Context, id int) error
+		CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error)
+		GetRoom(ctx context.Context, id int) (entity.Room, error)
+		GetRoomsByLocationID(ctx context.Context, locationID int) ([]entity.Room, error)
+		UpdateRoom(ctx context.Context, room entity.Room) (entity.Room, error)
+		DeleteRoom(ctx context.Context, id int) error
+	}
+
+	// APIKeyRepo -.
+	APIKeyRepo interface {
+		CreateAPIKey(ctx context.Context, key entity.APIKey, keyHash string) (entity.APIKey, error)
+		ListAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+		RevokeAPIKey(ctx context.Context, id int, now time.Time) (entity.APIKey, error)
+		UseAPIKey(ctx context.Context, keyHash string, now time.Time) (entity.APIKey, error)
+	}
 )
@@ -0,0 +1,180 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _apiKeyColumns are scanned by scanAPIKey, the key's permissions as a sorted text array.
+var _apiKeyColumns = []string{
+	"id", "name", "prefix", "created_by", "created_at", "expires_at", "last_used_at", "revoked_at",
+	"ARRAY(SELECT permission FROM api_key_permissions p WHERE p.api_key_id = api_keys.id ORDER BY permission)",
+}
+
+// APIKeyRepo -.
+type APIKeyRepo struct {
+	*postgres.Postgres
+}
+
+// NewAPIKey -.
+func NewAPIKey(pg *postgres.Postgres) *APIKeyRepo {
+	return &APIKeyRepo{pg}
+}
+
+// CreateAPIKey - stores the key with its hash and permissions. It returns
+// entity.ErrUnknownPermission if one of them doesn't exist.
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key entity.APIKey, keyHash string) (entity.APIKey, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - CreateAPIKey - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Insert("api_keys").
+		Columns("name", "prefix", "key_hash", "created_by", "expires_at").
+		Values(key.Name, key.Prefix, keyHash, key.CreatedBy, key.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - CreateAPIKey - r.Builder: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - CreateAPIKey - tx.QueryRow: %w", err)
+	}
+
+	insert := r.Builder.
+		Insert("api_key_permissions").
+		Columns("api_key_id", "permission")
+
+	for _, permission := range key.Permissions {
+		insert = insert.Values(key.ID, permission)
+	}
+
+	sql, args, err = insert.Suffix("ON CONFLICT DO NOTHING").ToSql()
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - CreateAPIKey - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.APIKey{}, entity.ErrUnknownPermission
+		}
+
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - CreateAPIKey - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - CreateAPIKey - tx.Commit: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys - returns every key, revoked and expired ones included, newest first.
+func (r *APIKeyRepo) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	sql, args, err := r.Builder.
+		Select(_apiKeyColumns...).
+		From("api_keys").
+		OrderBy("created_at DESC", "id DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("APIKeyRepo - ListAPIKeys - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("APIKeyRepo - ListAPIKeys - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]entity.APIKey, 0, _defaultEntityCap)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("APIKeyRepo - ListAPIKeys - scanAPIKey: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("APIKeyRepo - ListAPIKeys - rows.Err: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey - revokes the key and returns it. Revoking it again keeps the first revocation. It
+// returns entity.ErrAPIKeyNotFound if there is no such key.
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, id int, now time.Time) (entity.APIKey, error) {
+	sql, args, err := r.Builder.
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("COALESCE(revoked_at, ?)", now)).
+		Where("id = ?", id).
+		Suffix("RETURNING " + strings.Join(_apiKeyColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - RevokeAPIKey - r.Builder: %w", err)
+	}
+
+	key, err := scanAPIKey(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, entity.ErrAPIKeyNotFound
+		}
+
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - RevokeAPIKey - r.Pool.QueryRow: %w", err)
+	}
+
+	return key, nil
+}
+
+// UseAPIKey - returns the active key with the hash and records that it was used. It returns
+// entity.ErrAPIKeyRejected if there is no such key or it was revoked or has expired.
+func (r *APIKeyRepo) UseAPIKey(ctx context.Context, keyHash string, now time.Time) (entity.APIKey, error) {
+	sql, args, err := r.Builder.
+		Update("api_keys").
+		Set("last_used_at", now).
+		Where("key_hash = ? AND revoked_at IS NULL AND expires_at > ?", keyHash, now).
+		Suffix("RETURNING " + strings.Join(_apiKeyColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - UseAPIKey - r.Builder: %w", err)
+	}
+
+	key, err := scanAPIKey(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, entity.ErrAPIKeyRejected
+		}
+
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo - UseAPIKey - r.Pool.QueryRow: %w", err)
+	}
+
+	return key, nil
+}
+
+func scanAPIKey(row pgx.Row) (entity.APIKey, error) {
+	var key entity.APIKey
+
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.CreatedBy, &key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.Permissions)
+
+	return key, err
+}
@@ -2,10 +2,33 @@ package persistent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _doctorTimeZone selects the time zone of the appointment's doctor alongside the appointment.
+const _doctorTimeZone = "(SELECT time_zone FROM doctors WHERE doctors.id = appointments.doctor_id) AS time_zone"
+
+// _appointmentColumns are scanned by scanAppointment.
+var _appointmentColumns = []string{
+	"id", "patient_id", "doctor_id", "appointment_type_id", "appointment_time", "duration", "buffer_before", "buffer_after",
+	"capacity", "location_id", "room_id", "status", "created_at", "updated_at", _doctorTimeZone, "series_id",
+}
+
+// _roomNoOverlap keeps two doctors' appointments out of one room at a time.
+const _roomNoOverlap = "appointments_room_no_overlap"
+
+// Where the doctor's time an appointment takes, buffers included, starts and ends.
+const (
+	_blockStart = "appointment_time - buffer_before * INTERVAL '1 minute'"
+	_blockEnd   = "appointment_time + (duration + buffer_after) * INTERVAL '1 minute'"
 )
 
 // AppointmentRepo -.
@@ -18,53 +41,203 @@ func NewAppointment(pg *postgres.Postgres) *AppointmentRepo {
 	return &AppointmentRepo{pg}
 }
 
-// CreateAppointment -
-func (r *AppointmentRepo) CreateAppointment(ctx context.Context, appointment entity.Appointment) error {
+// CreateAppointment - books the appointment in a transaction and returns its id.
+// The doctor row is locked so concurrent bookings for the same doctor are serialized,
+// and the appointments_no_overlap and appointments_room_no_overlap constraints back the checks up
+// at the database level.
+func (r *AppointmentRepo) CreateAppointment(ctx context.Context, appointment entity.Appointment) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("AppointmentRepo - CreateAppointment - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = r.lockDoctor(ctx, tx, appointment.DoctorID)
+	if err != nil {
+		return 0, fmt.Errorf("AppointmentRepo - CreateAppointment - r.lockDoctor: %w", err)
+	}
+
+	err = r.checkOverlap(ctx, tx, appointment)
+	if err != nil {
+		return 0, err
+	}
+
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Insert("appointments").
+		Columns("patient_id", "doctor_id", "appointment_type_id", "appointment_time", "duration", "buffer_before", "buffer_after", "capacity",
+			"location_id", "room_id", "status").
+		Values(appointment.PatientID, appointment.DoctorID, appointment.AppointmentTypeID, appointment.AppointmentTime, appointment.Duration,
+			appointment.BufferBefore, appointment.BufferAfter, capacity(appointment), appointment.LocationID, appointment.RoomID, appointment.Status).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("AppointmentRepo - CreateAppointment - r.Builder: %w", err)
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		if err := overlapError(err); err != nil {
+			return 0, err
+		}
+
+		return 0, fmt.Errorf("AppointmentRepo - CreateAppointment - tx.QueryRow: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("AppointmentRepo - CreateAppointment - tx.Commit: %w", err)
+	}
+
+	return id, nil
+}
+
+// lockDoctor takes a row lock on the doctor until the end of the transaction.
+func (r *AppointmentRepo) lockDoctor(ctx context.Context, q querier, doctorID int) error {
+	sql, args, err := r.Builder.
+		Select("id").
+		From("doctors").
+		Where("id = ?", doctorID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("r.Builder: %w", err)
+	}
+
+	var id int
+	err = q.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("q.QueryRow: %w", err)
+	}
+
+	return nil
+}
+
+// lockDoctors takes row locks on the doctors in id order, so transactions locking the same
+// doctors can't deadlock.
+func (r *AppointmentRepo) lockDoctors(ctx context.Context, q querier, doctorIDs ...int) error {
+	ids := slices.Clone(doctorIDs)
+	slices.Sort(ids)
+
+	for _, id := range slices.Compact(ids) {
+		err := r.lockDoctor(ctx, q, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkOverlap returns entity.ErrAppointmentConflict if another active appointment of the doctor
+// shares time with the given one, buffers included, entity.ErrSlotFull if the appointment shares
+// its slot with others and every place is taken, and entity.ErrRoomTaken if another doctor is
+// booked into its room at the time. The appointment itself is ignored when it already has an id.
+func (r *AppointmentRepo) checkOverlap(ctx context.Context, q querier, appointment entity.Appointment) error {
+	sharing, sharingArgs := "FALSE", []any(nil)
+	if appointment.Capacity > 1 && appointment.AppointmentTypeID != nil {
+		sharing = "appointment_type_id = ? AND appointment_time = ?"
+		sharingArgs = []any{*appointment.AppointmentTypeID, appointment.AppointmentTime}
+	}
+
+	block := appointment.Block()
+
+	sql, args, err := r.Builder.
+		Select().
+		Column("COUNT(*) FILTER (WHERE NOT ("+sharing+"))", sharingArgs...).
+		Column("COUNT(*) FILTER (WHERE "+sharing+")", sharingArgs...).
 		From("appointments").
 		Where("doctor_id = ?", appointment.DoctorID).
-		Where("appointment_time = ?", appointment.AppointmentTime).
+		Where("id <> ?", appointment.ID).
+		Where(squirrel.Eq{"status": entity.ActiveStatuses}).
+		Where(_blockStart+" < ?", block.End).
+		Where(_blockEnd+" > ?", block.Start).
 		ToSql()
 
 	if err != nil {
-		return fmt.Errorf("AppointmentRepo - CreateAppointment - r.Builder: %w", err)
+		return fmt.Errorf("AppointmentRepo - checkOverlap - r.Builder: %w", err)
 	}
 
-	row := r.Pool.QueryRow(ctx, sql, args...)
-
-	var existingAppointment entity.Appointment
-	err = row.Scan(&existingAppointment.ID, &existingAppointment.UserID, &existingAppointment.DoctorID, &existingAppointment.AppointmentTime, &existingAppointment.Duration, &existingAppointment.Status, &existingAppointment.CreatedAt, &existingAppointment.UpdatedAt)
+	var overlapping, shared int
+	err = q.QueryRow(ctx, sql, args...).Scan(&overlapping, &shared)
 	if err != nil {
-		return fmt.Errorf("AppointmentRepo - CreateAppointment - row.Scan: %w", err)
+		return fmt.Errorf("AppointmentRepo - checkOverlap - q.QueryRow: %w", err)
+	}
+
+	switch {
+	case overlapping > 0:
+		return entity.ErrAppointmentConflict
+	case shared > 0 && shared >= appointment.Capacity:
+		return entity.ErrSlotFull
 	}
 
-	if existingAppointment.ID != 0 {
-		return fmt.Errorf("AppointmentRepo - CreateAppointment - appointment already booked")
+	if appointment.RoomID == nil {
+		return nil
 	}
 
 	sql, args, err = r.Builder.
-		Insert("appointments").
-		Columns("user_id", "doctor_id", "appointment_time", "duration", "status").
-		Values(appointment.UserID, appointment.DoctorID, appointment.AppointmentTime, appointment.Duration, appointment.Status).ToSql()
+		Select("COUNT(*)").
+		From("appointments").
+		Where("room_id = ?", *appointment.RoomID).
+		Where("doctor_id <> ?", appointment.DoctorID).
+		Where("id <> ?", appointment.ID).
+		Where(squirrel.Eq{"status": entity.ActiveStatuses}).
+		Where(_blockStart+" < ?", block.End).
+		Where(_blockEnd+" > ?", block.Start).
+		ToSql()
 
 	if err != nil {
-		return fmt.Errorf("AppointmentRepo - CreateAppointment - r.Builder: %w", err)
+		return fmt.Errorf("AppointmentRepo - checkOverlap - r.Builder: %w", err)
 	}
 
-	_, err = r.Pool.Exec(ctx, sql, args...)
+	var inRoom int
+	err = q.QueryRow(ctx, sql, args...).Scan(&inRoom)
 	if err != nil {
-		return fmt.Errorf("AppointmentRepo - CreateAppointment - r.Pool.Exec: %w", err)
+		return fmt.Errorf("AppointmentRepo - checkOverlap - q.QueryRow: %w", err)
+	}
+
+	if inRoom > 0 {
+		return entity.ErrRoomTaken
 	}
 
 	return nil
+}
+
+// overlapError maps a violation of the constraints keeping appointments apart to
+// entity.ErrRoomTaken or entity.ErrAppointmentConflict, or returns nil for any other error.
+func overlapError(err error) error {
+	if !isExclusionViolation(err) {
+		return nil
+	}
+
+	if violatedConstraint(err) == _roomNoOverlap {
+		return entity.ErrRoomTaken
+	}
+
+	return entity.ErrAppointmentConflict
+}
+
+// capacity returns the appointment's capacity, one for appointments booked without a type.
+func capacity(appointment entity.Appointment) int {
+	return max(appointment.Capacity, 1)
+}
 
+func scanAppointment(row pgx.Row) (entity.Appointment, error) {
+	var appointment entity.Appointment
+
+	err := row.Scan(&appointment.ID, &appointment.PatientID, &appointment.DoctorID, &appointment.AppointmentTypeID, &appointment.AppointmentTime,
+		&appointment.Duration, &appointment.BufferBefore, &appointment.BufferAfter, &appointment.Capacity, &appointment.LocationID, &appointment.RoomID, &appointment.Status,
+		&appointment.CreatedAt, &appointment.UpdatedAt, &appointment.TimeZone, &appointment.SeriesID)
+
+	return appointment, err
 }
 
 // GetAppointmentByID -.
 func (r *AppointmentRepo) GetAppointmentByID(ctx context.Context, id int) (entity.Appointment, error) {
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Select(_appointmentColumns...).
 		From("appointments").
 		Where("id = ?", id).
 		Limit(1).
@@ -74,11 +247,12 @@ func (r *AppointmentRepo) GetAppointmentByID(ctx context.Context, id int) (entit
 		return entity.Appointment{}, fmt.Errorf("AppointmentRepo - GetAppointmenrByID - r.Builder: %w", err)
 	}
 
-	row := r.Pool.QueryRow(ctx, sql, args...)
-
-	var appointment entity.Appointment
-	err = row.Scan(&appointment.ID, &appointment.UserID, &appointment.DoctorID, &appointment.AppointmentTime, &appointment.Duration, &appointment.Status, &appointment.CreatedAt, &appointment.UpdatedAt)
+	appointment, err := scanAppointment(r.Pool.QueryRow(ctx, sql, args...))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Appointment{}, entity.ErrAppointmentNotFound
+		}
+
 		return entity.Appointment{}, fmt.Errorf("AppointmentRepo - GetAppointmentByID - row.Scan: %w", err)
 	}
 
@@ -89,9 +263,9 @@ func (r *AppointmentRepo) GetAppointmentByID(ctx context.Context, id int) (entit
 // GetAppointmentsByUserID -.
 func (r *AppointmentRepo) GetAppointmentsByUserID(ctx context.Context, userID int) ([]entity.Appointment, error) {
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Select(_appointmentColumns...).
 		From("appointments").
-		Where("user_id = ?", userID).
+		Where("patient_id IN (SELECT patient_id FROM patient_users WHERE user_id = ?)", userID).
 		ToSql()
 
 	if err != nil {
@@ -106,8 +280,7 @@ func (r *AppointmentRepo) GetAppointmentsByUserID(ctx context.Context, userID in
 
 	var appointments []entity.Appointment
 	for rows.Next() {
-		var appointment entity.Appointment
-		err = rows.Scan(&appointment.ID, &appointment.UserID, &appointment.DoctorID, &appointment.AppointmentTime, &appointment.Duration, &appointment.Status, &appointment.CreatedAt, &appointment.UpdatedAt)
+		appointment, err := scanAppointment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("AppointmentRepo - GetAppointmentsByUserID - rows.Scan: %w", err)
 		}
@@ -121,7 +294,7 @@ func (r *AppointmentRepo) GetAppointmentsByUserID(ctx context.Context, userID in
 // GetAppointmentsByDoctorID -.
 func (r *AppointmentRepo) GetAppointmentsByDoctorID(ctx context.Context, doctorID int) ([]entity.Appointment, error) {
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Select(_appointmentColumns...).
 		From("appointments").
 		Where("doctor_id = ?", doctorID).
 		ToSql()
@@ -138,8 +311,7 @@ func (r *AppointmentRepo) GetAppointmentsByDoctorID(ctx context.Context, doctorI
 
 	var appointments []entity.Appointment
 	for rows.Next() {
-		var appointment entity.Appointment
-		err = rows.Scan(&appointment.ID, &appointment.UserID, &appointment.DoctorID, &appointment.AppointmentTime, &appointment.Duration, &appointment.Status, &appointment.CreatedAt, &appointment.UpdatedAt)
+		appointment, err := scanAppointment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("AppointmentRepo - GetAppointmentsByDoctorID - rows.Scan: %w", err)
 		}
@@ -150,21 +322,40 @@ func (r *AppointmentRepo) GetAppointmentsByDoctorID(ctx context.Context, doctorI
 	return appointments, nil
 }
 
-// UpdateAppointment -.
+// UpdateAppointment - updates the doctor, time, duration, location, room and status of the appointment.
 func (r *AppointmentRepo) UpdateAppointment(ctx context.Context, appointment entity.Appointment) error {
+	err := r.updateAppointment(ctx, r.Pool, appointment)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - UpdateAppointment - r.updateAppointment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AppointmentRepo) updateAppointment(ctx context.Context, q querier, appointment entity.Appointment) error {
 	sql, args, err := r.Builder.
 		Update("appointments").
+		Set("doctor_id", appointment.DoctorID).
+		Set("appointment_time", appointment.AppointmentTime).
+		Set("duration", appointment.Duration).
+		Set("location_id", appointment.LocationID).
+		Set("room_id", appointment.RoomID).
 		Set("status", appointment.Status).
+		Set("updated_at", squirrel.Expr("NOW()")).
 		Where("id = ?", appointment.ID).
 		ToSql()
 
 	if err != nil {
-		return fmt.Errorf("AppointmentRepo - UpdateAppointment - r.Builder: %w", err)
+		return fmt.Errorf("r.Builder: %w", err)
 	}
 
-	_, err = r.Pool.Exec(ctx, sql, args...)
+	_, err = q.Exec(ctx, sql, args...)
 	if err != nil {
-		return fmt.Errorf("AppointmentRepo - UpdateAppointment - r.Pool.Exec: %w", err)
+		if err := overlapError(err); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("q.Exec: %w", err)
 	}
 
 	return nil
@@ -188,10 +379,10 @@ func (r *AppointmentRepo) DeleteAppointment(ctx context.Context, id int) error {
 // GetBookedAppointmentsByDoctorId -.
 func (r *AppointmentRepo) GetBookedAppointmentsByDoctorId(ctx context.Context, doctorID int) ([]entity.Appointment, error) {
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Select(_appointmentColumns...).
 		From("appointments").
 		Where("doctor_id = ?", doctorID).
-		Where("status = ?", entity.StatusBooked).
+		Where(squirrel.Eq{"status": entity.ActiveStatuses}).
 		ToSql()
 
 	if err != nil {
@@ -206,8 +397,7 @@ func (r *AppointmentRepo) GetBookedAppointmentsByDoctorId(ctx context.Context, d
 
 	var appointments []entity.Appointment
 	for rows.Next() {
-		var appointment entity.Appointment
-		err = rows.Scan(&appointment.ID, &appointment.UserID, &appointment.DoctorID, &appointment.AppointmentTime, &appointment.Duration, &appointment.Status, &appointment.CreatedAt, &appointment.UpdatedAt)
+		appointment, err := scanAppointment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("AppointmentRepo - GetBookedAppointmentsByDoctorId - rows.Scan: %w", err)
 		}
@@ -221,10 +411,10 @@ func (r *AppointmentRepo) GetBookedAppointmentsByDoctorId(ctx context.Context, d
 // GetBookedAppointmentsByUserId -.
 func (r *AppointmentRepo) GetBookedAppointmentsByUserId(ctx context.Context, userID int) ([]entity.Appointment, error) {
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Select(_appointmentColumns...).
 		From("appointments").
-		Where("user_id = ?", userID).
-		Where("status = ?", entity.StatusBooked).
+		Where("patient_id IN (SELECT patient_id FROM patient_users WHERE user_id = ?)", userID).
+		Where(squirrel.Eq{"status": entity.ActiveStatuses}).
 		ToSql()
 
 	if err != nil {
@@ -239,8 +429,7 @@ func (r *AppointmentRepo) GetBookedAppointmentsByUserId(ctx context.Context, use
 
 	var appointments []entity.Appointment
 	for rows.Next() {
-		var appointment entity.Appointment
-		err = rows.Scan(&appointment.ID, &appointment.UserID, &appointment.DoctorID, &appointment.AppointmentTime, &appointment.Duration, &appointment.Status, &appointment.CreatedAt, &appointment.UpdatedAt)
+		appointment, err := scanAppointment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("AppointmentRepo - GetBookedAppointmentsByUserId - rows.Scan: %w", err)
 		}
@@ -254,7 +443,7 @@ func (r *AppointmentRepo) GetBookedAppointmentsByUserId(ctx context.Context, use
 // GetAllAppointments -.
 func (r *AppointmentRepo) GetAllAppointments(ctx context.Context) ([]entity.Appointment, error) {
 	sql, args, err := r.Builder.
-		Select("id", "user_id", "doctor_id", "appointment_time", "duration", "status", "created_at", "updated_at").
+		Select(_appointmentColumns...).
 		From("appointments").
 		ToSql()
 
@@ -270,8 +459,7 @@ func (r *AppointmentRepo) GetAllAppointments(ctx context.Context) ([]entity.Appo
 
 	var appointments []entity.Appointment
 	for rows.Next() {
-		var appointment entity.Appointment
-		err = rows.Scan(&appointment.ID, &appointment.UserID, &appointment.DoctorID, &appointment.AppointmentTime, &appointment.Duration, &appointment.Status, &appointment.CreatedAt, &appointment.UpdatedAt)
+		appointment, err := scanAppointment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("AppointmentRepo - GetAllAppointments - rows.Scan: %w", err)
 		}
@@ -281,3 +469,362 @@ func (r *AppointmentRepo) GetAllAppointments(ctx context.Context) ([]entity.Appo
 
 	return appointments, nil
 }
+
+// GetBookedAppointmentsInRange - returns the doctor's active appointments whose time, buffers
+// included, overlaps [from, to).
+func (r *AppointmentRepo) GetBookedAppointmentsInRange(ctx context.Context, doctorID int, from, to time.Time) ([]entity.Appointment, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentColumns...).
+		From("appointments").
+		Where("doctor_id = ?", doctorID).
+		Where(squirrel.Eq{"status": entity.ActiveStatuses}).
+		Where(_blockStart+" < ?", to).
+		Where(_blockEnd+" > ?", from).
+		OrderBy("appointment_time").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetBookedAppointmentsInRange - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetBookedAppointmentsInRange - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var appointments []entity.Appointment
+	for rows.Next() {
+		appointment, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentRepo - GetBookedAppointmentsInRange - rows.Scan: %w", err)
+		}
+
+		appointments = append(appointments, appointment)
+	}
+
+	return appointments, nil
+}
+
+// GetBookedRoomsInRange - returns the active appointments of doctors other than doctorID in the
+// rooms whose time, buffers included, overlaps [from, to).
+func (r *AppointmentRepo) GetBookedRoomsInRange(ctx context.Context, roomIDs []int, doctorID int, from, to time.Time) ([]entity.Appointment, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentColumns...).
+		From("appointments").
+		Where("room_id = ANY(?)", roomIDs).
+		Where("doctor_id <> ?", doctorID).
+		Where(squirrel.Eq{"status": entity.ActiveStatuses}).
+		Where(_blockStart+" < ?", to).
+		Where(_blockEnd+" > ?", from).
+		OrderBy("appointment_time").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetBookedRoomsInRange - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetBookedRoomsInRange - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	appointments := make([]entity.Appointment, 0, _defaultEntityCap)
+	for rows.Next() {
+		appointment, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentRepo - GetBookedRoomsInRange - rows.Scan: %w", err)
+		}
+
+		appointments = append(appointments, appointment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetBookedRoomsInRange - rows.Err: %w", err)
+	}
+
+	return appointments, nil
+}
+
+// GetDoctorAgenda - returns the doctor's appointments starting in [from, to), whatever their
+// status except cancelled, in time order.
+func (r *AppointmentRepo) GetDoctorAgenda(ctx context.Context, doctorID int, from, to time.Time) ([]entity.Appointment, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentColumns...).
+		From("appointments").
+		Where("doctor_id = ?", doctorID).
+		Where(squirrel.NotEq{"status": entity.StatusCancelled}).
+		Where("appointment_time >= ?", from).
+		Where("appointment_time < ?", to).
+		OrderBy("appointment_time").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetDoctorAgenda - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetDoctorAgenda - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	appointments := make([]entity.Appointment, 0, _defaultEntityCap)
+	for rows.Next() {
+		appointment, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentRepo - GetDoctorAgenda - rows.Scan: %w", err)
+		}
+
+		appointments = append(appointments, appointment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetDoctorAgenda - rows.Err: %w", err)
+	}
+
+	return appointments, nil
+}
+
+// TransitionAppointment - moves the appointment from change.FromStatus to change.ToStatus and records the change.
+// Returns entity.ErrInvalidTransition if the appointment is no longer in change.FromStatus.
+func (r *AppointmentRepo) TransitionAppointment(ctx context.Context, change entity.AppointmentStatusChange) error {
+	return r.TransitionAppointments(ctx, []entity.AppointmentStatusChange{change})
+}
+
+// TransitionAppointments - applies every status change in one transaction, so either all
+// appointments move or none do.
+func (r *AppointmentRepo) TransitionAppointments(ctx context.Context, changes []entity.AppointmentStatusChange) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - TransitionAppointments - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, change := range changes {
+		err = r.transitionAppointment(ctx, tx, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - TransitionAppointments - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AppointmentRepo) transitionAppointment(ctx context.Context, q querier, change entity.AppointmentStatusChange) error {
+	sql, args, err := r.Builder.
+		Update("appointments").
+		Set("status", change.ToStatus).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where("id = ?", change.AppointmentID).
+		Where("status = ?", change.FromStatus).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - transitionAppointment - r.Builder: %w", err)
+	}
+
+	tag, err := q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - transitionAppointment - q.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrInvalidTransition
+	}
+
+	sql, args, err = r.Builder.
+		Insert("appointment_status_changes").
+		Columns("appointment_id", "from_status", "to_status", "changed_by", "reason").
+		Values(change.AppointmentID, change.FromStatus, change.ToStatus, change.ChangedBy, change.Reason).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - transitionAppointment - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - transitionAppointment - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// GetAppointmentStatusHistory -.
+func (r *AppointmentRepo) GetAppointmentStatusHistory(ctx context.Context, appointmentID int) ([]entity.AppointmentStatusChange, error) {
+	sql, args, err := r.Builder.
+		Select("id", "appointment_id", "from_status", "to_status", "changed_by", "reason", "changed_at").
+		From("appointment_status_changes").
+		Where("appointment_id = ?", appointmentID).
+		OrderBy("changed_at", "id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetAppointmentStatusHistory - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetAppointmentStatusHistory - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []entity.AppointmentStatusChange
+	for rows.Next() {
+		var change entity.AppointmentStatusChange
+		err = rows.Scan(&change.ID, &change.AppointmentID, &change.FromStatus, &change.ToStatus, &change.ChangedBy, &change.Reason, &change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentRepo - GetAppointmentStatusHistory - rows.Scan: %w", err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// RescheduleAppointment - moves the appointment to change.DoctorID, change.AppointmentTime and change.Duration,
+// and into change.LocationID and change.RoomID, in one transaction and records the previous values, its
+// location and room included. Both doctors are locked for it. Returns entity.ErrAppointmentModified if the
+// appointment no longer matches change.Previous* or is no longer active.
+func (r *AppointmentRepo) RescheduleAppointment(ctx context.Context, change entity.AppointmentReschedule) error {
+	return r.RescheduleAppointments(ctx, []entity.AppointmentReschedule{change})
+}
+
+// RescheduleAppointments - applies the reschedules in order in one transaction. Callers moving
+// several occurrences of a series order them so an occurrence never collides with one not moved yet.
+func (r *AppointmentRepo) RescheduleAppointments(ctx context.Context, changes []entity.AppointmentReschedule) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - RescheduleAppointments - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, change := range changes {
+		err = r.rescheduleAppointment(ctx, tx, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - RescheduleAppointments - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AppointmentRepo) rescheduleAppointment(ctx context.Context, q querier, change entity.AppointmentReschedule) error {
+	err := r.lockDoctors(ctx, q, change.PreviousDoctorID, change.DoctorID)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - rescheduleAppointment - r.lockDoctors: %w", err)
+	}
+
+	sql, args, err := r.Builder.
+		Select(_appointmentColumns...).
+		From("appointments").
+		Where("id = ?", change.AppointmentID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - rescheduleAppointment - r.Builder: %w", err)
+	}
+
+	appointment, err := scanAppointment(q.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - rescheduleAppointment - q.QueryRow: %w", err)
+	}
+
+	if appointment.DoctorID != change.PreviousDoctorID ||
+		!appointment.AppointmentTime.Equal(change.PreviousTime) ||
+		appointment.Duration != change.PreviousDuration ||
+		!entity.IsActiveStatus(appointment.Status) {
+		return entity.ErrAppointmentModified
+	}
+
+	change.PreviousLocationID, change.PreviousRoomID = appointment.LocationID, appointment.RoomID
+
+	appointment.DoctorID = change.DoctorID
+	appointment.AppointmentTime = change.AppointmentTime
+	appointment.Duration = change.Duration
+	appointment.LocationID = change.LocationID
+	appointment.RoomID = change.RoomID
+
+	err = r.checkOverlap(ctx, q, appointment)
+	if err != nil {
+		return err
+	}
+
+	err = r.updateAppointment(ctx, q, appointment)
+	if err != nil {
+		if errors.Is(err, entity.ErrAppointmentConflict) || errors.Is(err, entity.ErrRoomTaken) {
+			return err
+		}
+
+		return fmt.Errorf("AppointmentRepo - rescheduleAppointment - r.updateAppointment: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Insert("appointment_reschedules").
+		Columns("appointment_id", "previous_doctor_id", "previous_time", "previous_duration", "previous_location_id", "previous_room_id",
+			"doctor_id", "appointment_time", "duration", "location_id", "room_id", "changed_by", "reason").
+		Values(change.AppointmentID, change.PreviousDoctorID, change.PreviousTime, change.PreviousDuration, change.PreviousLocationID, change.PreviousRoomID,
+			change.DoctorID, change.AppointmentTime, change.Duration, change.LocationID, change.RoomID, change.ChangedBy, change.Reason).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - rescheduleAppointment - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AppointmentRepo - rescheduleAppointment - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// GetAppointmentReschedules -.
+func (r *AppointmentRepo) GetAppointmentReschedules(ctx context.Context, appointmentID int) ([]entity.AppointmentReschedule, error) {
+	sql, args, err := r.Builder.
+		Select("id", "appointment_id", "previous_doctor_id", "previous_time", "previous_duration", "previous_location_id", "previous_room_id",
+			"doctor_id", "appointment_time", "duration", "location_id", "room_id", "changed_by", "reason", "changed_at").
+		From("appointment_reschedules").
+		Where("appointment_id = ?", appointmentID).
+		OrderBy("changed_at", "id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetAppointmentReschedules - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetAppointmentReschedules - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var reschedules []entity.AppointmentReschedule
+	for rows.Next() {
+		var reschedule entity.AppointmentReschedule
+		err = rows.Scan(&reschedule.ID, &reschedule.AppointmentID, &reschedule.PreviousDoctorID, &reschedule.PreviousTime, &reschedule.PreviousDuration,
+			&reschedule.PreviousLocationID, &reschedule.PreviousRoomID, &reschedule.DoctorID, &reschedule.AppointmentTime, &reschedule.Duration,
+			&reschedule.LocationID, &reschedule.RoomID, &reschedule.ChangedBy, &reschedule.Reason, &reschedule.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentRepo - GetAppointmentReschedules - rows.Scan: %w", err)
+		}
+
+		reschedules = append(reschedules, reschedule)
+	}
+
+	return reschedules, nil
+}
@@ -0,0 +1,227 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _appointmentTypeColumns are scanned by scanAppointmentType.
+var _appointmentTypeColumns = []string{
+	"t.id", "t.name", "t.doctor_id", "t.specialization_id", "t.duration", "t.buffer_before", "t.buffer_after", "t.capacity",
+	"t.created_at", "t.updated_at",
+}
+
+// AppointmentTypeRepo -.
+type AppointmentTypeRepo struct {
+	*postgres.Postgres
+}
+
+// NewAppointmentType -.
+func NewAppointmentType(pg *postgres.Postgres) *AppointmentTypeRepo {
+	return &AppointmentTypeRepo{pg}
+}
+
+// CreateAppointmentType - returns entity.ErrDoctorNotFound or entity.ErrSpecializationNotFound if
+// the type's owner doesn't exist.
+func (r *AppointmentTypeRepo) CreateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error) {
+	sql, args, err := r.Builder.
+		Insert("appointment_types").
+		Columns("name", "doctor_id", "specialization_id", "duration", "buffer_before", "buffer_after", "capacity").
+		Values(appointmentType.Name, appointmentType.DoctorID, appointmentType.SpecializationID, appointmentType.Duration,
+			appointmentType.BufferBefore, appointmentType.BufferAfter, appointmentType.Capacity).
+		Suffix("RETURNING id, created_at, updated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.AppointmentType{}, fmt.Errorf("AppointmentTypeRepo - CreateAppointmentType - r.Builder: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&appointmentType.ID, &appointmentType.CreatedAt, &appointmentType.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.AppointmentType{}, ownerNotFound(appointmentType)
+		}
+
+		return entity.AppointmentType{}, fmt.Errorf("AppointmentTypeRepo - CreateAppointmentType - r.Pool.QueryRow: %w", err)
+	}
+
+	return appointmentType, nil
+}
+
+// GetAppointmentType - returns entity.ErrAppointmentTypeNotFound if there is no such type.
+func (r *AppointmentTypeRepo) GetAppointmentType(ctx context.Context, id int) (entity.AppointmentType, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentTypeColumns...).
+		From("appointment_types t").
+		Where("t.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.AppointmentType{}, fmt.Errorf("AppointmentTypeRepo - GetAppointmentType - r.Builder: %w", err)
+	}
+
+	appointmentType, err := scanAppointmentType(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.AppointmentType{}, entity.ErrAppointmentTypeNotFound
+		}
+
+		return entity.AppointmentType{}, fmt.Errorf("AppointmentTypeRepo - GetAppointmentType - r.Pool.QueryRow: %w", err)
+	}
+
+	return appointmentType, nil
+}
+
+// ListAppointmentTypes - returns every type ordered by name.
+func (r *AppointmentTypeRepo) ListAppointmentTypes(ctx context.Context) ([]entity.AppointmentType, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentTypeColumns...).
+		From("appointment_types t").
+		OrderBy("t.name", "t.id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentTypeRepo - ListAppointmentTypes - r.Builder: %w", err)
+	}
+
+	return r.queryAppointmentTypes(ctx, sql, args)
+}
+
+// GetAppointmentTypesByDoctorID - returns the types the doctor can be booked with: their own and
+// those of the specializations they hold.
+func (r *AppointmentTypeRepo) GetAppointmentTypesByDoctorID(ctx context.Context, doctorID int) ([]entity.AppointmentType, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentTypeColumns...).
+		From("appointment_types t").
+		Where(squirrel.Or{
+			squirrel.Eq{"t.doctor_id": doctorID},
+			squirrel.Expr("t.specialization_id IN (SELECT specialization_id FROM doctor_specializations WHERE doctor_id = ?)", doctorID),
+		}).
+		OrderBy("t.name", "t.id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentTypeRepo - GetAppointmentTypesByDoctorID - r.Builder: %w", err)
+	}
+
+	return r.queryAppointmentTypes(ctx, sql, args)
+}
+
+// UpdateAppointmentType - replaces the type and returns the stored one. Appointments already booked
+// keep the buffers and capacity they were booked with. It returns entity.ErrAppointmentTypeNotFound
+// if there is no such type and, like CreateAppointmentType, an error if its owner doesn't exist.
+func (r *AppointmentTypeRepo) UpdateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error) {
+	sql, args, err := r.Builder.
+		Update("appointment_types t").
+		Set("name", appointmentType.Name).
+		Set("doctor_id", appointmentType.DoctorID).
+		Set("specialization_id", appointmentType.SpecializationID).
+		Set("duration", appointmentType.Duration).
+		Set("buffer_before", appointmentType.BufferBefore).
+		Set("buffer_after", appointmentType.BufferAfter).
+		Set("capacity", appointmentType.Capacity).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where("t.id = ?", appointmentType.ID).
+		Suffix("RETURNING " + strings.Join(_appointmentTypeColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.AppointmentType{}, fmt.Errorf("AppointmentTypeRepo - UpdateAppointmentType - r.Builder: %w", err)
+	}
+
+	updated, err := scanAppointmentType(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return entity.AppointmentType{}, entity.ErrAppointmentTypeNotFound
+		case isForeignKeyViolation(err):
+			return entity.AppointmentType{}, ownerNotFound(appointmentType)
+		}
+
+		return entity.AppointmentType{}, fmt.Errorf("AppointmentTypeRepo - UpdateAppointmentType - r.Pool.QueryRow: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteAppointmentType - returns entity.ErrAppointmentTypeNotFound if there is no such type and
+// entity.ErrAppointmentTypeInUse if active appointments are booked with it. Past appointments and
+// series lose their type.
+func (r *AppointmentTypeRepo) DeleteAppointmentType(ctx context.Context, id int) error {
+	sql, args, err := r.Builder.
+		Delete("appointment_types t").
+		Where("t.id = ?", id).
+		Where("NOT EXISTS (SELECT 1 FROM appointments a WHERE a.appointment_type_id = t.id AND a.status = ANY(?))", entity.ActiveStatuses).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("AppointmentTypeRepo - DeleteAppointmentType - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AppointmentTypeRepo - DeleteAppointmentType - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.GetAppointmentType(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return entity.ErrAppointmentTypeInUse
+}
+
+func (r *AppointmentTypeRepo) queryAppointmentTypes(ctx context.Context, sql string, args []any) ([]entity.AppointmentType, error) {
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentTypeRepo - queryAppointmentTypes - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	appointmentTypes := make([]entity.AppointmentType, 0, _defaultEntityCap)
+	for rows.Next() {
+		appointmentType, err := scanAppointmentType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentTypeRepo - queryAppointmentTypes - rows.Scan: %w", err)
+		}
+
+		appointmentTypes = append(appointmentTypes, appointmentType)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("AppointmentTypeRepo - queryAppointmentTypes - rows.Err: %w", err)
+	}
+
+	return appointmentTypes, nil
+}
+
+// ownerNotFound names the missing owner of a type that violated a foreign key; a type has exactly
+// one owner.
+func ownerNotFound(appointmentType entity.AppointmentType) error {
+	if appointmentType.DoctorID != nil {
+		return entity.ErrDoctorNotFound
+	}
+
+	return entity.ErrSpecializationNotFound
+}
+
+func scanAppointmentType(row pgx.Row) (entity.AppointmentType, error) {
+	var appointmentType entity.AppointmentType
+
+	err := row.Scan(&appointmentType.ID, &appointmentType.Name, &appointmentType.DoctorID, &appointmentType.SpecializationID,
+		&appointmentType.Duration, &appointmentType.BufferBefore, &appointmentType.BufferAfter, &appointmentType.Capacity,
+		&appointmentType.CreatedAt, &appointmentType.UpdatedAt)
+
+	return appointmentType, err
+}
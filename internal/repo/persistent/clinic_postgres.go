@@ -0,0 +1,485 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// Columns scanned by scanClinic, scanLocation and scanRoom.
+var (
+	_clinicColumns   = []string{"c.id", "c.name", "c.created_at", "c.updated_at"}
+	_locationColumns = []string{"l.id", "l.clinic_id", "l.name", "l.address", "l.created_at", "l.updated_at"}
+	_roomColumns     = []string{"rm.id", "rm.location_id", "rm.name", "rm.created_at", "rm.updated_at"}
+)
+
+// ClinicRepo - clinics, their locations and the rooms of every location.
+type ClinicRepo struct {
+	*postgres.Postgres
+}
+
+// NewClinic -.
+func NewClinic(pg *postgres.Postgres) *ClinicRepo {
+	return &ClinicRepo{pg}
+}
+
+// CreateClinic -.
+func (r *ClinicRepo) CreateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error) {
+	sql, args, err := r.Builder.
+		Insert("clinics").
+		Columns("name").
+		Values(clinic.Name).
+		Suffix("RETURNING id, created_at, updated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.Clinic{}, fmt.Errorf("ClinicRepo - CreateClinic - r.Builder: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&clinic.ID, &clinic.CreatedAt, &clinic.UpdatedAt)
+	if err != nil {
+		return entity.Clinic{}, fmt.Errorf("ClinicRepo - CreateClinic - r.Pool.QueryRow: %w", err)
+	}
+
+	return clinic, nil
+}
+
+// GetClinic - returns entity.ErrClinicNotFound if there is no such clinic.
+func (r *ClinicRepo) GetClinic(ctx context.Context, id int) (entity.Clinic, error) {
+	sql, args, err := r.Builder.
+		Select(_clinicColumns...).
+		From("clinics c").
+		Where("c.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.Clinic{}, fmt.Errorf("ClinicRepo - GetClinic - r.Builder: %w", err)
+	}
+
+	clinic, err := scanClinic(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Clinic{}, entity.ErrClinicNotFound
+		}
+
+		return entity.Clinic{}, fmt.Errorf("ClinicRepo - GetClinic - r.Pool.QueryRow: %w", err)
+	}
+
+	return clinic, nil
+}
+
+// ListClinics - returns every clinic ordered by name.
+func (r *ClinicRepo) ListClinics(ctx context.Context) ([]entity.Clinic, error) {
+	sql, args, err := r.Builder.
+		Select(_clinicColumns...).
+		From("clinics c").
+		OrderBy("c.name", "c.id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ClinicRepo - ListClinics - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ClinicRepo - ListClinics - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	clinics := make([]entity.Clinic, 0, _defaultEntityCap)
+	for rows.Next() {
+		clinic, err := scanClinic(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ClinicRepo - ListClinics - rows.Scan: %w", err)
+		}
+
+		clinics = append(clinics, clinic)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClinicRepo - ListClinics - rows.Err: %w", err)
+	}
+
+	return clinics, nil
+}
+
+// UpdateClinic - renames the clinic and returns the stored one, or entity.ErrClinicNotFound.
+func (r *ClinicRepo) UpdateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error) {
+	sql, args, err := r.Builder.
+		Update("clinics c").
+		Set("name", clinic.Name).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where("c.id = ?", clinic.ID).
+		Suffix("RETURNING " + strings.Join(_clinicColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.Clinic{}, fmt.Errorf("ClinicRepo - UpdateClinic - r.Builder: %w", err)
+	}
+
+	updated, err := scanClinic(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Clinic{}, entity.ErrClinicNotFound
+		}
+
+		return entity.Clinic{}, fmt.Errorf("ClinicRepo - UpdateClinic - r.Pool.QueryRow: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteClinic - returns entity.ErrClinicNotFound if there is no such clinic and
+// entity.ErrClinicInUse if it still has locations.
+func (r *ClinicRepo) DeleteClinic(ctx context.Context, id int) error {
+	sql, args, err := r.Builder.
+		Delete("clinics").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("ClinicRepo - DeleteClinic - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.ErrClinicInUse
+		}
+
+		return fmt.Errorf("ClinicRepo - DeleteClinic - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrClinicNotFound
+	}
+
+	return nil
+}
+
+// CreateLocation - returns entity.ErrClinicNotFound if the location's clinic doesn't exist.
+func (r *ClinicRepo) CreateLocation(ctx context.Context, location entity.Location) (entity.Location, error) {
+	sql, args, err := r.Builder.
+		Insert("locations").
+		Columns("clinic_id", "name", "address").
+		Values(location.ClinicID, location.Name, location.Address).
+		Suffix("RETURNING id, created_at, updated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.Location{}, fmt.Errorf("ClinicRepo - CreateLocation - r.Builder: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&location.ID, &location.CreatedAt, &location.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.Location{}, entity.ErrClinicNotFound
+		}
+
+		return entity.Location{}, fmt.Errorf("ClinicRepo - CreateLocation - r.Pool.QueryRow: %w", err)
+	}
+
+	return location, nil
+}
+
+// GetLocation - returns entity.ErrLocationNotFound if there is no such location.
+func (r *ClinicRepo) GetLocation(ctx context.Context, id int) (entity.Location, error) {
+	sql, args, err := r.Builder.
+		Select(_locationColumns...).
+		From("locations l").
+		Where("l.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.Location{}, fmt.Errorf("ClinicRepo - GetLocation - r.Builder: %w", err)
+	}
+
+	location, err := scanLocation(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Location{}, entity.ErrLocationNotFound
+		}
+
+		return entity.Location{}, fmt.Errorf("ClinicRepo - GetLocation - r.Pool.QueryRow: %w", err)
+	}
+
+	return location, nil
+}
+
+// GetLocationsByClinicID - returns the clinic's locations ordered by name.
+func (r *ClinicRepo) GetLocationsByClinicID(ctx context.Context, clinicID int) ([]entity.Location, error) {
+	sql, args, err := r.Builder.
+		Select(_locationColumns...).
+		From("locations l").
+		Where("l.clinic_id = ?", clinicID).
+		OrderBy("l.name", "l.id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ClinicRepo - GetLocationsByClinicID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ClinicRepo - GetLocationsByClinicID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	locations := make([]entity.Location, 0, _defaultEntityCap)
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ClinicRepo - GetLocationsByClinicID - rows.Scan: %w", err)
+		}
+
+		locations = append(locations, location)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClinicRepo - GetLocationsByClinicID - rows.Err: %w", err)
+	}
+
+	return locations, nil
+}
+
+// UpdateLocation - renames the location and changes its address; it stays with its clinic. Returns
+// the stored location or entity.ErrLocationNotFound.
+func (r *ClinicRepo) UpdateLocation(ctx context.Context, location entity.Location) (entity.Location, error) {
+	sql, args, err := r.Builder.
+		Update("locations l").
+		Set("name", location.Name).
+		Set("address", location.Address).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where("l.id = ?", location.ID).
+		Suffix("RETURNING " + strings.Join(_locationColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.Location{}, fmt.Errorf("ClinicRepo - UpdateLocation - r.Builder: %w", err)
+	}
+
+	updated, err := scanLocation(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Location{}, entity.ErrLocationNotFound
+		}
+
+		return entity.Location{}, fmt.Errorf("ClinicRepo - UpdateLocation - r.Pool.QueryRow: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteLocation - removes the location and its rooms. Returns entity.ErrLocationNotFound if there
+// is no such location and entity.ErrLocationInUse if a doctor's current schedule or an active
+// appointment is at it. Older schedule versions and past appointments lose their location.
+func (r *ClinicRepo) DeleteLocation(ctx context.Context, id int) error {
+	sql, args, err := r.Builder.
+		Delete("locations l").
+		Where("l.id = ?", id).
+		Where(notInUse("location_id", "l.id")).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("ClinicRepo - DeleteLocation - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("ClinicRepo - DeleteLocation - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.GetLocation(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return entity.ErrLocationInUse
+}
+
+// CreateRoom - returns entity.ErrLocationNotFound if the room's location doesn't exist.
+func (r *ClinicRepo) CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
+	sql, args, err := r.Builder.
+		Insert("rooms").
+		Columns("location_id", "name").
+		Values(room.LocationID, room.Name).
+		Suffix("RETURNING id, created_at, updated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.Room{}, fmt.Errorf("ClinicRepo - CreateRoom - r.Builder: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&room.ID, &room.CreatedAt, &room.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.Room{}, entity.ErrLocationNotFound
+		}
+
+		return entity.Room{}, fmt.Errorf("ClinicRepo - CreateRoom - r.Pool.QueryRow: %w", err)
+	}
+
+	return room, nil
+}
+
+// GetRoom - returns entity.ErrRoomNotFound if there is no such room.
+func (r *ClinicRepo) GetRoom(ctx context.Context, id int) (entity.Room, error) {
+	sql, args, err := r.Builder.
+		Select(_roomColumns...).
+		From("rooms rm").
+		Where("rm.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.Room{}, fmt.Errorf("ClinicRepo - GetRoom - r.Builder: %w", err)
+	}
+
+	room, err := scanRoom(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Room{}, entity.ErrRoomNotFound
+		}
+
+		return entity.Room{}, fmt.Errorf("ClinicRepo - GetRoom - r.Pool.QueryRow: %w", err)
+	}
+
+	return room, nil
+}
+
+// GetRoomsByLocationID - returns the location's rooms ordered by name.
+func (r *ClinicRepo) GetRoomsByLocationID(ctx context.Context, locationID int) ([]entity.Room, error) {
+	sql, args, err := r.Builder.
+		Select(_roomColumns...).
+		From("rooms rm").
+		Where("rm.location_id = ?", locationID).
+		OrderBy("rm.name", "rm.id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ClinicRepo - GetRoomsByLocationID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ClinicRepo - GetRoomsByLocationID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	rooms := make([]entity.Room, 0, _defaultEntityCap)
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ClinicRepo - GetRoomsByLocationID - rows.Scan: %w", err)
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClinicRepo - GetRoomsByLocationID - rows.Err: %w", err)
+	}
+
+	return rooms, nil
+}
+
+// UpdateRoom - renames the room; it stays at its location. Returns the stored room or
+// entity.ErrRoomNotFound.
+func (r *ClinicRepo) UpdateRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
+	sql, args, err := r.Builder.
+		Update("rooms rm").
+		Set("name", room.Name).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where("rm.id = ?", room.ID).
+		Suffix("RETURNING " + strings.Join(_roomColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.Room{}, fmt.Errorf("ClinicRepo - UpdateRoom - r.Builder: %w", err)
+	}
+
+	updated, err := scanRoom(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Room{}, entity.ErrRoomNotFound
+		}
+
+		return entity.Room{}, fmt.Errorf("ClinicRepo - UpdateRoom - r.Pool.QueryRow: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteRoom - returns entity.ErrRoomNotFound if there is no such room and entity.ErrRoomInUse if a
+// doctor's current schedule or an active appointment is in it. Older schedule versions and past
+// appointments lose their room.
+func (r *ClinicRepo) DeleteRoom(ctx context.Context, id int) error {
+	sql, args, err := r.Builder.
+		Delete("rooms rm").
+		Where("rm.id = ?", id).
+		Where(notInUse("room_id", "rm.id")).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("ClinicRepo - DeleteRoom - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("ClinicRepo - DeleteRoom - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.GetRoom(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return entity.ErrRoomInUse
+}
+
+// notInUse matches locations or rooms, by the column naming them and the id they are matched on,
+// that no current schedule rule and no active appointment refers to.
+func notInUse(column, id string) squirrel.Sqlizer {
+	return squirrel.And{
+		squirrel.Expr("NOT EXISTS (SELECT 1 FROM doctor_schedule_rules sr JOIN doctor_schedules s ON s.id = sr.schedule_id " +
+			"WHERE sr." + column + " = " + id + " AND " + _currentSchedule + ")"),
+		squirrel.Expr("NOT EXISTS (SELECT 1 FROM appointments a WHERE a."+column+" = "+id+" AND a.status = ANY(?))", entity.ActiveStatuses),
+	}
+}
+
+func scanClinic(row pgx.Row) (entity.Clinic, error) {
+	var clinic entity.Clinic
+
+	err := row.Scan(&clinic.ID, &clinic.Name, &clinic.CreatedAt, &clinic.UpdatedAt)
+
+	return clinic, err
+}
+
+func scanLocation(row pgx.Row) (entity.Location, error) {
+	var location entity.Location
+
+	err := row.Scan(&location.ID, &location.ClinicID, &location.Name, &location.Address, &location.CreatedAt, &location.UpdatedAt)
+
+	return location, err
+}
+
+func scanRoom(row pgx.Row) (entity.Room, error) {
+	var room entity.Room
+
+	err := row.Scan(&room.ID, &room.LocationID, &room.Name, &room.CreatedAt, &room.UpdatedAt)
+
+	return room, err
+}
@@ -2,13 +2,24 @@ package persistent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
 )
 
+// Kinds of rows in doctor_schedule_ranges.
+const (
+	_rangeWork  = "work"
+	_rangeBreak = "break"
+)
+
+// _currentSchedule limits doctor_schedules s to the latest version of every doctor's schedule.
+const _currentSchedule = "s.version = (SELECT MAX(version) FROM doctor_schedules latest WHERE latest.doctor_id = s.doctor_id)"
+
 // DoctorRepo -.
 type DoctorRepo struct {
 	*postgres.Postgres
@@ -19,20 +30,48 @@ func NewDoctor(pg *postgres.Postgres) *DoctorRepo {
 	return &DoctorRepo{pg}
 }
 
-// CreateDoctor - creates a new doctor in the database.
+// CreateDoctor - creates a new doctor in the database together with the first version of the schedule.
 func (r *DoctorRepo) CreateDoctor(ctx context.Context, doctor entity.Doctor) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - Store - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	sql, args, err := r.Builder.
 		Insert("doctors").
-		Columns("name", "specialization", "schedule").
-		Values(doctor.Name, doctor.Specialization, doctor.Schedule).ToSql()
+		Columns("name", "user_id", "holiday_calendar_id", "time_zone").
+		Values(doctor.Name, doctor.UserID, doctor.HolidayCalendarID, timeZoneOrUTC(doctor.TimeZone)).
+		Suffix("RETURNING id").
+		ToSql()
 
 	if err != nil {
 		return fmt.Errorf("DoctorRepo - Store - r.Builder: %w", err)
 	}
 
-	_, err = r.Pool.Exec(ctx, sql, args...)
+	var id int
+	err = tx.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		if err := doctorAccountError(err); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("DoctorRepo - Store - tx.QueryRow: %w", err)
+	}
+
+	err = r.insertSchedule(ctx, tx, id, doctor.Schedule)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - Store - r.insertSchedule: %w", err)
+	}
+
+	err = r.setSpecializations(ctx, tx, id, doctor.Specializations)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - Store - r.setSpecializations: %w", err)
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
-		return fmt.Errorf("DoctorRepo - Store - r.Pool.Exec: %w", err)
+		return fmt.Errorf("DoctorRepo - Store - tx.Commit: %w", err)
 	}
 
 	return nil
@@ -41,7 +80,7 @@ func (r *DoctorRepo) CreateDoctor(ctx context.Context, doctor entity.Doctor) err
 // GetDoctorByID -.
 func (r *DoctorRepo) GetDoctorByID(ctx context.Context, id int) (entity.Doctor, error) {
 	sql, args, err := r.Builder.
-		Select("id", "name", "specialization", "schedule", "created_at", "updated_at").
+		Select("id", "name", "user_id", "holiday_calendar_id", "time_zone", "created_at", "updated_at").
 		From("doctors").
 		Where("id = ?", id).
 		Limit(1).
@@ -54,18 +93,58 @@ func (r *DoctorRepo) GetDoctorByID(ctx context.Context, id int) (entity.Doctor,
 	row := r.Pool.QueryRow(ctx, sql, args...)
 
 	var doctor entity.Doctor
-	err = row.Scan(&doctor.ID, &doctor.Name, &doctor.Specialization, &doctor.Schedule, &doctor.CreatedAt, &doctor.UpdatedAt)
+	err = row.Scan(&doctor.ID, &doctor.Name, &doctor.UserID, &doctor.HolidayCalendarID, &doctor.TimeZone, &doctor.CreatedAt, &doctor.UpdatedAt)
 	if err != nil {
 		return entity.Doctor{}, fmt.Errorf("DoctorRepo - GetDoctorByID - row.Scan: %w", err)
 	}
 
+	schedules, err := r.currentSchedules(ctx, []int{doctor.ID})
+	if err != nil {
+		return entity.Doctor{}, fmt.Errorf("DoctorRepo - GetDoctorByID - r.currentSchedules: %w", err)
+	}
+
+	doctor.Schedule = schedules[doctor.ID]
+
+	specializations, err := r.doctorSpecializations(ctx, []int{doctor.ID})
+	if err != nil {
+		return entity.Doctor{}, fmt.Errorf("DoctorRepo - GetDoctorByID - r.doctorSpecializations: %w", err)
+	}
+
+	doctor.Specializations = specializations[doctor.ID]
+
 	return doctor, nil
 }
 
+// GetDoctorByUserID - returns the doctor the user signs in as, or entity.ErrDoctorNotFound.
+func (r *DoctorRepo) GetDoctorByUserID(ctx context.Context, userID int) (entity.Doctor, error) {
+	sql, args, err := r.Builder.
+		Select("id").
+		From("doctors").
+		Where("user_id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return entity.Doctor{}, fmt.Errorf("DoctorRepo - GetDoctorByUserID - r.Builder: %w", err)
+	}
+
+	var id int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Doctor{}, entity.ErrDoctorNotFound
+		}
+
+		return entity.Doctor{}, fmt.Errorf("DoctorRepo - GetDoctorByUserID - r.Pool.QueryRow: %w", err)
+	}
+
+	return r.GetDoctorByID(ctx, id)
+}
+
 // GetDoctors -.
 func (r *DoctorRepo) GetDoctors(ctx context.Context) ([]entity.Doctor, error) {
 	sql, args, err := r.Builder.
-		Select("id", "name", "specialization", "schedule", "created_at", "updated_at").
+		Select("id", "name", "user_id", "holiday_calendar_id", "time_zone", "created_at", "updated_at").
 		From("doctors").
 		ToSql()
 
@@ -82,7 +161,7 @@ func (r *DoctorRepo) GetDoctors(ctx context.Context) ([]entity.Doctor, error) {
 	var doctors []entity.Doctor
 	for rows.Next() {
 		var doctor entity.Doctor
-		err = rows.Scan(&doctor.ID, &doctor.Name, &doctor.Specialization, &doctor.Schedule, &doctor.CreatedAt, &doctor.UpdatedAt)
+		err = rows.Scan(&doctor.ID, &doctor.Name, &doctor.UserID, &doctor.HolidayCalendarID, &doctor.TimeZone, &doctor.CreatedAt, &doctor.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("DoctorRepo - GetDoctors - rows.Scan: %w", err)
 		}
@@ -90,18 +169,72 @@ func (r *DoctorRepo) GetDoctors(ctx context.Context) ([]entity.Doctor, error) {
 		doctors = append(doctors, doctor)
 	}
 
+	err = r.attachSchedules(ctx, doctors)
+	if err != nil {
+		return nil, fmt.Errorf("DoctorRepo - GetDoctors - r.attachSchedules: %w", err)
+	}
+
+	return doctors, nil
+}
+
+// GetDoctorsByLocationID - returns the doctors whose current schedule has them working at the
+// location on at least one day.
+func (r *DoctorRepo) GetDoctorsByLocationID(ctx context.Context, locationID int) ([]entity.Doctor, error) {
+	sql, args, err := r.Builder.
+		Select("id", "name", "user_id", "holiday_calendar_id", "time_zone", "created_at", "updated_at").
+		From("doctors").
+		Where(`id IN (
+			SELECT s.doctor_id FROM doctor_schedules s JOIN doctor_schedule_rules sr ON sr.schedule_id = s.id
+			WHERE sr.location_id = ? AND `+_currentSchedule+`
+		)`, locationID).
+		OrderBy("name", "id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("DoctorRepo - GetDoctorsByLocationID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("DoctorRepo - GetDoctorsByLocationID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var doctors []entity.Doctor
+	for rows.Next() {
+		var doctor entity.Doctor
+		err = rows.Scan(&doctor.ID, &doctor.Name, &doctor.UserID, &doctor.HolidayCalendarID, &doctor.TimeZone, &doctor.CreatedAt, &doctor.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("DoctorRepo - GetDoctorsByLocationID - rows.Scan: %w", err)
+		}
+
+		doctors = append(doctors, doctor)
+	}
+
+	err = r.attachSchedules(ctx, doctors)
+	if err != nil {
+		return nil, fmt.Errorf("DoctorRepo - GetDoctorsByLocationID - r.attachSchedules: %w", err)
+	}
+
 	return doctors, nil
 }
 
-// UpdateDoctor -.
+// UpdateDoctor - updates the doctor and stores its schedule as a new version.
 func (r *DoctorRepo) UpdateDoctor(ctx context.Context, doctor entity.Doctor) error {
 	updateTime := time.Now()
 
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - UpdateDoctor - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	sql, args, err := r.Builder.
 		Update("doctors").
 		Set("name", doctor.Name).
-		Set("specialization", doctor.Specialization).
-		Set("schedule", doctor.Schedule).
+		Set("user_id", doctor.UserID).
+		Set("holiday_calendar_id", doctor.HolidayCalendarID).
+		Set("time_zone", timeZoneOrUTC(doctor.TimeZone)).
 		Set("updated_at", updateTime).
 		Where("id = ?", doctor.ID).
 		ToSql()
@@ -110,9 +243,28 @@ func (r *DoctorRepo) UpdateDoctor(ctx context.Context, doctor entity.Doctor) err
 		return fmt.Errorf("DoctorRepo - UpdateDoctor - r.Builder: %w", err)
 	}
 
-	_, err = r.Pool.Exec(ctx, sql, args...)
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if err := doctorAccountError(err); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("DoctorRepo - UpdateDoctor - tx.Exec: %w", err)
+	}
+
+	err = r.insertSchedule(ctx, tx, doctor.ID, doctor.Schedule)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - UpdateDoctor - r.insertSchedule: %w", err)
+	}
+
+	err = r.setSpecializations(ctx, tx, doctor.ID, doctor.Specializations)
 	if err != nil {
-		return fmt.Errorf("DoctorRepo - UpdateDoctor - r.Pool.Exec: %w", err)
+		return fmt.Errorf("DoctorRepo - UpdateDoctor - r.setSpecializations: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - UpdateDoctor - tx.Commit: %w", err)
 	}
 
 	return nil
@@ -137,12 +289,16 @@ func (r *DoctorRepo) DeleteDoctor(ctx context.Context, id int) error {
 	return nil
 }
 
-// GetDoctorBySpecialization -.
-func (r *DoctorRepo) GetDoctorBySpecialization(ctx context.Context, specialization string) ([]entity.Doctor, error) {
+// GetDoctorBySpecialization - returns the doctors holding the specialization with the code.
+func (r *DoctorRepo) GetDoctorBySpecialization(ctx context.Context, code string) ([]entity.Doctor, error) {
 	sql, args, err := r.Builder.
-		Select("id", "name", "specialization", "schedule", "created_at", "updated_at").
+		Select("id", "name", "user_id", "holiday_calendar_id", "time_zone", "created_at", "updated_at").
 		From("doctors").
-		Where("specialization = ?", specialization).
+		Where(`id IN (
+			SELECT ds.doctor_id FROM doctor_specializations ds JOIN specializations s ON s.id = ds.specialization_id
+			WHERE s.code = ?
+		)`, code).
+		OrderBy("name", "id").
 		ToSql()
 
 	if err != nil {
@@ -158,7 +314,7 @@ func (r *DoctorRepo) GetDoctorBySpecialization(ctx context.Context, specializati
 	var doctors []entity.Doctor
 	for rows.Next() {
 		var doctor entity.Doctor
-		err = rows.Scan(&doctor.ID, &doctor.Name, &doctor.Specialization, &doctor.Schedule, &doctor.CreatedAt, &doctor.UpdatedAt)
+		err = rows.Scan(&doctor.ID, &doctor.Name, &doctor.UserID, &doctor.HolidayCalendarID, &doctor.TimeZone, &doctor.CreatedAt, &doctor.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("DoctorRepo - GetDoctorBySpecialization - rows.Scan: %w", err)
 		}
@@ -166,68 +322,304 @@ func (r *DoctorRepo) GetDoctorBySpecialization(ctx context.Context, specializati
 		doctors = append(doctors, doctor)
 	}
 
+	err = r.attachSchedules(ctx, doctors)
+	if err != nil {
+		return nil, fmt.Errorf("DoctorRepo - GetDoctorBySpecialization - r.attachSchedules: %w", err)
+	}
+
 	return doctors, nil
 }
 
-// ListSpecializations -.
-func (r *DoctorRepo) ListSpecializations(ctx context.Context) ([]string, error) {
+// GetBookedSchedulesByDoctorID - returns the current schedule of the doctor.
+func (r *DoctorRepo) GetBookedSchedulesByDoctorID(ctx context.Context, doctorID int) ([]entity.Schedule, error) {
+	schedules, err := r.currentSchedules(ctx, []int{doctorID})
+	if err != nil {
+		return nil, fmt.Errorf("DoctorRepo - GetBookedSchedulesByDoctorID - r.currentSchedules: %w", err)
+	}
+
+	schedule, ok := schedules[doctorID]
+	if !ok {
+		return nil, nil
+	}
+
+	return []entity.Schedule{schedule}, nil
+}
+
+// doctorAccountError maps a violation of the doctor's link to a user account to its error, or
+// returns nil for any other error.
+func doctorAccountError(err error) error {
+	switch {
+	case isUniqueViolation(err):
+		return entity.ErrDoctorAccountTaken
+	case isForeignKeyViolation(err):
+		return entity.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// insertSchedule stores the schedule as the next version for the doctor.
+func (r *DoctorRepo) insertSchedule(ctx context.Context, q querier, doctorID int, schedule entity.Schedule) error {
 	sql, args, err := r.Builder.
-		Select("specialization").
-		From("doctors").
+		Insert("doctor_schedules").
+		Columns("doctor_id", "version").
+		Select(r.Builder.
+			Select().
+			Column("?::int", doctorID).
+			Column("COALESCE(MAX(version), 0) + 1").
+			From("doctor_schedules").
+			Where("doctor_id = ?", doctorID)).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("r.Builder: %w", err)
+	}
+
+	var scheduleID int
+	err = q.QueryRow(ctx, sql, args...).Scan(&scheduleID)
+	if err != nil {
+		return fmt.Errorf("q.QueryRow: %w", err)
+	}
+
+	for _, rule := range schedule.Rules {
+		weekday, err := entity.ParseWeekday(rule.Weekday)
+		if err != nil {
+			return err
+		}
+
+		sql, args, err := r.Builder.
+			Insert("doctor_schedule_rules").
+			Columns("schedule_id", "weekday", "location_id", "room_id").
+			Values(scheduleID, int(weekday), rule.LocationID, rule.RoomID).
+			Suffix("RETURNING id").
+			ToSql()
+
+		if err != nil {
+			return fmt.Errorf("r.Builder: %w", err)
+		}
+
+		var ruleID int
+		err = q.QueryRow(ctx, sql, args...).Scan(&ruleID)
+		if err != nil {
+			return fmt.Errorf("q.QueryRow: %w", err)
+		}
+
+		insert := r.Builder.
+			Insert("doctor_schedule_ranges").
+			Columns("rule_id", "kind", "start_time", "end_time")
+
+		for _, rng := range rule.Ranges {
+			insert = insert.Values(ruleID, _rangeWork, rng.Start, rng.End)
+		}
+
+		for _, rng := range rule.Breaks {
+			insert = insert.Values(ruleID, _rangeBreak, rng.Start, rng.End)
+		}
+
+		sql, args, err = insert.ToSql()
+		if err != nil {
+			return fmt.Errorf("r.Builder: %w", err)
+		}
+
+		_, err = q.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("q.Exec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// attachSchedules loads the current schedule and the specializations of every doctor in place.
+func (r *DoctorRepo) attachSchedules(ctx context.Context, doctors []entity.Doctor) error {
+	ids := make([]int, 0, len(doctors))
+	for _, doctor := range doctors {
+		ids = append(ids, doctor.ID)
+	}
+
+	schedules, err := r.currentSchedules(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	specializations, err := r.doctorSpecializations(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range doctors {
+		doctors[i].Schedule = schedules[doctors[i].ID]
+		doctors[i].Specializations = specializations[doctors[i].ID]
+	}
+
+	return nil
+}
+
+// doctorSpecializations returns the specializations of each doctor, the main one first, keyed by
+// doctor id. Every doctor asked for has an entry, empty if they hold none.
+func (r *DoctorRepo) doctorSpecializations(ctx context.Context, doctorIDs []int) (map[int][]entity.Specialization, error) {
+	specializations := make(map[int][]entity.Specialization, len(doctorIDs))
+	for _, id := range doctorIDs {
+		specializations[id] = []entity.Specialization{}
+	}
+
+	if len(doctorIDs) == 0 {
+		return specializations, nil
+	}
+
+	sql, args, err := r.Builder.
+		Select(append([]string{"ds.doctor_id"}, _specializationColumns...)...).
+		From("doctor_specializations ds").
+		Join("specializations s ON s.id = ds.specialization_id").
+		Where("ds.doctor_id = ANY(?)", doctorIDs).
+		OrderBy("ds.doctor_id", "ds.position", "s.code").
 		ToSql()
 
 	if err != nil {
-		return nil, fmt.Errorf("DoctorRepo - ListSpecializations - r.Builder: %w", err)
+		return nil, fmt.Errorf("DoctorRepo - doctorSpecializations - r.Builder: %w", err)
 	}
 
 	rows, err := r.Pool.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("DoctorRepo - ListSpecializations - r.Pool.Query: %w", err)
+		return nil, fmt.Errorf("DoctorRepo - doctorSpecializations - r.Pool.Query: %w", err)
 	}
 	defer rows.Close()
 
-	var specializations []string
 	for rows.Next() {
-		var specialization string
-		err = rows.Scan(&specialization)
+		var (
+			doctorID       int
+			specialization entity.Specialization
+		)
+
+		err = rows.Scan(&doctorID, &specialization.ID, &specialization.Code, &specialization.Names, &specialization.Description,
+			&specialization.DefaultDuration, &specialization.CreatedAt, &specialization.UpdatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("DoctorRepo - ListSpecializations - rows.Scan: %w", err)
+			return nil, fmt.Errorf("DoctorRepo - doctorSpecializations - rows.Scan: %w", err)
 		}
 
-		specializations = append(specializations, specialization)
+		specializations[doctorID] = append(specializations[doctorID], specialization)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("DoctorRepo - doctorSpecializations - rows.Err: %w", err)
 	}
 
 	return specializations, nil
 }
 
-// GetBookedSchedulesByDoctorID -.
-func (r *DoctorRepo) GetBookedSchedulesByDoctorID(ctx context.Context, doctorID int) ([]entity.Schedule, error) {
+// setSpecializations replaces the doctor's specializations, keeping their order.
+func (r *DoctorRepo) setSpecializations(ctx context.Context, q querier, doctorID int, specializations []entity.Specialization) error {
 	sql, args, err := r.Builder.
-		Select("schedule").
-		From("doctors").
-		Where("id = ?", doctorID).
+		Delete("doctor_specializations").
+		Where("doctor_id = ?", doctorID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - setSpecializations - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - setSpecializations - q.Exec: %w", err)
+	}
+
+	if len(specializations) == 0 {
+		return nil
+	}
+
+	insert := r.Builder.
+		Insert("doctor_specializations").
+		Columns("doctor_id", "specialization_id", "position")
+
+	for i, specialization := range specializations {
+		insert = insert.Values(doctorID, specialization.ID, i)
+	}
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - setSpecializations - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("DoctorRepo - setSpecializations - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// currentSchedules returns the latest schedule version of each doctor, keyed by doctor id.
+func (r *DoctorRepo) currentSchedules(ctx context.Context, doctorIDs []int) (map[int]entity.Schedule, error) {
+	schedules := make(map[int]entity.Schedule, len(doctorIDs))
+	if len(doctorIDs) == 0 {
+		return schedules, nil
+	}
+
+	sql, args, err := r.Builder.
+		Select("s.doctor_id", "s.version", "sr.weekday", "sr.location_id", "sr.room_id", "rg.kind",
+			"to_char(rg.start_time, 'HH24:MI')", "to_char(rg.end_time, 'HH24:MI')").
+		From("doctor_schedules s").
+		LeftJoin("doctor_schedule_rules sr ON sr.schedule_id = s.id").
+		LeftJoin("doctor_schedule_ranges rg ON rg.rule_id = sr.id").
+		Where("s.doctor_id = ANY(?)", doctorIDs).
+		Where(_currentSchedule).
+		OrderBy("s.doctor_id", "sr.weekday", "rg.start_time").
 		ToSql()
 
 	if err != nil {
-		return nil, fmt.Errorf("DoctorRepo - GetBookedSchedulesByDoctorID - r.Builder: %w", err)
+		return nil, fmt.Errorf("DoctorRepo - currentSchedules - r.Builder: %w", err)
 	}
 
 	rows, err := r.Pool.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("DoctorRepo - GetBookedSchedulesByDoctorID - r.Pool.Query: %w", err)
+		return nil, fmt.Errorf("DoctorRepo - currentSchedules - r.Pool.Query: %w", err)
 	}
 	defer rows.Close()
 
-	var schedules []entity.Schedule
 	for rows.Next() {
-		var schedule entity.Schedule
-		err = rows.Scan(&schedule)
+		var (
+			doctorID, version           int
+			weekday, locationID, roomID *int
+			kind, start, end            *string
+		)
+
+		err = rows.Scan(&doctorID, &version, &weekday, &locationID, &roomID, &kind, &start, &end)
 		if err != nil {
-			return nil, fmt.Errorf("DoctorRepo - GetBookedSchedulesByDoctorID - rows.Scan: %w", err)
+			return nil, fmt.Errorf("DoctorRepo - currentSchedules - rows.Scan: %w", err)
 		}
 
-		schedules = append(schedules, schedule)
+		schedule := schedules[doctorID]
+		schedule.Version = version
+
+		if weekday != nil {
+			name := entity.WeekdayName(time.Weekday(*weekday))
+			if len(schedule.Rules) == 0 || schedule.Rules[len(schedule.Rules)-1].Weekday != name {
+				schedule.Rules = append(schedule.Rules, entity.ScheduleRule{Weekday: name, LocationID: locationID, RoomID: roomID})
+			}
+
+			if kind != nil {
+				rule := &schedule.Rules[len(schedule.Rules)-1]
+				rng := entity.TimeRange{Start: *start, End: *end}
+
+				if *kind == _rangeBreak {
+					rule.Breaks = append(rule.Breaks, rng)
+				} else {
+					rule.Ranges = append(rule.Ranges, rng)
+				}
+			}
+		}
+
+		schedules[doctorID] = schedule
 	}
 
 	return schedules, nil
 }
+
+func timeZoneOrUTC(name string) string {
+	if name == "" {
+		return "UTC"
+	}
+
+	return name
+}
@@ -0,0 +1,42 @@
+package persistent
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATEs postgres raises when a constraint is violated.
+const (
+	_exclusionViolation  = "23P01"
+	_foreignKeyViolation = "23503"
+	_uniqueViolation     = "23505"
+)
+
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == _exclusionViolation
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == _foreignKeyViolation
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == _uniqueViolation
+}
+
+// violatedConstraint returns the name of the constraint err violated, if any.
+func violatedConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+
+	return ""
+}
@@ -0,0 +1,232 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// IdentityRepo - accounts at an OpenID Connect provider and the sign-ins in flight to it.
+type IdentityRepo struct {
+	*postgres.Postgres
+}
+
+// NewIdentity -.
+func NewIdentity(pg *postgres.Postgres) *IdentityRepo {
+	return &IdentityRepo{pg}
+}
+
+// CreateOIDCLogin - stores a sign-in sent to the provider, clearing out ones that have expired.
+func (r *IdentityRepo) CreateOIDCLogin(ctx context.Context, login entity.OIDCLogin, now time.Time) error {
+	sql, args, err := r.Builder.
+		Delete("oidc_logins").
+		Where("expires_at <= ?", now).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - CreateOIDCLogin - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - CreateOIDCLogin - r.Pool.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Insert("oidc_logins").
+		Columns("state_hash", "nonce", "code_verifier", "expires_at").
+		Values(login.StateHash, login.Nonce, login.CodeVerifier, login.ExpiresAt).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - CreateOIDCLogin - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - CreateOIDCLogin - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// UseOIDCLogin - removes the sign-in with the state and returns it, or entity.ErrInvalidSSOState
+// if there is none or it has expired.
+func (r *IdentityRepo) UseOIDCLogin(ctx context.Context, stateHash string, now time.Time) (entity.OIDCLogin, error) {
+	sql, args, err := r.Builder.
+		Delete("oidc_logins").
+		Where("state_hash = ?", stateHash).
+		Suffix("RETURNING nonce, code_verifier, expires_at").
+		ToSql()
+
+	if err != nil {
+		return entity.OIDCLogin{}, fmt.Errorf("IdentityRepo - UseOIDCLogin - r.Builder: %w", err)
+	}
+
+	login := entity.OIDCLogin{StateHash: stateHash}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&login.Nonce, &login.CodeVerifier, &login.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.OIDCLogin{}, entity.ErrInvalidSSOState
+		}
+
+		return entity.OIDCLogin{}, fmt.Errorf("IdentityRepo - UseOIDCLogin - r.Pool.QueryRow: %w", err)
+	}
+
+	if !login.ExpiresAt.After(now) {
+		return entity.OIDCLogin{}, entity.ErrInvalidSSOState
+	}
+
+	return login, nil
+}
+
+// GetIdentityUser - returns the user the provider account is linked to, and the link, and records
+// the sign-in. It returns entity.ErrUserNotFound if the account isn't linked.
+func (r *IdentityRepo) GetIdentityUser(ctx context.Context, identity entity.Identity, now time.Time) (entity.User, entity.Identity, error) {
+	sql, args, err := r.Builder.
+		Update("user_identities").
+		Set("email", identity.Email).
+		Set("last_login_at", now).
+		From("users").
+		Where("users.id = user_identities.user_id AND issuer = ? AND subject = ?", identity.Issuer, identity.Subject).
+		Suffix("RETURNING user_identities.role_from_groups, user_identities.created_at, " +
+			"users.id, users.fullname, users.email, COALESCE(users.phone, ''), users.role, users.created_at, users.updated_at, users.email_verified_at, users.deactivated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.User{}, entity.Identity{}, fmt.Errorf("IdentityRepo - GetIdentityUser - r.Builder: %w", err)
+	}
+
+	var user entity.User
+
+	err = r.Pool.QueryRow(ctx, sql, args...).
+		Scan(&identity.RoleFromGroups, &identity.CreatedAt,
+			&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt, &user.DeactivatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, entity.Identity{}, entity.ErrUserNotFound
+		}
+
+		return entity.User{}, entity.Identity{}, fmt.Errorf("IdentityRepo - GetIdentityUser - r.Pool.QueryRow: %w", err)
+	}
+
+	identity.UserID = user.ID
+
+	return user, identity, nil
+}
+
+// AssignIdentityRole - gives the user the provider account is linked to the role, as long as their
+// role still comes from their provider groups. It reports whether the role was assigned and returns
+// entity.ErrUnknownRole if the role doesn't exist.
+func (r *IdentityRepo) AssignIdentityRole(ctx context.Context, identity entity.Identity, role entity.Role) (bool, error) {
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("role", role).
+		Where("id IN (SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ? AND role_from_groups)", identity.Issuer, identity.Subject).
+		ToSql()
+
+	if err != nil {
+		return false, fmt.Errorf("IdentityRepo - AssignIdentityRole - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return false, entity.ErrUnknownRole
+		}
+
+		return false, fmt.Errorf("IdentityRepo - AssignIdentityRole - r.Pool.Exec: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// LinkIdentity - links the provider account to the existing user.
+func (r *IdentityRepo) LinkIdentity(ctx context.Context, identity entity.Identity) error {
+	err := r.createIdentity(ctx, r.Pool, identity)
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - LinkIdentity - r.createIdentity: %w", err)
+	}
+
+	return nil
+}
+
+// CreateIdentityUser - creates a user for the provider account, with the patient they are, and
+// links the two. The user has no password, so they can only sign in through the provider, and
+// their email counts as verified. It returns entity.ErrIdentityConflict if a user with the email exists.
+func (r *IdentityRepo) CreateIdentityUser(ctx context.Context, user entity.User, identity entity.Identity, now time.Time) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("IdentityRepo - CreateIdentityUser - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Insert("users").
+		Columns("fullname", "email", "phone", "password_hash", "role", "email_verified_at").
+		Values(user.FullName, user.Email, user.Phone, "", user.Role, now).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("IdentityRepo - CreateIdentityUser - r.Builder: %w", err)
+	}
+
+	var id int
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return 0, entity.ErrIdentityConflict
+		case isForeignKeyViolation(err):
+			return 0, entity.ErrUnknownRole
+		}
+
+		return 0, fmt.Errorf("IdentityRepo - CreateIdentityUser - tx.QueryRow: %w", err)
+	}
+
+	identity.UserID = id
+
+	err = r.createIdentity(ctx, tx, identity)
+	if err != nil {
+		return 0, fmt.Errorf("IdentityRepo - CreateIdentityUser - r.createIdentity: %w", err)
+	}
+
+	err = createSelfPatient(ctx, r.Builder, tx, id, user.FullName)
+	if err != nil {
+		return 0, fmt.Errorf("IdentityRepo - CreateIdentityUser - createSelfPatient: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("IdentityRepo - CreateIdentityUser - tx.Commit: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *IdentityRepo) createIdentity(ctx context.Context, q querier, identity entity.Identity) error {
+	sql, args, err := r.Builder.
+		Insert("user_identities").
+		Columns("issuer", "subject", "user_id", "email", "role_from_groups").
+		Values(identity.Issuer, identity.Subject, identity.UserID, identity.Email, identity.RoleFromGroups).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - createIdentity - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("IdentityRepo - createIdentity - q.Exec: %w", err)
+	}
+
+	return nil
+}
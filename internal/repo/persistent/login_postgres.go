@@ -0,0 +1,157 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// LoginRepo - failed sign-ins per account and client IP.
+type LoginRepo struct {
+	*postgres.Postgres
+}
+
+// NewLogin -.
+func NewLogin(pg *postgres.Postgres) *LoginRepo {
+	return &LoginRepo{pg}
+}
+
+// GetLoginThrottles - returns the throttles stored for the keys. Keys without failures are left out.
+func (r *LoginRepo) GetLoginThrottles(ctx context.Context, keys ...entity.LoginKey) ([]entity.LoginThrottle, error) {
+	or := squirrel.Or{}
+	for _, key := range keys {
+		or = append(or, squirrel.Eq{"kind": key.Kind, "subject": key.Subject})
+	}
+
+	sql, args, err := r.Builder.
+		Select("kind", "subject", "failures", "last_failed_at", "locked_until").
+		From("login_throttles").
+		Where(or).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("LoginRepo - GetLoginThrottles - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("LoginRepo - GetLoginThrottles - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var throttles []entity.LoginThrottle
+	for rows.Next() {
+		var t entity.LoginThrottle
+		err = rows.Scan(&t.Key.Kind, &t.Key.Subject, &t.Failures, &t.LastFailedAt, &t.LockedUntil)
+		if err != nil {
+			return nil, fmt.Errorf("LoginRepo - GetLoginThrottles - rows.Scan: %w", err)
+		}
+
+		throttles = append(throttles, t)
+	}
+
+	return throttles, nil
+}
+
+// RecordLoginFailure - counts a failed sign-in at now against the key under the policy and
+// returns the throttle before and after it.
+func (r *LoginRepo) RecordLoginFailure(ctx context.Context, key entity.LoginKey, now time.Time, policy entity.LoginPolicy) (entity.LoginThrottle, entity.LoginThrottle, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Make sure there is a row to lock, so concurrent failures are counted one after another.
+	sql, args, err := r.Builder.
+		Insert("login_throttles").
+		Columns("kind", "subject", "failures", "last_failed_at").
+		Values(key.Kind, key.Subject, 0, now).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - tx.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Select("failures", "last_failed_at", "locked_until").
+		From("login_throttles").
+		Where(squirrel.Eq{"kind": key.Kind, "subject": key.Subject}).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - r.Builder: %w", err)
+	}
+
+	before := entity.LoginThrottle{Key: key}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&before.Failures, &before.LastFailedAt, &before.LockedUntil)
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - tx.QueryRow: %w", err)
+	}
+
+	after := before.Fail(now, policy)
+
+	sql, args, err = r.Builder.
+		Update("login_throttles").
+		Set("failures", after.Failures).
+		Set("last_failed_at", after.LastFailedAt).
+		Set("locked_until", after.LockedUntil).
+		Where(squirrel.Eq{"kind": key.Kind, "subject": key.Subject}).
+		ToSql()
+
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.LoginThrottle{}, entity.LoginThrottle{}, fmt.Errorf("LoginRepo - RecordLoginFailure - tx.Commit: %w", err)
+	}
+
+	return before, after, nil
+}
+
+// ClearLoginFailures - forgets the failed sign-ins of the key and returns whether it was locked at now.
+func (r *LoginRepo) ClearLoginFailures(ctx context.Context, key entity.LoginKey, now time.Time) (bool, error) {
+	sql, args, err := r.Builder.
+		Delete("login_throttles").
+		Where(squirrel.Eq{"kind": key.Kind, "subject": key.Subject}).
+		Suffix("RETURNING locked_until").
+		ToSql()
+
+	if err != nil {
+		return false, fmt.Errorf("LoginRepo - ClearLoginFailures - r.Builder: %w", err)
+	}
+
+	var lockedUntil *time.Time
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&lockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("LoginRepo - ClearLoginFailures - r.Pool.QueryRow: %w", err)
+	}
+
+	return lockedUntil != nil && now.Before(*lockedUntil), nil
+}
@@ -0,0 +1,346 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _patientColumns are scanned by scanPatient.
+var _patientColumns = []string{
+	"p.id", "p.full_name", "p.date_of_birth", "COALESCE(p.sex, '')", "p.emergency_contact_name",
+	"p.emergency_contact_phone", "p.preferred_language", "p.created_at", "p.updated_at",
+}
+
+// PatientRepo -.
+type PatientRepo struct {
+	*postgres.Postgres
+}
+
+// NewPatient -.
+func NewPatient(pg *postgres.Postgres) *PatientRepo {
+	return &PatientRepo{pg}
+}
+
+// CreatePatient - stores the patient and links the users to them. It returns entity.ErrUserNotFound
+// if one of the users doesn't exist and entity.ErrPatientLinkExists if one of them is linked as
+// their own patient already.
+func (r *PatientRepo) CreatePatient(ctx context.Context, patient entity.Patient, links []entity.PatientLink) (entity.Patient, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - CreatePatient - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Insert("patients").
+		Columns("full_name", "date_of_birth", "sex", "emergency_contact_name", "emergency_contact_phone", "preferred_language").
+		Values(patient.FullName, patient.DateOfBirth, nullableString(patient.Sex), patient.EmergencyContactName,
+			patient.EmergencyContactPhone, patient.PreferredLanguage).
+		Suffix("RETURNING id, created_at, updated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - CreatePatient - r.Builder: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&patient.ID, &patient.CreatedAt, &patient.UpdatedAt)
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - CreatePatient - tx.QueryRow: %w", err)
+	}
+
+	for _, link := range links {
+		link.PatientID = patient.ID
+
+		err = r.linkPatientUser(ctx, tx, link)
+		if err != nil {
+			return entity.Patient{}, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - CreatePatient - tx.Commit: %w", err)
+	}
+
+	return patient, nil
+}
+
+// GetPatient - returns entity.ErrPatientNotFound if there is no such patient.
+func (r *PatientRepo) GetPatient(ctx context.Context, id int) (entity.Patient, error) {
+	sql, args, err := r.Builder.
+		Select(_patientColumns...).
+		From("patients p").
+		Where("p.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - GetPatient - r.Builder: %w", err)
+	}
+
+	patient, err := scanPatient(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Patient{}, entity.ErrPatientNotFound
+		}
+
+		return entity.Patient{}, fmt.Errorf("PatientRepo - GetPatient - r.Pool.QueryRow: %w", err)
+	}
+
+	return patient, nil
+}
+
+// UpdatePatient - replaces the patient's details and returns the stored patient. It returns
+// entity.ErrPatientNotFound if there is no such patient.
+func (r *PatientRepo) UpdatePatient(ctx context.Context, patient entity.Patient) (entity.Patient, error) {
+	sql, args, err := r.Builder.
+		Update("patients p").
+		Set("full_name", patient.FullName).
+		Set("date_of_birth", patient.DateOfBirth).
+		Set("sex", nullableString(patient.Sex)).
+		Set("emergency_contact_name", patient.EmergencyContactName).
+		Set("emergency_contact_phone", patient.EmergencyContactPhone).
+		Set("preferred_language", patient.PreferredLanguage).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where("p.id = ?", patient.ID).
+		Suffix("RETURNING " + strings.Join(_patientColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - UpdatePatient - r.Builder: %w", err)
+	}
+
+	updated, err := scanPatient(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Patient{}, entity.ErrPatientNotFound
+		}
+
+		return entity.Patient{}, fmt.Errorf("PatientRepo - UpdatePatient - r.Pool.QueryRow: %w", err)
+	}
+
+	return updated, nil
+}
+
+// GetSelfPatient - returns the patient the user is themselves, or entity.ErrPatientNotFound.
+func (r *PatientRepo) GetSelfPatient(ctx context.Context, userID int) (entity.Patient, error) {
+	sql, args, err := r.Builder.
+		Select(_patientColumns...).
+		From("patients p").
+		Join("patient_users pu ON pu.patient_id = p.id").
+		Where("pu.user_id = ? AND pu.relationship = ?", userID, entity.RelationshipSelf).
+		ToSql()
+
+	if err != nil {
+		return entity.Patient{}, fmt.Errorf("PatientRepo - GetSelfPatient - r.Builder: %w", err)
+	}
+
+	patient, err := scanPatient(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Patient{}, entity.ErrPatientNotFound
+		}
+
+		return entity.Patient{}, fmt.Errorf("PatientRepo - GetSelfPatient - r.Pool.QueryRow: %w", err)
+	}
+
+	return patient, nil
+}
+
+// GetUserPatients - returns the patients the user is linked to, themselves first.
+func (r *PatientRepo) GetUserPatients(ctx context.Context, userID int) ([]entity.LinkedPatient, error) {
+	sql, args, err := r.Builder.
+		Select(_patientColumns...).
+		Column("pu.relationship").
+		From("patients p").
+		Join("patient_users pu ON pu.patient_id = p.id").
+		Where("pu.user_id = ?", userID).
+		OrderByClause("pu.relationship <> ?", entity.RelationshipSelf).
+		OrderBy("p.full_name", "p.id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("PatientRepo - GetUserPatients - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("PatientRepo - GetUserPatients - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	patients := make([]entity.LinkedPatient, 0, _defaultEntityCap)
+	for rows.Next() {
+		var patient entity.LinkedPatient
+
+		err = rows.Scan(&patient.ID, &patient.FullName, &patient.DateOfBirth, &patient.Sex, &patient.EmergencyContactName,
+			&patient.EmergencyContactPhone, &patient.PreferredLanguage, &patient.CreatedAt, &patient.UpdatedAt, &patient.Relationship)
+		if err != nil {
+			return nil, fmt.Errorf("PatientRepo - GetUserPatients - rows.Scan: %w", err)
+		}
+
+		patients = append(patients, patient)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("PatientRepo - GetUserPatients - rows.Err: %w", err)
+	}
+
+	return patients, nil
+}
+
+// GetPatientLinks - returns the users linked to the patient, their own account first.
+func (r *PatientRepo) GetPatientLinks(ctx context.Context, patientID int) ([]entity.PatientLink, error) {
+	sql, args, err := r.Builder.
+		Select("patient_id", "user_id", "relationship", "created_at").
+		From("patient_users").
+		Where("patient_id = ?", patientID).
+		OrderBy("relationship <> 'self'", "user_id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("PatientRepo - GetPatientLinks - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("PatientRepo - GetPatientLinks - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	links := make([]entity.PatientLink, 0, _defaultEntityCap)
+	for rows.Next() {
+		var link entity.PatientLink
+
+		err = rows.Scan(&link.PatientID, &link.UserID, &link.Relationship, &link.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("PatientRepo - GetPatientLinks - rows.Scan: %w", err)
+		}
+
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("PatientRepo - GetPatientLinks - rows.Err: %w", err)
+	}
+
+	return links, nil
+}
+
+// LinkPatientUser - lets the user act for the patient. It returns entity.ErrUserNotFound if either
+// of them doesn't exist and entity.ErrPatientLinkExists if the user is linked to the patient
+// already, or the link is 'self' and the user or patient has one.
+func (r *PatientRepo) LinkPatientUser(ctx context.Context, link entity.PatientLink) error {
+	return r.linkPatientUser(ctx, r.Pool, link)
+}
+
+func (r *PatientRepo) linkPatientUser(ctx context.Context, q querier, link entity.PatientLink) error {
+	sql, args, err := r.Builder.
+		Insert("patient_users").
+		Columns("patient_id", "user_id", "relationship").
+		Values(link.PatientID, link.UserID, link.Relationship).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("PatientRepo - linkPatientUser - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return entity.ErrPatientLinkExists
+		case isForeignKeyViolation(err):
+			return entity.ErrUserNotFound
+		}
+
+		return fmt.Errorf("PatientRepo - linkPatientUser - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// UnlinkPatientUser - returns entity.ErrPatientLinkNotFound if the user isn't linked to the patient.
+func (r *PatientRepo) UnlinkPatientUser(ctx context.Context, patientID, userID int) error {
+	sql, args, err := r.Builder.
+		Delete("patient_users").
+		Where("patient_id = ? AND user_id = ?", patientID, userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("PatientRepo - UnlinkPatientUser - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PatientRepo - UnlinkPatientUser - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrPatientLinkNotFound
+	}
+
+	return nil
+}
+
+// createSelfPatient creates the patient a new user is themselves.
+func createSelfPatient(ctx context.Context, builder squirrel.StatementBuilderType, q querier, userID int, fullName string) error {
+	sql, args, err := builder.
+		Insert("patients").
+		Columns("full_name").
+		Values(fullName).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("createSelfPatient - builder: %w", err)
+	}
+
+	var patientID int
+
+	err = q.QueryRow(ctx, sql, args...).Scan(&patientID)
+	if err != nil {
+		return fmt.Errorf("createSelfPatient - q.QueryRow: %w", err)
+	}
+
+	sql, args, err = builder.
+		Insert("patient_users").
+		Columns("patient_id", "user_id", "relationship").
+		Values(patientID, userID, entity.RelationshipSelf).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("createSelfPatient - builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("createSelfPatient - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// nullableString stores an empty string as NULL.
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func scanPatient(row pgx.Row) (entity.Patient, error) {
+	var patient entity.Patient
+
+	err := row.Scan(&patient.ID, &patient.FullName, &patient.DateOfBirth, &patient.Sex, &patient.EmergencyContactName,
+		&patient.EmergencyContactPhone, &patient.PreferredLanguage, &patient.CreatedAt, &patient.UpdatedAt)
+
+	return patient, err
+}
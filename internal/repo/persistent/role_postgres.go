@@ -0,0 +1,379 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _rolePermissions aggregates the permissions of roles.name, sorted, as a text array.
+const _rolePermissions = "ARRAY(SELECT permission FROM role_permissions WHERE role_permissions.role = roles.name ORDER BY permission) AS permissions"
+
+// RoleRepo - roles, permissions and the grants between them.
+type RoleRepo struct {
+	*postgres.Postgres
+}
+
+// NewRole -.
+func NewRole(pg *postgres.Postgres) *RoleRepo {
+	return &RoleRepo{pg}
+}
+
+// ListRoles -.
+func (r *RoleRepo) ListRoles(ctx context.Context) ([]entity.RoleDefinition, error) {
+	sql, args, err := r.Builder.
+		Select("name", "description", "require_two_factor", "created_at", _rolePermissions).
+		From("roles").
+		OrderBy("name").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("RoleRepo - ListRoles - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("RoleRepo - ListRoles - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []entity.RoleDefinition
+	for rows.Next() {
+		var role entity.RoleDefinition
+		err = rows.Scan(&role.Name, &role.Description, &role.RequireTwoFactor, &role.CreatedAt, &role.Permissions)
+		if err != nil {
+			return nil, fmt.Errorf("RoleRepo - ListRoles - rows.Scan: %w", err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// GetRole - returns entity.ErrUnknownRole if there is no such role.
+func (r *RoleRepo) GetRole(ctx context.Context, name entity.Role) (entity.RoleDefinition, error) {
+	sql, args, err := r.Builder.
+		Select("name", "description", "require_two_factor", "created_at", _rolePermissions).
+		From("roles").
+		Where("name = ?", name).
+		ToSql()
+
+	if err != nil {
+		return entity.RoleDefinition{}, fmt.Errorf("RoleRepo - GetRole - r.Builder: %w", err)
+	}
+
+	var role entity.RoleDefinition
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&role.Name, &role.Description, &role.RequireTwoFactor, &role.CreatedAt, &role.Permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.RoleDefinition{}, entity.ErrUnknownRole
+		}
+
+		return entity.RoleDefinition{}, fmt.Errorf("RoleRepo - GetRole - r.Pool.QueryRow: %w", err)
+	}
+
+	return role, nil
+}
+
+// CreateRole - stores the role with its permissions.
+func (r *RoleRepo) CreateRole(ctx context.Context, role entity.RoleDefinition) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - CreateRole - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Insert("roles").
+		Columns("name", "description", "require_two_factor").
+		Values(role.Name, role.Description, role.RequireTwoFactor).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - CreateRole - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.ErrRoleExists
+		}
+
+		return fmt.Errorf("RoleRepo - CreateRole - tx.Exec: %w", err)
+	}
+
+	err = r.grantPermissions(ctx, tx, role)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - CreateRole - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRole - replaces the description and permissions of the role.
+func (r *RoleRepo) UpdateRole(ctx context.Context, role entity.RoleDefinition) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - UpdateRole - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Update("roles").
+		Set("description", role.Description).
+		Where("name = ?", role.Name).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - UpdateRole - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - UpdateRole - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrUnknownRole
+	}
+
+	sql, args, err = r.Builder.
+		Delete("role_permissions").
+		Where("role = ?", role.Name).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - UpdateRole - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - UpdateRole - tx.Exec: %w", err)
+	}
+
+	err = r.grantPermissions(ctx, tx, role)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - UpdateRole - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// SetRoleTwoFactor - sets whether users with the role need TOTP to sign in.
+func (r *RoleRepo) SetRoleTwoFactor(ctx context.Context, name entity.Role, required bool) error {
+	sql, args, err := r.Builder.
+		Update("roles").
+		Set("require_two_factor", required).
+		Where("name = ?", name).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - SetRoleTwoFactor - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - SetRoleTwoFactor - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrUnknownRole
+	}
+
+	return nil
+}
+
+func (r *RoleRepo) grantPermissions(ctx context.Context, q querier, role entity.RoleDefinition) error {
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+
+	builder := r.Builder.
+		Insert("role_permissions").
+		Columns("role", "permission")
+
+	for _, permission := range role.Permissions {
+		builder = builder.Values(role.Name, permission)
+	}
+
+	sql, args, err := builder.Suffix("ON CONFLICT DO NOTHING").ToSql()
+	if err != nil {
+		return fmt.Errorf("RoleRepo - grantPermissions - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.ErrUnknownPermission
+		}
+
+		return fmt.Errorf("RoleRepo - grantPermissions - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRole - returns entity.ErrRoleInUse while users still have the role.
+func (r *RoleRepo) DeleteRole(ctx context.Context, name entity.Role) error {
+	sql, args, err := r.Builder.
+		Delete("roles").
+		Where("name = ?", name).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - DeleteRole - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.ErrRoleInUse
+		}
+
+		return fmt.Errorf("RoleRepo - DeleteRole - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrUnknownRole
+	}
+
+	return nil
+}
+
+// ListPermissions -.
+func (r *RoleRepo) ListPermissions(ctx context.Context) ([]entity.PermissionInfo, error) {
+	sql, args, err := r.Builder.
+		Select("name", "description").
+		From("permissions").
+		OrderBy("name").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("RoleRepo - ListPermissions - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("RoleRepo - ListPermissions - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []entity.PermissionInfo
+	for rows.Next() {
+		var permission entity.PermissionInfo
+		err = rows.Scan(&permission.Name, &permission.Description)
+		if err != nil {
+			return nil, fmt.Errorf("RoleRepo - ListPermissions - rows.Scan: %w", err)
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
+
+// AssignRole - returns entity.ErrUnknownRole if the role doesn't exist. The role is then set here,
+// so the user's provider groups no longer change it.
+func (r *RoleRepo) AssignRole(ctx context.Context, userID int, role entity.Role) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - AssignRole - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("role", role).
+		Where("id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - AssignRole - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.ErrUnknownRole
+		}
+
+		return fmt.Errorf("RoleRepo - AssignRole - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("RoleRepo - AssignRole - tx.Exec: %w", pgx.ErrNoRows)
+	}
+
+	sql, args, err = r.Builder.
+		Update("user_identities").
+		Set("role_from_groups", false).
+		Where("user_id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("RoleRepo - AssignRole - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - AssignRole - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("RoleRepo - AssignRole - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserAccess - returns the user's current role and the permissions it grants. It returns
+// entity.ErrUserNotFound if there is no such user and entity.ErrUserDeactivated if the user is
+// deactivated.
+func (r *RoleRepo) GetUserAccess(ctx context.Context, userID int) (entity.Role, []entity.Permission, error) {
+	sql, args, err := r.Builder.
+		Select("u.role", "ARRAY(SELECT permission FROM role_permissions rp WHERE rp.role = u.role)", "u.deactivated_at IS NOT NULL").
+		From("users u").
+		Where("u.id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return "", nil, fmt.Errorf("RoleRepo - GetUserAccess - r.Builder: %w", err)
+	}
+
+	var (
+		role        entity.Role
+		permissions []entity.Permission
+		deactivated bool
+	)
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&role, &permissions, &deactivated)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, entity.ErrUserNotFound
+		}
+
+		return "", nil, fmt.Errorf("RoleRepo - GetUserAccess - r.Pool.QueryRow: %w", err)
+	}
+
+	if deactivated {
+		return "", nil, entity.ErrUserDeactivated
+	}
+
+	return role, permissions, nil
+}
@@ -0,0 +1,228 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+)
+
+// ScheduleRepo - schedule exceptions and holiday calendars.
+type ScheduleRepo struct {
+	*postgres.Postgres
+}
+
+// NewSchedule -.
+func NewSchedule(pg *postgres.Postgres) *ScheduleRepo {
+	return &ScheduleRepo{pg}
+}
+
+// CreateScheduleException -.
+func (r *ScheduleRepo) CreateScheduleException(ctx context.Context, exception entity.ScheduleException) (int, error) {
+	sql, args, err := r.Builder.
+		Insert("doctor_schedule_exceptions").
+		Columns("doctor_id", "kind", "starts_at", "ends_at", "reason").
+		Values(exception.DoctorID, exception.Kind, exception.StartsAt, exception.EndsAt, exception.Reason).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ScheduleRepo - CreateScheduleException - r.Builder: %w", err)
+	}
+
+	var id int
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ScheduleRepo - CreateScheduleException - r.Pool.QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetScheduleExceptions - returns the doctor's exceptions overlapping [from, to).
+func (r *ScheduleRepo) GetScheduleExceptions(ctx context.Context, doctorID int, from, to time.Time) ([]entity.ScheduleException, error) {
+	sql, args, err := r.Builder.
+		Select("id", "doctor_id", "kind", "starts_at", "ends_at", "reason", "created_at").
+		From("doctor_schedule_exceptions").
+		Where("doctor_id = ?", doctorID).
+		Where("starts_at < ?", to).
+		Where("ends_at > ?", from).
+		OrderBy("starts_at").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ScheduleRepo - GetScheduleExceptions - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ScheduleRepo - GetScheduleExceptions - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var exceptions []entity.ScheduleException
+	for rows.Next() {
+		var exception entity.ScheduleException
+		err = rows.Scan(&exception.ID, &exception.DoctorID, &exception.Kind, &exception.StartsAt, &exception.EndsAt, &exception.Reason, &exception.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ScheduleRepo - GetScheduleExceptions - rows.Scan: %w", err)
+		}
+
+		exceptions = append(exceptions, exception)
+	}
+
+	return exceptions, nil
+}
+
+// DeleteScheduleException -.
+func (r *ScheduleRepo) DeleteScheduleException(ctx context.Context, doctorID, id int) error {
+	sql, args, err := r.Builder.
+		Delete("doctor_schedule_exceptions").
+		Where("id = ?", id).
+		Where("doctor_id = ?", doctorID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("ScheduleRepo - DeleteScheduleException - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("ScheduleRepo - DeleteScheduleException - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// CreateHolidayCalendar -.
+func (r *ScheduleRepo) CreateHolidayCalendar(ctx context.Context, calendar entity.HolidayCalendar) (int, error) {
+	sql, args, err := r.Builder.
+		Insert("holiday_calendars").
+		Columns("name").
+		Values(calendar.Name).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ScheduleRepo - CreateHolidayCalendar - r.Builder: %w", err)
+	}
+
+	var id int
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ScheduleRepo - CreateHolidayCalendar - r.Pool.QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListHolidayCalendars -.
+func (r *ScheduleRepo) ListHolidayCalendars(ctx context.Context) ([]entity.HolidayCalendar, error) {
+	sql, args, err := r.Builder.
+		Select("id", "name", "created_at").
+		From("holiday_calendars").
+		OrderBy("name").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ScheduleRepo - ListHolidayCalendars - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ScheduleRepo - ListHolidayCalendars - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var calendars []entity.HolidayCalendar
+	for rows.Next() {
+		var calendar entity.HolidayCalendar
+		err = rows.Scan(&calendar.ID, &calendar.Name, &calendar.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ScheduleRepo - ListHolidayCalendars - rows.Scan: %w", err)
+		}
+
+		calendars = append(calendars, calendar)
+	}
+
+	return calendars, nil
+}
+
+// CreateHoliday -.
+func (r *ScheduleRepo) CreateHoliday(ctx context.Context, holiday entity.Holiday) (int, error) {
+	sql, args, err := r.Builder.
+		Insert("holidays").
+		Columns("calendar_id", "date", "name").
+		Values(holiday.CalendarID, holiday.Date.Format(time.DateOnly), holiday.Name).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ScheduleRepo - CreateHoliday - r.Builder: %w", err)
+	}
+
+	var id int
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ScheduleRepo - CreateHoliday - r.Pool.QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetHolidays - returns the calendar's holidays whose day starts before to and ends after from.
+func (r *ScheduleRepo) GetHolidays(ctx context.Context, calendarID int, from, to time.Time) ([]entity.Holiday, error) {
+	sql, args, err := r.Builder.
+		Select("id", "calendar_id", "date", "name").
+		From("holidays").
+		Where("calendar_id = ?", calendarID).
+		Where("date >= ?::date", from.Format(time.DateOnly)).
+		Where("date < ?::timestamp", to.Format(time.DateTime)).
+		OrderBy("date").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ScheduleRepo - GetHolidays - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ScheduleRepo - GetHolidays - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []entity.Holiday
+	for rows.Next() {
+		var holiday entity.Holiday
+		err = rows.Scan(&holiday.ID, &holiday.CalendarID, &holiday.Date, &holiday.Name)
+		if err != nil {
+			return nil, fmt.Errorf("ScheduleRepo - GetHolidays - rows.Scan: %w", err)
+		}
+
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, nil
+}
+
+// DeleteHoliday -.
+func (r *ScheduleRepo) DeleteHoliday(ctx context.Context, calendarID, id int) error {
+	sql, args, err := r.Builder.
+		Delete("holidays").
+		Where("id = ?", id).
+		Where("calendar_id = ?", calendarID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("ScheduleRepo - DeleteHoliday - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("ScheduleRepo - DeleteHoliday - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
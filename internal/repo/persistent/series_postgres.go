@@ -0,0 +1,184 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAppointmentSeries - books the series with the appointment type in one transaction.
+// Occurrences that already carry a Conflict are skipped; the rest are checked for overlaps with the
+// doctor's active appointments and with other doctors' in the occurrence's room.
+// If any occurrence can't be booked and skipConflicts is false, or none can be booked at all,
+// nothing is stored and entity.ErrSeriesConflict is returned with the per-occurrence report.
+func (r *AppointmentRepo) CreateAppointmentSeries(ctx context.Context, series entity.AppointmentSeries, appointmentType entity.AppointmentType, occurrences []entity.SeriesOccurrence, skipConflicts bool) (entity.SeriesBooking, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = r.lockDoctor(ctx, tx, series.DoctorID)
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - r.lockDoctor: %w", err)
+	}
+
+	booking := entity.SeriesBooking{Series: series, Occurrences: occurrences}
+
+	bookable := 0
+	for i := range booking.Occurrences {
+		occurrence := &booking.Occurrences[i]
+		if occurrence.Conflict != nil {
+			continue
+		}
+
+		err = r.checkOverlap(ctx, tx, occurrenceAppointment(series, appointmentType, *occurrence))
+
+		var bookingErr *entity.BookingError
+
+		switch {
+		case errors.Is(err, entity.ErrAppointmentConflict):
+			occurrence.Conflict = entity.ErrSlotTaken
+
+			continue
+		case errors.As(err, &bookingErr):
+			occurrence.Conflict = bookingErr
+
+			continue
+		case err != nil:
+			return entity.SeriesBooking{}, err
+		}
+
+		bookable++
+	}
+
+	if bookable == 0 || (bookable < len(booking.Occurrences) && !skipConflicts) {
+		return booking, entity.ErrSeriesConflict
+	}
+
+	sql, args, err := r.Builder.
+		Insert("appointment_series").
+		Columns("patient_id", "doctor_id", "appointment_type_id", "rule", "starts_at", "duration").
+		Values(series.PatientID, series.DoctorID, series.AppointmentTypeID, series.Rule, series.StartsAt, series.Duration).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - r.Builder: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&booking.Series.ID, &booking.Series.CreatedAt)
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - tx.QueryRow: %w", err)
+	}
+
+	for i := range booking.Occurrences {
+		occurrence := &booking.Occurrences[i]
+		if occurrence.Conflict != nil {
+			continue
+		}
+
+		appointment := occurrenceAppointment(series, appointmentType, *occurrence)
+
+		sql, args, err = r.Builder.
+			Insert("appointments").
+			Columns("patient_id", "doctor_id", "appointment_type_id", "appointment_time", "duration", "buffer_before", "buffer_after", "capacity",
+				"location_id", "room_id", "status", "series_id").
+			Values(appointment.PatientID, appointment.DoctorID, appointment.AppointmentTypeID, appointment.AppointmentTime, appointment.Duration,
+				appointment.BufferBefore, appointment.BufferAfter, appointment.Capacity, appointment.LocationID, appointment.RoomID,
+				entity.StatusScheduled, booking.Series.ID).
+			Suffix("RETURNING id").
+			ToSql()
+
+		if err != nil {
+			return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - r.Builder: %w", err)
+		}
+
+		err = tx.QueryRow(ctx, sql, args...).Scan(&occurrence.AppointmentID)
+		if err != nil {
+			if err := overlapError(err); err != nil {
+				return entity.SeriesBooking{}, err
+			}
+
+			return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - tx.QueryRow: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("AppointmentRepo - CreateAppointmentSeries - tx.Commit: %w", err)
+	}
+
+	return booking, nil
+}
+
+// occurrenceAppointment returns the appointment booked for the occurrence of the series.
+func occurrenceAppointment(series entity.AppointmentSeries, appointmentType entity.AppointmentType, occurrence entity.SeriesOccurrence) entity.Appointment {
+	return appointmentType.Apply(entity.Appointment{
+		PatientID:       series.PatientID,
+		DoctorID:        series.DoctorID,
+		AppointmentTime: occurrence.AppointmentTime,
+		LocationID:      occurrence.LocationID,
+		RoomID:          occurrence.RoomID,
+	})
+}
+
+// GetAppointmentSeries -.
+func (r *AppointmentRepo) GetAppointmentSeries(ctx context.Context, id int) (entity.AppointmentSeries, error) {
+	sql, args, err := r.Builder.
+		Select("id", "patient_id", "doctor_id", "appointment_type_id", "rule", "starts_at", "duration", "created_at").
+		From("appointment_series").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.AppointmentSeries{}, fmt.Errorf("AppointmentRepo - GetAppointmentSeries - r.Builder: %w", err)
+	}
+
+	var series entity.AppointmentSeries
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&series.ID, &series.PatientID, &series.DoctorID, &series.AppointmentTypeID, &series.Rule, &series.StartsAt, &series.Duration, &series.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.AppointmentSeries{}, entity.ErrSeriesNotFound
+		}
+
+		return entity.AppointmentSeries{}, fmt.Errorf("AppointmentRepo - GetAppointmentSeries - r.Pool.QueryRow: %w", err)
+	}
+
+	return series, nil
+}
+
+// GetAppointmentsBySeriesID - returns the occurrences of the series ordered by time.
+func (r *AppointmentRepo) GetAppointmentsBySeriesID(ctx context.Context, seriesID int) ([]entity.Appointment, error) {
+	sql, args, err := r.Builder.
+		Select(_appointmentColumns...).
+		From("appointments").
+		Where("series_id = ?", seriesID).
+		OrderBy("appointment_time").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetAppointmentsBySeriesID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AppointmentRepo - GetAppointmentsBySeriesID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var appointments []entity.Appointment
+	for rows.Next() {
+		appointment, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("AppointmentRepo - GetAppointmentsBySeriesID - rows.Scan: %w", err)
+		}
+
+		appointments = append(appointments, appointment)
+	}
+
+	return appointments, nil
+}
@@ -0,0 +1,288 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// SessionRepo - sign-in sessions and their rotating refresh tokens.
+type SessionRepo struct {
+	*postgres.Postgres
+}
+
+// NewSession -.
+func NewSession(pg *postgres.Postgres) *SessionRepo {
+	return &SessionRepo{pg}
+}
+
+// CreateSession - stores the session along with the hash of its first refresh token.
+func (r *SessionRepo) CreateSession(ctx context.Context, session entity.Session, tokenHash string) (entity.Session, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - CreateSession - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Insert("sessions").
+		Columns("user_id", "user_agent", "ip", "expires_at").
+		Values(session.UserID, session.UserAgent, session.IP, session.ExpiresAt).
+		Suffix("RETURNING id, created_at, last_used_at").
+		ToSql()
+
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - CreateSession - r.Builder: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - CreateSession - tx.QueryRow: %w", err)
+	}
+
+	err = r.insertRefreshToken(ctx, tx, session.ID, tokenHash)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - CreateSession - r.insertRefreshToken: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - CreateSession - tx.Commit: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetSession - returns entity.ErrSessionRevoked if there is no such session.
+func (r *SessionRepo) GetSession(ctx context.Context, id int) (entity.Session, error) {
+	sql, args, err := r.Builder.
+		Select(_sessionColumns...).
+		From("sessions").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - GetSession - r.Builder: %w", err)
+	}
+
+	session, err := scanSession(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Session{}, entity.ErrSessionRevoked
+		}
+
+		return entity.Session{}, fmt.Errorf("SessionRepo - GetSession - scanSession: %w", err)
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken - exchanges the refresh token hashed as oldHash for newHash and extends the
+// session to expiresAt. An unknown token, or one of an ended session, gives
+// entity.ErrInvalidRefreshToken. A token that was already exchanged revokes its session, which is
+// committed, and gives entity.ErrRefreshTokenReused.
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, oldHash, newHash string, now, expiresAt time.Time) (entity.Session, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Select("session_id", "used_at").
+		From("refresh_tokens").
+		Where("token_hash = ?", oldHash).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.Builder: %w", err)
+	}
+
+	var (
+		sessionID int
+		usedAt    *time.Time
+	)
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&sessionID, &usedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Session{}, entity.ErrInvalidRefreshToken
+		}
+
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - tx.QueryRow: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Select(_sessionColumns...).
+		From("sessions").
+		Where("id = ?", sessionID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.Builder: %w", err)
+	}
+
+	session, err := scanSession(tx.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - scanSession: %w", err)
+	}
+
+	if !session.Active(now) {
+		return entity.Session{}, entity.ErrInvalidRefreshToken
+	}
+
+	if usedAt != nil {
+		_, err = r.revoke(ctx, tx, squirrel.Eq{"id": session.ID}, entity.RevokedReuse, now)
+		if err != nil {
+			return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.revoke: %w", err)
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - tx.Commit: %w", err)
+		}
+
+		return entity.Session{}, entity.ErrRefreshTokenReused
+	}
+
+	sql, args, err = r.Builder.
+		Update("refresh_tokens").
+		Set("used_at", now).
+		Where("token_hash = ?", oldHash).
+		ToSql()
+
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - tx.Exec: %w", err)
+	}
+
+	err = r.insertRefreshToken(ctx, tx, session.ID, newHash)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.insertRefreshToken: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Update("sessions").
+		Set("last_used_at", now).
+		Set("expires_at", expiresAt).
+		Where("id = ?", session.ID).
+		ToSql()
+
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("SessionRepo - RotateRefreshToken - tx.Commit: %w", err)
+	}
+
+	session.LastUsedAt, session.ExpiresAt = now, expiresAt
+
+	return session, nil
+}
+
+// RevokeSession - revokes the user's session. It returns entity.ErrSessionRevoked if the user has
+// no such session or it was already revoked.
+func (r *SessionRepo) RevokeSession(ctx context.Context, userID, id int, reason string, now time.Time) error {
+	revoked, err := r.revoke(ctx, r.Pool, squirrel.Eq{"id": id, "user_id": userID}, reason, now)
+	if err != nil {
+		return fmt.Errorf("SessionRepo - RevokeSession - r.revoke: %w", err)
+	}
+
+	if revoked == 0 {
+		return entity.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// RevokeUserSessions - revokes every session of the user that is still open and returns how many.
+func (r *SessionRepo) RevokeUserSessions(ctx context.Context, userID int, reason string, now time.Time) (int, error) {
+	revoked, err := r.revoke(ctx, r.Pool, squirrel.Eq{"user_id": userID}, reason, now)
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo - RevokeUserSessions - r.revoke: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeRoleSessions - revokes every session still open of the users with the role and returns how
+// many.
+func (r *SessionRepo) RevokeRoleSessions(ctx context.Context, role entity.Role, reason string, now time.Time) (int, error) {
+	revoked, err := r.revoke(ctx, r.Pool, squirrel.Expr("user_id IN (SELECT id FROM users WHERE role = ?)", role), reason, now)
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo - RevokeRoleSessions - r.revoke: %w", err)
+	}
+
+	return revoked, nil
+}
+
+func (r *SessionRepo) revoke(ctx context.Context, q querier, where squirrel.Sqlizer, reason string, now time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Update("sessions").
+		Set("revoked_at", now).
+		Set("revoked_reason", reason).
+		Where(where).
+		Where("revoked_at IS NULL").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo - revoke - r.Builder: %w", err)
+	}
+
+	tag, err := q.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo - revoke - q.Exec: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *SessionRepo) insertRefreshToken(ctx context.Context, q querier, sessionID int, tokenHash string) error {
+	sql, args, err := r.Builder.
+		Insert("refresh_tokens").
+		Columns("token_hash", "session_id").
+		Values(tokenHash, sessionID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("SessionRepo - insertRefreshToken - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SessionRepo - insertRefreshToken - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+var _sessionColumns = []string{
+	"id", "user_id", "user_agent", "ip", "created_at", "last_used_at", "expires_at", "revoked_at", "COALESCE(revoked_reason, '')",
+}
+
+func scanSession(row pgx.Row) (entity.Session, error) {
+	var session entity.Session
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt,
+		&session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt, &session.RevokedReason)
+
+	return session, err
+}
@@ -0,0 +1,221 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _specializationColumns are scanned by scanSpecialization.
+var _specializationColumns = []string{"s.id", "s.code", "s.names", "s.description", "s.default_duration", "s.created_at", "s.updated_at"}
+
+// SpecializationRepo -.
+type SpecializationRepo struct {
+	*postgres.Postgres
+}
+
+// NewSpecialization -.
+func NewSpecialization(pg *postgres.Postgres) *SpecializationRepo {
+	return &SpecializationRepo{pg}
+}
+
+// CreateSpecialization - returns entity.ErrSpecializationExists if the code is in use.
+func (r *SpecializationRepo) CreateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error) {
+	sql, args, err := r.Builder.
+		Insert("specializations").
+		Columns("code", "names", "description", "default_duration").
+		Values(specialization.Code, specialization.Names, specialization.Description, specialization.DefaultDuration).
+		Suffix("RETURNING id, created_at, updated_at").
+		ToSql()
+
+	if err != nil {
+		return entity.Specialization{}, fmt.Errorf("SpecializationRepo - CreateSpecialization - r.Builder: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&specialization.ID, &specialization.CreatedAt, &specialization.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Specialization{}, entity.ErrSpecializationExists
+		}
+
+		return entity.Specialization{}, fmt.Errorf("SpecializationRepo - CreateSpecialization - r.Pool.QueryRow: %w", err)
+	}
+
+	return specialization, nil
+}
+
+// GetSpecialization - returns entity.ErrSpecializationNotFound if there is no such specialization.
+func (r *SpecializationRepo) GetSpecialization(ctx context.Context, id int) (entity.Specialization, error) {
+	sql, args, err := r.Builder.
+		Select(_specializationColumns...).
+		From("specializations s").
+		Where("s.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.Specialization{}, fmt.Errorf("SpecializationRepo - GetSpecialization - r.Builder: %w", err)
+	}
+
+	specialization, err := scanSpecialization(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Specialization{}, entity.ErrSpecializationNotFound
+		}
+
+		return entity.Specialization{}, fmt.Errorf("SpecializationRepo - GetSpecialization - r.Pool.QueryRow: %w", err)
+	}
+
+	return specialization, nil
+}
+
+// GetSpecializationsByCode - returns the specializations with the codes, in the order of codes.
+// It returns entity.ErrSpecializationNotFound if one of them doesn't exist.
+func (r *SpecializationRepo) GetSpecializationsByCode(ctx context.Context, codes []string) ([]entity.Specialization, error) {
+	sql, args, err := r.Builder.
+		Select(_specializationColumns...).
+		From("specializations s").
+		Where("s.code = ANY(?)", codes).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SpecializationRepo - GetSpecializationsByCode - r.Builder: %w", err)
+	}
+
+	found, err := r.querySpecializations(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[string]entity.Specialization, len(found))
+	for _, specialization := range found {
+		byCode[specialization.Code] = specialization
+	}
+
+	specializations := make([]entity.Specialization, 0, len(codes))
+	for _, code := range codes {
+		specialization, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", entity.ErrSpecializationNotFound, code)
+		}
+
+		specializations = append(specializations, specialization)
+	}
+
+	return specializations, nil
+}
+
+// ListSpecializations - returns the catalogue ordered by code.
+func (r *SpecializationRepo) ListSpecializations(ctx context.Context) ([]entity.Specialization, error) {
+	sql, args, err := r.Builder.
+		Select(_specializationColumns...).
+		From("specializations s").
+		OrderBy("s.code").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SpecializationRepo - ListSpecializations - r.Builder: %w", err)
+	}
+
+	return r.querySpecializations(ctx, sql, args)
+}
+
+// UpdateSpecialization - replaces the specialization and returns the stored one. It returns
+// entity.ErrSpecializationNotFound if there is no such specialization and
+// entity.ErrSpecializationExists if the new code is in use. Waitlist entries follow a new code.
+func (r *SpecializationRepo) UpdateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error) {
+	sql, args, err := r.Builder.
+		Update("specializations s").
+		Set("code", specialization.Code).
+		Set("names", specialization.Names).
+		Set("description", specialization.Description).
+		Set("default_duration", specialization.DefaultDuration).
+		Set("updated_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where("s.id = ?", specialization.ID).
+		Suffix("RETURNING " + strings.Join(_specializationColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return entity.Specialization{}, fmt.Errorf("SpecializationRepo - UpdateSpecialization - r.Builder: %w", err)
+	}
+
+	updated, err := scanSpecialization(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return entity.Specialization{}, entity.ErrSpecializationNotFound
+		case isUniqueViolation(err):
+			return entity.Specialization{}, entity.ErrSpecializationExists
+		}
+
+		return entity.Specialization{}, fmt.Errorf("SpecializationRepo - UpdateSpecialization - r.Pool.QueryRow: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteSpecialization - returns entity.ErrSpecializationNotFound if there is no such
+// specialization and entity.ErrSpecializationInUse if doctors or waitlist entries still name it.
+func (r *SpecializationRepo) DeleteSpecialization(ctx context.Context, id int) error {
+	sql, args, err := r.Builder.
+		Delete("specializations").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("SpecializationRepo - DeleteSpecialization - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.ErrSpecializationInUse
+		}
+
+		return fmt.Errorf("SpecializationRepo - DeleteSpecialization - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrSpecializationNotFound
+	}
+
+	return nil
+}
+
+func (r *SpecializationRepo) querySpecializations(ctx context.Context, sql string, args []any) ([]entity.Specialization, error) {
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SpecializationRepo - querySpecializations - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	specializations := make([]entity.Specialization, 0, _defaultEntityCap)
+	for rows.Next() {
+		specialization, err := scanSpecialization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("SpecializationRepo - querySpecializations - rows.Scan: %w", err)
+		}
+
+		specializations = append(specializations, specialization)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SpecializationRepo - querySpecializations - rows.Err: %w", err)
+	}
+
+	return specializations, nil
+}
+
+func scanSpecialization(row pgx.Row) (entity.Specialization, error) {
+	var specialization entity.Specialization
+
+	err := row.Scan(&specialization.ID, &specialization.Code, &specialization.Names, &specialization.Description,
+		&specialization.DefaultDuration, &specialization.CreatedAt, &specialization.UpdatedAt)
+
+	return specialization, err
+}
@@ -0,0 +1,322 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// TwoFactorRepo - TOTP enrollments, recovery codes and sign-in challenges.
+type TwoFactorRepo struct {
+	*postgres.Postgres
+}
+
+// NewTwoFactor -.
+func NewTwoFactor(pg *postgres.Postgres) *TwoFactorRepo {
+	return &TwoFactorRepo{pg}
+}
+
+// GetTwoFactor - returns the user's enrollment, if any, and whether their role requires one. It
+// returns entity.ErrUserNotFound if there is no such user.
+func (r *TwoFactorRepo) GetTwoFactor(ctx context.Context, userID int) (entity.TwoFactor, error) {
+	sql, args, err := r.Builder.
+		Select("COALESCE(user_totp.secret, '')", "user_totp.confirmed_at", "COALESCE(user_totp.last_used_step, 0)", "roles.require_two_factor").
+		From("users").
+		Join("roles ON roles.name = users.role").
+		LeftJoin("user_totp ON user_totp.user_id = users.id").
+		Where("users.id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return entity.TwoFactor{}, fmt.Errorf("TwoFactorRepo - GetTwoFactor - r.Builder: %w", err)
+	}
+
+	twoFactor := entity.TwoFactor{UserID: userID}
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&twoFactor.Secret, &twoFactor.ConfirmedAt, &twoFactor.LastUsedStep, &twoFactor.Required)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.TwoFactor{}, entity.ErrUserNotFound
+		}
+
+		return entity.TwoFactor{}, fmt.Errorf("TwoFactorRepo - GetTwoFactor - r.Pool.QueryRow: %w", err)
+	}
+
+	return twoFactor, nil
+}
+
+// SetTOTPSecret - stores a new, unconfirmed secret for the user, replacing one that was never
+// confirmed. It returns entity.ErrTwoFactorEnabled if the user has a confirmed one.
+func (r *TwoFactorRepo) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	sql, args, err := r.Builder.
+		Insert("user_totp").
+		Columns("user_id", "secret").
+		Values(userID, secret).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = CURRENT_TIMESTAMP, last_used_step = 0 " +
+			"WHERE user_totp.confirmed_at IS NULL").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - SetTOTPSecret - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - SetTOTPSecret - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP - confirms the user's secret with a code of the step and replaces their recovery
+// codes. It returns entity.ErrTwoFactorNotSetUp if there is no unconfirmed secret, and
+// entity.ErrInvalidTwoFactorCode if the step was used before.
+func (r *TwoFactorRepo) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string, now time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Select("last_used_step").
+		From("user_totp").
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - r.Builder: %w", err)
+	}
+
+	var lastUsedStep int64
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&lastUsedStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ErrTwoFactorNotSetUp
+		}
+
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - tx.QueryRow: %w", err)
+	}
+
+	if step <= lastUsedStep {
+		return entity.ErrInvalidTwoFactorCode
+	}
+
+	sql, args, err = r.Builder.
+		Update("user_totp").
+		Set("confirmed_at", now).
+		Set("last_used_step", step).
+		Where("user_id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - tx.Exec: %w", err)
+	}
+
+	err = r.replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - r.replaceRecoveryCodes: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - EnableTOTP - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTOTP - removes the user's secret and recovery codes.
+func (r *TwoFactorRepo) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - DisableTOTP - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Delete("user_totp").
+		Where("user_id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - DisableTOTP - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - DisableTOTP - tx.Exec: %w", err)
+	}
+
+	err = r.replaceRecoveryCodes(ctx, tx, userID, nil)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - DisableTOTP - r.replaceRecoveryCodes: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - DisableTOTP - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep - records that a code of the step was accepted for the user's confirmed secret. It
+// returns entity.ErrInvalidTwoFactorCode if that or a later step was accepted already.
+func (r *TwoFactorRepo) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	sql, args, err := r.Builder.
+		Update("user_totp").
+		Set("last_used_step", step).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userID, step).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - UseTOTPStep - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - UseTOTPStep - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes - swaps all of the user's recovery codes for the hashed ones.
+func (r *TwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - ReplaceRecoveryCodes - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = r.replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - ReplaceRecoveryCodes - r.replaceRecoveryCodes: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - ReplaceRecoveryCodes - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode - marks the user's unused recovery code used. It returns
+// entity.ErrInvalidTwoFactorCode if the user has no such unused code.
+func (r *TwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string, now time.Time) error {
+	sql, args, err := r.Builder.
+		Update("recovery_codes").
+		Set("used_at", now).
+		Where("code_hash = ? AND user_id = ? AND used_at IS NULL", codeHash, userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - UseRecoveryCode - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - UseRecoveryCode - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// GetTwoFactorChallenge - returns the user of an unused, unexpired sign-in challenge, or
+// entity.ErrInvalidUserToken.
+func (r *TwoFactorRepo) GetTwoFactorChallenge(ctx context.Context, tokenHash string, now time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("user_id").
+		From("user_tokens").
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, entity.TokenTwoFactor, now).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("TwoFactorRepo - GetTwoFactorChallenge - r.Builder: %w", err)
+	}
+
+	var userID int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, entity.ErrInvalidUserToken
+		}
+
+		return 0, fmt.Errorf("TwoFactorRepo - GetTwoFactorChallenge - r.Pool.QueryRow: %w", err)
+	}
+
+	return userID, nil
+}
+
+// UseTwoFactorChallenge - uses up the sign-in challenge and returns its user, or
+// entity.ErrInvalidUserToken.
+func (r *TwoFactorRepo) UseTwoFactorChallenge(ctx context.Context, tokenHash string, now time.Time) (int, error) {
+	return useUserToken(ctx, r.Builder, r.Pool, tokenHash, entity.TokenTwoFactor, now)
+}
+
+func (r *TwoFactorRepo) replaceRecoveryCodes(ctx context.Context, q querier, userID int, codeHashes []string) error {
+	sql, args, err := r.Builder.
+		Delete("recovery_codes").
+		Where("user_id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - replaceRecoveryCodes - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - replaceRecoveryCodes - q.Exec: %w", err)
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	insert := r.Builder.
+		Insert("recovery_codes").
+		Columns("code_hash", "user_id")
+
+	for _, codeHash := range codeHashes {
+		insert = insert.Values(codeHash, userID)
+	}
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - replaceRecoveryCodes - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - replaceRecoveryCodes - q.Exec: %w", err)
+	}
+
+	return nil
+}
@@ -2,11 +2,14 @@ package persistent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
 )
 
 const _defaultEntityCap = 64
@@ -21,33 +24,54 @@ func NewUser(pg *postgres.Postgres) *UserRepo {
 	return &UserRepo{pg}
 }
 
-// Create -. return id
+// Create -. return id. The user is created with the patient they are themselves.
 func (r *UserRepo) CreateUser(ctx context.Context, user entity.User) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - Store - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	sql, args, err := r.Builder.
 		Insert("users").
 		Columns("fullname", "email", "phone", "password_hash", "role").
-		Options("RETURNING id").
-		Values(user.FullName, user.Email, user.Phone, user.Password, user.Role).ToSql()
+		Values(user.FullName, user.Email, user.Phone, user.Password, user.Role).
+		Suffix("RETURNING id").
+		ToSql()
 
 	if err != nil {
 		return 0, fmt.Errorf("UserRepo - Store - r.Builder: %w", err)
 	}
 
-	row := r.Pool.QueryRow(ctx, sql, args...)
+	row := tx.QueryRow(ctx, sql, args...)
 
 	var id int
 	err = row.Scan(&id)
 	if err != nil {
-		return 0, fmt.Errorf("UserRepo - Store - r.Pool.Exec: %w", err)
+		if isForeignKeyViolation(err) {
+			return 0, entity.ErrUnknownRole
+		}
+
+		return 0, fmt.Errorf("UserRepo - Store - tx.QueryRow: %w", err)
+	}
+
+	err = createSelfPatient(ctx, r.Builder, tx, id, user.FullName)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - Store - createSelfPatient: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - Store - tx.Commit: %w", err)
 	}
 
 	return id, nil
 }
 
-// GetUserByEmail -.
+// GetUserByEmail - returns entity.ErrUserNotFound if there is no such user.
 func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
 	sql, args, err := r.Builder.
-		Select("id", "fullname", "email", "phone", "role", "created_at", "updated_at").
+		Select("id", "fullname", "email", "phone", "role", "created_at", "updated_at", "email_verified_at", "deactivated_at").
 		From("users").
 		Where("email = ?", email).
 		Limit(1).
@@ -60,8 +84,12 @@ func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (entity.Use
 	row := r.Pool.QueryRow(ctx, sql, args...)
 
 	var user entity.User
-	err = row.Scan(&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err = row.Scan(&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt, &user.DeactivatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, entity.ErrUserNotFound
+		}
+
 		return entity.User{}, fmt.Errorf("UserRepo - GetByEmail - row.Scan: %w", err)
 	}
 
@@ -71,7 +99,7 @@ func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (entity.Use
 // ListUsers -.
 func (r *UserRepo) ListUsers(ctx context.Context) ([]entity.User, error) {
 	sql, args, err := r.Builder.
-		Select("id", "fullname", "email", "phone", "role", "created_at", "updated_at").
+		Select("id", "fullname", "email", "phone", "role", "created_at", "updated_at", "email_verified_at", "deactivated_at").
 		From("users").
 		ToSql()
 
@@ -88,7 +116,7 @@ func (r *UserRepo) ListUsers(ctx context.Context) ([]entity.User, error) {
 	users := make([]entity.User, 0, _defaultEntityCap)
 	for rows.Next() {
 		var user entity.User
-		err = rows.Scan(&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		err = rows.Scan(&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt, &user.DeactivatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("UserRepo - ListUser - rows.Scan: %w", err)
 		}
@@ -99,26 +127,80 @@ func (r *UserRepo) ListUsers(ctx context.Context) ([]entity.User, error) {
 	return users, nil
 }
 
-// UpdateUser -.
+// UpdateUser - a new email address is no longer verified, and the password reset and
+// verification links mailed to the old one stop working. It returns entity.ErrUserNotFound if
+// there is no such user.
 func (r *UserRepo) UpdateUser(ctx context.Context, user entity.UserUpdate) error {
-	updateTime := time.Now()
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateUser - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	sql, args, err := r.Builder.
+		Select("email").
+		From("users").
+		Where("id = ?", user.ID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateUser - r.Builder: %w", err)
+	}
+
+	var email string
+	err = tx.QueryRow(ctx, sql, args...).Scan(&email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ErrUserNotFound
+		}
+
+		return fmt.Errorf("UserRepo - UpdateUser - tx.QueryRow: %w", err)
+	}
+
+	emailChanged := email != user.Email
+
+	update := r.Builder.
 		Update("users").
 		Set("fullname", user.FullName).
 		Set("email", user.Email).
 		Set("phone", user.Phone).
-		Set("password", user.Password).
-		Set("updated_at", updateTime).
-		Where("id = ?", user.ID).
-		ToSql()
+		Set("updated_at", time.Now()).
+		Where("id = ?", user.ID)
+
+	if emailChanged {
+		update = update.Set("email_verified_at", nil)
+	}
 
+	sql, args, err = update.ToSql()
 	if err != nil {
 		return fmt.Errorf("UserRepo - UpdateUser - r.Builder: %w", err)
 	}
 
-	_, err = r.Pool.Exec(ctx, sql, args...)
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateUser - tx.Exec: %w", err)
+	}
+
+	if emailChanged {
+		sql, args, err = r.Builder.
+			Delete("user_tokens").
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			ToSql()
+
+		if err != nil {
+			return fmt.Errorf("UserRepo - UpdateUser - r.Builder: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("UserRepo - UpdateUser - tx.Exec: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
-		return fmt.Errorf("UserRepo - UpdateUser - r.Pool.Exec: %w", err)
+		return fmt.Errorf("UserRepo - UpdateUser - tx.Commit: %w", err)
 	}
 
 	return nil
@@ -143,10 +225,10 @@ func (r *UserRepo) DeleteUser(ctx context.Context, id int) error {
 	return nil
 }
 
-// GetUserByID -.
+// GetUserByID - returns entity.ErrUserNotFound if there is no such user.
 func (r *UserRepo) GetUserByID(ctx context.Context, id int) (entity.User, error) {
 	sql, args, err := r.Builder.
-		Select("id", "fullname", "email", "phone", "role", "created_at", "updated_at").
+		Select("id", "fullname", "email", "phone", "role", "created_at", "updated_at", "email_verified_at", "deactivated_at").
 		From("users").
 		Where("id = ?", id).
 		Limit(1).
@@ -159,8 +241,12 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id int) (entity.User, error)
 	row := r.Pool.QueryRow(ctx, sql, args...)
 
 	var user entity.User
-	err = row.Scan(&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err = row.Scan(&user.ID, &user.FullName, &user.Email, &user.Phone, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt, &user.DeactivatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, entity.ErrUserNotFound
+		}
+
 		return entity.User{}, fmt.Errorf("UserRepo - GetUserByID - row.Scan: %w", err)
 	}
 
@@ -168,10 +254,10 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id int) (entity.User, error)
 
 }
 
-// GetPasswordHash -.
+// GetPasswordHash - returns entity.ErrUserNotFound if no user has the email.
 func (r *UserRepo) GetPasswordHash(ctx context.Context, email string) (entity.GetPasswordHash, error) {
 	sql, args, err := r.Builder.
-		Select("password_hash", "id").
+		Select("password_hash", "id", "role", "deactivated_at").
 		From("users").
 		Where("email = ?", email).
 		Limit(1).
@@ -185,33 +271,204 @@ func (r *UserRepo) GetPasswordHash(ctx context.Context, email string) (entity.Ge
 
 	var passwordHash string
 	var id int
-	err = row.Scan(&passwordHash, &id)
+	var role entity.Role
+	var deactivatedAt *time.Time
+	err = row.Scan(&passwordHash, &id, &role, &deactivatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.GetPasswordHash{}, entity.ErrUserNotFound
+		}
+
 		return entity.GetPasswordHash{}, fmt.Errorf("UserRepo - GetPasswordHash - row.Scan: %w", err)
 	}
 
 	return entity.GetPasswordHash{
-		PasswordHash: passwordHash,
-		ID:           id,
+		PasswordHash:  passwordHash,
+		ID:            id,
+		Role:          role,
+		DeactivatedAt: deactivatedAt,
 	}, nil
 }
 
-// UpdateToken -.
-func (r *UserRepo) UpdateToken(ctx context.Context, id int, token string) error {
+// DeactivateUser - deactivates the user, keeping an earlier deactivation. It returns
+// entity.ErrUserNotFound if there is no such user.
+func (r *UserRepo) DeactivateUser(ctx context.Context, id int, now time.Time) error {
+	return r.setDeactivatedAt(ctx, "DeactivateUser", id, squirrel.Expr("COALESCE(deactivated_at, ?)", now), now)
+}
+
+// ReactivateUser - returns entity.ErrUserNotFound if there is no such user.
+func (r *UserRepo) ReactivateUser(ctx context.Context, id int, now time.Time) error {
+	return r.setDeactivatedAt(ctx, "ReactivateUser", id, nil, now)
+}
+
+func (r *UserRepo) setDeactivatedAt(ctx context.Context, method string, id int, deactivatedAt any, now time.Time) error {
 	sql, args, err := r.Builder.
 		Update("users").
-		Set("token", token).
+		Set("deactivated_at", deactivatedAt).
+		Set("updated_at", now).
 		Where("id = ?", id).
 		ToSql()
 
 	if err != nil {
-		return fmt.Errorf("UserRepo - UpdateToken - r.Builder: %w", err)
+		return fmt.Errorf("UserRepo - %s - r.Builder: %w", method, err)
 	}
 
-	_, err = r.Pool.Exec(ctx, sql, args...)
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - %s - r.Pool.Exec: %w", method, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// CreateUserToken - stores the token, replacing the user's unused tokens of the same purpose.
+func (r *UserRepo) CreateUserToken(ctx context.Context, token entity.UserToken) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("UserRepo - CreateUserToken - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Delete("user_tokens").
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("UserRepo - CreateUserToken - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - CreateUserToken - tx.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Insert("user_tokens").
+		Columns("token_hash", "user_id", "purpose", "expires_at").
+		Values(token.TokenHash, token.UserID, token.Purpose, token.ExpiresAt).
+		ToSql()
+
 	if err != nil {
-		return fmt.Errorf("UserRepo - UpdateToken - r.Pool.Exec: %w", err)
+		return fmt.Errorf("UserRepo - CreateUserToken - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - CreateUserToken - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("UserRepo - CreateUserToken - tx.Commit: %w", err)
 	}
 
 	return nil
 }
+
+// ResetPassword - uses up the password reset token and sets the password hash of its user, whose
+// email it also verifies. It returns the user id, or entity.ErrInvalidUserToken.
+func (r *UserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - ResetPassword - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	userID, err := useUserToken(ctx, r.Builder, tx, tokenHash, entity.TokenPasswordReset, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("password_hash", passwordHash).
+		Set("email_verified_at", squirrel.Expr("COALESCE(email_verified_at, ?)", now)).
+		Set("updated_at", now).
+		Where("id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - ResetPassword - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - ResetPassword - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - ResetPassword - tx.Commit: %w", err)
+	}
+
+	return userID, nil
+}
+
+// VerifyEmail - uses up the verification token and marks the email of its user verified. It
+// returns the user id, or entity.ErrInvalidUserToken.
+func (r *UserRepo) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - VerifyEmail - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	userID, err := useUserToken(ctx, r.Builder, tx, tokenHash, entity.TokenEmailVerification, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("email_verified_at", squirrel.Expr("COALESCE(email_verified_at, ?)", now)).
+		Where("id = ?", userID).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - VerifyEmail - r.Builder: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - VerifyEmail - tx.Exec: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - VerifyEmail - tx.Commit: %w", err)
+	}
+
+	return userID, nil
+}
+
+// useUserToken marks an unused, unexpired token of the purpose used and returns its user id.
+func useUserToken(ctx context.Context, builder squirrel.StatementBuilderType, q querier, tokenHash, purpose string, now time.Time) (int, error) {
+	sql, args, err := builder.
+		Update("user_tokens").
+		Set("used_at", now).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Suffix("RETURNING user_id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("UserRepo - useUserToken - builder: %w", err)
+	}
+
+	var userID int
+
+	err = q.QueryRow(ctx, sql, args...).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, entity.ErrInvalidUserToken
+		}
+
+		return 0, fmt.Errorf("UserRepo - useUserToken - q.QueryRow: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,579 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// _offerColumns selects a waitlist offer together with the slot held by its appointment.
+var _offerColumns = []string{
+	"o.id", "o.entry_id", "e.patient_id", "o.appointment_id", "a.doctor_id", "a.appointment_time", "a.duration",
+	"a.appointment_type_id", "a.buffer_before", "a.buffer_after", "a.capacity", "a.location_id", "a.room_id", "o.status", "o.expires_at", "o.created_at", "o.responded_at",
+}
+
+// WaitlistRepo - waitlist entries and the slot offers made to them. Offers hold their slot with an
+// appointment, so the repo shares the appointment locking and overlap checks.
+type WaitlistRepo struct {
+	*AppointmentRepo
+}
+
+// NewWaitlist -.
+func NewWaitlist(pg *postgres.Postgres) *WaitlistRepo {
+	return &WaitlistRepo{NewAppointment(pg)}
+}
+
+// CreateWaitlistEntry -.
+func (r *WaitlistRepo) CreateWaitlistEntry(ctx context.Context, entry entity.WaitlistEntry) (int, error) {
+	sql, args, err := r.Builder.
+		Insert("waitlist_entries").
+		Columns("patient_id", "doctor_id", "specialization", "window_start", "window_end").
+		Values(entry.PatientID, entry.DoctorID, nullableString(entry.Specialization), entry.From, entry.To).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("WaitlistRepo - CreateWaitlistEntry - r.Builder: %w", err)
+	}
+
+	var id int
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("WaitlistRepo - CreateWaitlistEntry - r.Pool.QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetWaitlistEntry -.
+func (r *WaitlistRepo) GetWaitlistEntry(ctx context.Context, id int) (entity.WaitlistEntry, error) {
+	sql, args, err := r.Builder.
+		Select("id", "patient_id", "doctor_id", "COALESCE(specialization, '')", "window_start", "window_end", "status", "created_at").
+		From("waitlist_entries").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.WaitlistEntry{}, fmt.Errorf("WaitlistRepo - GetWaitlistEntry - r.Builder: %w", err)
+	}
+
+	var entry entity.WaitlistEntry
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&entry.ID, &entry.PatientID, &entry.DoctorID, &entry.Specialization, &entry.From, &entry.To, &entry.Status, &entry.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.WaitlistEntry{}, entity.ErrWaitlistEntryNotFound
+		}
+
+		return entity.WaitlistEntry{}, fmt.Errorf("WaitlistRepo - GetWaitlistEntry - r.Pool.QueryRow: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetWaitlistEntriesByUserID -.
+func (r *WaitlistRepo) GetWaitlistEntriesByUserID(ctx context.Context, userID int) ([]entity.WaitlistEntry, error) {
+	sql, args, err := r.Builder.
+		Select("id", "patient_id", "doctor_id", "COALESCE(specialization, '')", "window_start", "window_end", "status", "created_at").
+		From("waitlist_entries").
+		Where("patient_id IN (SELECT patient_id FROM patient_users WHERE user_id = ?)", userID).
+		OrderBy("created_at DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - GetWaitlistEntriesByUserID - r.Builder: %w", err)
+	}
+
+	return r.queryEntries(ctx, sql, args)
+}
+
+// GetWaitingEntries - returns the waiting entries a slot of the doctor would suit, first come first
+// served. An entry matches by doctor, or by specialization when it isn't tied to a doctor, and its
+// window must cover the whole slot. Entries that already passed on this slot are left out.
+func (r *WaitlistRepo) GetWaitingEntries(ctx context.Context, doctor entity.Doctor, slot entity.Appointment) ([]entity.WaitlistEntry, error) {
+	sql, args, err := r.Builder.
+		Select("id", "patient_id", "doctor_id", "COALESCE(specialization, '')", "window_start", "window_end", "status", "created_at").
+		From("waitlist_entries e").
+		Where("status = ?", entity.WaitlistWaiting).
+		Where(squirrel.Or{
+			squirrel.Eq{"doctor_id": doctor.ID},
+			squirrel.And{
+				squirrel.Eq{"doctor_id": nil},
+				squirrel.Expr(`specialization IN (
+					SELECT s.code FROM doctor_specializations ds JOIN specializations s ON s.id = ds.specialization_id
+					WHERE ds.doctor_id = ?
+				)`, doctor.ID),
+			},
+		}).
+		Where("window_start <= ?", slot.AppointmentTime).
+		Where("window_end >= ?", slot.End()).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM waitlist_offers o JOIN appointments a ON a.id = o.appointment_id
+			WHERE o.entry_id = e.id AND a.doctor_id = ? AND a.appointment_time = ?
+		)`, doctor.ID, slot.AppointmentTime).
+		OrderBy("created_at", "id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - GetWaitingEntries - r.Builder: %w", err)
+	}
+
+	return r.queryEntries(ctx, sql, args)
+}
+
+func (r *WaitlistRepo) queryEntries(ctx context.Context, sql string, args []any) ([]entity.WaitlistEntry, error) {
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - queryEntries - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entity.WaitlistEntry
+	for rows.Next() {
+		var entry entity.WaitlistEntry
+		err = rows.Scan(&entry.ID, &entry.PatientID, &entry.DoctorID, &entry.Specialization, &entry.From, &entry.To, &entry.Status, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("WaitlistRepo - queryEntries - rows.Scan: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// CreateWaitlistOffer - holds the slot for the entry until expiresAt in one transaction: the entry
+// moves to offered, a held appointment takes the slot and the offer is stored. Returns
+// entity.ErrEntryClosed if the entry is no longer waiting and, like checkOverlap, an error if the
+// slot or its room was booked in the meantime.
+func (r *WaitlistRepo) CreateWaitlistOffer(ctx context.Context, entryID int, slot entity.Appointment, expiresAt time.Time) (entity.WaitlistOffer, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Update("waitlist_entries").
+		Set("status", entity.WaitlistOffered).
+		Where("id = ?", entryID).
+		Where("status = ?", entity.WaitlistWaiting).
+		Suffix("RETURNING patient_id").
+		ToSql()
+
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - r.Builder: %w", err)
+	}
+
+	offer := entity.WaitlistOffer{
+		EntryID:           entryID,
+		DoctorID:          slot.DoctorID,
+		AppointmentTime:   slot.AppointmentTime,
+		Duration:          slot.Duration,
+		AppointmentTypeID: slot.AppointmentTypeID,
+		BufferBefore:      slot.BufferBefore,
+		BufferAfter:       slot.BufferAfter,
+		Capacity:          capacity(slot),
+		LocationID:        slot.LocationID,
+		RoomID:            slot.RoomID,
+		Status:            entity.OfferPending,
+		ExpiresAt:         expiresAt,
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&offer.PatientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.WaitlistOffer{}, entity.ErrEntryClosed
+		}
+
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - tx.QueryRow: %w", err)
+	}
+
+	err = r.lockDoctor(ctx, tx, slot.DoctorID)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - r.lockDoctor: %w", err)
+	}
+
+	err = r.checkOverlap(ctx, tx, slot)
+	if err != nil {
+		return entity.WaitlistOffer{}, err
+	}
+
+	sql, args, err = r.Builder.
+		Insert("appointments").
+		Columns("patient_id", "doctor_id", "appointment_type_id", "appointment_time", "duration", "buffer_before", "buffer_after",
+			"capacity", "location_id", "room_id", "status").
+		Values(offer.PatientID, slot.DoctorID, slot.AppointmentTypeID, slot.AppointmentTime, slot.Duration, slot.BufferBefore,
+			slot.BufferAfter, capacity(slot), slot.LocationID, slot.RoomID, entity.StatusHeld).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - r.Builder: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&offer.AppointmentID)
+	if err != nil {
+		if err := overlapError(err); err != nil {
+			return entity.WaitlistOffer{}, err
+		}
+
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - tx.QueryRow: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Insert("waitlist_offers").
+		Columns("entry_id", "appointment_id", "expires_at").
+		Values(entryID, offer.AppointmentID, expiresAt).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - r.Builder: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&offer.ID, &offer.CreatedAt)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - tx.QueryRow: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - CreateWaitlistOffer - tx.Commit: %w", err)
+	}
+
+	return offer, nil
+}
+
+// GetWaitlistOffer -.
+func (r *WaitlistRepo) GetWaitlistOffer(ctx context.Context, id int) (entity.WaitlistOffer, error) {
+	sql, args, err := r.offers().
+		Where("o.id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - GetWaitlistOffer - r.Builder: %w", err)
+	}
+
+	offer, err := scanOffer(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.WaitlistOffer{}, entity.ErrWaitlistOfferNotFound
+		}
+
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - GetWaitlistOffer - scanOffer: %w", err)
+	}
+
+	return offer, nil
+}
+
+// GetWaitlistOffersByUserID -.
+func (r *WaitlistRepo) GetWaitlistOffersByUserID(ctx context.Context, userID int) ([]entity.WaitlistOffer, error) {
+	sql, args, err := r.offers().
+		Where("e.patient_id IN (SELECT patient_id FROM patient_users WHERE user_id = ?)", userID).
+		OrderBy("o.created_at DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - GetWaitlistOffersByUserID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - GetWaitlistOffersByUserID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var offers []entity.WaitlistOffer
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("WaitlistRepo - GetWaitlistOffersByUserID - scanOffer: %w", err)
+		}
+
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
+
+// GetExpiredWaitlistOffers - returns the ids of pending offers whose hold ran out by now.
+func (r *WaitlistRepo) GetExpiredWaitlistOffers(ctx context.Context, now time.Time) ([]int, error) {
+	sql, args, err := r.Builder.
+		Select("id").
+		From("waitlist_offers").
+		Where("status = ?", entity.OfferPending).
+		Where("expires_at <= ?", now).
+		OrderBy("expires_at").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - GetExpiredWaitlistOffers - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - GetExpiredWaitlistOffers - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("WaitlistRepo - GetExpiredWaitlistOffers - rows.Scan: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// AcceptWaitlistOffer - books the held slot for the patient on behalf of the user acceptedBy: the
+// hold becomes a scheduled appointment, the offer is accepted and the entry booked. Returns
+// entity.ErrOfferUnavailable if the offer isn't pending or expired before now.
+func (r *WaitlistRepo) AcceptWaitlistOffer(ctx context.Context, id int, acceptedBy *int, now time.Time) (entity.WaitlistOffer, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - AcceptWaitlistOffer - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	offer, err := r.lockPendingOffer(ctx, tx, id)
+	if err != nil {
+		return entity.WaitlistOffer{}, err
+	}
+
+	if !offer.ExpiresAt.After(now) {
+		return entity.WaitlistOffer{}, entity.ErrOfferUnavailable
+	}
+
+	err = r.transitionAppointment(ctx, tx, entity.AppointmentStatusChange{
+		AppointmentID: offer.AppointmentID,
+		FromStatus:    entity.StatusHeld,
+		ToStatus:      entity.StatusScheduled,
+		ChangedBy:     acceptedBy,
+		Reason:        "waitlist offer accepted",
+	})
+	if err != nil {
+		return entity.WaitlistOffer{}, err
+	}
+
+	err = r.closeOffer(ctx, tx, offer, entity.OfferAccepted, entity.WaitlistBooked)
+	if err != nil {
+		return entity.WaitlistOffer{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - AcceptWaitlistOffer - tx.Commit: %w", err)
+	}
+
+	offer.Status = entity.OfferAccepted
+
+	return offer, nil
+}
+
+// ReleaseWaitlistOffer - closes a pending offer as declined or expired, cancels its hold and puts
+// the entry back in the queue. Returns entity.ErrOfferUnavailable if the offer isn't pending.
+func (r *WaitlistRepo) ReleaseWaitlistOffer(ctx context.Context, id int, status string) (entity.WaitlistOffer, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - ReleaseWaitlistOffer - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	offer, err := r.lockPendingOffer(ctx, tx, id)
+	if err != nil {
+		return entity.WaitlistOffer{}, err
+	}
+
+	err = r.releaseOffer(ctx, tx, offer, status, entity.WaitlistWaiting)
+	if err != nil {
+		return entity.WaitlistOffer{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - ReleaseWaitlistOffer - tx.Commit: %w", err)
+	}
+
+	offer.Status = status
+
+	return offer, nil
+}
+
+// LeaveWaitlist - closes the entry. A pending offer made to it is declined and its hold cancelled;
+// the released offer is returned so the slot can be passed on. Returns entity.ErrEntryClosed if the
+// entry was already booked or left.
+func (r *WaitlistRepo) LeaveWaitlist(ctx context.Context, id int) (*entity.WaitlistOffer, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - LeaveWaitlist - r.Pool.Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, err := r.Builder.
+		Select("status").
+		From("waitlist_entries").
+		Where("id = ?", id).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - LeaveWaitlist - r.Builder: %w", err)
+	}
+
+	var status string
+	err = tx.QueryRow(ctx, sql, args...).Scan(&status)
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - LeaveWaitlist - tx.QueryRow: %w", err)
+	}
+
+	var released *entity.WaitlistOffer
+
+	switch status {
+	case entity.WaitlistWaiting:
+		err = r.setEntryStatus(ctx, tx, id, entity.WaitlistLeft)
+	case entity.WaitlistOffered:
+		released, err = r.releaseEntryOffer(ctx, tx, id)
+	default:
+		return nil, entity.ErrEntryClosed
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - LeaveWaitlist - tx.Commit: %w", err)
+	}
+
+	return released, nil
+}
+
+func (r *WaitlistRepo) releaseEntryOffer(ctx context.Context, q querier, entryID int) (*entity.WaitlistOffer, error) {
+	sql, args, err := r.offers().
+		Where("o.entry_id = ?", entryID).
+		Where("o.status = ?", entity.OfferPending).
+		Suffix("FOR UPDATE OF o").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - releaseEntryOffer - r.Builder: %w", err)
+	}
+
+	offer, err := scanOffer(q.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return nil, fmt.Errorf("WaitlistRepo - releaseEntryOffer - scanOffer: %w", err)
+	}
+
+	err = r.releaseOffer(ctx, q, offer, entity.OfferDeclined, entity.WaitlistLeft)
+	if err != nil {
+		return nil, err
+	}
+
+	offer.Status = entity.OfferDeclined
+
+	return &offer, nil
+}
+
+// lockPendingOffer reads the offer and locks it until the end of the transaction.
+func (r *WaitlistRepo) lockPendingOffer(ctx context.Context, q querier, id int) (entity.WaitlistOffer, error) {
+	sql, args, err := r.offers().
+		Where("o.id = ?", id).
+		Suffix("FOR UPDATE OF o").
+		ToSql()
+
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - lockPendingOffer - r.Builder: %w", err)
+	}
+
+	offer, err := scanOffer(q.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return entity.WaitlistOffer{}, fmt.Errorf("WaitlistRepo - lockPendingOffer - scanOffer: %w", err)
+	}
+
+	if offer.Status != entity.OfferPending {
+		return entity.WaitlistOffer{}, entity.ErrOfferUnavailable
+	}
+
+	return offer, nil
+}
+
+func (r *WaitlistRepo) releaseOffer(ctx context.Context, q querier, offer entity.WaitlistOffer, status, entryStatus string) error {
+	err := r.transitionAppointment(ctx, q, entity.AppointmentStatusChange{
+		AppointmentID: offer.AppointmentID,
+		FromStatus:    entity.StatusHeld,
+		ToStatus:      entity.StatusCancelled,
+		Reason:        "waitlist offer " + status,
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.closeOffer(ctx, q, offer, status, entryStatus)
+}
+
+func (r *WaitlistRepo) closeOffer(ctx context.Context, q querier, offer entity.WaitlistOffer, status, entryStatus string) error {
+	sql, args, err := r.Builder.
+		Update("waitlist_offers").
+		Set("status", status).
+		Set("responded_at", squirrel.Expr("NOW()")).
+		Where("id = ?", offer.ID).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("WaitlistRepo - closeOffer - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("WaitlistRepo - closeOffer - q.Exec: %w", err)
+	}
+
+	return r.setEntryStatus(ctx, q, offer.EntryID, entryStatus)
+}
+
+func (r *WaitlistRepo) setEntryStatus(ctx context.Context, q querier, id int, status string) error {
+	sql, args, err := r.Builder.
+		Update("waitlist_entries").
+		Set("status", status).
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("WaitlistRepo - setEntryStatus - r.Builder: %w", err)
+	}
+
+	_, err = q.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("WaitlistRepo - setEntryStatus - q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WaitlistRepo) offers() squirrel.SelectBuilder {
+	return r.Builder.
+		Select(_offerColumns...).
+		From("waitlist_offers o").
+		Join("waitlist_entries e ON e.id = o.entry_id").
+		Join("appointments a ON a.id = o.appointment_id")
+}
+
+func scanOffer(row pgx.Row) (entity.WaitlistOffer, error) {
+	var offer entity.WaitlistOffer
+	err := row.Scan(&offer.ID, &offer.EntryID, &offer.PatientID, &offer.AppointmentID, &offer.DoctorID, &offer.AppointmentTime, &offer.Duration,
+		&offer.AppointmentTypeID, &offer.BufferBefore, &offer.BufferAfter, &offer.Capacity, &offer.LocationID, &offer.RoomID, &offer.Status, &offer.ExpiresAt, &offer.CreatedAt, &offer.RespondedAt)
+
+	return offer, err
+}
@@ -31,6 +31,18 @@ func TestUserPostgres(t *testing.T) {
 		persistent.NewUser(pg),
 		persistent.NewDoctor(pg),
 		persistent.NewAppointment(pg),
+		persistent.NewSchedule(pg),
+		persistent.NewWaitlist(pg),
+		persistent.NewRole(pg),
+		persistent.NewSession(pg),
+		persistent.NewLogin(pg),
+		persistent.NewTwoFactor(pg),
+		persistent.NewIdentity(pg),
+		persistent.NewAPIKey(pg),
+		persistent.NewPatient(pg),
+		persistent.NewSpecialization(pg),
+		persistent.NewAppointmentType(pg),
+		persistent.NewClinic(pg),
 	)
 
 	// Test create user
@@ -84,8 +96,12 @@ func TestUserPostgres(t *testing.T) {
 	assert.NotEmpty(t, users)
 
 	// Test update user
-	user.FullName = "Jane Doe"
-	err = usecase.UpdateUser(context.Background(), user)
+	err = usecase.UpdateUser(context.Background(), entity.UserUpdate{
+		ID:       user.ID,
+		FullName: "Jane Doe",
+		Email:    user.Email,
+		Phone:    user.Phone,
+	})
 	if err != nil {
 		t.Fatalf("Failed to update user: %v", err)
 	}
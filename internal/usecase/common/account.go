@@ -0,0 +1,183 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/mailer"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+)
+
+// RequestPasswordReset mails a password reset link to the user with the email. An unknown email
+// is not an error, so the response doesn't reveal who has an account.
+func (uc *UseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := uc.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestPasswordReset - uc.userRepo.GetUserByEmail: %w", err)
+	}
+
+	link, err := uc.issueUserToken(ctx, user.ID, entity.TokenPasswordReset, uc.passwordResetTTL, uc.passwordResetURL, "")
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestPasswordReset - uc.issueUserToken: %w", err)
+	}
+
+	err = uc.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nFollow this link to choose a new password:\n%s\n\n"+
+			"The link works once and expires in %s. If you didn't ask to reset your password, ignore this email.",
+			user.FullName, link, uc.passwordResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestPasswordReset - uc.mailer.Send: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets the password of the user the reset token was mailed to and signs them out
+// everywhere. The token can't be used again.
+func (uc *UseCase) ResetPassword(ctx context.Context, token, passwordHash string) error {
+	now := time.Now()
+
+	userID, err := uc.userRepo.ResetPassword(ctx, tokens.HashOpaqueToken(token), passwordHash, now)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidUserToken) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - ResetPassword - uc.userRepo.ResetPassword: %w", err)
+	}
+
+	_, err = uc.sessionRepo.RevokeUserSessions(ctx, userID, entity.RevokedPasswordReset, now)
+	if err != nil {
+		return fmt.Errorf("UseCase - ResetPassword - uc.sessionRepo.RevokeUserSessions: %w", err)
+	}
+
+	return nil
+}
+
+// SendEmailVerification mails the user a link that verifies their email address.
+func (uc *UseCase) SendEmailVerification(ctx context.Context, userID int) error {
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - SendEmailVerification - uc.userRepo.GetUserByID: %w", err)
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return entity.ErrEmailAlreadyVerified
+	}
+
+	link, err := uc.issueUserToken(ctx, user.ID, entity.TokenEmailVerification, uc.emailVerificationTTL, uc.linkBaseURL, _verifyEmailPath)
+	if err != nil {
+		return fmt.Errorf("UseCase - SendEmailVerification - uc.issueUserToken: %w", err)
+	}
+
+	err = uc.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nFollow this link to verify your email address and start booking appointments:\n%s\n\n"+
+			"The link expires in %s.", user.FullName, link, uc.emailVerificationTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - SendEmailVerification - uc.mailer.Send: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email address of the user the verification token was mailed to verified.
+func (uc *UseCase) VerifyEmail(ctx context.Context, token string) error {
+	_, err := uc.userRepo.VerifyEmail(ctx, tokens.HashOpaqueToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidUserToken) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - VerifyEmail - uc.userRepo.VerifyEmail: %w", err)
+	}
+
+	return nil
+}
+
+// DeactivateUser keeps the user from signing in and ends their sessions on behalf of the admin
+// by. Their appointments and history are kept.
+func (uc *UseCase) DeactivateUser(ctx context.Context, userID, by int) error {
+	if userID == by {
+		return entity.ErrDeactivateSelf
+	}
+
+	now := time.Now()
+
+	err := uc.userRepo.DeactivateUser(ctx, userID, now)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeactivateUser - uc.userRepo.DeactivateUser: %w", err)
+	}
+
+	_, err = uc.sessionRepo.RevokeUserSessions(ctx, userID, entity.RevokedDeactivated, now)
+	if err != nil {
+		return fmt.Errorf("UseCase - DeactivateUser - uc.sessionRepo.RevokeUserSessions: %w", err)
+	}
+
+	uc.l.Warn("user %d deactivated by user %d", userID, by)
+
+	return nil
+}
+
+// ReactivateUser lets a deactivated user sign in again on behalf of the admin by.
+func (uc *UseCase) ReactivateUser(ctx context.Context, userID, by int) error {
+	err := uc.userRepo.ReactivateUser(ctx, userID, time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - ReactivateUser - uc.userRepo.ReactivateUser: %w", err)
+	}
+
+	uc.l.Info("user %d reactivated by user %d", userID, by)
+
+	return nil
+}
+
+// _verifyEmailPath is the API route that verifies the token of a mailed link.
+const _verifyEmailPath = "/v1/auth/verify-email"
+
+// issueUserToken stores a new token of the purpose for the user and returns the link to base and
+// path carrying it.
+func (uc *UseCase) issueUserToken(ctx context.Context, userID int, purpose string, ttl time.Duration, base, path string) (string, error) {
+	token, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("UseCase - issueUserToken - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	err = uc.userRepo.CreateUserToken(ctx, entity.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: tokens.HashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("UseCase - issueUserToken - uc.userRepo.CreateUserToken: %w", err)
+	}
+
+	return userTokenLink(base, path, token), nil
+}
+
+// userTokenLink appends path and the token query parameter to base.
+func userTokenLink(base, path, token string) string {
+	return strings.TrimRight(base, "/") + path + "?" + url.Values{"token": {token}}.Encode()
+}
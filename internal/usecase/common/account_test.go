@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserTokenLink(t *testing.T) {
+	tests := []struct {
+		name, base, want string
+	}{
+		{name: "base", base: "https://clinic.example.com", want: "https://clinic.example.com/verify-email?token=a%2Bb"},
+		{name: "trailing slash", base: "https://clinic.example.com/", want: "https://clinic.example.com/verify-email?token=a%2Bb"},
+		{name: "base path", base: "https://example.com/clinic", want: "https://example.com/clinic/verify-email?token=a%2Bb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, userTokenLink(tt.base, "/verify-email", "a+b"))
+		})
+	}
+}
+
+// mailStore has user 1 and keeps the mail sent to them.
+type mailStore struct {
+	repo.UserRepo
+	sent []mailer.Message
+}
+
+func (s *mailStore) GetUserByID(_ context.Context, id int) (entity.User, error) {
+	return entity.User{ID: id, Email: "patient@example.com"}, nil
+}
+
+func (s *mailStore) GetUserByEmail(_ context.Context, email string) (entity.User, error) {
+	return entity.User{ID: 1, Email: email}, nil
+}
+
+func (s *mailStore) CreateUserToken(context.Context, entity.UserToken) error {
+	return nil
+}
+
+func (s *mailStore) Send(_ context.Context, msg mailer.Message) error {
+	s.sent = append(s.sent, msg)
+
+	return nil
+}
+
+func TestMailedLinks(t *testing.T) {
+	s := &mailStore{}
+	uc := NewUseCase(s, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		Mailer(s), LinkBaseURL("https://api.clinic.example.com"),
+		PasswordResetURL("https://clinic.example.com/reset-password"))
+	ctx := context.Background()
+
+	require.NoError(t, uc.SendEmailVerification(ctx, 1))
+	require.NoError(t, uc.RequestPasswordReset(ctx, "patient@example.com"))
+	require.Len(t, s.sent, 2)
+
+	assert.Contains(t, s.sent[0].Body, "https://api.clinic.example.com/v1/auth/verify-email?token=",
+		"the verification link opens the API route")
+	assert.Contains(t, s.sent[1].Body, "https://clinic.example.com/reset-password?token=",
+		"the reset link opens the page to choose a password on")
+}
+
+// deactivationStore records deactivations and revoked sessions in memory.
+type deactivationStore struct {
+	repo.UserRepo
+	repo.SessionRepo
+	deactivated map[int]bool
+	revoked     map[int]string
+}
+
+func (s *deactivationStore) DeactivateUser(_ context.Context, id int, _ time.Time) error {
+	if id > 10 {
+		return entity.ErrUserNotFound
+	}
+
+	s.deactivated[id] = true
+
+	return nil
+}
+
+func (s *deactivationStore) ReactivateUser(_ context.Context, id int, _ time.Time) error {
+	delete(s.deactivated, id)
+
+	return nil
+}
+
+func (s *deactivationStore) RevokeUserSessions(_ context.Context, userID int, reason string, _ time.Time) (int, error) {
+	s.revoked[userID] = reason
+
+	return 1, nil
+}
+
+func TestDeactivateUser(t *testing.T) {
+	s := &deactivationStore{deactivated: map[int]bool{}, revoked: map[int]string{}}
+	uc := NewUseCase(s, nil, nil, nil, nil, nil, s, nil, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	require.NoError(t, uc.DeactivateUser(ctx, 2, 1))
+	assert.True(t, s.deactivated[2])
+	assert.Equal(t, entity.RevokedDeactivated, s.revoked[2], "deactivation signs the user out everywhere")
+
+	assert.ErrorIs(t, uc.DeactivateUser(ctx, 1, 1), entity.ErrDeactivateSelf)
+	assert.ErrorIs(t, uc.DeactivateUser(ctx, 11, 1), entity.ErrUserNotFound)
+	assert.False(t, s.deactivated[1])
+
+	require.NoError(t, uc.ReactivateUser(ctx, 2, 1))
+	assert.False(t, s.deactivated[2])
+}
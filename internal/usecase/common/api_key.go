@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+)
+
+// _apiKeyPrefixLength is how much of a key is kept to tell it apart, past entity.APIKeyPrefix.
+const _apiKeyPrefixLength = 8
+
+// CreateAPIKey creates a key with the name, permissions and expiry of key and returns it with the
+// key itself, which isn't stored and can't be shown again. The creator can only grant
+// permissions their own role has.
+func (uc *UseCase) CreateAPIKey(ctx context.Context, key entity.APIKey, createdBy int) (entity.APIKey, string, error) {
+	now := time.Now()
+
+	err := key.Validate(now)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+
+	_, permissions, err := uc.roleRepo.GetUserAccess(ctx, createdBy)
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("UseCase - CreateAPIKey - uc.roleRepo.GetUserAccess: %w", err)
+	}
+
+	for _, permission := range key.Permissions {
+		if !slices.Contains(permissions, permission) {
+			return entity.APIKey{}, "", fmt.Errorf("%w: you can't grant %s", entity.ErrInvalidAPIKey, permission)
+		}
+	}
+
+	secret, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("UseCase - CreateAPIKey - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	plain := entity.APIKeyPrefix + secret
+
+	key.Name = strings.TrimSpace(key.Name)
+	key.Prefix = plain[:len(entity.APIKeyPrefix)+_apiKeyPrefixLength]
+	key.CreatedBy = &createdBy
+
+	key, err = uc.apiKeyRepo.CreateAPIKey(ctx, key, tokens.HashOpaqueToken(plain))
+	if err != nil {
+		if errors.Is(err, entity.ErrUnknownPermission) {
+			return entity.APIKey{}, "", err
+		}
+
+		return entity.APIKey{}, "", fmt.Errorf("UseCase - CreateAPIKey - uc.apiKeyRepo.CreateAPIKey: %w", err)
+	}
+
+	uc.l.Info("api key %d (%s) created by user %d with %v, expires %s",
+		key.ID, key.Prefix, createdBy, key.Permissions, key.ExpiresAt.Format(time.RFC3339))
+
+	return key, plain, nil
+}
+
+// ListAPIKeys -.
+func (uc *UseCase) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	return uc.apiKeyRepo.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey stops the key from working. It is kept, so its use can still be traced.
+func (uc *UseCase) RevokeAPIKey(ctx context.Context, id, by int) error {
+	key, err := uc.apiKeyRepo.RevokeAPIKey(ctx, id, time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrAPIKeyNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - RevokeAPIKey - uc.apiKeyRepo.RevokeAPIKey: %w", err)
+	}
+
+	uc.l.Info("api key %d (%s) revoked by user %d", key.ID, key.Prefix, by)
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the active key a request presented and records its use, or
+// entity.ErrAPIKeyRejected.
+func (uc *UseCase) AuthenticateAPIKey(ctx context.Context, key string) (entity.APIKey, error) {
+	if !strings.HasPrefix(key, entity.APIKeyPrefix) {
+		return entity.APIKey{}, entity.ErrAPIKeyRejected
+	}
+
+	apiKey, err := uc.apiKeyRepo.UseAPIKey(ctx, tokens.HashOpaqueToken(key), time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrAPIKeyRejected) {
+			return entity.APIKey{}, err
+		}
+
+		return entity.APIKey{}, fmt.Errorf("UseCase - AuthenticateAPIKey - uc.apiKeyRepo.UseAPIKey: %w", err)
+	}
+
+	return apiKey, nil
+}
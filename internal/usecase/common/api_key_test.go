@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiKeyStore keeps API keys in memory by their hash.
+type apiKeyStore struct {
+	repo.APIKeyRepo
+	keys map[string]entity.APIKey
+}
+
+func (s *apiKeyStore) CreateAPIKey(_ context.Context, key entity.APIKey, keyHash string) (entity.APIKey, error) {
+	key.ID = len(s.keys) + 1
+	s.keys[keyHash] = key
+
+	return key, nil
+}
+
+func (s *apiKeyStore) UseAPIKey(_ context.Context, keyHash string, now time.Time) (entity.APIKey, error) {
+	key, ok := s.keys[keyHash]
+	if !ok || !key.Active(now) {
+		return entity.APIKey{}, entity.ErrAPIKeyRejected
+	}
+
+	key.LastUsedAt = &now
+	s.keys[keyHash] = key
+
+	return key, nil
+}
+
+// apiKeyRoleRepo gives every user the receptionist's booking permissions.
+type apiKeyRoleRepo struct {
+	repo.RoleRepo
+}
+
+func (apiKeyRoleRepo) GetUserAccess(context.Context, int) (entity.Role, []entity.Permission, error) {
+	return entity.RoleReceptionist, []entity.Permission{entity.PermAppointmentReadAny, entity.PermAppointmentWriteAny}, nil
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	store := &apiKeyStore{keys: map[string]entity.APIKey{}}
+	uc := NewUseCase(nil, nil, nil, nil, nil, apiKeyRoleRepo{}, nil, nil, nil, nil, store, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	key, secret, err := uc.CreateAPIKey(ctx, entity.APIKey{
+		Name:        " Lab system ",
+		Permissions: []entity.Permission{entity.PermAppointmentWriteAny},
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}, 4)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Lab system", key.Name)
+	assert.Equal(t, 4, *key.CreatedBy)
+	assert.Len(t, key.Prefix, len(entity.APIKeyPrefix)+_apiKeyPrefixLength)
+	assert.True(t, strings.HasPrefix(secret, key.Prefix))
+	assert.NotContains(t, store.keys, secret, "only the hash is stored")
+
+	used, err := uc.AuthenticateAPIKey(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, used.ID)
+	assert.NotNil(t, used.LastUsedAt)
+
+	_, err = uc.AuthenticateAPIKey(ctx, secret+"x")
+	assert.ErrorIs(t, err, entity.ErrAPIKeyRejected)
+
+	_, _, err = uc.CreateAPIKey(ctx, entity.APIKey{
+		Name:        "Too much",
+		Permissions: []entity.Permission{entity.PermAppointmentWriteAny, entity.PermRoleManage},
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}, 4)
+	assert.ErrorIs(t, err, entity.ErrInvalidAPIKey, "a key can't have permissions its creator lacks")
+}
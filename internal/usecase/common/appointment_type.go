@@ -0,0 +1,121 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// CreateAppointmentType adds a type for a doctor or for every doctor of a specialization.
+func (uc *UseCase) CreateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error) {
+	appointmentType = normalizeAppointmentType(appointmentType)
+
+	err := appointmentType.Validate()
+	if err != nil {
+		return entity.AppointmentType{}, err
+	}
+
+	created, err := uc.appointmentTypeRepo.CreateAppointmentType(ctx, appointmentType)
+	if err != nil {
+		if isAppointmentTypeError(err) {
+			return entity.AppointmentType{}, err
+		}
+
+		return entity.AppointmentType{}, fmt.Errorf("UseCase - CreateAppointmentType - uc.appointmentTypeRepo.CreateAppointmentType: %w", err)
+	}
+
+	uc.l.Info("appointment type %d created", created.ID)
+
+	return created, nil
+}
+
+// GetAppointmentType -.
+func (uc *UseCase) GetAppointmentType(ctx context.Context, id int) (entity.AppointmentType, error) {
+	return uc.appointmentTypeRepo.GetAppointmentType(ctx, id)
+}
+
+// ListAppointmentTypes -.
+func (uc *UseCase) ListAppointmentTypes(ctx context.Context) ([]entity.AppointmentType, error) {
+	return uc.appointmentTypeRepo.ListAppointmentTypes(ctx)
+}
+
+// GetAppointmentTypesByDoctorID returns the types the doctor can be booked with: their own and
+// those of the specializations they hold.
+func (uc *UseCase) GetAppointmentTypesByDoctorID(ctx context.Context, doctorID int) ([]entity.AppointmentType, error) {
+	return uc.appointmentTypeRepo.GetAppointmentTypesByDoctorID(ctx, doctorID)
+}
+
+// UpdateAppointmentType replaces the type. Appointments already booked keep the duration, buffers
+// and capacity they were booked with.
+func (uc *UseCase) UpdateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error) {
+	appointmentType = normalizeAppointmentType(appointmentType)
+
+	err := appointmentType.Validate()
+	if err != nil {
+		return entity.AppointmentType{}, err
+	}
+
+	updated, err := uc.appointmentTypeRepo.UpdateAppointmentType(ctx, appointmentType)
+	if err != nil {
+		if isAppointmentTypeError(err) {
+			return entity.AppointmentType{}, err
+		}
+
+		return entity.AppointmentType{}, fmt.Errorf("UseCase - UpdateAppointmentType - uc.appointmentTypeRepo.UpdateAppointmentType: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteAppointmentType removes the type. One that active appointments are booked with is kept.
+func (uc *UseCase) DeleteAppointmentType(ctx context.Context, id int) error {
+	err := uc.appointmentTypeRepo.DeleteAppointmentType(ctx, id)
+	if err != nil {
+		if isAppointmentTypeError(err) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeleteAppointmentType - uc.appointmentTypeRepo.DeleteAppointmentType: %w", err)
+	}
+
+	uc.l.Info("appointment type %d deleted", id)
+
+	return nil
+}
+
+// appointmentTypeFor loads the type an appointment with the doctor is booked with. It returns
+// entity.ErrTypeNotOffered if the doctor can't be booked with it.
+func (uc *UseCase) appointmentTypeFor(ctx context.Context, doctor entity.Doctor, id int) (entity.AppointmentType, error) {
+	appointmentType, err := uc.appointmentTypeRepo.GetAppointmentType(ctx, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrAppointmentTypeNotFound) {
+			return entity.AppointmentType{}, err
+		}
+
+		return entity.AppointmentType{}, fmt.Errorf("UseCase - appointmentTypeFor - uc.appointmentTypeRepo.GetAppointmentType: %w", err)
+	}
+
+	if !appointmentType.OfferedBy(doctor) {
+		return entity.AppointmentType{}, entity.ErrTypeNotOffered
+	}
+
+	return appointmentType, nil
+}
+
+func isAppointmentTypeError(err error) bool {
+	return errors.Is(err, entity.ErrAppointmentTypeNotFound) || errors.Is(err, entity.ErrAppointmentTypeInUse) ||
+		errors.Is(err, entity.ErrDoctorNotFound) || errors.Is(err, entity.ErrSpecializationNotFound)
+}
+
+func normalizeAppointmentType(appointmentType entity.AppointmentType) entity.AppointmentType {
+	appointmentType.Name = strings.TrimSpace(appointmentType.Name)
+
+	if appointmentType.Capacity == 0 {
+		appointmentType.Capacity = 1
+	}
+
+	return appointmentType
+}
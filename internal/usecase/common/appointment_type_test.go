@@ -0,0 +1,119 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appointmentTypeStore has doctor 5, a cardiologist, with a consultation of their own (type 1).
+// Type 2 is for every cardiologist and type 3 for every dermatologist. Appointment 10 is booked
+// with type 1, appointment 11 before types existed.
+type appointmentTypeStore struct {
+	repo.DoctorRepo
+	repo.AppointmentRepo
+	repo.AppointmentTypeRepo
+}
+
+func (appointmentTypeStore) GetDoctorByID(_ context.Context, id int) (entity.Doctor, error) {
+	doctor := entity.Doctor{ID: id, TimeZone: "UTC"}
+	if id == 5 {
+		doctor.Specializations = []entity.Specialization{_catalogue["cardiology"]}
+	}
+
+	return doctor, nil
+}
+
+func (appointmentTypeStore) GetAppointmentType(_ context.Context, id int) (entity.AppointmentType, error) {
+	doctorID, cardiology, dermatology := 5, 1, 2
+
+	switch id {
+	case 1:
+		return entity.AppointmentType{ID: id, Name: "Consultation", DoctorID: &doctorID, Duration: 40, Capacity: 1}, nil
+	case 2:
+		return entity.AppointmentType{ID: id, Name: "ECG", SpecializationID: &cardiology, Duration: 15, Capacity: 1}, nil
+	case 3:
+		return entity.AppointmentType{ID: id, Name: "Skin check", SpecializationID: &dermatology, Duration: 20, Capacity: 1}, nil
+	}
+
+	return entity.AppointmentType{}, entity.ErrAppointmentTypeNotFound
+}
+
+func (appointmentTypeStore) GetAppointmentByID(_ context.Context, id int) (entity.Appointment, error) {
+	appointment := entity.Appointment{
+		ID: id, DoctorID: 5, AppointmentTime: time.Now().Add(24 * time.Hour), Duration: 40, Status: entity.StatusScheduled,
+	}
+
+	if id == 10 {
+		consultation := 1
+		appointment.AppointmentTypeID = &consultation
+	}
+
+	return appointment, nil
+}
+
+func TestCreateAppointmentType(t *testing.T) {
+	store := appointmentTypeStore{}
+	uc := NewUseCase(nil, store, store, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, store, nil)
+	ctx := context.Background()
+
+	_, err := uc.CreateAppointment(ctx, entity.Appointment{DoctorID: 5, PatientID: 1})
+	assert.ErrorIs(t, err, entity.ErrAppointmentTypeRequired)
+
+	for id, want := range map[int]error{3: entity.ErrTypeNotOffered, 4: entity.ErrAppointmentTypeNotFound} {
+		_, err = uc.CreateAppointment(ctx, entity.Appointment{DoctorID: 5, PatientID: 1, AppointmentTypeID: &id})
+		assert.ErrorIs(t, err, want)
+	}
+}
+
+func TestAppointmentTypeFor(t *testing.T) {
+	store := appointmentTypeStore{}
+	uc := NewUseCase(nil, store, store, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, store, nil)
+	ctx := context.Background()
+
+	doctor, err := store.GetDoctorByID(ctx, 5)
+	require.NoError(t, err)
+
+	for _, id := range []int{1, 2} {
+		appointmentType, err := uc.appointmentTypeFor(ctx, doctor, id)
+		require.NoError(t, err)
+		assert.Equal(t, id, appointmentType.ID)
+	}
+
+	_, err = uc.appointmentTypeFor(ctx, doctor, 3)
+	assert.ErrorIs(t, err, entity.ErrTypeNotOffered)
+
+	other, err := store.GetDoctorByID(ctx, 6)
+	require.NoError(t, err)
+
+	_, err = uc.appointmentTypeFor(ctx, other, 1)
+	assert.ErrorIs(t, err, entity.ErrTypeNotOffered)
+}
+
+func TestRescheduleTypedAppointment(t *testing.T) {
+	store := appointmentTypeStore{}
+	uc := NewUseCase(nil, store, store, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, store, nil)
+	ctx := context.Background()
+
+	later := time.Now().Add(48 * time.Hour)
+
+	_, err := uc.RescheduleAppointment(ctx, entity.AppointmentReschedule{AppointmentID: 10, AppointmentTime: later, Duration: 20})
+	assert.ErrorIs(t, err, entity.ErrDurationSetByType)
+
+	// Doctor 6 doesn't offer doctor 5's own consultation.
+	_, err = uc.RescheduleAppointment(ctx, entity.AppointmentReschedule{AppointmentID: 10, AppointmentTime: later, DoctorID: 6})
+	assert.ErrorIs(t, err, entity.ErrTypeNotOffered)
+
+	doctor, err := store.GetDoctorByID(ctx, 6)
+	require.NoError(t, err)
+
+	untyped, err := store.GetAppointmentByID(ctx, 11)
+	require.NoError(t, err)
+
+	assert.NoError(t, uc.checkTypedMove(ctx, doctor, untyped, 20))
+}
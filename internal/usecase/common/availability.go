@@ -0,0 +1,208 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// _maxAvailabilityRange caps how far a single availability request may look ahead.
+const _maxAvailabilityRange = 31 * 24 * time.Hour
+
+// GetAvailability returns the free slots of the given length in the doctor's schedule within [from, to),
+// honouring holidays and schedule exceptions. With a locationID only the slots on days the doctor
+// works at the location are returned.
+func (uc *UseCase) GetAvailability(ctx context.Context, doctorID int, from, to time.Time, duration time.Duration, locationID *int) ([]entity.Slot, error) {
+	if duration <= 0 {
+		return nil, entity.ErrInvalidTimeRange
+	}
+
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, doctorID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - GetAvailability - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	return uc.availability(ctx, doctor, from, to, entity.Appointment{DoctorID: doctorID, Duration: int(duration / time.Minute)}, locationID)
+}
+
+// GetAppointmentTypeAvailability returns the slots within [from, to) the doctor can still be booked
+// in with the appointment type: its buffers keep slots apart and a slot several patients share is
+// offered until it is full. A locationID narrows the slots down like in GetAvailability.
+func (uc *UseCase) GetAppointmentTypeAvailability(ctx context.Context, doctorID, appointmentTypeID int, from, to time.Time, locationID *int) ([]entity.Slot, error) {
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, doctorID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - GetAppointmentTypeAvailability - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	appointmentType, err := uc.appointmentTypeFor(ctx, doctor, appointmentTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.availability(ctx, doctor, from, to, appointmentType.Apply(entity.Appointment{DoctorID: doctorID}), locationID)
+}
+
+// availability returns the free slots within [from, to) for appointments like candidate, at the
+// location if one is given.
+func (uc *UseCase) availability(ctx context.Context, doctor entity.Doctor, from, to time.Time, candidate entity.Appointment, locationID *int) ([]entity.Slot, error) {
+	if candidate.Duration <= 0 || !from.Before(to) || to.Sub(from) > _maxAvailabilityRange {
+		return nil, entity.ErrInvalidTimeRange
+	}
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - availability - doctor.Location: %w", err)
+	}
+
+	if now := time.Now().In(from.Location()); from.Before(now) {
+		from = now
+	}
+
+	windows, _, err := uc.effectiveWindows(ctx, doctor, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - availability - uc.effectiveWindows: %w", err)
+	}
+
+	// Buffers of slots at the edges of the range reach out of it.
+	bookedFrom, bookedTo := from.Add(-time.Duration(candidate.BufferBefore)*time.Minute), to.Add(time.Duration(candidate.BufferAfter)*time.Minute)
+
+	booked, err := uc.appointmentRepo.GetBookedAppointmentsInRange(ctx, doctor.ID, bookedFrom, bookedTo)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - availability - uc.appointmentRepo.GetBookedAppointmentsInRange: %w", err)
+	}
+
+	if rooms := doctor.Schedule.Rooms(); len(rooms) > 0 {
+		inRooms, err := uc.appointmentRepo.GetBookedRoomsInRange(ctx, rooms, doctor.ID, bookedFrom, bookedTo)
+		if err != nil {
+			return nil, fmt.Errorf("UseCase - availability - uc.appointmentRepo.GetBookedRoomsInRange: %w", err)
+		}
+
+		booked = append(booked, inRooms...)
+	}
+
+	slots := freeSlots(windows, booked, candidate, doctor.Schedule, loc, from, to)
+
+	if locationID != nil {
+		slots = atLocation(slots, doctor.Schedule, loc, *locationID)
+	}
+
+	return slots, nil
+}
+
+// atLocation keeps the slots on days the schedule has the doctor working at the location, in loc.
+func atLocation(slots []entity.Slot, schedule entity.Schedule, loc *time.Location, locationID int) []entity.Slot {
+	return slices.DeleteFunc(slots, func(slot entity.Slot) bool {
+		placed := schedule.Place(entity.Appointment{AppointmentTime: slot.Start}, loc)
+
+		return placed.LocationID == nil || *placed.LocationID != locationID
+	})
+}
+
+// workingWindows expands a weekly schedule into the concrete working periods of every day touching [from, to).
+// Breaks are cut out, so a day with a lunch break yields two windows.
+func workingWindows(schedule entity.Schedule, from, to time.Time) ([]entity.Slot, error) {
+	days := make(map[time.Weekday][][2]time.Duration, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		weekday, err := entity.ParseWeekday(rule.Weekday)
+		if err != nil {
+			return nil, err
+		}
+
+		windows, err := rule.Windows()
+		if err != nil {
+			return nil, err
+		}
+
+		days[weekday] = append(days[weekday], windows...)
+	}
+
+	loc := from.Location()
+
+	var windows []entity.Slot
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, window := range days[day.Weekday()] {
+			windows = append(windows, entity.Slot{
+				Start: atClock(day, window[0]),
+				End:   atClock(day, window[1]),
+			})
+		}
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+
+	return windows, nil
+}
+
+// atClock returns the wall-clock time offset after midnight of day, in day's location.
+func atClock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// freeSlots cuts windows into slots for appointments like candidate, aligned to the start of each
+// window and spaced by its length and buffers, and keeps those inside [from, to) whose time, buffers
+// included, doesn't overlap a booked appointment. A slot is only offered when the appointment ends at
+// or before the end of its window; its buffers may reach outside. Appointments of a type several
+// patients share may join those already booked at the same time until the slot is full. Booked
+// appointments of other doctors only take slots in the room the schedule puts the slot in, in loc.
+func freeSlots(windows []entity.Slot, booked []entity.Appointment, candidate entity.Appointment, schedule entity.Schedule, loc *time.Location, from, to time.Time) []entity.Slot {
+	slots := make([]entity.Slot, 0)
+
+	duration := time.Duration(candidate.Duration) * time.Minute
+	step := time.Duration(candidate.BufferBefore+candidate.Duration+candidate.BufferAfter) * time.Minute
+	capacity := max(candidate.Capacity, 1)
+
+	for _, window := range windows {
+		for start := window.Start; !start.Add(duration).After(window.End); start = start.Add(step) {
+			slot := entity.Slot{Start: start, End: start.Add(duration)}
+			if slot.Start.Before(from) || slot.End.After(to) {
+				continue
+			}
+
+			candidate.AppointmentTime = start
+			candidate = schedule.Place(candidate, loc)
+			block := candidate.Block()
+
+			free, remaining := true, capacity
+			for _, appointment := range booked {
+				other := appointment.Block()
+				if !block.Overlaps(other.Start, other.End) {
+					continue
+				}
+
+				if appointment.DoctorID != candidate.DoctorID {
+					if candidate.SharesRoomWith(appointment) {
+						free = false
+
+						break
+					}
+
+					continue
+				}
+
+				if !candidate.SharesSlotWith(appointment) {
+					free = false
+
+					break
+				}
+
+				remaining--
+			}
+
+			if !free || remaining <= 0 {
+				continue
+			}
+
+			if capacity > 1 {
+				slot.Remaining = remaining
+			}
+
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots
+}
@@ -0,0 +1,344 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeSlots(t *testing.T) {
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "mon", Ranges: []entity.TimeRange{{Start: "09:00", End: "11:00"}}},
+		{Weekday: "wed", Ranges: []entity.TimeRange{{Start: "09:00", End: "11:00"}}},
+	}}
+
+	// 2026-03-02 is a Monday.
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 3)
+
+	windows, err := workingWindows(schedule, from, to)
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		booked   []entity.Appointment
+		want     []string
+	}{
+		{
+			name:     "last slot ends exactly at the end of the day",
+			duration: 30 * time.Minute,
+			want: []string{
+				"2026-03-02T09:00", "2026-03-02T09:30", "2026-03-02T10:00", "2026-03-02T10:30",
+				"2026-03-04T09:00", "2026-03-04T09:30", "2026-03-04T10:00", "2026-03-04T10:30",
+			},
+		},
+		{
+			name:     "slot running past the end of the day is dropped",
+			duration: 45 * time.Minute,
+			want: []string{
+				"2026-03-02T09:00", "2026-03-02T09:45",
+				"2026-03-04T09:00", "2026-03-04T09:45",
+			},
+		},
+		{
+			name:     "booked appointments remove overlapping slots",
+			duration: 30 * time.Minute,
+			booked: []entity.Appointment{
+				{AppointmentTime: time.Date(2026, 3, 2, 9, 15, 0, 0, time.UTC), Duration: 30},
+				{AppointmentTime: time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC), Duration: 30},
+			},
+			want: []string{
+				"2026-03-02T10:00", "2026-03-02T10:30",
+				"2026-03-04T09:00", "2026-03-04T09:30", "2026-03-04T10:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := freeSlots(windows, tt.booked, entity.Appointment{Duration: int(tt.duration / time.Minute)}, schedule, time.UTC, from, to)
+
+			got := make([]string, 0, len(slots))
+			for _, slot := range slots {
+				assert.Equal(t, tt.duration, slot.End.Sub(slot.Start))
+				got = append(got, slot.Start.Format("2006-01-02T15:04"))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFreeSlotsRespectsRange(t *testing.T) {
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "monday", Ranges: []entity.TimeRange{{Start: "09:00", End: "12:00"}}},
+	}}
+
+	from := time.Date(2026, 3, 2, 10, 10, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 2, 11, 30, 0, 0, time.UTC)
+
+	windows, err := workingWindows(schedule, from, to)
+	require.NoError(t, err)
+
+	slots := freeSlots(windows, nil, entity.Appointment{Duration: 30}, schedule, time.UTC, from, to)
+	require.Len(t, slots, 2)
+	assert.Equal(t, time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC), slots[0].Start)
+	assert.Equal(t, time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC), slots[1].Start)
+}
+
+func TestFreeSlotsBuffersAndCapacity(t *testing.T) {
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "mon", Ranges: []entity.TimeRange{{Start: "09:00", End: "10:00"}}},
+	}}
+
+	// 2026-03-02 is a Monday.
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	windows, err := workingWindows(schedule, from, to)
+	require.NoError(t, err)
+
+	consultation, vaccination, checkup := 1, 2, 3
+	at := func(hour, minute int) time.Time { return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		candidate entity.Appointment
+		booked    []entity.Appointment
+		want      []string
+	}{
+		{
+			name:      "buffers space the slots and may reach outside the window",
+			candidate: entity.Appointment{AppointmentTypeID: &consultation, Duration: 20, BufferBefore: 5, BufferAfter: 5, Capacity: 1},
+			want:      []string{"09:00 1", "09:30 1"},
+		},
+		{
+			name:      "a booked appointment's buffer blocks the slot next to it",
+			candidate: entity.Appointment{AppointmentTypeID: &consultation, Duration: 20, Capacity: 1},
+			booked: []entity.Appointment{
+				{AppointmentTypeID: &checkup, AppointmentTime: at(9, 20), Duration: 15, BufferAfter: 10, Capacity: 1},
+			},
+			want: []string{"09:00 1"},
+		},
+		{
+			name:      "a shared slot is offered until it is full",
+			candidate: entity.Appointment{AppointmentTypeID: &vaccination, Duration: 20, Capacity: 3},
+			booked: []entity.Appointment{
+				{AppointmentTypeID: &vaccination, AppointmentTime: at(9, 0), Duration: 20, Capacity: 3},
+				{AppointmentTypeID: &vaccination, AppointmentTime: at(9, 20), Duration: 20, Capacity: 3},
+				{AppointmentTypeID: &vaccination, AppointmentTime: at(9, 20), Duration: 20, Capacity: 3},
+				{AppointmentTypeID: &vaccination, AppointmentTime: at(9, 20), Duration: 20, Capacity: 3},
+			},
+			want: []string{"09:00 2", "09:40 3"},
+		},
+		{
+			name:      "another type doesn't share the slot",
+			candidate: entity.Appointment{AppointmentTypeID: &vaccination, Duration: 20, Capacity: 3},
+			booked: []entity.Appointment{
+				{AppointmentTypeID: &consultation, AppointmentTime: at(9, 0), Duration: 20, Capacity: 1},
+			},
+			want: []string{"09:20 3", "09:40 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := freeSlots(windows, tt.booked, tt.candidate, schedule, time.UTC, from, to)
+
+			got := make([]string, 0, len(slots))
+			for _, slot := range slots {
+				remaining := slot.Remaining
+				if remaining == 0 {
+					remaining = 1
+				}
+
+				got = append(got, fmt.Sprintf("%s %d", slot.Start.Format("15:04"), remaining))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFreeSlotsSharedRoom(t *testing.T) {
+	downtown, uptown, room := 1, 2, 7
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "mon", Ranges: []entity.TimeRange{{Start: "09:00", End: "10:00"}}, LocationID: &downtown, RoomID: &room},
+		{Weekday: "tue", Ranges: []entity.TimeRange{{Start: "09:00", End: "10:00"}}, LocationID: &uptown},
+	}}
+
+	// 2026-03-02 is a Monday.
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	windows, err := workingWindows(schedule, from, to)
+	require.NoError(t, err)
+
+	// Doctor 6 has the room on Monday at 9:00 and an appointment elsewhere at 9:30; Tuesday's
+	// slots aren't in the room at all.
+	booked := []entity.Appointment{
+		{DoctorID: 6, AppointmentTime: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), Duration: 30, RoomID: &room},
+		{DoctorID: 6, AppointmentTime: time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC), Duration: 30},
+		{DoctorID: 6, AppointmentTime: time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), Duration: 30, RoomID: &room},
+	}
+
+	slots := freeSlots(windows, booked, entity.Appointment{DoctorID: 5, Duration: 30}, schedule, time.UTC, from, to)
+
+	got := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		got = append(got, slot.Start.Format("2006-01-02T15:04"))
+	}
+
+	assert.Equal(t, []string{"2026-03-02T09:30", "2026-03-03T09:00", "2026-03-03T09:30"}, got)
+
+	uptownSlots := atLocation(slots, schedule, time.UTC, uptown)
+	require.Len(t, uptownSlots, 2)
+	assert.Equal(t, time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), uptownSlots[0].Start)
+}
+
+func TestWorkingWindowsShiftsAndBreaks(t *testing.T) {
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{
+			Weekday: "mon",
+			Ranges:  []entity.TimeRange{{Start: "14:00", End: "18:00"}, {Start: "09:00", End: "13:00"}},
+			Breaks:  []entity.TimeRange{{Start: "15:30", End: "16:00"}},
+		},
+		{Weekday: "fri", Ranges: []entity.TimeRange{{Start: "08:00", End: "12:00"}}},
+	}}
+
+	// 2026-03-02 is a Monday.
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	windows, err := workingWindows(schedule, from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(windows))
+	for _, window := range windows {
+		got = append(got, window.Start.Format("Mon 15:04")+"-"+window.End.Format("15:04"))
+	}
+
+	assert.Equal(t, []string{"Mon 09:00-13:00", "Mon 14:00-15:30", "Mon 16:00-18:00", "Fri 08:00-12:00"}, got)
+}
+
+func TestWorkingWindowsInvalidSchedule(t *testing.T) {
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	_, err := workingWindows(entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "Funday", Ranges: []entity.TimeRange{{Start: "09:00", End: "17:00"}}},
+	}}, from, from.AddDate(0, 0, 1))
+	assert.ErrorIs(t, err, entity.ErrInvalidSchedule)
+
+	_, err = workingWindows(entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "mon", Ranges: []entity.TimeRange{{Start: "17:00", End: "09:00"}}},
+	}}, from, from.AddDate(0, 0, 1))
+	assert.ErrorIs(t, err, entity.ErrInvalidSchedule)
+}
+
+func TestWorkingWindowsAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	daily := func(start, end string) entity.Schedule {
+		var rules []entity.ScheduleRule
+		for _, weekday := range []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"} {
+			rules = append(rules, entity.ScheduleRule{Weekday: weekday, Ranges: []entity.TimeRange{{Start: start, End: end}}})
+		}
+
+		return entity.Schedule{Rules: rules}
+	}
+
+	tests := []struct {
+		name     string
+		schedule entity.Schedule
+		from     time.Time
+		days     int
+		want     []string // UTC
+		lengths  []time.Duration
+	}{
+		{
+			// Clocks spring forward on 2026-03-08 at 02:00 in New York.
+			name:     "spring forward keeps local hours",
+			schedule: daily("09:00", "17:00"),
+			from:     time.Date(2026, 3, 7, 0, 0, 0, 0, newYork),
+			days:     2,
+			want:     []string{"03-07 14:00", "03-08 13:00"},
+			lengths:  []time.Duration{8 * time.Hour, 8 * time.Hour},
+		},
+		{
+			// Clocks fall back on 2026-10-25 at 03:00 in Berlin.
+			name:     "fall back keeps local hours",
+			schedule: daily("09:00", "17:00"),
+			from:     time.Date(2026, 10, 24, 0, 0, 0, 0, berlin),
+			days:     2,
+			want:     []string{"10-24 07:00", "10-25 08:00"},
+			lengths:  []time.Duration{8 * time.Hour, 8 * time.Hour},
+		},
+		{
+			name:     "night shift over the spring forward gap is an hour shorter",
+			schedule: daily("00:00", "06:00"),
+			from:     time.Date(2026, 3, 8, 0, 0, 0, 0, newYork),
+			days:     1,
+			want:     []string{"03-08 05:00"},
+			lengths:  []time.Duration{5 * time.Hour},
+		},
+		{
+			name:     "night shift over the fall back overlap is an hour longer",
+			schedule: daily("00:00", "06:00"),
+			from:     time.Date(2026, 10, 25, 0, 0, 0, 0, berlin),
+			days:     1,
+			want:     []string{"10-24 22:00"},
+			lengths:  []time.Duration{7 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := workingWindows(tt.schedule, tt.from, tt.from.AddDate(0, 0, tt.days))
+			require.NoError(t, err)
+
+			got := make([]string, 0, len(windows))
+			lengths := make([]time.Duration, 0, len(windows))
+			for _, window := range windows {
+				got = append(got, window.Start.UTC().Format("01-02 15:04"))
+				lengths = append(lengths, window.End.Sub(window.Start))
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.lengths, lengths)
+		})
+	}
+}
+
+func TestFreeSlotsOnDSTDay(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "sun", Ranges: []entity.TimeRange{{Start: "01:00", End: "04:00"}}},
+	}}
+
+	// 02:00-03:00 doesn't exist on 2026-03-08, so the window holds two hours.
+	from := time.Date(2026, 3, 8, 0, 0, 0, 0, newYork)
+	to := from.AddDate(0, 0, 1)
+
+	windows, err := workingWindows(schedule, from, to)
+	require.NoError(t, err)
+
+	slots := freeSlots(windows, nil, entity.Appointment{Duration: 60}, schedule, newYork, from, to)
+
+	got := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		got = append(got, slot.Start.In(newYork).Format("15:04 -07:00"))
+	}
+
+	assert.Equal(t, []string{"01:00 -05:00", "03:00 -04:00"}, got)
+}
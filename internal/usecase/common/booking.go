@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// checkBooking validates the appointment against the doctor's schedule, holidays and exceptions
+// on its day in the doctor's time zone.
+func (uc *UseCase) checkBooking(ctx context.Context, doctor entity.Doctor, appointment entity.Appointment) error {
+	loc, err := doctor.Location()
+	if err != nil {
+		return fmt.Errorf("UseCase - checkBooking - doctor.Location: %w", err)
+	}
+
+	start := appointment.AppointmentTime.In(loc)
+	dayStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	windows, blocked, err := uc.effectiveWindows(ctx, doctor, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	return validateBooking(windows, blocked, appointment, time.Now())
+}
+
+// validateBooking checks that the appointment lies in the future and fits in one of the working windows
+// of its day. blocked holds the holidays and time off of that day and only explains why a booking doesn't fit,
+// so extra hours on a holiday stay bookable.
+func validateBooking(windows, blocked []entity.Slot, appointment entity.Appointment, now time.Time) error {
+	if appointment.Duration <= 0 {
+		return entity.ErrInvalidDuration
+	}
+
+	start, end := appointment.AppointmentTime, appointment.End()
+	if start.Before(now) {
+		return entity.ErrBookingInPast
+	}
+
+	var exceedsShift bool
+	for _, window := range windows {
+		if start.Before(window.Start) || !start.Before(window.End) {
+			continue
+		}
+
+		if !end.After(window.End) {
+			return nil
+		}
+
+		exceedsShift = true
+	}
+
+	for _, period := range blocked {
+		if period.Overlaps(start, end) {
+			return entity.ErrDoctorUnavailable
+		}
+	}
+
+	switch {
+	case exceedsShift:
+		return entity.ErrExceedsShiftEnd
+	case len(windows) == 0:
+		return entity.ErrOutsideWorkingDays
+	default:
+		return entity.ErrOutsideWorkingHours
+	}
+}
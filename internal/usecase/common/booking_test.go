@@ -0,0 +1,119 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBooking(t *testing.T) {
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{
+			Weekday: "mon",
+			Ranges:  []entity.TimeRange{{Start: "09:00", End: "17:00"}},
+			Breaks:  []entity.TimeRange{{Start: "13:00", End: "14:00"}},
+		},
+		{Weekday: "tue", Ranges: []entity.TimeRange{{Start: "09:00", End: "17:00"}}},
+	}}
+
+	// 2026-03-02 is a Monday, 2026-03-04 a Wednesday.
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		duration int
+		want     error
+	}{
+		{name: "inside working hours", start: at(2, 9, 0), duration: 30},
+		{name: "ends exactly at shift end", start: at(2, 16, 30), duration: 30},
+		{name: "zero duration", start: at(2, 10, 0), duration: 0, want: entity.ErrInvalidDuration},
+		{name: "in the past", start: at(1, 10, 0), duration: 30, want: entity.ErrBookingInPast},
+		{name: "day off", start: at(4, 10, 0), duration: 30, want: entity.ErrOutsideWorkingDays},
+		{name: "during the break", start: at(2, 13, 15), duration: 30, want: entity.ErrOutsideWorkingHours},
+		{name: "runs into the break", start: at(2, 12, 45), duration: 30, want: entity.ErrExceedsShiftEnd},
+		{name: "right after the break", start: at(2, 14, 0), duration: 30},
+		{name: "before shift start", start: at(2, 8, 30), duration: 30, want: entity.ErrOutsideWorkingHours},
+		{name: "starts at shift end", start: at(2, 17, 0), duration: 30, want: entity.ErrOutsideWorkingHours},
+		{name: "runs past shift end", start: at(2, 16, 45), duration: 30, want: entity.ErrExceedsShiftEnd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := time.Date(tt.start.Year(), tt.start.Month(), tt.start.Day(), 0, 0, 0, 0, time.UTC)
+			windows, err := workingWindows(schedule, day, day.AddDate(0, 0, 1))
+			require.NoError(t, err)
+
+			err = validateBooking(windows, nil, entity.Appointment{AppointmentTime: tt.start, Duration: tt.duration}, now)
+			if tt.want == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestValidateBookingBlocked(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	now := at(7, 0)
+	windows := []entity.Slot{{Start: at(9, 0), End: at(12, 0)}, {Start: at(14, 0), End: at(17, 0)}}
+	blocked := []entity.Slot{{Start: at(12, 0), End: at(14, 0)}}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  error
+	}{
+		{name: "fits before time off", start: at(11, 30)},
+		{name: "inside time off", start: at(12, 30), want: entity.ErrDoctorUnavailable},
+		{name: "runs into time off", start: at(11, 45), want: entity.ErrDoctorUnavailable},
+		{name: "outside hours, no time off", start: at(17, 30), want: entity.ErrOutsideWorkingHours},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBooking(windows, blocked, entity.Appointment{AppointmentTime: tt.start, Duration: 30}, now)
+			if tt.want == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestValidateBookingInDoctorTimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "mon", Ranges: []entity.TimeRange{{Start: "09:00", End: "17:00"}}},
+	}}
+
+	// Monday 2026-03-02 09:00 in Tokyo is Monday 00:00 UTC; Sunday 23:30 UTC is still before the shift.
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, tokyo)
+	windows, err := workingWindows(schedule, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	err = validateBooking(windows, nil, entity.Appointment{AppointmentTime: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Duration: 30}, now)
+	assert.NoError(t, err)
+
+	err = validateBooking(windows, nil, entity.Appointment{AppointmentTime: time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC), Duration: 30}, now)
+	assert.ErrorIs(t, err, entity.ErrOutsideWorkingHours)
+}
@@ -0,0 +1,292 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// CreateClinic -.
+func (uc *UseCase) CreateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error) {
+	clinic.Name = strings.TrimSpace(clinic.Name)
+
+	err := clinic.Validate()
+	if err != nil {
+		return entity.Clinic{}, err
+	}
+
+	created, err := uc.clinicRepo.CreateClinic(ctx, clinic)
+	if err != nil {
+		return entity.Clinic{}, fmt.Errorf("UseCase - CreateClinic - uc.clinicRepo.CreateClinic: %w", err)
+	}
+
+	uc.l.Info("clinic %d created", created.ID)
+
+	return created, nil
+}
+
+// GetClinic -.
+func (uc *UseCase) GetClinic(ctx context.Context, id int) (entity.Clinic, error) {
+	return uc.clinicRepo.GetClinic(ctx, id)
+}
+
+// ListClinics -.
+func (uc *UseCase) ListClinics(ctx context.Context) ([]entity.Clinic, error) {
+	return uc.clinicRepo.ListClinics(ctx)
+}
+
+// UpdateClinic renames the clinic.
+func (uc *UseCase) UpdateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error) {
+	clinic.Name = strings.TrimSpace(clinic.Name)
+
+	err := clinic.Validate()
+	if err != nil {
+		return entity.Clinic{}, err
+	}
+
+	updated, err := uc.clinicRepo.UpdateClinic(ctx, clinic)
+	if err != nil {
+		if isClinicError(err) {
+			return entity.Clinic{}, err
+		}
+
+		return entity.Clinic{}, fmt.Errorf("UseCase - UpdateClinic - uc.clinicRepo.UpdateClinic: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteClinic removes the clinic. One that still has locations is kept.
+func (uc *UseCase) DeleteClinic(ctx context.Context, id int) error {
+	err := uc.clinicRepo.DeleteClinic(ctx, id)
+	if err != nil {
+		if isClinicError(err) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeleteClinic - uc.clinicRepo.DeleteClinic: %w", err)
+	}
+
+	uc.l.Info("clinic %d deleted", id)
+
+	return nil
+}
+
+// CreateLocation adds a location to its clinic.
+func (uc *UseCase) CreateLocation(ctx context.Context, location entity.Location) (entity.Location, error) {
+	location = normalizeLocation(location)
+
+	err := location.Validate()
+	if err != nil {
+		return entity.Location{}, err
+	}
+
+	created, err := uc.clinicRepo.CreateLocation(ctx, location)
+	if err != nil {
+		if isClinicError(err) {
+			return entity.Location{}, err
+		}
+
+		return entity.Location{}, fmt.Errorf("UseCase - CreateLocation - uc.clinicRepo.CreateLocation: %w", err)
+	}
+
+	uc.l.Info("location %d created", created.ID)
+
+	return created, nil
+}
+
+// GetLocation -.
+func (uc *UseCase) GetLocation(ctx context.Context, id int) (entity.Location, error) {
+	return uc.clinicRepo.GetLocation(ctx, id)
+}
+
+// GetLocationsByClinicID returns the clinic's locations, or entity.ErrClinicNotFound.
+func (uc *UseCase) GetLocationsByClinicID(ctx context.Context, clinicID int) ([]entity.Location, error) {
+	_, err := uc.clinicRepo.GetClinic(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.clinicRepo.GetLocationsByClinicID(ctx, clinicID)
+}
+
+// UpdateLocation renames the location and changes its address; it stays with its clinic.
+func (uc *UseCase) UpdateLocation(ctx context.Context, location entity.Location) (entity.Location, error) {
+	location = normalizeLocation(location)
+
+	err := location.Validate()
+	if err != nil {
+		return entity.Location{}, err
+	}
+
+	updated, err := uc.clinicRepo.UpdateLocation(ctx, location)
+	if err != nil {
+		if isClinicError(err) {
+			return entity.Location{}, err
+		}
+
+		return entity.Location{}, fmt.Errorf("UseCase - UpdateLocation - uc.clinicRepo.UpdateLocation: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteLocation removes the location and its rooms. One that doctors' current schedules or active
+// appointments are at is kept.
+func (uc *UseCase) DeleteLocation(ctx context.Context, id int) error {
+	err := uc.clinicRepo.DeleteLocation(ctx, id)
+	if err != nil {
+		if isClinicError(err) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeleteLocation - uc.clinicRepo.DeleteLocation: %w", err)
+	}
+
+	uc.l.Info("location %d deleted", id)
+
+	return nil
+}
+
+// CreateRoom adds a room to its location.
+func (uc *UseCase) CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
+	room.Name = strings.TrimSpace(room.Name)
+
+	err := room.Validate()
+	if err != nil {
+		return entity.Room{}, err
+	}
+
+	created, err := uc.clinicRepo.CreateRoom(ctx, room)
+	if err != nil {
+		if isClinicError(err) {
+			return entity.Room{}, err
+		}
+
+		return entity.Room{}, fmt.Errorf("UseCase - CreateRoom - uc.clinicRepo.CreateRoom: %w", err)
+	}
+
+	uc.l.Info("room %d created", created.ID)
+
+	return created, nil
+}
+
+// GetRoom -.
+func (uc *UseCase) GetRoom(ctx context.Context, id int) (entity.Room, error) {
+	return uc.clinicRepo.GetRoom(ctx, id)
+}
+
+// GetRoomsByLocationID returns the location's rooms, or entity.ErrLocationNotFound.
+func (uc *UseCase) GetRoomsByLocationID(ctx context.Context, locationID int) ([]entity.Room, error) {
+	_, err := uc.clinicRepo.GetLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.clinicRepo.GetRoomsByLocationID(ctx, locationID)
+}
+
+// UpdateRoom renames the room; it stays at its location.
+func (uc *UseCase) UpdateRoom(ctx context.Context, room entity.Room) (entity.Room, error) {
+	room.Name = strings.TrimSpace(room.Name)
+
+	err := room.Validate()
+	if err != nil {
+		return entity.Room{}, err
+	}
+
+	updated, err := uc.clinicRepo.UpdateRoom(ctx, room)
+	if err != nil {
+		if isClinicError(err) {
+			return entity.Room{}, err
+		}
+
+		return entity.Room{}, fmt.Errorf("UseCase - UpdateRoom - uc.clinicRepo.UpdateRoom: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteRoom removes the room. One that doctors' current schedules or active appointments are in
+// is kept.
+func (uc *UseCase) DeleteRoom(ctx context.Context, id int) error {
+	err := uc.clinicRepo.DeleteRoom(ctx, id)
+	if err != nil {
+		if isClinicError(err) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeleteRoom - uc.clinicRepo.DeleteRoom: %w", err)
+	}
+
+	uc.l.Info("room %d deleted", id)
+
+	return nil
+}
+
+// GetDoctorsByLocationID returns the doctors whose current schedule has them working at the
+// location, or entity.ErrLocationNotFound.
+func (uc *UseCase) GetDoctorsByLocationID(ctx context.Context, locationID int) ([]entity.Doctor, error) {
+	_, err := uc.clinicRepo.GetLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.doctorRepo.GetDoctorsByLocationID(ctx, locationID)
+}
+
+// checkSchedulePlaces checks that the locations and rooms the schedule's rules name exist and that
+// every room is at its rule's location.
+func (uc *UseCase) checkSchedulePlaces(ctx context.Context, schedule entity.Schedule) error {
+	for _, rule := range schedule.Rules {
+		if rule.LocationID == nil {
+			continue
+		}
+
+		_, err := uc.clinicRepo.GetLocation(ctx, *rule.LocationID)
+		if err != nil {
+			return err
+		}
+
+		if rule.RoomID == nil {
+			continue
+		}
+
+		room, err := uc.clinicRepo.GetRoom(ctx, *rule.RoomID)
+		if err != nil {
+			return err
+		}
+
+		if room.LocationID != *rule.LocationID {
+			return fmt.Errorf("%w: room %d on %s isn't at location %d", entity.ErrInvalidSchedule, room.ID, rule.Weekday, *rule.LocationID)
+		}
+	}
+
+	return nil
+}
+
+// place puts the appointment at the location and in the room the doctor works in on its day.
+func place(doctor entity.Doctor, appointment entity.Appointment) (entity.Appointment, error) {
+	loc, err := doctor.Location()
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("doctor.Location: %w", err)
+	}
+
+	return doctor.Schedule.Place(appointment, loc), nil
+}
+
+func isClinicError(err error) bool {
+	return errors.Is(err, entity.ErrClinicNotFound) || errors.Is(err, entity.ErrClinicInUse) ||
+		errors.Is(err, entity.ErrLocationNotFound) || errors.Is(err, entity.ErrLocationInUse) ||
+		errors.Is(err, entity.ErrRoomNotFound) || errors.Is(err, entity.ErrRoomInUse)
+}
+
+func normalizeLocation(location entity.Location) entity.Location {
+	location.Name = strings.TrimSpace(location.Name)
+	location.Address = strings.TrimSpace(location.Address)
+
+	return location
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+// clinicStore has locations 1 and 2; room 7 is at location 1.
+type clinicStore struct {
+	repo.ClinicRepo
+}
+
+func (clinicStore) GetLocation(_ context.Context, id int) (entity.Location, error) {
+	if id != 1 && id != 2 {
+		return entity.Location{}, entity.ErrLocationNotFound
+	}
+
+	return entity.Location{ID: id, ClinicID: 1}, nil
+}
+
+func (clinicStore) GetRoom(_ context.Context, id int) (entity.Room, error) {
+	if id != 7 {
+		return entity.Room{}, entity.ErrRoomNotFound
+	}
+
+	return entity.Room{ID: id, LocationID: 1}, nil
+}
+
+func TestCheckSchedulePlaces(t *testing.T) {
+	uc := NewUseCase(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, clinicStore{})
+	ctx := context.Background()
+
+	rule := func(locationID, roomID int) entity.Schedule {
+		r := entity.ScheduleRule{Weekday: "mon"}
+		if locationID != 0 {
+			r.LocationID = &locationID
+		}
+
+		if roomID != 0 {
+			r.RoomID = &roomID
+		}
+
+		return entity.Schedule{Rules: []entity.ScheduleRule{r}}
+	}
+
+	assert.NoError(t, uc.checkSchedulePlaces(ctx, rule(0, 0)))
+	assert.NoError(t, uc.checkSchedulePlaces(ctx, rule(2, 0)))
+	assert.NoError(t, uc.checkSchedulePlaces(ctx, rule(1, 7)))
+	assert.ErrorIs(t, uc.checkSchedulePlaces(ctx, rule(3, 0)), entity.ErrLocationNotFound)
+	assert.ErrorIs(t, uc.checkSchedulePlaces(ctx, rule(1, 8)), entity.ErrRoomNotFound)
+	assert.ErrorIs(t, uc.checkSchedulePlaces(ctx, rule(2, 7)), entity.ErrInvalidSchedule)
+}
@@ -2,23 +2,131 @@ package common
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/mailer"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/totp"
+)
+
+const (
+	_defaultWaitlistHold    = 30 * time.Minute
+	_defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	_defaultPasswordResetTTL     = time.Hour
+	_defaultEmailVerificationTTL = 48 * time.Hour
+	_defaultLinkBaseURL          = "http://localhost:8070"
+	_defaultPasswordResetURL     = "http://localhost:3000/reset-password"
+
+	_defaultTwoFactorIssuer       = "Doctor Appointment"
+	_defaultTwoFactorChallengeTTL = 5 * time.Minute
+
+	_defaultOIDCLoginTTL = 10 * time.Minute
+)
+
+var (
+	_defaultAccountLoginPolicy = entity.LoginPolicy{
+		Threshold: 5, Window: 15 * time.Minute, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Lockout: 15 * time.Minute,
+	}
+	_defaultIPLoginPolicy = entity.LoginPolicy{
+		Threshold: 20, Window: 15 * time.Minute, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Lockout: 15 * time.Minute,
+	}
 )
 
 type UseCase struct {
-	userRepo        repo.UserRepo
-	doctorRepo      repo.DoctorRepo
-	appointmentRepo repo.AppointmentRepo
+	userRepo            repo.UserRepo
+	doctorRepo          repo.DoctorRepo
+	appointmentRepo     repo.AppointmentRepo
+	scheduleRepo        repo.ScheduleRepo
+	waitlistRepo        repo.WaitlistRepo
+	roleRepo            repo.RoleRepo
+	sessionRepo         repo.SessionRepo
+	loginRepo           repo.LoginRepo
+	twoFactorRepo       repo.TwoFactorRepo
+	identityRepo        repo.IdentityRepo
+	apiKeyRepo          repo.APIKeyRepo
+	patientRepo         repo.PatientRepo
+	specializationRepo  repo.SpecializationRepo
+	appointmentTypeRepo repo.AppointmentTypeRepo
+	clinicRepo          repo.ClinicRepo
+
+	waitlistHold    time.Duration
+	refreshTokenTTL time.Duration
+
+	mailer               mailer.Mailer
+	linkBaseURL          string
+	passwordResetURL     string
+	passwordResetTTL     time.Duration
+	emailVerificationTTL time.Duration
+
+	accountLoginPolicy entity.LoginPolicy
+	ipLoginPolicy      entity.LoginPolicy
+
+	totp                  *totp.Generator
+	twoFactorIssuer       string
+	twoFactorChallengeTTL time.Duration
+
+	// oidc is nil unless single sign-on is configured.
+	oidc            *oidc.Provider
+	oidcGroupRoles  []entity.GroupRole
+	oidcDefaultRole entity.Role
+	oidcLoginTTL    time.Duration
+
+	l logger.Interface
 }
 
-func NewUseCase(userRepo repo.UserRepo, doctorRepo repo.DoctorRepo, appointmentRepo repo.AppointmentRepo) *UseCase {
-	return &UseCase{
-		userRepo:        userRepo,
-		doctorRepo:      doctorRepo,
-		appointmentRepo: appointmentRepo,
+func NewUseCase(userRepo repo.UserRepo, doctorRepo repo.DoctorRepo, appointmentRepo repo.AppointmentRepo, scheduleRepo repo.ScheduleRepo, waitlistRepo repo.WaitlistRepo, roleRepo repo.RoleRepo, sessionRepo repo.SessionRepo, loginRepo repo.LoginRepo, twoFactorRepo repo.TwoFactorRepo, identityRepo repo.IdentityRepo, apiKeyRepo repo.APIKeyRepo, patientRepo repo.PatientRepo, specializationRepo repo.SpecializationRepo, appointmentTypeRepo repo.AppointmentTypeRepo, clinicRepo repo.ClinicRepo, opts ...Option) *UseCase {
+	uc := &UseCase{
+		userRepo:            userRepo,
+		doctorRepo:          doctorRepo,
+		appointmentRepo:     appointmentRepo,
+		scheduleRepo:        scheduleRepo,
+		waitlistRepo:        waitlistRepo,
+		roleRepo:            roleRepo,
+		sessionRepo:         sessionRepo,
+		loginRepo:           loginRepo,
+		twoFactorRepo:       twoFactorRepo,
+		identityRepo:        identityRepo,
+		apiKeyRepo:          apiKeyRepo,
+		patientRepo:         patientRepo,
+		specializationRepo:  specializationRepo,
+		appointmentTypeRepo: appointmentTypeRepo,
+		clinicRepo:          clinicRepo,
+		waitlistHold:        _defaultWaitlistHold,
+		refreshTokenTTL:     _defaultRefreshTokenTTL,
+
+		linkBaseURL:          _defaultLinkBaseURL,
+		passwordResetURL:     _defaultPasswordResetURL,
+		passwordResetTTL:     _defaultPasswordResetTTL,
+		emailVerificationTTL: _defaultEmailVerificationTTL,
+
+		accountLoginPolicy: _defaultAccountLoginPolicy,
+		ipLoginPolicy:      _defaultIPLoginPolicy,
+
+		totp:                  totp.New(),
+		twoFactorIssuer:       _defaultTwoFactorIssuer,
+		twoFactorChallengeTTL: _defaultTwoFactorChallengeTTL,
+
+		oidcLoginTTL: _defaultOIDCLoginTTL,
 	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	if uc.l == nil {
+		uc.l = logger.New("info")
+	}
+
+	if uc.mailer == nil {
+		uc.mailer = mailer.NewLog(uc.l)
+	}
+
+	return uc
 }
 
 // CreateUser -.
@@ -56,13 +164,23 @@ func (uc *UseCase) GetPasswordHash(ctx context.Context, email string) (entity.Ge
 	return uc.userRepo.GetPasswordHash(ctx, email)
 }
 
-// UpdateToken -.
-func (uc *UseCase) UpdateToken(ctx context.Context, id int, token string) error {
-	return uc.userRepo.UpdateToken(ctx, id, token)
-}
-
 // CreateDoctor -.
 func (uc *UseCase) CreateDoctor(ctx context.Context, doctor entity.Doctor) error {
+	err := uc.checkDoctorAccount(ctx, doctor)
+	if err != nil {
+		return err
+	}
+
+	doctor.Specializations, err = uc.resolveSpecializations(ctx, doctor.Specializations)
+	if err != nil {
+		return err
+	}
+
+	err = uc.checkSchedulePlaces(ctx, doctor.Schedule)
+	if err != nil {
+		return err
+	}
+
 	return uc.doctorRepo.CreateDoctor(ctx, doctor)
 }
 
@@ -71,9 +189,9 @@ func (uc *UseCase) GetDoctorByID(ctx context.Context, id int) (entity.Doctor, er
 	return uc.doctorRepo.GetDoctorByID(ctx, id)
 }
 
-// GetDoctorByEmail -.
-func (uc *UseCase) GetDoctorBySpecialization(ctx context.Context, specialization string) ([]entity.Doctor, error) {
-	return uc.doctorRepo.GetDoctorBySpecialization(ctx, specialization)
+// GetDoctorBySpecialization returns the doctors holding the specialization with the code.
+func (uc *UseCase) GetDoctorBySpecialization(ctx context.Context, code string) ([]entity.Doctor, error) {
+	return uc.doctorRepo.GetDoctorBySpecialization(ctx, normalizeSpecializationCode(code))
 }
 
 // ListDoctors -.
@@ -83,6 +201,21 @@ func (uc *UseCase) GetDoctors(ctx context.Context) ([]entity.Doctor, error) {
 
 // UpdateDoctor -.
 func (uc *UseCase) UpdateDoctor(ctx context.Context, doctor entity.Doctor) error {
+	err := uc.checkDoctorAccount(ctx, doctor)
+	if err != nil {
+		return err
+	}
+
+	doctor.Specializations, err = uc.resolveSpecializations(ctx, doctor.Specializations)
+	if err != nil {
+		return err
+	}
+
+	err = uc.checkSchedulePlaces(ctx, doctor.Schedule)
+	if err != nil {
+		return err
+	}
+
 	return uc.doctorRepo.UpdateDoctor(ctx, doctor)
 }
 
@@ -91,13 +224,33 @@ func (uc *UseCase) DeleteDoctor(ctx context.Context, id int) error {
 	return uc.doctorRepo.DeleteDoctor(ctx, id)
 }
 
-// ListSpecializations -.
-func (uc *UseCase) ListSpecializations(ctx context.Context) ([]string, error) {
-	return uc.doctorRepo.ListSpecializations(ctx)
-}
+// CreateAppointment - books the appointment with its type if it fits the doctor's working schedule
+// and exceptions. The type sets the duration, buffers and capacity.
+func (uc *UseCase) CreateAppointment(ctx context.Context, appointment entity.Appointment) (int, error) {
+	if appointment.AppointmentTypeID == nil {
+		return 0, entity.ErrAppointmentTypeRequired
+	}
+
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, appointment.DoctorID)
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - CreateAppointment - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	appointmentType, err := uc.appointmentTypeFor(ctx, doctor, *appointment.AppointmentTypeID)
+	if err != nil {
+		return 0, err
+	}
+
+	appointment, err = place(doctor, appointmentType.Apply(appointment))
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - CreateAppointment - %w", err)
+	}
+
+	err = uc.checkBooking(ctx, doctor, appointment)
+	if err != nil {
+		return 0, err
+	}
 
-// CreateAppointment -.
-func (uc *UseCase) CreateAppointment(ctx context.Context, appointment entity.Appointment) error {
 	return uc.appointmentRepo.CreateAppointment(ctx, appointment)
 }
 
@@ -111,14 +264,67 @@ func (uc *UseCase) GetBookedAppointmentsByDoctorId(ctx context.Context, doctorID
 	return uc.appointmentRepo.GetBookedAppointmentsByDoctorId(ctx, doctorID)
 }
 
-// UpdateAppointment -.
+// UpdateAppointment - a new time, duration or doctor is applied as a reschedule and a new status
+// as a lifecycle transition, so both are validated and recorded. Zero values keep the current ones.
 func (uc *UseCase) UpdateAppointment(ctx context.Context, appointment entity.Appointment) error {
-	return uc.appointmentRepo.UpdateAppointment(ctx, appointment)
+	current, err := uc.appointmentRepo.GetAppointmentByID(ctx, appointment.ID)
+	if err != nil {
+		return fmt.Errorf("UseCase - UpdateAppointment - uc.appointmentRepo.GetAppointmentByID: %w", err)
+	}
+
+	moved := (!appointment.AppointmentTime.IsZero() && !appointment.AppointmentTime.Equal(current.AppointmentTime)) ||
+		(appointment.Duration != 0 && appointment.Duration != current.Duration) ||
+		(appointment.DoctorID != 0 && appointment.DoctorID != current.DoctorID)
+
+	if moved {
+		change := entity.AppointmentReschedule{
+			AppointmentID:   appointment.ID,
+			DoctorID:        appointment.DoctorID,
+			AppointmentTime: appointment.AppointmentTime,
+			Duration:        appointment.Duration,
+		}
+
+		if change.AppointmentTime.IsZero() {
+			change.AppointmentTime = current.AppointmentTime
+		}
+
+		_, err = uc.RescheduleAppointment(ctx, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	if appointment.Status == "" || appointment.Status == current.Status {
+		return nil
+	}
+
+	_, err = uc.ChangeAppointmentStatus(ctx, appointment.ID, appointment.Status, nil, "")
+
+	return err
 }
 
-// DeleteAppointment -.
+// DeleteAppointment - deletes the appointment and offers its slot to the waitlist if it was still
+// taking the doctor's time. Held slots belong to a waitlist offer and are released through it.
 func (uc *UseCase) DeleteAppointment(ctx context.Context, id int) error {
-	return uc.appointmentRepo.DeleteAppointment(ctx, id)
+	appointment, err := uc.appointmentRepo.GetAppointmentByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("UseCase - DeleteAppointment - uc.appointmentRepo.GetAppointmentByID: %w", err)
+	}
+
+	if appointment.Status == entity.StatusHeld {
+		return fmt.Errorf("%w: held appointments are released through their waitlist offer", entity.ErrInvalidTransition)
+	}
+
+	err = uc.appointmentRepo.DeleteAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if entity.IsActiveStatus(appointment.Status) {
+		uc.passOnSlot(ctx, appointment)
+	}
+
+	return nil
 }
 
 // GetBookedAppointmentsByUserId -.
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// GetDoctorByUserID returns the doctor the user signs in as, or entity.ErrDoctorNotFound.
+func (uc *UseCase) GetDoctorByUserID(ctx context.Context, userID int) (entity.Doctor, error) {
+	return uc.doctorRepo.GetDoctorByUserID(ctx, userID)
+}
+
+// GetDoctorAgenda returns the doctor's appointments on the given day in the doctor's time zone,
+// cancelled ones left out. A zero day means today.
+func (uc *UseCase) GetDoctorAgenda(ctx context.Context, doctorID int, day time.Time) ([]entity.Appointment, error) {
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, doctorID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - GetDoctorAgenda - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - GetDoctorAgenda - doctor.Location: %w", err)
+	}
+
+	if day.IsZero() {
+		day = time.Now()
+	}
+
+	day = day.In(loc)
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	appointments, err := uc.appointmentRepo.GetDoctorAgenda(ctx, doctorID, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - GetDoctorAgenda - uc.appointmentRepo.GetDoctorAgenda: %w", err)
+	}
+
+	return appointments, nil
+}
+
+// checkDoctorAccount makes sure the account a doctor is linked to, if any, has a role that lets
+// them work as a doctor.
+func (uc *UseCase) checkDoctorAccount(ctx context.Context, doctor entity.Doctor) error {
+	if doctor.UserID == nil {
+		return nil
+	}
+
+	_, permissions, err := uc.roleRepo.GetUserAccess(ctx, *doctor.UserID)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			return err
+		}
+
+		if errors.Is(err, entity.ErrUserDeactivated) {
+			return fmt.Errorf("%w: %w", entity.ErrInvalidDoctorAccount, err)
+		}
+
+		return fmt.Errorf("UseCase - checkDoctorAccount - uc.roleRepo.GetUserAccess: %w", err)
+	}
+
+	if !slices.Contains(permissions, entity.PermDoctorSelf) {
+		return entity.ErrInvalidDoctorAccount
+	}
+
+	return nil
+}
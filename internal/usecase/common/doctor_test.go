@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doctorStore keeps one doctor in Berlin and records what it is asked for.
+type doctorStore struct {
+	repo.DoctorRepo
+	repo.AppointmentRepo
+	created    []entity.Doctor
+	agendaFrom time.Time
+	agendaTo   time.Time
+}
+
+func (s *doctorStore) CreateDoctor(_ context.Context, doctor entity.Doctor) error {
+	s.created = append(s.created, doctor)
+
+	return nil
+}
+
+func (s *doctorStore) GetDoctorByID(_ context.Context, id int) (entity.Doctor, error) {
+	return entity.Doctor{ID: id, TimeZone: "Europe/Berlin"}, nil
+}
+
+func (s *doctorStore) GetDoctorAgenda(_ context.Context, _ int, from, to time.Time) ([]entity.Appointment, error) {
+	s.agendaFrom, s.agendaTo = from, to
+
+	return nil, nil
+}
+
+// doctorRoleRepo makes user 6 a doctor and everyone else a patient.
+type doctorRoleRepo struct {
+	repo.RoleRepo
+}
+
+func (doctorRoleRepo) GetUserAccess(_ context.Context, userID int) (entity.Role, []entity.Permission, error) {
+	switch userID {
+	case 6:
+		return entity.RoleDoctor, []entity.Permission{entity.PermDoctorSelf}, nil
+	case 404:
+		return "", nil, entity.ErrUserNotFound
+	}
+
+	return entity.RoleUser, nil, nil
+}
+
+func TestCreateDoctorAccount(t *testing.T) {
+	store := &doctorStore{}
+	uc := NewUseCase(nil, store, nil, nil, nil, doctorRoleRepo{}, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	require.NoError(t, uc.CreateDoctor(ctx, entity.Doctor{Name: "Without account"}))
+
+	doctorUser, patientUser, missingUser := 6, 1, 404
+
+	require.NoError(t, uc.CreateDoctor(ctx, entity.Doctor{Name: "Dr. Who", UserID: &doctorUser}))
+
+	err := uc.CreateDoctor(ctx, entity.Doctor{Name: "Patient", UserID: &patientUser})
+	assert.ErrorIs(t, err, entity.ErrInvalidDoctorAccount)
+
+	err = uc.CreateDoctor(ctx, entity.Doctor{Name: "Nobody", UserID: &missingUser})
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+
+	assert.Len(t, store.created, 2)
+}
+
+func TestGetDoctorAgenda(t *testing.T) {
+	store := &doctorStore{}
+	uc := NewUseCase(nil, store, store, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// 23:30 UTC on the 29th is already the 30th in Berlin, the 23-hour day summer time starts.
+	_, err = uc.GetDoctorAgenda(context.Background(), 5, time.Date(2025, 3, 29, 23, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2025, 3, 30, 0, 0, 0, 0, berlin).UTC(), store.agendaFrom.UTC())
+	assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, berlin).UTC(), store.agendaTo.UTC())
+	assert.Equal(t, 23*time.Hour, store.agendaTo.Sub(store.agendaFrom))
+}
@@ -0,0 +1,241 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// AddScheduleException stores an extra_hours exception. Time off goes through AddTimeOff.
+func (uc *UseCase) AddScheduleException(ctx context.Context, exception entity.ScheduleException) (entity.ScheduleException, error) {
+	if exception.Kind != entity.ExceptionExtraHours {
+		return entity.ScheduleException{}, fmt.Errorf("%w: unknown kind %q", entity.ErrInvalidException, exception.Kind)
+	}
+
+	return uc.createScheduleException(ctx, exception)
+}
+
+// AddTimeOff blocks [exception.StartsAt, exception.EndsAt) for the doctor and reports the active
+// appointments falling into it. With cancelConflicts they are cancelled, recording changedBy and the
+// exception reason; appointments already past the point of cancelling are only reported.
+func (uc *UseCase) AddTimeOff(ctx context.Context, exception entity.ScheduleException, cancelConflicts bool, changedBy *int) (entity.TimeOffResult, error) {
+	exception.Kind = entity.ExceptionTimeOff
+
+	created, err := uc.createScheduleException(ctx, exception)
+	if err != nil {
+		return entity.TimeOffResult{}, err
+	}
+
+	result := entity.TimeOffResult{Exception: created}
+
+	booked, err := uc.appointmentRepo.GetBookedAppointmentsInRange(ctx, created.DoctorID, created.StartsAt, created.EndsAt)
+	if err != nil {
+		return entity.TimeOffResult{}, fmt.Errorf("UseCase - AddTimeOff - uc.appointmentRepo.GetBookedAppointmentsInRange: %w", err)
+	}
+
+	// Buffers may reach into time off, like outside working hours; only the appointments themselves conflict.
+	conflicts := make([]entity.Appointment, 0, len(booked))
+	for _, appointment := range booked {
+		if (entity.Slot{Start: created.StartsAt, End: created.EndsAt}).Overlaps(appointment.AppointmentTime, appointment.End()) {
+			conflicts = append(conflicts, appointment)
+		}
+	}
+
+	result.Conflicts = conflicts
+
+	if !cancelConflicts {
+		return result, nil
+	}
+
+	reason := "doctor unavailable"
+	if created.Reason != "" {
+		reason += ": " + created.Reason
+	}
+
+	for _, appointment := range conflicts {
+		if !entity.CanTransition(appointment.Status, entity.StatusCancelled) {
+			continue
+		}
+
+		cancelled, err := uc.ChangeAppointmentStatus(ctx, appointment.ID, entity.StatusCancelled, changedBy, reason)
+		if err != nil {
+			return entity.TimeOffResult{}, fmt.Errorf("UseCase - AddTimeOff - uc.ChangeAppointmentStatus: %w", err)
+		}
+
+		result.Cancelled = append(result.Cancelled, cancelled)
+	}
+
+	return result, nil
+}
+
+func (uc *UseCase) createScheduleException(ctx context.Context, exception entity.ScheduleException) (entity.ScheduleException, error) {
+	if !exception.StartsAt.Before(exception.EndsAt) {
+		return entity.ScheduleException{}, fmt.Errorf("%w: starts_at must be before ends_at", entity.ErrInvalidException)
+	}
+
+	_, err := uc.doctorRepo.GetDoctorByID(ctx, exception.DoctorID)
+	if err != nil {
+		return entity.ScheduleException{}, fmt.Errorf("UseCase - createScheduleException - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	exception.ID, err = uc.scheduleRepo.CreateScheduleException(ctx, exception)
+	if err != nil {
+		return entity.ScheduleException{}, fmt.Errorf("UseCase - createScheduleException - uc.scheduleRepo.CreateScheduleException: %w", err)
+	}
+
+	return exception, nil
+}
+
+// GetScheduleExceptions -.
+func (uc *UseCase) GetScheduleExceptions(ctx context.Context, doctorID int, from, to time.Time) ([]entity.ScheduleException, error) {
+	return uc.scheduleRepo.GetScheduleExceptions(ctx, doctorID, from, to)
+}
+
+// DeleteScheduleException -.
+func (uc *UseCase) DeleteScheduleException(ctx context.Context, doctorID, id int) error {
+	return uc.scheduleRepo.DeleteScheduleException(ctx, doctorID, id)
+}
+
+// CreateHolidayCalendar -.
+func (uc *UseCase) CreateHolidayCalendar(ctx context.Context, calendar entity.HolidayCalendar) (int, error) {
+	return uc.scheduleRepo.CreateHolidayCalendar(ctx, calendar)
+}
+
+// ListHolidayCalendars -.
+func (uc *UseCase) ListHolidayCalendars(ctx context.Context) ([]entity.HolidayCalendar, error) {
+	return uc.scheduleRepo.ListHolidayCalendars(ctx)
+}
+
+// AddHoliday -.
+func (uc *UseCase) AddHoliday(ctx context.Context, holiday entity.Holiday) (int, error) {
+	return uc.scheduleRepo.CreateHoliday(ctx, holiday)
+}
+
+// GetHolidays -.
+func (uc *UseCase) GetHolidays(ctx context.Context, calendarID int, from, to time.Time) ([]entity.Holiday, error) {
+	return uc.scheduleRepo.GetHolidays(ctx, calendarID, from, to)
+}
+
+// DeleteHoliday -.
+func (uc *UseCase) DeleteHoliday(ctx context.Context, calendarID, id int) error {
+	return uc.scheduleRepo.DeleteHoliday(ctx, calendarID, id)
+}
+
+// effectiveWindows returns the doctor's working windows in [from, to) once holidays and schedule
+// exceptions are applied, along with the periods the doctor is explicitly unavailable.
+// Days are counted in the doctor's time zone.
+func (uc *UseCase) effectiveWindows(ctx context.Context, doctor entity.Doctor, from, to time.Time) ([]entity.Slot, []entity.Slot, error) {
+	loc, err := doctor.Location()
+	if err != nil {
+		return nil, nil, fmt.Errorf("UseCase - effectiveWindows - doctor.Location: %w", err)
+	}
+
+	from, to = from.In(loc), to.In(loc)
+
+	windows, err := workingWindows(doctor.Schedule, from, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("UseCase - effectiveWindows - workingWindows: %w", err)
+	}
+
+	exceptions, err := uc.scheduleRepo.GetScheduleExceptions(ctx, doctor.ID, from, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("UseCase - effectiveWindows - uc.scheduleRepo.GetScheduleExceptions: %w", err)
+	}
+
+	var holidays []entity.Holiday
+	if doctor.HolidayCalendarID != nil {
+		holidays, err = uc.scheduleRepo.GetHolidays(ctx, *doctor.HolidayCalendarID, from, to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("UseCase - effectiveWindows - uc.scheduleRepo.GetHolidays: %w", err)
+		}
+	}
+
+	windows, blocked := applyExceptions(windows, exceptions, holidays, loc)
+
+	return windows, blocked, nil
+}
+
+// applyExceptions adjusts the weekly working windows for specific dates. Holidays remove whole days
+// in loc, extra hours are then added back (so a doctor can choose to work on a holiday), and time off
+// is cut out last since leave always wins. The second result lists the holidays and time off.
+func applyExceptions(windows []entity.Slot, exceptions []entity.ScheduleException, holidays []entity.Holiday, loc *time.Location) ([]entity.Slot, []entity.Slot) {
+	var holidayDays, timeOff, extra []entity.Slot
+
+	for _, holiday := range holidays {
+		day := time.Date(holiday.Date.Year(), holiday.Date.Month(), holiday.Date.Day(), 0, 0, 0, 0, loc)
+		holidayDays = append(holidayDays, entity.Slot{Start: day, End: day.AddDate(0, 0, 1)})
+	}
+
+	for _, exception := range exceptions {
+		period := entity.Slot{Start: exception.StartsAt, End: exception.EndsAt}
+
+		switch exception.Kind {
+		case entity.ExceptionTimeOff:
+			timeOff = append(timeOff, period)
+		case entity.ExceptionExtraHours:
+			extra = append(extra, period)
+		}
+	}
+
+	windows = subtractPeriods(windows, holidayDays)
+	windows = mergePeriods(append(windows, extra...))
+	windows = subtractPeriods(windows, timeOff)
+
+	return windows, mergePeriods(append(holidayDays, timeOff...))
+}
+
+// subtractPeriods removes every cut from the periods, splitting a period when a cut falls inside it.
+func subtractPeriods(periods, cuts []entity.Slot) []entity.Slot {
+	for _, cut := range cuts {
+		var rest []entity.Slot
+
+		for _, period := range periods {
+			if !period.Overlaps(cut.Start, cut.End) {
+				rest = append(rest, period)
+
+				continue
+			}
+
+			if period.Start.Before(cut.Start) {
+				rest = append(rest, entity.Slot{Start: period.Start, End: cut.Start})
+			}
+
+			if cut.End.Before(period.End) {
+				rest = append(rest, entity.Slot{Start: cut.End, End: period.End})
+			}
+		}
+
+		periods = rest
+	}
+
+	return periods
+}
+
+// mergePeriods sorts the periods and joins the ones that overlap. Touching periods stay apart so
+// a booking can't run from one shift straight into the next.
+func mergePeriods(periods []entity.Slot) []entity.Slot {
+	if len(periods) == 0 {
+		return periods
+	}
+
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+
+	merged := []entity.Slot{periods[0]}
+	for _, period := range periods[1:] {
+		last := &merged[len(merged)-1]
+		if !period.Start.Before(last.End) {
+			merged = append(merged, period)
+
+			continue
+		}
+
+		if period.End.After(last.End) {
+			last.End = period.End
+		}
+	}
+
+	return merged
+}
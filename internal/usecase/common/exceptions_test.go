@@ -0,0 +1,99 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyExceptions(t *testing.T) {
+	schedule := entity.Schedule{Rules: []entity.ScheduleRule{
+		{Weekday: "mon", Ranges: []entity.TimeRange{{Start: "09:00", End: "17:00"}}},
+		{Weekday: "tue", Ranges: []entity.TimeRange{{Start: "09:00", End: "17:00"}}},
+		{Weekday: "wed", Ranges: []entity.TimeRange{{Start: "09:00", End: "17:00"}}},
+	}}
+
+	// 2026-03-02 is a Monday.
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	windows, err := workingWindows(schedule, from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+
+	format := func(periods []entity.Slot) []string {
+		got := make([]string, 0, len(periods))
+		for _, period := range periods {
+			got = append(got, period.Start.Format("Mon 15:04")+"-"+period.End.Format("Mon 15:04"))
+		}
+
+		return got
+	}
+
+	tests := []struct {
+		name        string
+		exceptions  []entity.ScheduleException
+		holidays    []entity.Holiday
+		wantWindows []string
+		wantBlocked []string
+	}{
+		{
+			name:        "no exceptions",
+			wantWindows: []string{"Mon 09:00-Mon 17:00", "Tue 09:00-Tue 17:00", "Wed 09:00-Wed 17:00"},
+			wantBlocked: []string{},
+		},
+		{
+			name:        "holiday removes the whole day",
+			holidays:    []entity.Holiday{{Date: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)}},
+			wantWindows: []string{"Mon 09:00-Mon 17:00", "Wed 09:00-Wed 17:00"},
+			wantBlocked: []string{"Tue 00:00-Wed 00:00"},
+		},
+		{
+			name: "time off splits a day and spans days",
+			exceptions: []entity.ScheduleException{
+				{Kind: entity.ExceptionTimeOff, StartsAt: at(2, 12), EndsAt: at(2, 13)},
+				{Kind: entity.ExceptionTimeOff, StartsAt: at(3, 15), EndsAt: at(4, 11)},
+			},
+			wantWindows: []string{"Mon 09:00-Mon 12:00", "Mon 13:00-Mon 17:00", "Tue 09:00-Tue 15:00", "Wed 11:00-Wed 17:00"},
+			wantBlocked: []string{"Mon 12:00-Mon 13:00", "Tue 15:00-Wed 11:00"},
+		},
+		{
+			name: "extra hours on a day off and past shift end",
+			exceptions: []entity.ScheduleException{
+				{Kind: entity.ExceptionExtraHours, StartsAt: at(7, 10), EndsAt: at(7, 14)},
+				{Kind: entity.ExceptionExtraHours, StartsAt: at(4, 16), EndsAt: at(4, 19)},
+			},
+			wantWindows: []string{"Mon 09:00-Mon 17:00", "Tue 09:00-Tue 17:00", "Wed 09:00-Wed 19:00", "Sat 10:00-Sat 14:00"},
+			wantBlocked: []string{},
+		},
+		{
+			name:        "extra hours override a holiday",
+			holidays:    []entity.Holiday{{Date: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)}},
+			exceptions:  []entity.ScheduleException{{Kind: entity.ExceptionExtraHours, StartsAt: at(3, 9), EndsAt: at(3, 12)}},
+			wantWindows: []string{"Mon 09:00-Mon 17:00", "Tue 09:00-Tue 12:00", "Wed 09:00-Wed 17:00"},
+			wantBlocked: []string{"Tue 00:00-Wed 00:00"},
+		},
+		{
+			name: "time off wins over extra hours",
+			exceptions: []entity.ScheduleException{
+				{Kind: entity.ExceptionExtraHours, StartsAt: at(7, 10), EndsAt: at(7, 14)},
+				{Kind: entity.ExceptionTimeOff, StartsAt: at(7, 0), EndsAt: at(8, 0)},
+			},
+			wantWindows: []string{"Mon 09:00-Mon 17:00", "Tue 09:00-Tue 17:00", "Wed 09:00-Wed 17:00"},
+			wantBlocked: []string{"Sat 00:00-Sun 00:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotWindows, gotBlocked := applyExceptions(append([]entity.Slot(nil), windows...), tt.exceptions, tt.holidays, time.UTC)
+
+			assert.Equal(t, tt.wantWindows, format(gotWindows))
+			assert.Equal(t, tt.wantBlocked, format(gotBlocked))
+		})
+	}
+}
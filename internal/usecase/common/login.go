@@ -0,0 +1,95 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// CheckLogin returns an *entity.LoginThrottledError if the account or the client IP has to wait
+// before trying to sign in again.
+func (uc *UseCase) CheckLogin(ctx context.Context, email, ip string) error {
+	throttles, err := uc.loginRepo.GetLoginThrottles(ctx, entity.AccountLoginKey(email), entity.IPLoginKey(ip))
+	if err != nil {
+		return fmt.Errorf("UseCase - CheckLogin - uc.loginRepo.GetLoginThrottles: %w", err)
+	}
+
+	now := time.Now()
+
+	var throttled *entity.LoginThrottledError
+	for _, throttle := range throttles {
+		wait := throttle.RetryAfter(now, uc.loginPolicy(throttle.Key))
+		if wait > 0 && (throttled == nil || wait > throttled.RetryAfter) {
+			throttled = &entity.LoginThrottledError{RetryAfter: wait, Locked: throttle.Locked(now)}
+		}
+	}
+
+	if throttled != nil {
+		return throttled
+	}
+
+	return nil
+}
+
+// RecordLoginFailure counts a failed sign-in against the account and the client IP and logs
+// either of them becoming locked.
+func (uc *UseCase) RecordLoginFailure(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	for _, key := range []entity.LoginKey{entity.AccountLoginKey(email), entity.IPLoginKey(ip)} {
+		before, after, err := uc.loginRepo.RecordLoginFailure(ctx, key, now, uc.loginPolicy(key))
+		if err != nil {
+			return fmt.Errorf("UseCase - RecordLoginFailure - uc.loginRepo.RecordLoginFailure: %w", err)
+		}
+
+		if after.Locked(now) && !before.Locked(now) {
+			uc.l.Warn("login locked: %s %s after %d failed sign-ins, until %s",
+				key.Kind, key.Subject, after.Failures, after.LockedUntil.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// RecordLoginSuccess forgets the failed sign-ins of the account. Those of the client IP are kept,
+// so one valid account doesn't let an IP keep guessing at others.
+func (uc *UseCase) RecordLoginSuccess(ctx context.Context, email string) error {
+	_, err := uc.loginRepo.ClearLoginFailures(ctx, entity.AccountLoginKey(email), time.Now())
+	if err != nil {
+		return fmt.Errorf("UseCase - RecordLoginSuccess - uc.loginRepo.ClearLoginFailures: %w", err)
+	}
+
+	return nil
+}
+
+// UnlockAccount lifts the lockout of the user's account and forgets its failed sign-ins on behalf
+// of the admin by.
+func (uc *UseCase) UnlockAccount(ctx context.Context, userID, by int) error {
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - UnlockAccount - uc.userRepo.GetUserByID: %w", err)
+	}
+
+	key := entity.AccountLoginKey(user.Email)
+
+	locked, err := uc.loginRepo.ClearLoginFailures(ctx, key, time.Now())
+	if err != nil {
+		return fmt.Errorf("UseCase - UnlockAccount - uc.loginRepo.ClearLoginFailures: %w", err)
+	}
+
+	if locked {
+		uc.l.Warn("login unlocked: %s %s by user %d", key.Kind, key.Subject, by)
+	}
+
+	return nil
+}
+
+func (uc *UseCase) loginPolicy(key entity.LoginKey) entity.LoginPolicy {
+	if key.Kind == entity.LoginKeyIP {
+		return uc.ipLoginPolicy
+	}
+
+	return uc.accountLoginPolicy
+}
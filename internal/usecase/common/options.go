@@ -0,0 +1,93 @@
+package common
+
+import (
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/mailer"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc"
+)
+
+// Option -.
+type Option func(*UseCase)
+
+// WaitlistHold sets how long a freed slot is held for a waitlisted patient before it moves on.
+func WaitlistHold(hold time.Duration) Option {
+	return func(uc *UseCase) {
+		uc.waitlistHold = hold
+	}
+}
+
+// RefreshTokenTTL sets how long a session lasts without its refresh token being used.
+func RefreshTokenTTL(ttl time.Duration) Option {
+	return func(uc *UseCase) {
+		uc.refreshTokenTTL = ttl
+	}
+}
+
+// Mailer sets where password reset and verification mail goes. By default it is only logged.
+func Mailer(m mailer.Mailer) Option {
+	return func(uc *UseCase) {
+		uc.mailer = m
+	}
+}
+
+// LinkBaseURL sets the address of this API that email verification links point to, e.g.
+// https://api.clinic.example.com.
+func LinkBaseURL(url string) Option {
+	return func(uc *UseCase) {
+		uc.linkBaseURL = url
+	}
+}
+
+// PasswordResetURL sets the page password reset links point to, e.g.
+// https://clinic.example.com/reset-password. The page gets the token as the token query
+// parameter and posts it with the new password to /v1/auth/reset-password.
+func PasswordResetURL(url string) Option {
+	return func(uc *UseCase) {
+		uc.passwordResetURL = url
+	}
+}
+
+// UserTokenTTLs sets how long password reset and email verification links work.
+func UserTokenTTLs(passwordReset, emailVerification time.Duration) Option {
+	return func(uc *UseCase) {
+		uc.passwordResetTTL = passwordReset
+		uc.emailVerificationTTL = emailVerification
+	}
+}
+
+// LoginPolicies sets how failed sign-ins are throttled per account and per client IP.
+func LoginPolicies(account, ip entity.LoginPolicy) Option {
+	return func(uc *UseCase) {
+		uc.accountLoginPolicy = account
+		uc.ipLoginPolicy = ip
+	}
+}
+
+// TwoFactor sets the issuer authenticator apps list TOTP codes under and how long the challenge
+// between the password and the code lasts.
+func TwoFactor(issuer string, challengeTTL time.Duration) Option {
+	return func(uc *UseCase) {
+		uc.twoFactorIssuer = issuer
+		uc.twoFactorChallengeTTL = challengeTTL
+	}
+}
+
+// OIDC turns on single sign-on through the provider. Users get the role of the first of
+// groupRoles whose group they are in, or defaultRole; without one they can't sign in.
+func OIDC(provider *oidc.Provider, groupRoles []entity.GroupRole, defaultRole entity.Role) Option {
+	return func(uc *UseCase) {
+		uc.oidc = provider
+		uc.oidcGroupRoles = groupRoles
+		uc.oidcDefaultRole = defaultRole
+	}
+}
+
+// Logger sets the logger for failures that shouldn't fail the request, such as offering a freed slot.
+func Logger(l logger.Interface) Option {
+	return func(uc *UseCase) {
+		uc.l = l
+	}
+}
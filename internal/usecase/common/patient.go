@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// CreatePatient creates the patient and links the users to them. A patient created by staff may
+// have no user yet.
+func (uc *UseCase) CreatePatient(ctx context.Context, patient entity.Patient, links []entity.PatientLink) (entity.Patient, error) {
+	patient = normalizePatient(patient)
+
+	err := patient.Validate(time.Now())
+	if err != nil {
+		return entity.Patient{}, err
+	}
+
+	for _, link := range links {
+		if !entity.IsRelationship(link.Relationship) {
+			return entity.Patient{}, fmt.Errorf("%w: unknown relationship %q", entity.ErrInvalidPatient, link.Relationship)
+		}
+	}
+
+	patient, err = uc.patientRepo.CreatePatient(ctx, patient, links)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) || errors.Is(err, entity.ErrPatientLinkExists) {
+			return entity.Patient{}, err
+		}
+
+		return entity.Patient{}, fmt.Errorf("UseCase - CreatePatient - uc.patientRepo.CreatePatient: %w", err)
+	}
+
+	return patient, nil
+}
+
+// GetPatient -.
+func (uc *UseCase) GetPatient(ctx context.Context, id int) (entity.Patient, error) {
+	return uc.patientRepo.GetPatient(ctx, id)
+}
+
+// UpdatePatient replaces the patient's details.
+func (uc *UseCase) UpdatePatient(ctx context.Context, patient entity.Patient) (entity.Patient, error) {
+	patient = normalizePatient(patient)
+
+	err := patient.Validate(time.Now())
+	if err != nil {
+		return entity.Patient{}, err
+	}
+
+	return uc.patientRepo.UpdatePatient(ctx, patient)
+}
+
+// GetSelfPatient returns the patient the user is themselves, or entity.ErrPatientNotFound.
+func (uc *UseCase) GetSelfPatient(ctx context.Context, userID int) (entity.Patient, error) {
+	return uc.patientRepo.GetSelfPatient(ctx, userID)
+}
+
+// GetUserPatients returns the patients the user may act for: themselves and their dependants.
+func (uc *UseCase) GetUserPatients(ctx context.Context, userID int) ([]entity.LinkedPatient, error) {
+	return uc.patientRepo.GetUserPatients(ctx, userID)
+}
+
+// GetPatientLinks -.
+func (uc *UseCase) GetPatientLinks(ctx context.Context, patientID int) ([]entity.PatientLink, error) {
+	return uc.patientRepo.GetPatientLinks(ctx, patientID)
+}
+
+// LinkPatientUser lets another user act for the patient, such as a second parent.
+func (uc *UseCase) LinkPatientUser(ctx context.Context, link entity.PatientLink) error {
+	if !entity.IsRelationship(link.Relationship) {
+		return fmt.Errorf("%w: unknown relationship %q", entity.ErrInvalidPatient, link.Relationship)
+	}
+
+	_, err := uc.patientRepo.GetPatient(ctx, link.PatientID)
+	if err != nil {
+		if errors.Is(err, entity.ErrPatientNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - LinkPatientUser - uc.patientRepo.GetPatient: %w", err)
+	}
+
+	err = uc.patientRepo.LinkPatientUser(ctx, link)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) || errors.Is(err, entity.ErrPatientLinkExists) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - LinkPatientUser - uc.patientRepo.LinkPatientUser: %w", err)
+	}
+
+	uc.l.Info("user %d linked to patient %d as %s", link.UserID, link.PatientID, link.Relationship)
+
+	return nil
+}
+
+// UnlinkPatientUser stops the user from acting for the patient. The patient and their
+// appointments are kept.
+func (uc *UseCase) UnlinkPatientUser(ctx context.Context, patientID, userID int) error {
+	err := uc.patientRepo.UnlinkPatientUser(ctx, patientID, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrPatientLinkNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - UnlinkPatientUser - uc.patientRepo.UnlinkPatientUser: %w", err)
+	}
+
+	uc.l.Info("user %d unlinked from patient %d", userID, patientID)
+
+	return nil
+}
+
+func normalizePatient(patient entity.Patient) entity.Patient {
+	patient.FullName = strings.TrimSpace(patient.FullName)
+	patient.EmergencyContactName = strings.TrimSpace(patient.EmergencyContactName)
+	patient.EmergencyContactPhone = strings.TrimSpace(patient.EmergencyContactPhone)
+	patient.PreferredLanguage = strings.TrimSpace(patient.PreferredLanguage)
+
+	return patient
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// patientStore keeps patients and the users linked to them in memory.
+type patientStore struct {
+	repo.PatientRepo
+	patients map[int]entity.Patient
+	links    []entity.PatientLink
+}
+
+func (s *patientStore) CreatePatient(_ context.Context, patient entity.Patient, links []entity.PatientLink) (entity.Patient, error) {
+	patient.ID = len(s.patients) + 1
+	s.patients[patient.ID] = patient
+
+	for _, link := range links {
+		link.PatientID = patient.ID
+		s.links = append(s.links, link)
+	}
+
+	return patient, nil
+}
+
+func (s *patientStore) GetPatient(_ context.Context, id int) (entity.Patient, error) {
+	patient, ok := s.patients[id]
+	if !ok {
+		return entity.Patient{}, entity.ErrPatientNotFound
+	}
+
+	return patient, nil
+}
+
+func (s *patientStore) LinkPatientUser(_ context.Context, link entity.PatientLink) error {
+	s.links = append(s.links, link)
+
+	return nil
+}
+
+func TestCreatePatient(t *testing.T) {
+	store := &patientStore{patients: map[int]entity.Patient{}}
+	uc := NewUseCase(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, store, nil, nil, nil)
+	ctx := context.Background()
+
+	patient, err := uc.CreatePatient(ctx, entity.Patient{FullName: " Ada Doe ", PreferredLanguage: " en "},
+		[]entity.PatientLink{{UserID: 1, Relationship: entity.RelationshipParent}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Ada Doe", patient.FullName)
+	assert.Equal(t, "en", patient.PreferredLanguage)
+	assert.Equal(t, []entity.PatientLink{{PatientID: patient.ID, UserID: 1, Relationship: entity.RelationshipParent}}, store.links)
+
+	_, err = uc.CreatePatient(ctx, entity.Patient{FullName: "Ada Doe"}, []entity.PatientLink{{UserID: 1, Relationship: "neighbour"}})
+	assert.ErrorIs(t, err, entity.ErrInvalidPatient)
+	assert.Len(t, store.patients, 1)
+}
+
+func TestLinkPatientUser(t *testing.T) {
+	store := &patientStore{patients: map[int]entity.Patient{30: {ID: 30, FullName: "Ada Doe"}}}
+	uc := NewUseCase(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, store, nil, nil, nil)
+	ctx := context.Background()
+
+	require.NoError(t, uc.LinkPatientUser(ctx, entity.PatientLink{PatientID: 30, UserID: 2, Relationship: entity.RelationshipGuardian}))
+	assert.Len(t, store.links, 1)
+
+	err := uc.LinkPatientUser(ctx, entity.PatientLink{PatientID: 31, UserID: 2, Relationship: entity.RelationshipGuardian})
+	assert.ErrorIs(t, err, entity.ErrPatientNotFound)
+
+	err = uc.LinkPatientUser(ctx, entity.PatientLink{PatientID: 30, UserID: 2, Relationship: ""})
+	assert.ErrorIs(t, err, entity.ErrInvalidPatient)
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// RescheduleAppointment moves a scheduled or confirmed appointment to change.AppointmentTime.
+// A zero change.Duration or change.DoctorID keeps the current value; an appointment booked with a
+// type keeps its duration and only moves to doctors offering the type. The new time goes through
+// the same schedule and overlap checks as a new booking.
+func (uc *UseCase) RescheduleAppointment(ctx context.Context, change entity.AppointmentReschedule) (entity.Appointment, error) {
+	appointment, err := uc.appointmentRepo.GetAppointmentByID(ctx, change.AppointmentID)
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("UseCase - RescheduleAppointment - uc.appointmentRepo.GetAppointmentByID: %w", err)
+	}
+
+	if !canReschedule(appointment.Status) {
+		return entity.Appointment{}, fmt.Errorf("%w: can't reschedule a %s appointment", entity.ErrInvalidTransition, appointment.Status)
+	}
+
+	change.PreviousDoctorID = appointment.DoctorID
+	change.PreviousTime = appointment.AppointmentTime
+	change.PreviousDuration = appointment.Duration
+
+	if change.DoctorID == 0 {
+		change.DoctorID = appointment.DoctorID
+	}
+
+	if change.Duration == 0 {
+		change.Duration = appointment.Duration
+	}
+
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, change.DoctorID)
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("UseCase - RescheduleAppointment - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	err = uc.checkTypedMove(ctx, doctor, appointment, change.Duration)
+	if err != nil {
+		return entity.Appointment{}, err
+	}
+
+	appointment.DoctorID = change.DoctorID
+	appointment.AppointmentTime = change.AppointmentTime
+	appointment.Duration = change.Duration
+
+	appointment.TimeZone = doctor.TimeZone
+
+	appointment, err = place(doctor, appointment)
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("UseCase - RescheduleAppointment - %w", err)
+	}
+
+	change.LocationID = appointment.LocationID
+	change.RoomID = appointment.RoomID
+
+	err = uc.checkBooking(ctx, doctor, appointment)
+	if err != nil {
+		return entity.Appointment{}, err
+	}
+
+	err = uc.appointmentRepo.RescheduleAppointment(ctx, change)
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("UseCase - RescheduleAppointment - uc.appointmentRepo.RescheduleAppointment: %w", err)
+	}
+
+	return appointment, nil
+}
+
+// checkTypedMove refuses the changes the type of an appointment doesn't allow: another duration, or
+// a doctor who doesn't offer the type.
+func (uc *UseCase) checkTypedMove(ctx context.Context, doctor entity.Doctor, appointment entity.Appointment, duration int) error {
+	if appointment.AppointmentTypeID == nil {
+		return nil
+	}
+
+	if duration != appointment.Duration {
+		return entity.ErrDurationSetByType
+	}
+
+	if doctor.ID == appointment.DoctorID {
+		return nil
+	}
+
+	_, err := uc.appointmentTypeFor(ctx, doctor, *appointment.AppointmentTypeID)
+
+	return err
+}
+
+// GetAppointmentReschedules -.
+func (uc *UseCase) GetAppointmentReschedules(ctx context.Context, appointmentID int) ([]entity.AppointmentReschedule, error) {
+	return uc.appointmentRepo.GetAppointmentReschedules(ctx, appointmentID)
+}
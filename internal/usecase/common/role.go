@@ -0,0 +1,99 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// ListRoles -.
+func (uc *UseCase) ListRoles(ctx context.Context) ([]entity.RoleDefinition, error) {
+	return uc.roleRepo.ListRoles(ctx)
+}
+
+// GetRole -.
+func (uc *UseCase) GetRole(ctx context.Context, name entity.Role) (entity.RoleDefinition, error) {
+	return uc.roleRepo.GetRole(ctx, name)
+}
+
+// CreateRole -.
+func (uc *UseCase) CreateRole(ctx context.Context, role entity.RoleDefinition) error {
+	err := role.Validate()
+	if err != nil {
+		return err
+	}
+
+	return uc.roleRepo.CreateRole(ctx, role)
+}
+
+// UpdateRole replaces the description and permissions of a role. The admin role always keeps
+// every permission, so it can't be changed.
+func (uc *UseCase) UpdateRole(ctx context.Context, role entity.RoleDefinition) error {
+	if role.Name == entity.RoleAdmin {
+		return entity.ErrBuiltinRole
+	}
+
+	return uc.roleRepo.UpdateRole(ctx, role)
+}
+
+// SetRoleTwoFactor sets whether users with the role need TOTP. Requiring it signs the role's users
+// out everywhere, so they get back in with a code; those without TOTP are asked to set it up then.
+func (uc *UseCase) SetRoleTwoFactor(ctx context.Context, name entity.Role, required bool) error {
+	err := uc.roleRepo.SetRoleTwoFactor(ctx, name, required)
+	if err != nil {
+		if errors.Is(err, entity.ErrUnknownRole) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - SetRoleTwoFactor - uc.roleRepo.SetRoleTwoFactor: %w", err)
+	}
+
+	if !required {
+		return nil
+	}
+
+	revoked, err := uc.sessionRepo.RevokeRoleSessions(ctx, name, entity.RevokedTwoFactorRequired, time.Now())
+	if err != nil {
+		return fmt.Errorf("UseCase - SetRoleTwoFactor - uc.sessionRepo.RevokeRoleSessions: %w", err)
+	}
+
+	uc.l.Info("role %s requires two-factor authentication; %d sessions revoked", name, revoked)
+
+	return nil
+}
+
+// DeleteRole deletes a role that no user has any more. Built-in roles can't be deleted.
+func (uc *UseCase) DeleteRole(ctx context.Context, name entity.Role) error {
+	if entity.IsBuiltinRole(name) {
+		return entity.ErrBuiltinRole
+	}
+
+	return uc.roleRepo.DeleteRole(ctx, name)
+}
+
+// ListPermissions -.
+func (uc *UseCase) ListPermissions(ctx context.Context) ([]entity.PermissionInfo, error) {
+	return uc.roleRepo.ListPermissions(ctx)
+}
+
+// AssignRole gives the user the role. It takes effect on the user's next request.
+func (uc *UseCase) AssignRole(ctx context.Context, userID int, role entity.Role) error {
+	err := uc.roleRepo.AssignRole(ctx, userID, role)
+	if err != nil {
+		if errors.Is(err, entity.ErrUnknownRole) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - AssignRole - uc.roleRepo.AssignRole: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserAccess returns the user's current role and the permissions it grants.
+func (uc *UseCase) GetUserAccess(ctx context.Context, userID int) (entity.Role, []entity.Permission, error) {
+	return uc.roleRepo.GetUserAccess(ctx, userID)
+}
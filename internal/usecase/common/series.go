@@ -0,0 +1,380 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// CreateAppointmentSeries books every occurrence of series.Rule starting at series.StartsAt with the
+// series' appointment type in one transaction. Each occurrence is checked against the doctor's
+// schedule and existing bookings; with skipConflicts the free ones are booked and the rest reported,
+// otherwise any conflict books nothing.
+func (uc *UseCase) CreateAppointmentSeries(ctx context.Context, series entity.AppointmentSeries, skipConflicts bool) (entity.SeriesBooking, error) {
+	if series.AppointmentTypeID == nil {
+		return entity.SeriesBooking{}, entity.ErrAppointmentTypeRequired
+	}
+
+	rule, err := entity.ParseRecurrenceRule(series.Rule)
+	if err != nil {
+		return entity.SeriesBooking{}, err
+	}
+
+	series.Rule = rule.String()
+
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, series.DoctorID)
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("UseCase - CreateAppointmentSeries - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	appointmentType, err := uc.appointmentTypeFor(ctx, doctor, *series.AppointmentTypeID)
+	if err != nil {
+		return entity.SeriesBooking{}, err
+	}
+
+	series.Duration = appointmentType.Duration
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return entity.SeriesBooking{}, fmt.Errorf("UseCase - CreateAppointmentSeries - doctor.Location: %w", err)
+	}
+
+	times, err := rule.Occurrences(series.StartsAt.In(loc))
+	if err != nil {
+		return entity.SeriesBooking{}, err
+	}
+
+	appointments := make([]entity.Appointment, 0, len(times))
+	for _, t := range times {
+		appointments = append(appointments, doctor.Schedule.Place(appointmentType.Apply(entity.Appointment{
+			PatientID:       series.PatientID,
+			DoctorID:        series.DoctorID,
+			AppointmentTime: t,
+		}), loc))
+	}
+
+	conflicts, err := uc.checkBookings(ctx, doctor, appointments)
+	if err != nil {
+		return entity.SeriesBooking{}, err
+	}
+
+	occurrences := make([]entity.SeriesOccurrence, 0, len(times))
+	for i, t := range times {
+		occurrences = append(occurrences, entity.SeriesOccurrence{
+			AppointmentTime: t,
+			LocationID:      appointments[i].LocationID,
+			RoomID:          appointments[i].RoomID,
+			Conflict:        conflicts[i],
+		})
+	}
+
+	booking, err := uc.appointmentRepo.CreateAppointmentSeries(ctx, series, appointmentType, occurrences, skipConflicts)
+	if err != nil {
+		if errors.Is(err, entity.ErrSeriesConflict) {
+			return booking, err
+		}
+
+		return entity.SeriesBooking{}, fmt.Errorf("UseCase - CreateAppointmentSeries - uc.appointmentRepo.CreateAppointmentSeries: %w", err)
+	}
+
+	return booking, nil
+}
+
+// GetAppointmentSeries returns the series and its occurrences.
+func (uc *UseCase) GetAppointmentSeries(ctx context.Context, id int) (entity.AppointmentSeries, []entity.Appointment, error) {
+	series, err := uc.appointmentRepo.GetAppointmentSeries(ctx, id)
+	if err != nil {
+		return entity.AppointmentSeries{}, nil, fmt.Errorf("UseCase - GetAppointmentSeries - uc.appointmentRepo.GetAppointmentSeries: %w", err)
+	}
+
+	appointments, err := uc.appointmentRepo.GetAppointmentsBySeriesID(ctx, id)
+	if err != nil {
+		return entity.AppointmentSeries{}, nil, fmt.Errorf("UseCase - GetAppointmentSeries - uc.appointmentRepo.GetAppointmentsBySeriesID: %w", err)
+	}
+
+	return series, appointments, nil
+}
+
+// CancelAppointments cancels the appointment and, depending on scope, the following or all other
+// occurrences of its series in one transaction. Occurrences that can no longer be cancelled,
+// such as completed ones, are left alone. The freed slots are offered to the waitlist.
+func (uc *UseCase) CancelAppointments(ctx context.Context, appointmentID int, scope string, changedBy *int, reason string) ([]entity.Appointment, error) {
+	anchor, err := uc.appointmentRepo.GetAppointmentByID(ctx, appointmentID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - CancelAppointments - uc.appointmentRepo.GetAppointmentByID: %w", err)
+	}
+
+	if !entity.CanTransition(anchor.Status, entity.StatusCancelled) {
+		return nil, fmt.Errorf("%w: %s -> %s", entity.ErrInvalidTransition, anchor.Status, entity.StatusCancelled)
+	}
+
+	targets, err := uc.scopeAppointments(ctx, anchor, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changes   []entity.AppointmentStatusChange
+		cancelled []entity.Appointment
+	)
+
+	for _, appointment := range targets {
+		if !entity.CanTransition(appointment.Status, entity.StatusCancelled) {
+			continue
+		}
+
+		changes = append(changes, entity.AppointmentStatusChange{
+			AppointmentID: appointment.ID,
+			FromStatus:    appointment.Status,
+			ToStatus:      entity.StatusCancelled,
+			ChangedBy:     changedBy,
+			Reason:        reason,
+		})
+
+		appointment.Status = entity.StatusCancelled
+		cancelled = append(cancelled, appointment)
+	}
+
+	err = uc.appointmentRepo.TransitionAppointments(ctx, changes)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidTransition) {
+			return nil, entity.ErrAppointmentModified
+		}
+
+		return nil, fmt.Errorf("UseCase - CancelAppointments - uc.appointmentRepo.TransitionAppointments: %w", err)
+	}
+
+	for _, appointment := range cancelled {
+		uc.passOnSlot(ctx, appointment)
+	}
+
+	return cancelled, nil
+}
+
+// RescheduleAppointments moves the appointment to change.AppointmentTime and, depending on scope,
+// shifts the following or all other scheduled and confirmed occurrences of its series the same way:
+// by the same number of days, to the same wall-clock time in the doctor's time zone. Occurrences
+// booked with a type keep their duration and only move to doctors offering the type.
+// All moves are validated first and applied in one transaction.
+func (uc *UseCase) RescheduleAppointments(ctx context.Context, change entity.AppointmentReschedule, scope string) ([]entity.Appointment, error) {
+	anchor, err := uc.appointmentRepo.GetAppointmentByID(ctx, change.AppointmentID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RescheduleAppointments - uc.appointmentRepo.GetAppointmentByID: %w", err)
+	}
+
+	if !canReschedule(anchor.Status) {
+		return nil, fmt.Errorf("%w: can't reschedule a %s appointment", entity.ErrInvalidTransition, anchor.Status)
+	}
+
+	targets, err := uc.scopeAppointments(ctx, anchor, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.DoctorID == 0 {
+		change.DoctorID = anchor.DoctorID
+	}
+
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, change.DoctorID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RescheduleAppointments - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RescheduleAppointments - doctor.Location: %w", err)
+	}
+
+	var (
+		changes []entity.AppointmentReschedule
+		moved   []entity.Appointment
+	)
+
+	for _, appointment := range targets {
+		if !canReschedule(appointment.Status) {
+			continue
+		}
+
+		next := change
+		next.AppointmentID = appointment.ID
+		next.PreviousDoctorID = appointment.DoctorID
+		next.PreviousTime = appointment.AppointmentTime
+		next.PreviousDuration = appointment.Duration
+		next.AppointmentTime = shiftOccurrence(appointment.AppointmentTime, anchor.AppointmentTime, change.AppointmentTime, loc)
+
+		if next.Duration == 0 {
+			next.Duration = appointment.Duration
+		}
+
+		err = uc.checkTypedMove(ctx, doctor, appointment, next.Duration)
+		if err != nil {
+			return nil, err
+		}
+
+		appointment.DoctorID = next.DoctorID
+		appointment.AppointmentTime = next.AppointmentTime
+		appointment.Duration = next.Duration
+		appointment.TimeZone = doctor.TimeZone
+		appointment = doctor.Schedule.Place(appointment, loc)
+		moved = append(moved, appointment)
+
+		next.LocationID = appointment.LocationID
+		next.RoomID = appointment.RoomID
+		changes = append(changes, next)
+	}
+
+	conflicts, err := uc.checkBookings(ctx, doctor, moved)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, conflict := range conflicts {
+		if conflict != nil {
+			return nil, &entity.BookingError{
+				Code:    conflict.Code,
+				Message: fmt.Sprintf("occurrence at %s: %s", moved[i].AppointmentTime.In(loc).Format(time.RFC3339), conflict.Message),
+			}
+		}
+	}
+
+	// Move the occurrences in the direction of the shift first, so none lands on one that hasn't moved yet.
+	later := change.AppointmentTime.After(anchor.AppointmentTime)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if later {
+			return changes[i].PreviousTime.After(changes[j].PreviousTime)
+		}
+
+		return changes[i].PreviousTime.Before(changes[j].PreviousTime)
+	})
+
+	err = uc.appointmentRepo.RescheduleAppointments(ctx, changes)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RescheduleAppointments - uc.appointmentRepo.RescheduleAppointments: %w", err)
+	}
+
+	return moved, nil
+}
+
+// scopeAppointments returns the appointments a change with the given scope applies to, anchor included.
+// An appointment outside a series only ever affects itself.
+func (uc *UseCase) scopeAppointments(ctx context.Context, anchor entity.Appointment, scope string) ([]entity.Appointment, error) {
+	switch scope {
+	case "", entity.ScopeThis:
+		return []entity.Appointment{anchor}, nil
+	case entity.ScopeFollowing, entity.ScopeAll:
+	default:
+		return nil, entity.ErrInvalidScope
+	}
+
+	if anchor.SeriesID == nil {
+		return []entity.Appointment{anchor}, nil
+	}
+
+	appointments, err := uc.appointmentRepo.GetAppointmentsBySeriesID(ctx, *anchor.SeriesID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - scopeAppointments - uc.appointmentRepo.GetAppointmentsBySeriesID: %w", err)
+	}
+
+	if scope == entity.ScopeAll {
+		return appointments, nil
+	}
+
+	var following []entity.Appointment
+	for _, appointment := range appointments {
+		if !appointment.AppointmentTime.Before(anchor.AppointmentTime) {
+			following = append(following, appointment)
+		}
+	}
+
+	return following, nil
+}
+
+// checkBookings validates several appointments of one doctor, loading the schedule exceptions once.
+// The result holds the booking error of every appointment, nil for those that fit.
+func (uc *UseCase) checkBookings(ctx context.Context, doctor entity.Doctor, appointments []entity.Appointment) ([]*entity.BookingError, error) {
+	conflicts := make([]*entity.BookingError, len(appointments))
+	if len(appointments) == 0 {
+		return conflicts, nil
+	}
+
+	loc, err := doctor.Location()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - checkBookings - doctor.Location: %w", err)
+	}
+
+	first, last := appointments[0].AppointmentTime, appointments[0].AppointmentTime
+	for _, appointment := range appointments {
+		if appointment.AppointmentTime.Before(first) {
+			first = appointment.AppointmentTime
+		}
+
+		if appointment.AppointmentTime.After(last) {
+			last = appointment.AppointmentTime
+		}
+	}
+
+	from := startOfDay(first.In(loc))
+	to := startOfDay(last.In(loc)).AddDate(0, 0, 1)
+
+	windows, blocked, err := uc.effectiveWindows(ctx, doctor, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i, appointment := range appointments {
+		day := startOfDay(appointment.AppointmentTime.In(loc))
+		dayEnd := day.AddDate(0, 0, 1)
+
+		err = validateBooking(periodsWithin(windows, day, dayEnd), periodsWithin(blocked, day, dayEnd), appointment, now)
+
+		var bookingErr *entity.BookingError
+		if errors.As(err, &bookingErr) {
+			conflicts[i] = bookingErr
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return conflicts, nil
+}
+
+// shiftOccurrence moves t by as many calendar days as from is moved to reach to, and sets it to the
+// wall-clock time of to, all in loc. A weekly 10:00 series moved from Monday to Tuesday 11:00 stays
+// weekly on Tuesdays at 11:00, even across DST changes.
+func shiftOccurrence(t, from, to time.Time, loc *time.Location) time.Time {
+	t, from, to = t.In(loc), from.In(loc), to.In(loc)
+
+	days := int(civilDate(to).Sub(civilDate(from)).Hours() / 24)
+
+	return time.Date(t.Year(), t.Month(), t.Day()+days, to.Hour(), to.Minute(), to.Second(), 0, loc)
+}
+
+// civilDate returns t's calendar date as midnight UTC, so dates can be subtracted without DST skew.
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// periodsWithin returns the periods overlapping [from, to).
+func periodsWithin(periods []entity.Slot, from, to time.Time) []entity.Slot {
+	var within []entity.Slot
+	for _, period := range periods {
+		if period.Overlaps(from, to) {
+			within = append(within, period)
+		}
+	}
+
+	return within
+}
+
+func canReschedule(status string) bool {
+	return status == entity.StatusScheduled || status == entity.StatusConfirmed
+}
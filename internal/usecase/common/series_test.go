@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShiftOccurrence(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	// The anchor moves from Monday 2026-03-23 10:00 to Tuesday 11:00.
+	from, to := at(3, 23, 10), at(3, 24, 11)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "anchor itself", t: from, want: to},
+		{name: "a week later, across DST", t: at(3, 30, 10), want: at(3, 31, 11)},
+		{name: "a week earlier", t: at(3, 16, 10), want: at(3, 17, 11)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shiftOccurrence(tt.t, from, to, berlin)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
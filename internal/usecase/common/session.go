@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+)
+
+// CreateSession opens a session for the user and returns it with its first refresh token.
+func (uc *UseCase) CreateSession(ctx context.Context, userID int, userAgent, ip string) (entity.Session, string, error) {
+	refreshToken, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return entity.Session{}, "", fmt.Errorf("UseCase - CreateSession - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	session, err := uc.sessionRepo.CreateSession(ctx, entity.Session{
+		UserID:    userID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(uc.refreshTokenTTL),
+	}, tokens.HashOpaqueToken(refreshToken))
+	if err != nil {
+		return entity.Session{}, "", fmt.Errorf("UseCase - CreateSession - uc.sessionRepo.CreateSession: %w", err)
+	}
+
+	return session, refreshToken, nil
+}
+
+// RefreshSession exchanges a refresh token for a new one. Each token works once: presenting a
+// rotated token again revokes the whole session, since either the client or an attacker holds a copy.
+func (uc *UseCase) RefreshSession(ctx context.Context, refreshToken string) (entity.Session, string, error) {
+	next, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return entity.Session{}, "", fmt.Errorf("UseCase - RefreshSession - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	now := time.Now()
+
+	session, err := uc.sessionRepo.RotateRefreshToken(ctx, tokens.HashOpaqueToken(refreshToken), tokens.HashOpaqueToken(next), now, now.Add(uc.refreshTokenTTL))
+	if err != nil {
+		if errors.Is(err, entity.ErrRefreshTokenReused) {
+			uc.l.Warn("refresh token reused, session revoked")
+		}
+
+		if errors.Is(err, entity.ErrInvalidRefreshToken) || errors.Is(err, entity.ErrRefreshTokenReused) {
+			return entity.Session{}, "", err
+		}
+
+		return entity.Session{}, "", fmt.Errorf("UseCase - RefreshSession - uc.sessionRepo.RotateRefreshToken: %w", err)
+	}
+
+	return session, next, nil
+}
+
+// CheckSession returns entity.ErrSessionRevoked unless the session belongs to the user and is still active.
+func (uc *UseCase) CheckSession(ctx context.Context, userID, sessionID int) error {
+	session, err := uc.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, entity.ErrSessionRevoked) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - CheckSession - uc.sessionRepo.GetSession: %w", err)
+	}
+
+	if session.UserID != userID || !session.Active(time.Now()) {
+		return entity.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// Logout revokes one session of the user.
+func (uc *UseCase) Logout(ctx context.Context, userID, sessionID int) error {
+	return uc.sessionRepo.RevokeSession(ctx, userID, sessionID, entity.RevokedLogout, time.Now())
+}
+
+// LogoutAll revokes every session of the user and returns how many were open.
+func (uc *UseCase) LogoutAll(ctx context.Context, userID int) (int, error) {
+	return uc.sessionRepo.RevokeUserSessions(ctx, userID, entity.RevokedLogoutAll, time.Now())
+}
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// CreateSpecialization adds the specialization to the catalogue.
+func (uc *UseCase) CreateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error) {
+	specialization = normalizeSpecialization(specialization)
+
+	err := specialization.Validate()
+	if err != nil {
+		return entity.Specialization{}, err
+	}
+
+	created, err := uc.specializationRepo.CreateSpecialization(ctx, specialization)
+	if err != nil {
+		if errors.Is(err, entity.ErrSpecializationExists) {
+			return entity.Specialization{}, err
+		}
+
+		return entity.Specialization{}, fmt.Errorf("UseCase - CreateSpecialization - uc.specializationRepo.CreateSpecialization: %w", err)
+	}
+
+	uc.l.Info("specialization %s created", created.Code)
+
+	return created, nil
+}
+
+// GetSpecialization -.
+func (uc *UseCase) GetSpecialization(ctx context.Context, id int) (entity.Specialization, error) {
+	return uc.specializationRepo.GetSpecialization(ctx, id)
+}
+
+// ListSpecializations returns the catalogue, one entry per specialization.
+func (uc *UseCase) ListSpecializations(ctx context.Context) ([]entity.Specialization, error) {
+	return uc.specializationRepo.ListSpecializations(ctx)
+}
+
+// UpdateSpecialization replaces the specialization. Doctors keep holding it and waitlist entries
+// follow a new code.
+func (uc *UseCase) UpdateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error) {
+	specialization = normalizeSpecialization(specialization)
+
+	err := specialization.Validate()
+	if err != nil {
+		return entity.Specialization{}, err
+	}
+
+	updated, err := uc.specializationRepo.UpdateSpecialization(ctx, specialization)
+	if err != nil {
+		if errors.Is(err, entity.ErrSpecializationNotFound) || errors.Is(err, entity.ErrSpecializationExists) {
+			return entity.Specialization{}, err
+		}
+
+		return entity.Specialization{}, fmt.Errorf("UseCase - UpdateSpecialization - uc.specializationRepo.UpdateSpecialization: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteSpecialization removes the specialization from the catalogue. One that doctors hold or
+// patients wait for is kept.
+func (uc *UseCase) DeleteSpecialization(ctx context.Context, id int) error {
+	err := uc.specializationRepo.DeleteSpecialization(ctx, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrSpecializationNotFound) || errors.Is(err, entity.ErrSpecializationInUse) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeleteSpecialization - uc.specializationRepo.DeleteSpecialization: %w", err)
+	}
+
+	uc.l.Info("specialization %d deleted", id)
+
+	return nil
+}
+
+// resolveSpecializations looks the specializations up in the catalogue by code, keeping their
+// order and dropping repeats. It returns entity.ErrSpecializationNotFound for an unknown code.
+func (uc *UseCase) resolveSpecializations(ctx context.Context, specializations []entity.Specialization) ([]entity.Specialization, error) {
+	codes := make([]string, 0, len(specializations))
+	seen := make(map[string]bool, len(specializations))
+
+	for _, specialization := range specializations {
+		code := normalizeSpecializationCode(specialization.Code)
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	resolved, err := uc.specializationRepo.GetSpecializationsByCode(ctx, codes)
+	if err != nil {
+		if errors.Is(err, entity.ErrSpecializationNotFound) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("UseCase - resolveSpecializations - uc.specializationRepo.GetSpecializationsByCode: %w", err)
+	}
+
+	return resolved, nil
+}
+
+func normalizeSpecialization(specialization entity.Specialization) entity.Specialization {
+	specialization.Code = normalizeSpecializationCode(specialization.Code)
+	specialization.Description = strings.TrimSpace(specialization.Description)
+
+	if specialization.DefaultDuration == 0 {
+		specialization.DefaultDuration = entity.DefaultVisitMinutes
+	}
+
+	names := make(map[string]string, len(specialization.Names))
+	for language, name := range specialization.Names {
+		names[strings.ToLower(strings.TrimSpace(language))] = strings.TrimSpace(name)
+	}
+
+	specialization.Names = names
+
+	return specialization
+}
+
+func normalizeSpecializationCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
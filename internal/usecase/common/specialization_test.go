@@ -0,0 +1,108 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// specializationStore holds a catalogue of cardiology and dermatology.
+type specializationStore struct {
+	repo.SpecializationRepo
+	created []entity.Specialization
+}
+
+var _catalogue = map[string]entity.Specialization{
+	"cardiology":  {ID: 1, Code: "cardiology", Names: map[string]string{"en": "Cardiology"}, DefaultDuration: 20},
+	"dermatology": {ID: 2, Code: "dermatology", Names: map[string]string{"en": "Dermatology"}, DefaultDuration: 15},
+}
+
+func (s *specializationStore) CreateSpecialization(_ context.Context, specialization entity.Specialization) (entity.Specialization, error) {
+	s.created = append(s.created, specialization)
+
+	return specialization, nil
+}
+
+func (s *specializationStore) GetSpecializationsByCode(_ context.Context, codes []string) ([]entity.Specialization, error) {
+	specializations := make([]entity.Specialization, 0, len(codes))
+	for _, code := range codes {
+		specialization, ok := _catalogue[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", entity.ErrSpecializationNotFound, code)
+		}
+
+		specializations = append(specializations, specialization)
+	}
+
+	return specializations, nil
+}
+
+// waitlistStore accepts every entry.
+type waitlistStore struct {
+	repo.WaitlistRepo
+}
+
+func (waitlistStore) CreateWaitlistEntry(context.Context, entity.WaitlistEntry) (int, error) {
+	return 1, nil
+}
+
+func TestCreateSpecialization(t *testing.T) {
+	store := &specializationStore{}
+	uc := NewUseCase(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, store, nil, nil)
+
+	_, err := uc.CreateSpecialization(context.Background(), entity.Specialization{
+		Code:  " Sports-Medicine ",
+		Names: map[string]string{" EN ": " Sports medicine ", "de": "Sportmedizin"},
+	})
+	require.NoError(t, err)
+	require.Len(t, store.created, 1)
+
+	assert.Equal(t, entity.Specialization{
+		Code:            "sports-medicine",
+		Names:           map[string]string{"en": "Sports medicine", "de": "Sportmedizin"},
+		DefaultDuration: entity.DefaultVisitMinutes,
+	}, store.created[0])
+
+	_, err = uc.CreateSpecialization(context.Background(), entity.Specialization{Code: "sports medicine", Names: map[string]string{"en": "Sports medicine"}})
+	assert.ErrorIs(t, err, entity.ErrInvalidSpecialization)
+	assert.Len(t, store.created, 1)
+}
+
+func TestCreateDoctorSpecializations(t *testing.T) {
+	doctors := &doctorStore{}
+	uc := NewUseCase(nil, doctors, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &specializationStore{}, nil, nil)
+	ctx := context.Background()
+
+	err := uc.CreateDoctor(ctx, entity.Doctor{Name: "Dr. Heart", Specializations: []entity.Specialization{
+		{Code: "Cardiology"}, {Code: "dermatology"}, {Code: "cardiology "},
+	}})
+	require.NoError(t, err)
+	require.Len(t, doctors.created, 1)
+
+	assert.Equal(t, []entity.Specialization{_catalogue["cardiology"], _catalogue["dermatology"]}, doctors.created[0].Specializations)
+	assert.Equal(t, 20, doctors.created[0].VisitMinutes())
+
+	err = uc.CreateDoctor(ctx, entity.Doctor{Name: "Dr. Who", Specializations: []entity.Specialization{{Code: "cardiologist"}}})
+	assert.ErrorIs(t, err, entity.ErrSpecializationNotFound)
+	assert.Len(t, doctors.created, 1)
+}
+
+func TestJoinWaitlistSpecialization(t *testing.T) {
+	uc := NewUseCase(nil, nil, nil, nil, waitlistStore{}, nil, nil, nil, nil, nil, nil, nil, &specializationStore{}, nil, nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	entry, err := uc.JoinWaitlist(ctx, entity.WaitlistEntry{PatientID: 1, Specialization: " Cardiology", From: now, To: now.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, "cardiology", entry.Specialization)
+
+	_, err = uc.JoinWaitlist(ctx, entity.WaitlistEntry{PatientID: 1, Specialization: "cardiologist", From: now, To: now.Add(48 * time.Hour)})
+	assert.ErrorIs(t, err, entity.ErrInvalidWaitlistEntry)
+	assert.ErrorIs(t, err, entity.ErrSpecializationNotFound)
+}
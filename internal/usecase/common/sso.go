@@ -0,0 +1,200 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+)
+
+// _maxFullNameLength is the length of users.fullname.
+const _maxFullNameLength = 50
+
+// StartOIDCLogin returns the identity provider's sign-in page to send the user to. The sign-in
+// has to be finished with FinishOIDCLogin before it expires.
+func (uc *UseCase) StartOIDCLogin(ctx context.Context) (string, error) {
+	if uc.oidc == nil {
+		return "", entity.ErrSSODisabled
+	}
+
+	state, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("UseCase - StartOIDCLogin - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	nonce, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("UseCase - StartOIDCLogin - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", fmt.Errorf("UseCase - StartOIDCLogin - oidc.NewCodeVerifier: %w", err)
+	}
+
+	now := time.Now()
+
+	err = uc.identityRepo.CreateOIDCLogin(ctx, entity.OIDCLogin{
+		StateHash:    tokens.HashOpaqueToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(uc.oidcLoginTTL),
+	}, now)
+	if err != nil {
+		return "", fmt.Errorf("UseCase - StartOIDCLogin - uc.identityRepo.CreateOIDCLogin: %w", err)
+	}
+
+	authURL, err := uc.oidc.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return "", fmt.Errorf("UseCase - StartOIDCLogin - uc.oidc.AuthCodeURL: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// FinishOIDCLogin exchanges the code the identity provider redirected back with for the user's
+// ID token and returns the user it signs in. A provider account seen for the first time is linked
+// to the user with its email, if the provider has verified the address, or gets a new user. A new
+// user's role comes from their provider groups, or is the default role; only one that came from the
+// groups keeps following them on later sign-ins, and never falls back to the default role. Linked
+// users keep the role they have here. Deactivated users are refused with entity.ErrUserDeactivated.
+func (uc *UseCase) FinishOIDCLogin(ctx context.Context, code, state string) (entity.User, error) {
+	if uc.oidc == nil {
+		return entity.User{}, entity.ErrSSODisabled
+	}
+
+	now := time.Now()
+
+	login, err := uc.identityRepo.UseOIDCLogin(ctx, tokens.HashOpaqueToken(state), now)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidSSOState) {
+			return entity.User{}, err
+		}
+
+		return entity.User{}, fmt.Errorf("UseCase - FinishOIDCLogin - uc.identityRepo.UseOIDCLogin: %w", err)
+	}
+
+	idToken, err := uc.oidc.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("%w: %v", entity.ErrSSOFailed, err)
+	}
+
+	claims, err := uc.oidc.VerifyIDToken(ctx, idToken, login.Nonce)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("%w: %v", entity.ErrSSOFailed, err)
+	}
+
+	role, fromGroups := entity.RoleForGroups(uc.oidcGroupRoles, claims.Groups)
+	if !fromGroups {
+		if uc.oidcDefaultRole == "" {
+			return entity.User{}, entity.ErrNoRoleForGroups
+		}
+
+		role = uc.oidcDefaultRole
+	}
+
+	identity := entity.Identity{Issuer: claims.Issuer, Subject: claims.Subject, Email: strings.TrimSpace(claims.Email)}
+
+	user, linked, err := uc.identityRepo.GetIdentityUser(ctx, identity, now)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		identity.RoleFromGroups = fromGroups
+
+		user, err = uc.linkIdentity(ctx, identity, claims, role, now)
+		if err != nil {
+			return entity.User{}, err
+		}
+	} else if err != nil {
+		return entity.User{}, fmt.Errorf("UseCase - FinishOIDCLogin - uc.identityRepo.GetIdentityUser: %w", err)
+	}
+
+	if user.DeactivatedAt != nil {
+		return entity.User{}, entity.ErrUserDeactivated
+	}
+
+	if fromGroups && linked.RoleFromGroups && user.Role != role {
+		assigned, err := uc.identityRepo.AssignIdentityRole(ctx, linked, role)
+		if err != nil {
+			return entity.User{}, fmt.Errorf("UseCase - FinishOIDCLogin - uc.identityRepo.AssignIdentityRole: %w", err)
+		}
+
+		if assigned {
+			uc.l.Info("sso: user %d role changed from %s to %s by their provider groups", user.ID, user.Role, role)
+
+			user.Role = role
+		}
+	}
+
+	return user, nil
+}
+
+// linkIdentity links the provider account to the user with its email, who keeps their role, or to
+// a new user with the role if there is none.
+func (uc *UseCase) linkIdentity(ctx context.Context, identity entity.Identity, claims oidc.Claims, role entity.Role, now time.Time) (entity.User, error) {
+	if identity.Email == "" {
+		return entity.User{}, fmt.Errorf("%w: the provider did not share an email address", entity.ErrSSOFailed)
+	}
+
+	user, err := uc.userRepo.GetUserByEmail(ctx, identity.Email)
+	if err == nil {
+		if !claims.EmailVerified {
+			return entity.User{}, entity.ErrIdentityConflict
+		}
+
+		identity.UserID = user.ID
+		identity.RoleFromGroups = false
+
+		err = uc.identityRepo.LinkIdentity(ctx, identity)
+		if err != nil {
+			return entity.User{}, fmt.Errorf("UseCase - linkIdentity - uc.identityRepo.LinkIdentity: %w", err)
+		}
+
+		uc.l.Info("sso: linked %s account %s to user %d", identity.Issuer, identity.Subject, user.ID)
+
+		return user, nil
+	}
+
+	if !errors.Is(err, entity.ErrUserNotFound) {
+		return entity.User{}, fmt.Errorf("UseCase - linkIdentity - uc.userRepo.GetUserByEmail: %w", err)
+	}
+
+	id, err := uc.identityRepo.CreateIdentityUser(ctx, entity.User{
+		FullName: identityFullName(claims),
+		Email:    identity.Email,
+		Role:     role,
+	}, identity, now)
+	if err != nil {
+		if errors.Is(err, entity.ErrIdentityConflict) || errors.Is(err, entity.ErrUnknownRole) {
+			return entity.User{}, err
+		}
+
+		return entity.User{}, fmt.Errorf("UseCase - linkIdentity - uc.identityRepo.CreateIdentityUser: %w", err)
+	}
+
+	user, err = uc.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("UseCase - linkIdentity - uc.userRepo.GetUserByID: %w", err)
+	}
+
+	return user, nil
+}
+
+// identityFullName is the name the provider has for the user, or the local part of their email.
+func identityFullName(claims oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	for utf8.RuneCountInString(name) > _maxFullNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	return name
+}
@@ -0,0 +1,300 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ssoStore keeps the users, identities and sign-ins single sign-on touches in memory.
+type ssoStore struct {
+	users      map[int]entity.User
+	identities map[string]entity.Identity
+	logins     map[string]entity.OIDCLogin
+}
+
+func newSSOStore(users ...entity.User) *ssoStore {
+	s := &ssoStore{users: map[int]entity.User{}, identities: map[string]entity.Identity{}, logins: map[string]entity.OIDCLogin{}}
+	for _, u := range users {
+		s.users[u.ID] = u
+	}
+
+	return s
+}
+
+func (s *ssoStore) CreateOIDCLogin(_ context.Context, login entity.OIDCLogin, _ time.Time) error {
+	s.logins[login.StateHash] = login
+
+	return nil
+}
+
+func (s *ssoStore) UseOIDCLogin(_ context.Context, stateHash string, now time.Time) (entity.OIDCLogin, error) {
+	login, ok := s.logins[stateHash]
+	delete(s.logins, stateHash)
+
+	if !ok || !login.ExpiresAt.After(now) {
+		return entity.OIDCLogin{}, entity.ErrInvalidSSOState
+	}
+
+	return login, nil
+}
+
+func (s *ssoStore) GetIdentityUser(_ context.Context, identity entity.Identity, _ time.Time) (entity.User, entity.Identity, error) {
+	linked, ok := s.identities[identity.Issuer+" "+identity.Subject]
+	if !ok {
+		return entity.User{}, entity.Identity{}, entity.ErrUserNotFound
+	}
+
+	return s.users[linked.UserID], linked, nil
+}
+
+func (s *ssoStore) AssignIdentityRole(_ context.Context, identity entity.Identity, role entity.Role) (bool, error) {
+	linked := s.identities[identity.Issuer+" "+identity.Subject]
+	if !linked.RoleFromGroups {
+		return false, nil
+	}
+
+	user := s.users[linked.UserID]
+	user.Role = role
+	s.users[linked.UserID] = user
+
+	return true, nil
+}
+
+func (s *ssoStore) LinkIdentity(_ context.Context, identity entity.Identity) error {
+	s.identities[identity.Issuer+" "+identity.Subject] = identity
+
+	return nil
+}
+
+func (s *ssoStore) CreateIdentityUser(ctx context.Context, user entity.User, identity entity.Identity, _ time.Time) (int, error) {
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return 0, entity.ErrIdentityConflict
+		}
+	}
+
+	user.ID = len(s.users) + 1
+	s.users[user.ID] = user
+	identity.UserID = user.ID
+
+	return user.ID, s.LinkIdentity(ctx, identity)
+}
+
+type ssoUserRepo struct {
+	repo.UserRepo
+	s *ssoStore
+}
+
+func (r ssoUserRepo) GetUserByID(_ context.Context, id int) (entity.User, error) {
+	user, ok := r.s.users[id]
+	if !ok {
+		return entity.User{}, entity.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (r ssoUserRepo) GetUserByEmail(_ context.Context, email string) (entity.User, error) {
+	for _, u := range r.s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+
+	return entity.User{}, entity.ErrUserNotFound
+}
+
+type ssoRoleRepo struct {
+	repo.RoleRepo
+	s *ssoStore
+}
+
+func (r ssoRoleRepo) AssignRole(_ context.Context, userID int, role entity.Role) error {
+	user := r.s.users[userID]
+	user.Role = role
+	r.s.users[userID] = user
+
+	for key, identity := range r.s.identities {
+		if identity.UserID == userID {
+			identity.RoleFromGroups = false
+			r.s.identities[key] = identity
+		}
+	}
+
+	return nil
+}
+
+var _ssoGroupRoles = []entity.GroupRole{{Group: "it-admins", Role: entity.RoleAdmin}, {Group: "front-desk", Role: entity.RoleReceptionist}}
+
+func newSSOUseCase(t *testing.T, s *ssoStore, defaultRole entity.Role) (*UseCase, *oidctest.Provider) {
+	t.Helper()
+
+	idp := oidctest.NewProvider("clinic", "s3cret")
+	t.Cleanup(idp.Close)
+
+	provider := oidc.New(idp.URL, "clinic", "s3cret", "http://localhost:8070/v1/auth/oidc/callback")
+
+	uc := NewUseCase(ssoUserRepo{s: s}, nil, nil, nil, nil, ssoRoleRepo{s: s}, nil, nil, nil, s, nil, nil, nil, nil, nil,
+		OIDC(provider, _ssoGroupRoles, defaultRole))
+
+	return uc, idp
+}
+
+func signInWithSSO(t *testing.T, uc *UseCase, idp *oidctest.Provider) (entity.User, error) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	authURL, err := uc.StartOIDCLogin(ctx)
+	require.NoError(t, err)
+
+	code, state, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+
+	return uc.FinishOIDCLogin(ctx, code, state)
+}
+
+func TestSSOCreatesUser(t *testing.T) {
+	s := newSSOStore()
+	uc, idp := newSSOUseCase(t, s, "")
+
+	idp.SignInAs(map[string]any{"sub": "u-1", "email": "jane@clinic.example", "name": "Jane Doe", "groups": []string{"front-desk"}})
+
+	user, err := signInWithSSO(t, uc, idp)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", user.FullName)
+	assert.Equal(t, "jane@clinic.example", user.Email)
+	assert.Equal(t, entity.RoleReceptionist, user.Role)
+
+	// The next sign-in finds the same user and moves them to their new group's role.
+	idp.SignInAs(map[string]any{"sub": "u-1", "email": "jane@clinic.example", "groups": []string{"front-desk", "it-admins"}})
+
+	again, err := signInWithSSO(t, uc, idp)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID)
+	assert.Equal(t, entity.RoleAdmin, again.Role)
+	assert.Equal(t, entity.RoleAdmin, s.users[user.ID].Role)
+
+	// A role assigned here is kept whatever the groups say.
+	require.NoError(t, uc.AssignRole(context.Background(), user.ID, entity.RoleDoctor))
+
+	idp.SignInAs(map[string]any{"sub": "u-1", "email": "jane@clinic.example", "groups": []string{"front-desk"}})
+
+	again, err = signInWithSSO(t, uc, idp)
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoleDoctor, again.Role)
+}
+
+func TestSSOLinksUserByEmail(t *testing.T) {
+	existing := entity.User{ID: 7, FullName: "Jane Doe", Email: "jane@clinic.example", Role: entity.RoleUser}
+
+	t.Run("verified", func(t *testing.T) {
+		s := newSSOStore(existing)
+		uc, idp := newSSOUseCase(t, s, "")
+
+		idp.SignInAs(map[string]any{"sub": "u-1", "email": "jane@clinic.example", "email_verified": true, "groups": []string{"front-desk"}})
+
+		user, err := signInWithSSO(t, uc, idp)
+		require.NoError(t, err)
+		assert.Equal(t, 7, user.ID)
+		assert.Equal(t, entity.RoleUser, user.Role, "a linked user keeps their role")
+		assert.Len(t, s.users, 1)
+	})
+
+	t.Run("unverified", func(t *testing.T) {
+		s := newSSOStore(existing)
+		uc, idp := newSSOUseCase(t, s, "")
+
+		idp.SignInAs(map[string]any{"sub": "u-1", "email": "jane@clinic.example", "groups": []string{"front-desk"}})
+
+		_, err := signInWithSSO(t, uc, idp)
+		assert.ErrorIs(t, err, entity.ErrIdentityConflict)
+		assert.Empty(t, s.identities)
+	})
+}
+
+func TestSSOGroupsWithoutRole(t *testing.T) {
+	claims := map[string]any{"sub": "u-1", "email": "guest@clinic.example", "groups": []string{"visitors"}}
+
+	t.Run("denied", func(t *testing.T) {
+		s := newSSOStore()
+		uc, idp := newSSOUseCase(t, s, "")
+		idp.SignInAs(claims)
+
+		_, err := signInWithSSO(t, uc, idp)
+		assert.ErrorIs(t, err, entity.ErrNoRoleForGroups)
+		assert.Empty(t, s.users)
+	})
+
+	t.Run("default role", func(t *testing.T) {
+		uc, idp := newSSOUseCase(t, newSSOStore(), entity.RoleUser)
+		idp.SignInAs(claims)
+
+		user, err := signInWithSSO(t, uc, idp)
+		require.NoError(t, err)
+		assert.Equal(t, entity.RoleUser, user.Role)
+	})
+
+	t.Run("default role keeps a higher one", func(t *testing.T) {
+		s := newSSOStore()
+		uc, idp := newSSOUseCase(t, s, entity.RoleUser)
+
+		idp.SignInAs(map[string]any{"sub": "u-1", "email": "guest@clinic.example", "groups": []string{"it-admins"}})
+
+		user, err := signInWithSSO(t, uc, idp)
+		require.NoError(t, err)
+		assert.Equal(t, entity.RoleAdmin, user.Role)
+
+		idp.SignInAs(claims)
+
+		again, err := signInWithSSO(t, uc, idp)
+		require.NoError(t, err)
+		assert.Equal(t, entity.RoleAdmin, again.Role)
+	})
+}
+
+func TestSSOState(t *testing.T) {
+	uc, idp := newSSOUseCase(t, newSSOStore(), entity.RoleUser)
+	idp.SignInAs(map[string]any{"sub": "u-1", "email": "jane@clinic.example"})
+	ctx := context.Background()
+
+	authURL, err := uc.StartOIDCLogin(ctx)
+	require.NoError(t, err)
+
+	code, state, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = uc.FinishOIDCLogin(ctx, code, "forged")
+	assert.ErrorIs(t, err, entity.ErrInvalidSSOState)
+
+	_, err = uc.FinishOIDCLogin(ctx, code, state)
+	require.NoError(t, err)
+
+	_, err = uc.FinishOIDCLogin(ctx, code, state)
+	assert.ErrorIs(t, err, entity.ErrInvalidSSOState, "a sign-in can't be finished twice")
+}
+
+func TestSSODisabled(t *testing.T) {
+	uc := NewUseCase(nil, nil, nil, nil, nil, nil, nil, nil, nil, newSSOStore(), nil, nil, nil, nil, nil)
+
+	_, err := uc.StartOIDCLogin(context.Background())
+	assert.ErrorIs(t, err, entity.ErrSSODisabled)
+
+	_, err = uc.FinishOIDCLogin(context.Background(), "code", "state")
+	assert.ErrorIs(t, err, entity.ErrSSODisabled)
+}
+
+func TestIdentityFullName(t *testing.T) {
+	assert.Equal(t, "Jane Doe", identityFullName(oidc.Claims{Name: " Jane Doe ", Email: "jane@clinic.example"}))
+	assert.Equal(t, "jane", identityFullName(oidc.Claims{Email: "jane@clinic.example"}))
+	assert.Equal(t, strings.Repeat("é", _maxFullNameLength), identityFullName(oidc.Claims{Name: strings.Repeat("é", 60)}))
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// ChangeAppointmentStatus moves the appointment to the given status if the lifecycle allows it
+// and records who made the change. A cancelled appointment's slot is offered to the waitlist.
+func (uc *UseCase) ChangeAppointmentStatus(ctx context.Context, id int, status string, changedBy *int, reason string) (entity.Appointment, error) {
+	appointment, err := uc.appointmentRepo.GetAppointmentByID(ctx, id)
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("UseCase - ChangeAppointmentStatus - uc.appointmentRepo.GetAppointmentByID: %w", err)
+	}
+
+	if !entity.CanTransition(appointment.Status, status) {
+		return entity.Appointment{}, fmt.Errorf("%w: %s -> %s", entity.ErrInvalidTransition, appointment.Status, status)
+	}
+
+	err = uc.appointmentRepo.TransitionAppointment(ctx, entity.AppointmentStatusChange{
+		AppointmentID: id,
+		FromStatus:    appointment.Status,
+		ToStatus:      status,
+		ChangedBy:     changedBy,
+		Reason:        reason,
+	})
+	if err != nil {
+		return entity.Appointment{}, fmt.Errorf("UseCase - ChangeAppointmentStatus - uc.appointmentRepo.TransitionAppointment: %w", err)
+	}
+
+	if status == entity.StatusCancelled {
+		uc.passOnSlot(ctx, appointment)
+	}
+
+	appointment.Status = status
+
+	return appointment, nil
+}
+
+// GetAppointmentStatusHistory -.
+func (uc *UseCase) GetAppointmentStatusHistory(ctx context.Context, appointmentID int) ([]entity.AppointmentStatusChange, error) {
+	return uc.appointmentRepo.GetAppointmentStatusHistory(ctx, appointmentID)
+}
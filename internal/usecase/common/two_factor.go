@@ -0,0 +1,338 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	tokens "github.com/dostonshernazarov/doctor-appointment/pkg/token"
+	"github.com/dostonshernazarov/doctor-appointment/pkg/totp"
+)
+
+const (
+	// _totpSkew is how many periods either side of now a code is accepted, for clock drift.
+	_totpSkew = 1
+
+	_recoveryCodes     = 10
+	_recoveryCodeBytes = 7
+)
+
+// GetTwoFactor -.
+func (uc *UseCase) GetTwoFactor(ctx context.Context, userID int) (entity.TwoFactor, error) {
+	return uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+}
+
+// StartTwoFactor returns the challenge a user who passed the password check has to answer before
+// getting tokens, or a zero challenge if they sign in with the password alone.
+func (uc *UseCase) StartTwoFactor(ctx context.Context, userID int) (entity.TwoFactorChallenge, error) {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return entity.TwoFactorChallenge{}, fmt.Errorf("UseCase - StartTwoFactor - uc.twoFactorRepo.GetTwoFactor: %w", err)
+	}
+
+	method := twoFactor.Method()
+	if method == "" {
+		return entity.TwoFactorChallenge{}, nil
+	}
+
+	token, err := tokens.GenerateOpaqueToken()
+	if err != nil {
+		return entity.TwoFactorChallenge{}, fmt.Errorf("UseCase - StartTwoFactor - tokens.GenerateOpaqueToken: %w", err)
+	}
+
+	expiresAt := time.Now().Add(uc.twoFactorChallengeTTL)
+
+	err = uc.userRepo.CreateUserToken(ctx, entity.UserToken{
+		UserID:    userID,
+		Purpose:   entity.TokenTwoFactor,
+		TokenHash: tokens.HashOpaqueToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return entity.TwoFactorChallenge{}, fmt.Errorf("UseCase - StartTwoFactor - uc.userRepo.CreateUserToken: %w", err)
+	}
+
+	return entity.TwoFactorChallenge{Token: token, Method: method, ExpiresAt: expiresAt}, nil
+}
+
+// TwoFactorChallengeUser returns the user a sign-in challenge was handed to, or
+// entity.ErrInvalidUserToken.
+func (uc *UseCase) TwoFactorChallengeUser(ctx context.Context, challenge string) (int, error) {
+	userID, err := uc.twoFactorRepo.GetTwoFactorChallenge(ctx, tokens.HashOpaqueToken(challenge), time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidUserToken) {
+			return 0, err
+		}
+
+		return 0, fmt.Errorf("UseCase - TwoFactorChallengeUser - uc.twoFactorRepo.GetTwoFactorChallenge: %w", err)
+	}
+
+	return userID, nil
+}
+
+// VerifyTwoFactor answers a sign-in challenge with a TOTP or recovery code and returns the user,
+// who may now be given tokens. Answering an enrollment challenge turns TOTP on and also returns
+// the user's first recovery codes. Wrong codes count as failed sign-ins from ip, and a right one
+// forgets the failed sign-ins of the account.
+func (uc *UseCase) VerifyTwoFactor(ctx context.Context, challenge, code, ip string) (int, []string, error) {
+	now := time.Now()
+	challengeHash := tokens.HashOpaqueToken(challenge)
+
+	userID, err := uc.twoFactorRepo.GetTwoFactorChallenge(ctx, challengeHash, now)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidUserToken) {
+			return 0, nil, err
+		}
+
+		return 0, nil, fmt.Errorf("UseCase - VerifyTwoFactor - uc.twoFactorRepo.GetTwoFactorChallenge: %w", err)
+	}
+
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("UseCase - VerifyTwoFactor - uc.userRepo.GetUserByID: %w", err)
+	}
+
+	err = uc.CheckLogin(ctx, user.Email, ip)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("UseCase - VerifyTwoFactor - uc.twoFactorRepo.GetTwoFactor: %w", err)
+	}
+
+	var recoveryCodes []string
+
+	switch twoFactor.Method() {
+	case entity.TwoFactorTOTP:
+		err = uc.checkTwoFactorCode(ctx, twoFactor, code, now)
+	case entity.TwoFactorEnroll:
+		recoveryCodes, err = uc.enableTOTP(ctx, twoFactor, code, now)
+	}
+
+	if errors.Is(err, entity.ErrInvalidTwoFactorCode) {
+		if failErr := uc.RecordLoginFailure(ctx, user.Email, ip); failErr != nil {
+			uc.l.Error(failErr, "UseCase - VerifyTwoFactor")
+		}
+
+		return 0, nil, err
+	}
+
+	if err != nil {
+		if errors.Is(err, entity.ErrTwoFactorNotSetUp) {
+			return 0, nil, err
+		}
+
+		return 0, nil, fmt.Errorf("UseCase - VerifyTwoFactor - uc.checkTwoFactorCode: %w", err)
+	}
+
+	_, err = uc.twoFactorRepo.UseTwoFactorChallenge(ctx, challengeHash, now)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidUserToken) {
+			return 0, nil, err
+		}
+
+		return 0, nil, fmt.Errorf("UseCase - VerifyTwoFactor - uc.twoFactorRepo.UseTwoFactorChallenge: %w", err)
+	}
+
+	err = uc.RecordLoginSuccess(ctx, user.Email)
+	if err != nil {
+		uc.l.Error(err, "UseCase - VerifyTwoFactor")
+	}
+
+	return userID, recoveryCodes, nil
+}
+
+// SetupTOTP gives the user a new secret to add to their authenticator app. TOTP stays off until
+// ConfirmTOTP, or an enrollment challenge, gets a code generated from it.
+func (uc *UseCase) SetupTOTP(ctx context.Context, userID int) (entity.TOTPSetup, error) {
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.TOTPSetup{}, fmt.Errorf("UseCase - SetupTOTP - uc.userRepo.GetUserByID: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return entity.TOTPSetup{}, fmt.Errorf("UseCase - SetupTOTP - totp.GenerateSecret: %w", err)
+	}
+
+	err = uc.twoFactorRepo.SetTOTPSecret(ctx, userID, secret)
+	if err != nil {
+		if errors.Is(err, entity.ErrTwoFactorEnabled) {
+			return entity.TOTPSetup{}, err
+		}
+
+		return entity.TOTPSetup{}, fmt.Errorf("UseCase - SetupTOTP - uc.twoFactorRepo.SetTOTPSecret: %w", err)
+	}
+
+	return entity.TOTPSetup{Secret: secret, URI: uc.totp.URI(uc.twoFactorIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmTOTP turns TOTP on with a code from the secret SetupTOTP gave and returns the user's
+// recovery codes, which are shown only this once.
+func (uc *UseCase) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - ConfirmTOTP - uc.twoFactorRepo.GetTwoFactor: %w", err)
+	}
+
+	if twoFactor.Enabled() {
+		return nil, entity.ErrTwoFactorEnabled
+	}
+
+	return uc.enableTOTP(ctx, twoFactor, code, time.Now())
+}
+
+// DisableTOTP turns TOTP off after checking a current TOTP or recovery code. Users whose role
+// requires TOTP can't turn it off.
+func (uc *UseCase) DisableTOTP(ctx context.Context, userID int, code string) error {
+	twoFactor, err := uc.enabledTwoFactor(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+
+	if twoFactor.Required {
+		return entity.ErrTwoFactorRequired
+	}
+
+	err = uc.twoFactorRepo.DisableTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - DisableTOTP - uc.twoFactorRepo.DisableTOTP: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current TOTP or
+// recovery code, and returns the new ones.
+func (uc *UseCase) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	_, err := uc.enabledTwoFactor(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RegenerateRecoveryCodes - newRecoveryCodes: %w", err)
+	}
+
+	err = uc.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RegenerateRecoveryCodes - uc.twoFactorRepo.ReplaceRecoveryCodes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// enabledTwoFactor returns the user's enrollment once the code checks out against it.
+func (uc *UseCase) enabledTwoFactor(ctx context.Context, userID int, code string) (entity.TwoFactor, error) {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return entity.TwoFactor{}, fmt.Errorf("UseCase - enabledTwoFactor - uc.twoFactorRepo.GetTwoFactor: %w", err)
+	}
+
+	if !twoFactor.Enabled() {
+		return entity.TwoFactor{}, entity.ErrTwoFactorNotSetUp
+	}
+
+	err = uc.checkTwoFactorCode(ctx, twoFactor, code, time.Now())
+	if err != nil {
+		return entity.TwoFactor{}, err
+	}
+
+	return twoFactor, nil
+}
+
+// checkTwoFactorCode accepts a TOTP code of a step not used before, or an unused recovery code.
+func (uc *UseCase) checkTwoFactorCode(ctx context.Context, twoFactor entity.TwoFactor, code string, now time.Time) error {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+
+	if !isTOTPCode(code) {
+		return uc.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hashRecoveryCode(code), now)
+	}
+
+	step, ok := uc.totp.Validate(code, twoFactor.Secret, now, _totpSkew)
+	if !ok || step <= twoFactor.LastUsedStep {
+		return entity.ErrInvalidTwoFactorCode
+	}
+
+	return uc.twoFactorRepo.UseTOTPStep(ctx, twoFactor.UserID, step)
+}
+
+// enableTOTP confirms the pending secret with a code from it and returns new recovery codes.
+func (uc *UseCase) enableTOTP(ctx context.Context, twoFactor entity.TwoFactor, code string, now time.Time) ([]string, error) {
+	if twoFactor.Secret == "" {
+		return nil, entity.ErrTwoFactorNotSetUp
+	}
+
+	step, ok := uc.totp.Validate(strings.ReplaceAll(strings.TrimSpace(code), " ", ""), twoFactor.Secret, now, _totpSkew)
+	if !ok {
+		return nil, entity.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - enableTOTP - newRecoveryCodes: %w", err)
+	}
+
+	err = uc.twoFactorRepo.EnableTOTP(ctx, twoFactor.UserID, step, hashes, now)
+	if err != nil {
+		if errors.Is(err, entity.ErrTwoFactorNotSetUp) || errors.Is(err, entity.ErrInvalidTwoFactorCode) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("UseCase - enableTOTP - uc.twoFactorRepo.EnableTOTP: %w", err)
+	}
+
+	return codes, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRecoveryCodes returns random codes formatted like "abcde-fghij" and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, _recoveryCodes)
+	hashes := make([]string, 0, _recoveryCodes)
+
+	for i := 0; i < _recoveryCodes; i++ {
+		b := make([]byte, _recoveryCodeBytes)
+
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("newRecoveryCodes - rand.Read: %w", err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b)[:10])
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes the code ignoring case, spaces and dashes, however the user typed it.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	return tokens.HashOpaqueToken(code)
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+	"github.com/dostonshernazarov/doctor-appointment/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, _recoveryCodes)
+	require.Len(t, hashes, _recoveryCodes)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		assert.Regexp(t, regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`), code)
+		assert.Equal(t, hashRecoveryCode(code), hashes[i])
+		assert.False(t, seen[code], "duplicate code %s", code)
+
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := hashRecoveryCode("abcde-fghij")
+
+	assert.Equal(t, want, hashRecoveryCode("ABCDE-FGHIJ"))
+	assert.Equal(t, want, hashRecoveryCode("abcdefghij"))
+	assert.Equal(t, want, hashRecoveryCode("abcde fghij"))
+	assert.NotEqual(t, want, hashRecoveryCode("abcde-fghik"))
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	assert.True(t, isTOTPCode("012345"))
+	assert.False(t, isTOTPCode("01234"))
+	assert.False(t, isTOTPCode("0123456"))
+	assert.False(t, isTOTPCode("abcde-fghij"))
+}
+
+// roleTwoFactorStore records the roles required to use two factors and whose sessions were revoked.
+type roleTwoFactorStore struct {
+	repo.RoleRepo
+	repo.SessionRepo
+	required map[entity.Role]bool
+	revoked  map[entity.Role]string
+}
+
+func (s *roleTwoFactorStore) SetRoleTwoFactor(_ context.Context, name entity.Role, required bool) error {
+	s.required[name] = required
+
+	return nil
+}
+
+func (s *roleTwoFactorStore) RevokeRoleSessions(_ context.Context, role entity.Role, reason string, _ time.Time) (int, error) {
+	s.revoked[role] = reason
+
+	return 1, nil
+}
+
+func TestSetRoleTwoFactor(t *testing.T) {
+	s := &roleTwoFactorStore{required: map[entity.Role]bool{}, revoked: map[entity.Role]string{}}
+	uc := NewUseCase(nil, nil, nil, nil, nil, s, s, nil, nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	require.NoError(t, uc.SetRoleTwoFactor(ctx, entity.RoleDoctor, true))
+	assert.True(t, s.required[entity.RoleDoctor])
+	assert.Equal(t, entity.RevokedTwoFactorRequired, s.revoked[entity.RoleDoctor], "requiring two factors signs the role's users out")
+
+	require.NoError(t, uc.SetRoleTwoFactor(ctx, entity.RoleReceptionist, false))
+	assert.NotContains(t, s.revoked, entity.RoleReceptionist)
+}
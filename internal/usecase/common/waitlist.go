@@ -0,0 +1,259 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
+)
+
+// JoinWaitlist puts the patient in the queue for a slot within [entry.From, entry.To).
+func (uc *UseCase) JoinWaitlist(ctx context.Context, entry entity.WaitlistEntry) (entity.WaitlistEntry, error) {
+	entry.Specialization = normalizeSpecializationCode(entry.Specialization)
+
+	err := entry.Validate(time.Now())
+	if err != nil {
+		return entity.WaitlistEntry{}, err
+	}
+
+	if entry.Specialization != "" {
+		_, err = uc.specializationRepo.GetSpecializationsByCode(ctx, []string{entry.Specialization})
+		if err != nil {
+			if errors.Is(err, entity.ErrSpecializationNotFound) {
+				return entity.WaitlistEntry{}, fmt.Errorf("%w: %w", entity.ErrInvalidWaitlistEntry, err)
+			}
+
+			return entity.WaitlistEntry{}, fmt.Errorf("UseCase - JoinWaitlist - uc.specializationRepo.GetSpecializationsByCode: %w", err)
+		}
+	}
+
+	if entry.DoctorID != nil {
+		_, err = uc.doctorRepo.GetDoctorByID(ctx, *entry.DoctorID)
+		if err != nil {
+			return entity.WaitlistEntry{}, fmt.Errorf("UseCase - JoinWaitlist - uc.doctorRepo.GetDoctorByID: %w", err)
+		}
+	}
+
+	entry.Status = entity.WaitlistWaiting
+
+	entry.ID, err = uc.waitlistRepo.CreateWaitlistEntry(ctx, entry)
+	if err != nil {
+		return entity.WaitlistEntry{}, fmt.Errorf("UseCase - JoinWaitlist - uc.waitlistRepo.CreateWaitlistEntry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// LeaveWaitlist closes the entry. If a slot was on offer to it, the slot moves on to the next patient.
+func (uc *UseCase) LeaveWaitlist(ctx context.Context, id int) error {
+	released, err := uc.waitlistRepo.LeaveWaitlist(ctx, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrEntryClosed) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - LeaveWaitlist - uc.waitlistRepo.LeaveWaitlist: %w", err)
+	}
+
+	if released != nil {
+		uc.passOnSlot(ctx, offeredSlot(*released))
+	}
+
+	return nil
+}
+
+// GetWaitlistEntry -.
+func (uc *UseCase) GetWaitlistEntry(ctx context.Context, id int) (entity.WaitlistEntry, error) {
+	return uc.waitlistRepo.GetWaitlistEntry(ctx, id)
+}
+
+// GetWaitlistEntriesByUserID -.
+func (uc *UseCase) GetWaitlistEntriesByUserID(ctx context.Context, userID int) ([]entity.WaitlistEntry, error) {
+	return uc.waitlistRepo.GetWaitlistEntriesByUserID(ctx, userID)
+}
+
+// GetWaitlistOffer -.
+func (uc *UseCase) GetWaitlistOffer(ctx context.Context, id int) (entity.WaitlistOffer, error) {
+	return uc.waitlistRepo.GetWaitlistOffer(ctx, id)
+}
+
+// GetWaitlistOffersByUserID -.
+func (uc *UseCase) GetWaitlistOffersByUserID(ctx context.Context, userID int) ([]entity.WaitlistOffer, error) {
+	return uc.waitlistRepo.GetWaitlistOffersByUserID(ctx, userID)
+}
+
+// AcceptWaitlistOffer books the held slot for the patient on behalf of the user acceptedBy and
+// returns the appointment.
+func (uc *UseCase) AcceptWaitlistOffer(ctx context.Context, id int, acceptedBy *int) (entity.Appointment, error) {
+	offer, err := uc.waitlistRepo.AcceptWaitlistOffer(ctx, id, acceptedBy, time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrOfferUnavailable) {
+			return entity.Appointment{}, err
+		}
+
+		return entity.Appointment{}, fmt.Errorf("UseCase - AcceptWaitlistOffer - uc.waitlistRepo.AcceptWaitlistOffer: %w", err)
+	}
+
+	return uc.appointmentRepo.GetAppointmentByID(ctx, offer.AppointmentID)
+}
+
+// DeclineWaitlistOffer releases the held slot and offers it to the next patient. The entry stays
+// in the queue for other slots.
+func (uc *UseCase) DeclineWaitlistOffer(ctx context.Context, id int) error {
+	offer, err := uc.waitlistRepo.ReleaseWaitlistOffer(ctx, id, entity.OfferDeclined)
+	if err != nil {
+		if errors.Is(err, entity.ErrOfferUnavailable) {
+			return err
+		}
+
+		return fmt.Errorf("UseCase - DeclineWaitlistOffer - uc.waitlistRepo.ReleaseWaitlistOffer: %w", err)
+	}
+
+	uc.passOnSlot(ctx, offeredSlot(offer))
+
+	return nil
+}
+
+// ExpireWaitlistOffers releases every offer whose hold ran out by now and passes each slot on.
+// Returns how many offers expired.
+func (uc *UseCase) ExpireWaitlistOffers(ctx context.Context, now time.Time) (int, error) {
+	ids, err := uc.waitlistRepo.GetExpiredWaitlistOffers(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - ExpireWaitlistOffers - uc.waitlistRepo.GetExpiredWaitlistOffers: %w", err)
+	}
+
+	expired := 0
+	for _, id := range ids {
+		offer, err := uc.waitlistRepo.ReleaseWaitlistOffer(ctx, id, entity.OfferExpired)
+		if errors.Is(err, entity.ErrOfferUnavailable) {
+			// answered after it was listed
+			continue
+		}
+
+		if err != nil {
+			return expired, fmt.Errorf("UseCase - ExpireWaitlistOffers - uc.waitlistRepo.ReleaseWaitlistOffer: %w", err)
+		}
+
+		expired++
+
+		uc.passOnSlot(ctx, offeredSlot(offer))
+	}
+
+	return expired, nil
+}
+
+// RunWaitlistExpiry expires offers every interval until ctx is done.
+func (uc *UseCase) RunWaitlistExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := uc.ExpireWaitlistOffers(ctx, time.Now())
+			if err != nil {
+				uc.l.Error(fmt.Errorf("UseCase - RunWaitlistExpiry - uc.ExpireWaitlistOffers: %w", err))
+			}
+		}
+	}
+}
+
+// passOnSlot offers a freed slot to the waitlist. The change that freed it has already been made,
+// so a failure here is logged rather than returned.
+func (uc *UseCase) passOnSlot(ctx context.Context, slot entity.Appointment) {
+	_, err := uc.offerSlot(ctx, slot)
+	if err != nil {
+		uc.l.Error(fmt.Errorf("UseCase - passOnSlot - uc.offerSlot: %w", err))
+	}
+}
+
+// offerSlot holds the slot for the first waiting patient it suits. Returns nil when the slot has
+// started, no longer fits the doctor's schedule, was taken again, or nobody is waiting for it.
+func (uc *UseCase) offerSlot(ctx context.Context, freed entity.Appointment) (*entity.WaitlistOffer, error) {
+	now := time.Now()
+	if !freed.AppointmentTime.After(now) {
+		return nil, nil
+	}
+
+	slot := entity.Appointment{
+		DoctorID:          freed.DoctorID,
+		AppointmentTime:   freed.AppointmentTime,
+		Duration:          freed.Duration,
+		AppointmentTypeID: freed.AppointmentTypeID,
+		BufferBefore:      freed.BufferBefore,
+		BufferAfter:       freed.BufferAfter,
+		Capacity:          freed.Capacity,
+		LocationID:        freed.LocationID,
+		RoomID:            freed.RoomID,
+	}
+
+	doctor, err := uc.doctorRepo.GetDoctorByID(ctx, slot.DoctorID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - offerSlot - uc.doctorRepo.GetDoctorByID: %w", err)
+	}
+
+	err = uc.checkBooking(ctx, doctor, slot)
+	if err != nil {
+		var bookingErr *entity.BookingError
+		if errors.As(err, &bookingErr) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	entries, err := uc.waitlistRepo.GetWaitingEntries(ctx, doctor, slot)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - offerSlot - uc.waitlistRepo.GetWaitingEntries: %w", err)
+	}
+
+	expiresAt := offerExpiry(now, slot.AppointmentTime, uc.waitlistHold)
+
+	for _, entry := range entries {
+		offer, err := uc.waitlistRepo.CreateWaitlistOffer(ctx, entry.ID, slot, expiresAt)
+
+		switch {
+		case errors.Is(err, entity.ErrEntryClosed):
+			continue
+		case errors.Is(err, entity.ErrAppointmentConflict), errors.Is(err, entity.ErrSlotFull),
+			errors.Is(err, entity.ErrRoomTaken):
+			return nil, nil
+		case err != nil:
+			return nil, fmt.Errorf("UseCase - offerSlot - uc.waitlistRepo.CreateWaitlistOffer: %w", err)
+		}
+
+		return &offer, nil
+	}
+
+	return nil, nil
+}
+
+// offerExpiry returns when an offer made at now runs out: after hold, but never later than the
+// start of the slot itself.
+func offerExpiry(now, start time.Time, hold time.Duration) time.Time {
+	expiresAt := now.Add(hold)
+	if start.Before(expiresAt) {
+		return start
+	}
+
+	return expiresAt
+}
+
+// offeredSlot returns the slot an offer was holding.
+func offeredSlot(offer entity.WaitlistOffer) entity.Appointment {
+	return entity.Appointment{
+		DoctorID:          offer.DoctorID,
+		AppointmentTime:   offer.AppointmentTime,
+		Duration:          offer.Duration,
+		AppointmentTypeID: offer.AppointmentTypeID,
+		BufferBefore:      offer.BufferBefore,
+		BufferAfter:       offer.BufferAfter,
+		Capacity:          offer.Capacity,
+		LocationID:        offer.LocationID,
+		RoomID:            offer.RoomID,
+	}
+}
@@ -0,0 +1,28 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfferExpiry(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  time.Time
+	}{
+		{name: "full hold", start: now.Add(3 * time.Hour), want: now.Add(30 * time.Minute)},
+		{name: "slot starts during the hold", start: now.Add(10 * time.Minute), want: now.Add(10 * time.Minute)},
+		{name: "slot starts as the hold ends", start: now.Add(30 * time.Minute), want: now.Add(30 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, offerExpiry(now, tt.start, 30*time.Minute))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/dostonshernazarov/doctor-appointment/internal/entity"
 )
@@ -15,13 +16,18 @@ type (
 		ListUsers(ctx context.Context) ([]entity.User, error)
 		UpdateUser(ctx context.Context, user entity.UserUpdate) error
 		DeleteUser(ctx context.Context, id int) error
+		DeactivateUser(ctx context.Context, userID, by int) error
+		ReactivateUser(ctx context.Context, userID, by int) error
 		GetPasswordHash(ctx context.Context, email string) (entity.GetPasswordHash, error)
-		UpdateToken(ctx context.Context, id int, token string) error
+		RequestPasswordReset(ctx context.Context, email string) error
+		ResetPassword(ctx context.Context, token, passwordHash string) error
+		SendEmailVerification(ctx context.Context, userID int) error
+		VerifyEmail(ctx context.Context, token string) error
 	}
 
 	// AppointmentUsecase -.
 	AppointmentUsecase interface {
-		CreateAppointment(ctx context.Context, appointment entity.Appointment) error
+		CreateAppointment(ctx context.Context, appointment entity.Appointment) (int, error)
 		GetAppointmentsByDoctorID(ctx context.Context, doctorID int) ([]entity.Appointment, error)
 		GetBookedAppointmentsByDoctorId(ctx context.Context, doctorID int) ([]entity.Appointment, error)
 		UpdateAppointment(ctx context.Context, appointment entity.Appointment) error
@@ -30,17 +36,160 @@ type (
 		GetAppointmentByID(ctx context.Context, id int) (entity.Appointment, error)
 		GetAppointmentsByUserID(ctx context.Context, userID int) ([]entity.Appointment, error)
 		GetAllAppointments(ctx context.Context) ([]entity.Appointment, error)
+		ChangeAppointmentStatus(ctx context.Context, id int, status string, changedBy *int, reason string) (entity.Appointment, error)
+		GetAppointmentStatusHistory(ctx context.Context, appointmentID int) ([]entity.AppointmentStatusChange, error)
+		RescheduleAppointment(ctx context.Context, change entity.AppointmentReschedule) (entity.Appointment, error)
+		GetAppointmentReschedules(ctx context.Context, appointmentID int) ([]entity.AppointmentReschedule, error)
+		CreateAppointmentSeries(ctx context.Context, series entity.AppointmentSeries, skipConflicts bool) (entity.SeriesBooking, error)
+		GetAppointmentSeries(ctx context.Context, id int) (entity.AppointmentSeries, []entity.Appointment, error)
+		CancelAppointments(ctx context.Context, appointmentID int, scope string, changedBy *int, reason string) ([]entity.Appointment, error)
+		RescheduleAppointments(ctx context.Context, change entity.AppointmentReschedule, scope string) ([]entity.Appointment, error)
+		GetDoctorAgenda(ctx context.Context, doctorID int, day time.Time) ([]entity.Appointment, error)
 	}
 
 	// DoctorUsecase -.
 	DoctorUsecase interface {
 		CreateDoctor(ctx context.Context, doctor entity.Doctor) error
 		GetDoctorByID(ctx context.Context, id int) (entity.Doctor, error)
-		GetDoctorBySpecialization(ctx context.Context, specialization string) ([]entity.Doctor, error)
+		GetDoctorByUserID(ctx context.Context, userID int) (entity.Doctor, error)
+		GetDoctorBySpecialization(ctx context.Context, code string) ([]entity.Doctor, error)
 		GetDoctors(ctx context.Context) ([]entity.Doctor, error)
+		GetDoctorsByLocationID(ctx context.Context, locationID int) ([]entity.Doctor, error)
 		UpdateDoctor(ctx context.Context, doctor entity.Doctor) error
 		DeleteDoctor(ctx context.Context, id int) error
-		ListSpecializations(ctx context.Context) ([]string, error)
 		GetBookedSchedulesByDoctorID(ctx context.Context, doctorID int) ([]entity.Schedule, error)
+		GetAvailability(ctx context.Context, doctorID int, from, to time.Time, duration time.Duration, locationID *int) ([]entity.Slot, error)
+		GetAppointmentTypeAvailability(ctx context.Context, doctorID, appointmentTypeID int, from, to time.Time, locationID *int) ([]entity.Slot, error)
+	}
+
+	// ScheduleUsecase -.
+	ScheduleUsecase interface {
+		AddScheduleException(ctx context.Context, exception entity.ScheduleException) (entity.ScheduleException, error)
+		AddTimeOff(ctx context.Context, exception entity.ScheduleException, cancelConflicts bool, changedBy *int) (entity.TimeOffResult, error)
+		GetScheduleExceptions(ctx context.Context, doctorID int, from, to time.Time) ([]entity.ScheduleException, error)
+		DeleteScheduleException(ctx context.Context, doctorID, id int) error
+		CreateHolidayCalendar(ctx context.Context, calendar entity.HolidayCalendar) (int, error)
+		ListHolidayCalendars(ctx context.Context) ([]entity.HolidayCalendar, error)
+		AddHoliday(ctx context.Context, holiday entity.Holiday) (int, error)
+		GetHolidays(ctx context.Context, calendarID int, from, to time.Time) ([]entity.Holiday, error)
+		DeleteHoliday(ctx context.Context, calendarID, id int) error
+	}
+
+	// WaitlistUsecase -.
+	WaitlistUsecase interface {
+		JoinWaitlist(ctx context.Context, entry entity.WaitlistEntry) (entity.WaitlistEntry, error)
+		LeaveWaitlist(ctx context.Context, id int) error
+		GetWaitlistEntry(ctx context.Context, id int) (entity.WaitlistEntry, error)
+		GetWaitlistEntriesByUserID(ctx context.Context, userID int) ([]entity.WaitlistEntry, error)
+		GetWaitlistOffer(ctx context.Context, id int) (entity.WaitlistOffer, error)
+		GetWaitlistOffersByUserID(ctx context.Context, userID int) ([]entity.WaitlistOffer, error)
+		AcceptWaitlistOffer(ctx context.Context, id int, acceptedBy *int) (entity.Appointment, error)
+		DeclineWaitlistOffer(ctx context.Context, id int) error
+	}
+
+	// RoleUsecase -.
+	RoleUsecase interface {
+		ListRoles(ctx context.Context) ([]entity.RoleDefinition, error)
+		GetRole(ctx context.Context, name entity.Role) (entity.RoleDefinition, error)
+		CreateRole(ctx context.Context, role entity.RoleDefinition) error
+		UpdateRole(ctx context.Context, role entity.RoleDefinition) error
+		SetRoleTwoFactor(ctx context.Context, name entity.Role, required bool) error
+		DeleteRole(ctx context.Context, name entity.Role) error
+		ListPermissions(ctx context.Context) ([]entity.PermissionInfo, error)
+		AssignRole(ctx context.Context, userID int, role entity.Role) error
+		GetUserAccess(ctx context.Context, userID int) (entity.Role, []entity.Permission, error)
+	}
+
+	// SessionUsecase -.
+	SessionUsecase interface {
+		CreateSession(ctx context.Context, userID int, userAgent, ip string) (entity.Session, string, error)
+		RefreshSession(ctx context.Context, refreshToken string) (entity.Session, string, error)
+		CheckSession(ctx context.Context, userID, sessionID int) error
+		Logout(ctx context.Context, userID, sessionID int) error
+		LogoutAll(ctx context.Context, userID int) (int, error)
+	}
+
+	// LoginUsecase -.
+	LoginUsecase interface {
+		CheckLogin(ctx context.Context, email, ip string) error
+		RecordLoginFailure(ctx context.Context, email, ip string) error
+		RecordLoginSuccess(ctx context.Context, email string) error
+		UnlockAccount(ctx context.Context, userID, by int) error
+	}
+
+	// TwoFactorUsecase -.
+	TwoFactorUsecase interface {
+		GetTwoFactor(ctx context.Context, userID int) (entity.TwoFactor, error)
+		StartTwoFactor(ctx context.Context, userID int) (entity.TwoFactorChallenge, error)
+		TwoFactorChallengeUser(ctx context.Context, challenge string) (int, error)
+		VerifyTwoFactor(ctx context.Context, challenge, code, ip string) (int, []string, error)
+		SetupTOTP(ctx context.Context, userID int) (entity.TOTPSetup, error)
+		ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error)
+		DisableTOTP(ctx context.Context, userID int, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+	}
+
+	// SSOUsecase -.
+	SSOUsecase interface {
+		StartOIDCLogin(ctx context.Context) (string, error)
+		FinishOIDCLogin(ctx context.Context, code, state string) (entity.User, error)
+	}
+
+	// PatientUsecase -.
+	PatientUsecase interface {
+		CreatePatient(ctx context.Context, patient entity.Patient, links []entity.PatientLink) (entity.Patient, error)
+		GetPatient(ctx context.Context, id int) (entity.Patient, error)
+		UpdatePatient(ctx context.Context, patient entity.Patient) (entity.Patient, error)
+		GetSelfPatient(ctx context.Context, userID int) (entity.Patient, error)
+		GetUserPatients(ctx context.Context, userID int) ([]entity.LinkedPatient, error)
+		GetPatientLinks(ctx context.Context, patientID int) ([]entity.PatientLink, error)
+		LinkPatientUser(ctx context.Context, link entity.PatientLink) error
+		UnlinkPatientUser(ctx context.Context, patientID, userID int) error
+	}
+
+	// SpecializationUsecase -.
+	SpecializationUsecase interface {
+		CreateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error)
+		GetSpecialization(ctx context.Context, id int) (entity.Specialization, error)
+		ListSpecializations(ctx context.Context) ([]entity.Specialization, error)
+		UpdateSpecialization(ctx context.Context, specialization entity.Specialization) (entity.Specialization, error)
+		DeleteSpecialization(ctx context.Context, id int) error
+	}
+
+	// AppointmentTypeUsecase -.
+	AppointmentTypeUsecase interface {
+		CreateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error)
+		GetAppointmentType(ctx context.Context, id int) (entity.AppointmentType, error)
+		ListAppointmentTypes(ctx context.Context) ([]entity.AppointmentType, error)
+		GetAppointmentTypesByDoctorID(ctx context.Context, doctorID int) ([]entity.AppointmentType, error)
+		UpdateAppointmentType(ctx context.Context, appointmentType entity.AppointmentType) (entity.AppointmentType, error)
+		DeleteAppointmentType(ctx context.Context, id int) error
+	}
+
+	// ClinicUsecase -.
+	ClinicUsecase interface {
+		CreateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error)
+		GetClinic(ctx context.Context, id int) (entity.Clinic, error)
+		ListClinics(ctx context.Context) ([]entity.Clinic, error)
+		UpdateClinic(ctx context.Context, clinic entity.Clinic) (entity.Clinic, error)
+		DeleteClinic(ctx context.Context, id int) error
+		CreateLocation(ctx context.Context, location entity.Location) (entity.Location, error)
+		GetLocation(ctx context.Context, id int) (entity.Location, error)
+		GetLocationsByClinicID(ctx context.Context, clinicID int) ([]entity.Location, error)
+		UpdateLocation(ctx context.Context, location entity.Location) (entity.Location, error)
+		DeleteLocation(ctx context.Context, id int) error
+		CreateRoom(ctx context.Context, room entity.Room) (entity.Room, error)
+		GetRoom(ctx context.Context, id int) (entity.Room, error)
+		GetRoomsByLocationID(ctx context.Context, locationID int) ([]entity.Room, error)
+		UpdateRoom(ctx context.Context, room entity.Room) (entity.Room, error)
+		DeleteRoom(ctx context.Context, id int) error
+	}
+
+	// APIKeyUsecase -.
+	APIKeyUsecase interface {
+		CreateAPIKey(ctx context.Context, key entity.APIKey, createdBy int) (entity.APIKey, string, error)
+		ListAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+		RevokeAPIKey(ctx context.Context, id, by int) error
+		AuthenticateAPIKey(ctx context.Context, key string) (entity.APIKey, error)
 	}
 )
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS appointment_overlap_cancellations;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_no_overlap;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_duration_positive;
@@ -0,0 +1,58 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Bookings without a positive duration can't be fixed automatically; stop before changing anything.
+DO $$
+DECLARE
+    ids TEXT;
+BEGIN
+    SELECT string_agg(id::TEXT, ', ' ORDER BY id) INTO ids FROM appointments WHERE duration <= 0;
+
+    IF ids IS NOT NULL THEN
+        RAISE EXCEPTION 'appointments % have a non-positive duration; correct them before migrating', ids;
+    END IF;
+END $$;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_duration_positive CHECK (duration > 0);
+
+-- Only bookings at the very same time were refused so far, so existing ones may overlap. Going
+-- through them in booking order, every scheduled appointment overlapping an earlier one of the
+-- same doctor that is still scheduled is cancelled. The cancellations are kept so the status
+-- history, once it exists, can record them.
+CREATE TABLE appointment_overlap_cancellations (
+    appointment_id INTEGER PRIMARY KEY REFERENCES appointments(id) ON DELETE CASCADE,
+    overlapped_id INTEGER NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    cancelled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+DECLARE
+    a RECORD;
+    earlier INTEGER;
+BEGIN
+    FOR a IN SELECT id, doctor_id, appointment_time, duration FROM appointments WHERE status = 'scheduled' ORDER BY id LOOP
+        SELECT o.id INTO earlier
+        FROM appointments o
+        WHERE o.status = 'scheduled'
+          AND o.doctor_id = a.doctor_id
+          AND o.id < a.id
+          AND tsrange(o.appointment_time, o.appointment_time + o.duration * INTERVAL '1 minute')
+              && tsrange(a.appointment_time, a.appointment_time + a.duration * INTERVAL '1 minute')
+        ORDER BY o.id
+        LIMIT 1;
+
+        IF FOUND THEN
+            UPDATE appointments SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP WHERE id = a.id;
+            INSERT INTO appointment_overlap_cancellations (appointment_id, overlapped_id, from_status)
+            VALUES (a.id, earlier, 'scheduled');
+        END IF;
+    END LOOP;
+END $$;
+
+-- Two scheduled appointments of the same doctor may not share any minute.
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        tsrange(appointment_time, appointment_time + duration * INTERVAL '1 minute') WITH &&
+    ) WHERE (status = 'scheduled');
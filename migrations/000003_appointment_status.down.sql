@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS appointment_status_changes;
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_no_overlap;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        tsrange(appointment_time, appointment_time + duration * INTERVAL '1 minute') WITH &&
+    ) WHERE (status = 'scheduled');
+
+ALTER TABLE appointments
+    DROP CONSTRAINT IF EXISTS appointments_status_check,
+    ALTER COLUMN status DROP NOT NULL;
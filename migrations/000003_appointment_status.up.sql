@@ -0,0 +1,69 @@
+-- Anything outside the lifecycle was set through the old free-form status field.
+UPDATE appointments
+SET status = 'cancelled'
+WHERE status IS NULL
+   OR status NOT IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'completed', 'cancelled', 'no_show');
+
+ALTER TABLE appointments
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT appointments_status_check CHECK (
+        status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'completed', 'cancelled', 'no_show')
+    );
+
+CREATE TABLE appointment_status_changes (
+    id SERIAL PRIMARY KEY,
+    appointment_id INTEGER NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_appointment_status_changes_appointment ON appointment_status_changes(appointment_id);
+
+-- Record the overlapping bookings the previous migration cancelled.
+INSERT INTO appointment_status_changes (appointment_id, from_status, to_status, reason, changed_at)
+SELECT appointment_id, from_status, 'cancelled', 'overlapped appointment ' || overlapped_id, cancelled_at
+FROM appointment_overlap_cancellations;
+
+DROP TABLE appointment_overlap_cancellations;
+
+-- Confirmed and started appointments now count as well, so they may overlap each other or
+-- scheduled ones. As before, the later booking of each overlapping pair is cancelled.
+DO $$
+DECLARE
+    a RECORD;
+    earlier INTEGER;
+BEGIN
+    FOR a IN
+        SELECT id, doctor_id, appointment_time, duration, status
+        FROM appointments
+        WHERE status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress')
+        ORDER BY id
+    LOOP
+        SELECT o.id INTO earlier
+        FROM appointments o
+        WHERE o.status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress')
+          AND o.doctor_id = a.doctor_id
+          AND o.id < a.id
+          AND tsrange(o.appointment_time, o.appointment_time + o.duration * INTERVAL '1 minute')
+              && tsrange(a.appointment_time, a.appointment_time + a.duration * INTERVAL '1 minute')
+        ORDER BY o.id
+        LIMIT 1;
+
+        IF FOUND THEN
+            UPDATE appointments SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP WHERE id = a.id;
+            INSERT INTO appointment_status_changes (appointment_id, from_status, to_status, reason)
+            VALUES (a.id, a.status, 'cancelled', 'overlapped appointment ' || earlier);
+        END IF;
+    END LOOP;
+END $$;
+
+ALTER TABLE appointments DROP CONSTRAINT appointments_no_overlap;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        tsrange(appointment_time, appointment_time + duration * INTERVAL '1 minute') WITH &&
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress'));
@@ -0,0 +1,15 @@
+CREATE TABLE appointment_reschedules (
+    id SERIAL PRIMARY KEY,
+    appointment_id INTEGER NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    previous_doctor_id INTEGER NOT NULL,
+    previous_time TIMESTAMP NOT NULL,
+    previous_duration INTEGER NOT NULL,
+    doctor_id INTEGER NOT NULL,
+    appointment_time TIMESTAMP NOT NULL,
+    duration INTEGER NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_appointment_reschedules_appointment ON appointment_reschedules(appointment_id);
@@ -0,0 +1,36 @@
+ALTER TABLE doctors ADD COLUMN schedule JSONB NOT NULL DEFAULT '{"days": [], "start": "09:00", "end": "17:00"}';
+
+-- The old format has one range for all days, so the latest version collapses to its earliest start and latest end.
+WITH latest AS (
+    SELECT DISTINCT ON (doctor_id) id, doctor_id
+    FROM doctor_schedules
+    ORDER BY doctor_id, version DESC
+)
+UPDATE doctors d
+SET schedule = jsonb_build_object(
+    'days', (
+        SELECT COALESCE(jsonb_agg((ARRAY['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'])[r.weekday + 1] ORDER BY r.weekday), '[]'::jsonb)
+        FROM doctor_schedule_rules r
+        WHERE r.schedule_id = l.id
+    ),
+    'start', COALESCE((
+        SELECT to_char(MIN(g.start_time), 'HH24:MI')
+        FROM doctor_schedule_rules r
+        JOIN doctor_schedule_ranges g ON g.rule_id = r.id AND g.kind = 'work'
+        WHERE r.schedule_id = l.id
+    ), '09:00'),
+    'end', COALESCE((
+        SELECT to_char(MAX(g.end_time), 'HH24:MI')
+        FROM doctor_schedule_rules r
+        JOIN doctor_schedule_ranges g ON g.rule_id = r.id AND g.kind = 'work'
+        WHERE r.schedule_id = l.id
+    ), '17:00')
+)
+FROM latest l
+WHERE l.doctor_id = d.id;
+
+ALTER TABLE doctors ALTER COLUMN schedule DROP DEFAULT;
+
+DROP TABLE IF EXISTS doctor_schedule_ranges;
+DROP TABLE IF EXISTS doctor_schedule_rules;
+DROP TABLE IF EXISTS doctor_schedules;
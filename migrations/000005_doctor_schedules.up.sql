@@ -0,0 +1,60 @@
+CREATE TABLE doctor_schedules (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES doctors(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (doctor_id, version)
+);
+
+-- weekday follows EXTRACT(DOW): 0 is Sunday.
+CREATE TABLE doctor_schedule_rules (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER NOT NULL REFERENCES doctor_schedules(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    UNIQUE (schedule_id, weekday)
+);
+
+CREATE TABLE doctor_schedule_ranges (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES doctor_schedule_rules(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('work', 'break')),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    CHECK (start_time < end_time)
+);
+
+CREATE INDEX idx_doctor_schedule_rules_schedule ON doctor_schedule_rules(schedule_id);
+CREATE INDEX idx_doctor_schedule_ranges_rule ON doctor_schedule_ranges(rule_id);
+
+-- Convert {"days": [...], "start": "HH:MM", "end": "HH:MM"} into version 1 of every doctor's schedule.
+INSERT INTO doctor_schedules (doctor_id, version)
+SELECT id, 1 FROM doctors;
+
+INSERT INTO doctor_schedule_rules (schedule_id, weekday)
+SELECT DISTINCT s.id,
+       CASE lower(left(trim(day), 3))
+           WHEN 'sun' THEN 0
+           WHEN 'mon' THEN 1
+           WHEN 'tue' THEN 2
+           WHEN 'wed' THEN 3
+           WHEN 'thu' THEN 4
+           WHEN 'fri' THEN 5
+           WHEN 'sat' THEN 6
+       END
+FROM doctors d
+JOIN doctor_schedules s ON s.doctor_id = d.id
+CROSS JOIN LATERAL jsonb_array_elements_text(COALESCE(d.schedule -> 'days', '[]'::jsonb)) AS day
+WHERE lower(left(trim(day), 3)) IN ('sun', 'mon', 'tue', 'wed', 'thu', 'fri', 'sat');
+
+INSERT INTO doctor_schedule_ranges (rule_id, kind, start_time, end_time)
+SELECT r.id, 'work', (d.schedule ->> 'start')::TIME, (d.schedule ->> 'end')::TIME
+FROM doctor_schedule_rules r
+JOIN doctor_schedules s ON s.id = r.schedule_id
+JOIN doctors d ON d.id = s.doctor_id
+WHERE (d.schedule ->> 'start')::TIME < (d.schedule ->> 'end')::TIME;
+
+-- Days whose hours couldn't be converted would otherwise look like working days without hours.
+DELETE FROM doctor_schedule_rules r
+WHERE NOT EXISTS (SELECT 1 FROM doctor_schedule_ranges g WHERE g.rule_id = r.id);
+
+ALTER TABLE doctors DROP COLUMN schedule;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS doctor_schedule_exceptions;
+
+ALTER TABLE doctors DROP COLUMN IF EXISTS holiday_calendar_id;
+
+DROP TABLE IF EXISTS holidays;
+DROP TABLE IF EXISTS holiday_calendars;
@@ -0,0 +1,29 @@
+CREATE TABLE holiday_calendars (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE holidays (
+    id SERIAL PRIMARY KEY,
+    calendar_id INTEGER NOT NULL REFERENCES holiday_calendars(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (calendar_id, date)
+);
+
+ALTER TABLE doctors
+    ADD COLUMN holiday_calendar_id INTEGER REFERENCES holiday_calendars(id) ON DELETE SET NULL;
+
+CREATE TABLE doctor_schedule_exceptions (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES doctors(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('time_off', 'extra_hours')),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX idx_doctor_schedule_exceptions_doctor ON doctor_schedule_exceptions(doctor_id, starts_at);
@@ -0,0 +1,43 @@
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_no_overlap;
+
+DROP FUNCTION IF EXISTS appointment_period(TIMESTAMPTZ, INTEGER);
+
+ALTER TABLE doctor_schedule_exceptions
+    ALTER COLUMN starts_at TYPE TIMESTAMP USING starts_at AT TIME ZONE 'UTC',
+    ALTER COLUMN ends_at TYPE TIMESTAMP USING ends_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE holiday_calendars
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE doctor_schedules
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointment_reschedules
+    ALTER COLUMN previous_time TYPE TIMESTAMP USING previous_time AT TIME ZONE 'UTC',
+    ALTER COLUMN appointment_time TYPE TIMESTAMP USING appointment_time AT TIME ZONE 'UTC',
+    ALTER COLUMN changed_at TYPE TIMESTAMP USING changed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointment_status_changes
+    ALTER COLUMN changed_at TYPE TIMESTAMP USING changed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointments
+    ALTER COLUMN appointment_time TYPE TIMESTAMP USING appointment_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE doctors
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        tsrange(appointment_time, appointment_time + duration * INTERVAL '1 minute') WITH &&
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress'));
+
+ALTER TABLE doctors DROP COLUMN IF EXISTS time_zone;
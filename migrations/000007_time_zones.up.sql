@@ -0,0 +1,49 @@
+-- Every doctor works in an IANA time zone; weekly schedules and holidays are read in it.
+ALTER TABLE doctors ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE appointments DROP CONSTRAINT appointments_no_overlap;
+
+-- Values so far were written as UTC wall-clock times.
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE doctors
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointments
+    ALTER COLUMN appointment_time TYPE TIMESTAMPTZ USING appointment_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointment_status_changes
+    ALTER COLUMN changed_at TYPE TIMESTAMPTZ USING changed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE appointment_reschedules
+    ALTER COLUMN previous_time TYPE TIMESTAMPTZ USING previous_time AT TIME ZONE 'UTC',
+    ALTER COLUMN appointment_time TYPE TIMESTAMPTZ USING appointment_time AT TIME ZONE 'UTC',
+    ALTER COLUMN changed_at TYPE TIMESTAMPTZ USING changed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE doctor_schedules
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE holiday_calendars
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE doctor_schedule_exceptions
+    ALTER COLUMN starts_at TYPE TIMESTAMPTZ USING starts_at AT TIME ZONE 'UTC',
+    ALTER COLUMN ends_at TYPE TIMESTAMPTZ USING ends_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+-- timestamptz + interval is only STABLE in general, but adding minutes doesn't depend on the
+-- session time zone, so the range can safely be used in an index.
+CREATE FUNCTION appointment_period(start_at TIMESTAMPTZ, minutes INTEGER) RETURNS TSTZRANGE
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT tstzrange(start_at, start_at + minutes * INTERVAL '1 minute') $$;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        appointment_period(appointment_time, duration) WITH &&
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress'));
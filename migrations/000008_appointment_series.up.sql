@@ -0,0 +1,14 @@
+CREATE TABLE appointment_series (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    doctor_id INTEGER NOT NULL REFERENCES doctors(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL, -- RRULE subset, e.g. FREQ=WEEKLY;COUNT=12
+    starts_at TIMESTAMPTZ NOT NULL,
+    duration INTEGER NOT NULL CHECK (duration > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE appointments
+    ADD COLUMN series_id INTEGER REFERENCES appointment_series(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_appointments_series ON appointments(series_id, appointment_time);
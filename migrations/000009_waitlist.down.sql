@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS waitlist_offers;
+
+DROP TABLE IF EXISTS waitlist_entries;
+
+-- Holds have no meaning without the waitlist.
+UPDATE appointments SET status = 'cancelled' WHERE status = 'held';
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_no_overlap;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        appointment_period(appointment_time, duration) WITH &&
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress'));
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_status_check;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_status_check CHECK (
+        status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'completed', 'cancelled', 'no_show')
+    );
@@ -0,0 +1,43 @@
+-- Slots offered to waitlisted patients are held by an appointment in the 'held' status.
+ALTER TABLE appointments DROP CONSTRAINT appointments_status_check;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_status_check CHECK (
+        status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'completed', 'cancelled', 'no_show', 'held')
+    );
+
+ALTER TABLE appointments DROP CONSTRAINT appointments_no_overlap;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        appointment_period(appointment_time, duration) WITH &&
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'held'));
+
+CREATE TABLE waitlist_entries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    doctor_id INTEGER REFERENCES doctors(id) ON DELETE CASCADE,
+    specialization VARCHAR(100) NOT NULL DEFAULT '',
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'offered', 'booked', 'left')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (doctor_id IS NOT NULL OR specialization <> ''),
+    CHECK (window_start < window_end)
+);
+
+CREATE INDEX idx_waitlist_entries_queue ON waitlist_entries(status, created_at);
+
+CREATE TABLE waitlist_offers (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES waitlist_entries(id) ON DELETE CASCADE,
+    appointment_id INTEGER NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'expired')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_waitlist_offers_pending ON waitlist_offers(status, expires_at);
+CREATE INDEX idx_waitlist_offers_entry ON waitlist_offers(entry_id);
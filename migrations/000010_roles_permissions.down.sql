@@ -0,0 +1,11 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+
+UPDATE users SET role = 'user' WHERE role NOT IN ('admin', 'user');
+
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(20);
+
+DROP TABLE IF EXISTS role_permissions;
+
+DROP TABLE IF EXISTS permissions;
+
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,55 @@
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('user:read:any', 'Read any user'),
+    ('user:manage', 'Create, update and delete any user'),
+    ('role:manage', 'Manage roles and assign them to users'),
+    ('doctor:manage', 'Create, update and delete doctors'),
+    ('schedule:manage', 'Manage time off, extra hours and holiday calendars'),
+    ('appointment:read:any', 'Read any patient''s appointments, series and waitlist entries'),
+    ('appointment:write:any', 'Book, cancel and reschedule for any patient'),
+    ('appointment:status', 'Confirm, check in, start, complete and mark no-shows'),
+    ('appointment:delete', 'Delete appointments');
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access'),
+    ('user', 'Patient; reaches only their own appointments'),
+    ('receptionist', 'Front desk: books for patients and manages schedules'),
+    ('doctor', 'Reads appointments and moves them through a visit'),
+    ('auditor', 'Read-only access to users and appointments');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'user:read:any'),
+    ('receptionist', 'schedule:manage'),
+    ('receptionist', 'appointment:read:any'),
+    ('receptionist', 'appointment:write:any'),
+    ('receptionist', 'appointment:status'),
+    ('doctor', 'appointment:read:any'),
+    ('doctor', 'appointment:status'),
+    ('auditor', 'user:read:any'),
+    ('auditor', 'appointment:read:any');
+
+-- Users created without a role so far are patients.
+UPDATE users SET role = 'user' WHERE role NOT IN (SELECT name FROM roles);
+
+ALTER TABLE users
+    ALTER COLUMN role TYPE VARCHAR(50),
+    ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token VARCHAR(255);
+
+DROP TABLE IF EXISTS refresh_tokens;
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,9 @@
+-- Failed sign-ins counted per account (normalized email) and per client IP.
+CREATE TABLE login_throttles (
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('account', 'ip')),
+    subject VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (kind, subject)
+);
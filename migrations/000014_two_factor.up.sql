@@ -0,0 +1,26 @@
+-- TOTP enrollments. A secret is confirmed once the user has entered a code generated from it.
+CREATE TABLE user_totp (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at TIMESTAMPTZ,
+    -- The time step of the last accepted code; a code can't be used twice.
+    last_used_step BIGINT NOT NULL DEFAULT 0
+);
+
+-- Single-use codes that stand in for a TOTP code. Only the SHA-256 of a code is stored.
+CREATE TABLE recovery_codes (
+    code_hash CHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+
+ALTER TABLE roles ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Signing in with two factors hands out a challenge token between the password and the code.
+ALTER TABLE user_tokens DROP CONSTRAINT user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('password_reset', 'email_verification', 'two_factor_challenge'));
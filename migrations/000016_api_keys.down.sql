@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_key_permissions;
+DROP TABLE IF EXISTS api_keys;
+
+DELETE FROM permissions WHERE name = 'apikey:manage';
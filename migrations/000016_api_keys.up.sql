@@ -0,0 +1,24 @@
+INSERT INTO permissions (name, description) VALUES
+    ('apikey:manage', 'Create and revoke API keys for integrations');
+
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'apikey:manage');
+
+-- Keys other systems call the API with. A key acts with its own permissions, not as a user. Only
+-- the SHA-256 of a key is stored; prefix is its first characters, to tell keys apart.
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE api_key_permissions (
+    api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (api_key_id, permission)
+);
@@ -0,0 +1,2 @@
+-- A deactivated user can't sign in, but keeps their appointments and history.
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMPTZ;
@@ -0,0 +1,35 @@
+-- Appointments go back to a user linked to their patient, the patient's own account first.
+-- Those of patients no user is linked to are lost.
+ALTER TABLE waitlist_entries DROP CONSTRAINT IF EXISTS waitlist_entries_patient_id_fkey;
+DROP INDEX IF EXISTS waitlist_entries_patient_id_idx;
+DELETE FROM waitlist_entries e WHERE NOT EXISTS (SELECT 1 FROM patient_users pu WHERE pu.patient_id = e.patient_id);
+UPDATE waitlist_entries e SET patient_id = (
+    SELECT pu.user_id FROM patient_users pu WHERE pu.patient_id = e.patient_id ORDER BY pu.relationship <> 'self', pu.user_id LIMIT 1
+);
+ALTER TABLE waitlist_entries RENAME COLUMN patient_id TO user_id;
+ALTER TABLE waitlist_entries
+    ADD CONSTRAINT waitlist_entries_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE appointment_series DROP CONSTRAINT IF EXISTS appointment_series_patient_id_fkey;
+DELETE FROM appointment_series s WHERE NOT EXISTS (SELECT 1 FROM patient_users pu WHERE pu.patient_id = s.patient_id);
+UPDATE appointment_series s SET patient_id = (
+    SELECT pu.user_id FROM patient_users pu WHERE pu.patient_id = s.patient_id ORDER BY pu.relationship <> 'self', pu.user_id LIMIT 1
+);
+ALTER TABLE appointment_series RENAME COLUMN patient_id TO user_id;
+ALTER TABLE appointment_series
+    ADD CONSTRAINT appointment_series_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_patient_id_fkey;
+DROP INDEX IF EXISTS appointments_patient_id_idx;
+DELETE FROM appointments a WHERE NOT EXISTS (SELECT 1 FROM patient_users pu WHERE pu.patient_id = a.patient_id);
+UPDATE appointments a SET patient_id = (
+    SELECT pu.user_id FROM patient_users pu WHERE pu.patient_id = a.patient_id ORDER BY pu.relationship <> 'self', pu.user_id LIMIT 1
+);
+ALTER TABLE appointments RENAME COLUMN patient_id TO user_id;
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DROP TABLE IF EXISTS patient_users;
+DROP TABLE IF EXISTS patients;
+
+DELETE FROM permissions WHERE name = 'patient:manage';
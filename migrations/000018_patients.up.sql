@@ -0,0 +1,61 @@
+INSERT INTO permissions (name, description) VALUES
+    ('patient:manage', 'Create any patient and link or unlink their user accounts');
+
+INSERT INTO role_permissions (role, permission)
+SELECT name, 'patient:manage' FROM roles WHERE name IN ('admin', 'receptionist');
+
+-- A patient is who appointments are for. The users linked to a patient may act for them: the
+-- patient's own account ('self') and, for children or relatives in their care, the accounts of a
+-- parent, guardian or carer.
+CREATE TABLE patients (
+    id SERIAL PRIMARY KEY,
+    full_name VARCHAR(100) NOT NULL,
+    date_of_birth DATE,
+    sex VARCHAR(10) CHECK (sex IN ('female', 'male', 'other')),
+    emergency_contact_name VARCHAR(100) NOT NULL DEFAULT '',
+    emergency_contact_phone VARCHAR(20) NOT NULL DEFAULT '',
+    preferred_language VARCHAR(35) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE patient_users (
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    relationship VARCHAR(20) NOT NULL CHECK (relationship IN ('self', 'parent', 'guardian', 'carer', 'spouse', 'other')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (patient_id, user_id)
+);
+
+CREATE INDEX patient_users_user_id_idx ON patient_users (user_id);
+
+-- A user is at most one patient themselves, and a patient has at most one account of their own.
+CREATE UNIQUE INDEX patient_users_self_user_idx ON patient_users (user_id) WHERE relationship = 'self';
+CREATE UNIQUE INDEX patient_users_self_patient_idx ON patient_users (patient_id) WHERE relationship = 'self';
+
+-- Every existing user becomes a patient with the same id, so appointments keep pointing at them.
+INSERT INTO patients (id, full_name, created_at, updated_at)
+SELECT id, fullname, COALESCE(created_at, CURRENT_TIMESTAMP), COALESCE(updated_at, created_at, CURRENT_TIMESTAMP)
+FROM users;
+
+INSERT INTO patient_users (patient_id, user_id, relationship)
+SELECT id, id, 'self' FROM users;
+
+SELECT setval(pg_get_serial_sequence('patients', 'id'), COALESCE((SELECT MAX(id) FROM patients), 0) + 1, false);
+
+ALTER TABLE appointments RENAME COLUMN user_id TO patient_id;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_user_id_fkey;
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_patient_id_fkey FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE;
+CREATE INDEX appointments_patient_id_idx ON appointments (patient_id, appointment_time);
+
+ALTER TABLE appointment_series RENAME COLUMN user_id TO patient_id;
+ALTER TABLE appointment_series DROP CONSTRAINT IF EXISTS appointment_series_user_id_fkey;
+ALTER TABLE appointment_series
+    ADD CONSTRAINT appointment_series_patient_id_fkey FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE;
+
+ALTER TABLE waitlist_entries RENAME COLUMN user_id TO patient_id;
+ALTER TABLE waitlist_entries DROP CONSTRAINT IF EXISTS waitlist_entries_user_id_fkey;
+ALTER TABLE waitlist_entries
+    ADD CONSTRAINT waitlist_entries_patient_id_fkey FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE;
+CREATE INDEX waitlist_entries_patient_id_idx ON waitlist_entries (patient_id);
@@ -0,0 +1,8 @@
+INSERT INTO role_permissions (role, permission)
+SELECT name, permission FROM roles, (VALUES ('appointment:read:any'), ('appointment:status')) AS p (permission)
+WHERE name = 'doctor'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM permissions WHERE name = 'doctor:self';
+
+ALTER TABLE doctors DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,12 @@
+-- A doctor may sign in with a user account of their own; an account is at most one doctor.
+ALTER TABLE doctors ADD COLUMN user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE SET NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('doctor:self', 'Work one''s own agenda as a doctor: confirm, decline, start, complete, mark no-shows and block time');
+
+INSERT INTO role_permissions (role, permission)
+SELECT name, 'doctor:self' FROM roles WHERE name IN ('admin', 'doctor');
+
+-- Doctors reach their own appointments through their account now, not everyone's.
+DELETE FROM role_permissions
+WHERE role = 'doctor' AND permission IN ('appointment:read:any', 'appointment:status');
@@ -0,0 +1,36 @@
+ALTER TABLE waitlist_entries
+    DROP CONSTRAINT IF EXISTS waitlist_entries_check,
+    DROP CONSTRAINT IF EXISTS waitlist_entries_specialization_fkey,
+    ALTER COLUMN specialization TYPE VARCHAR(100);
+
+-- Entries go back to the specialization's English name, which doctors are given below too.
+UPDATE waitlist_entries e
+SET specialization = COALESCE(s.names->>'en', s.code)
+FROM specializations s
+WHERE s.code = e.specialization;
+
+UPDATE waitlist_entries SET specialization = '' WHERE specialization IS NULL;
+
+ALTER TABLE waitlist_entries
+    ALTER COLUMN specialization SET DEFAULT '',
+    ALTER COLUMN specialization SET NOT NULL,
+    ADD CONSTRAINT waitlist_entries_check CHECK (doctor_id IS NOT NULL OR specialization <> '');
+
+-- A doctor keeps their main specialization only.
+ALTER TABLE doctors ADD COLUMN specialization VARCHAR(100) NOT NULL DEFAULT '';
+
+UPDATE doctors d
+SET specialization = (
+    SELECT COALESCE(s.names->>'en', s.code)
+    FROM doctor_specializations ds
+    JOIN specializations s ON s.id = ds.specialization_id
+    WHERE ds.doctor_id = d.id
+    ORDER BY ds.position, s.code
+    LIMIT 1
+)
+WHERE EXISTS (SELECT 1 FROM doctor_specializations ds WHERE ds.doctor_id = d.id);
+
+ALTER TABLE doctors ALTER COLUMN specialization DROP DEFAULT;
+
+DROP TABLE IF EXISTS doctor_specializations;
+DROP TABLE IF EXISTS specializations;
@@ -0,0 +1,69 @@
+-- Specializations were free text on doctors, so 'Cardiology', 'cardiology' and 'Cardiologist'
+-- were three different things. They become one catalogue entry each, identified by a code, and
+-- a doctor may hold several.
+CREATE TABLE specializations (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE CHECK (code ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+    -- Names by language tag, such as {"en": "Cardiology", "de": "Kardiologie"}.
+    names JSONB NOT NULL DEFAULT '{}',
+    description TEXT NOT NULL DEFAULT '',
+    default_duration INTEGER NOT NULL DEFAULT 30 CHECK (default_duration > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE doctor_specializations (
+    doctor_id INTEGER NOT NULL REFERENCES doctors(id) ON DELETE CASCADE,
+    specialization_id INTEGER NOT NULL REFERENCES specializations(id),
+    -- The first specialization is the doctor's main one.
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (doctor_id, specialization_id)
+);
+
+CREATE INDEX doctor_specializations_specialization_id_idx ON doctor_specializations (specialization_id);
+
+-- The code of a free-text value: lower case, words joined by '-', and the specialist named by the
+-- field ('Cardiologist' is 'cardiology', 'Psychiatrist' is 'psychiatry').
+CREATE FUNCTION pg_temp.specialization_code(value TEXT) RETURNS TEXT AS $$
+    SELECT btrim(left(regexp_replace(
+        regexp_replace(regexp_replace(lower(btrim(value)), 'ologists?\M', 'ology', 'g'), 'iatrists?\M', 'iatry', 'g'),
+        '[^a-z0-9]+', '-', 'g'), 50), '-')
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Each code is named by the spelling most doctors used, preferring one that names the field over
+-- the specialist and a capitalized one over a lower-case one.
+WITH spellings AS (
+    SELECT btrim(specialization) AS name, pg_temp.specialization_code(specialization) AS code, COUNT(*) AS uses
+    FROM (
+        SELECT specialization FROM doctors
+        UNION ALL
+        SELECT specialization FROM waitlist_entries
+    ) v
+    GROUP BY 1, 2
+)
+INSERT INTO specializations (code, names)
+SELECT DISTINCT ON (code) code, jsonb_build_object('en', name)
+FROM spellings
+WHERE code <> ''
+ORDER BY code, btrim(regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g'), '-') = code DESC, uses DESC, name <> lower(name) DESC, name;
+
+INSERT INTO doctor_specializations (doctor_id, specialization_id)
+SELECT d.id, s.id
+FROM doctors d
+JOIN specializations s ON s.code = pg_temp.specialization_code(d.specialization);
+
+ALTER TABLE doctors DROP COLUMN specialization;
+
+-- Waitlist entries name the specialization by its code; entries for a doctor name none.
+ALTER TABLE waitlist_entries DROP CONSTRAINT waitlist_entries_check;
+ALTER TABLE waitlist_entries ALTER COLUMN specialization DROP NOT NULL, ALTER COLUMN specialization DROP DEFAULT;
+
+UPDATE waitlist_entries SET specialization = NULLIF(pg_temp.specialization_code(specialization), '');
+
+ALTER TABLE waitlist_entries
+    ALTER COLUMN specialization TYPE VARCHAR(50),
+    ADD CONSTRAINT waitlist_entries_specialization_fkey
+        FOREIGN KEY (specialization) REFERENCES specializations(code) ON UPDATE CASCADE,
+    ADD CONSTRAINT waitlist_entries_check CHECK (doctor_id IS NOT NULL OR specialization IS NOT NULL);
+
+DROP FUNCTION pg_temp.specialization_code(TEXT);
@@ -0,0 +1,34 @@
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_no_overlap;
+
+-- Without types a slot holds one patient: only the first booking of every shared slot is kept.
+UPDATE appointments a SET status = 'cancelled'
+WHERE a.capacity > 1
+  AND a.appointment_type_id IS NOT NULL
+  AND a.status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'held')
+  AND EXISTS (
+      SELECT 1 FROM appointments b
+      WHERE b.doctor_id = a.doctor_id
+        AND b.appointment_type_id = a.appointment_type_id
+        AND b.appointment_time = a.appointment_time
+        AND b.status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'held')
+        AND b.id < a.id
+  );
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        appointment_period(appointment_time, duration) WITH &&
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'held'));
+
+DROP FUNCTION IF EXISTS appointment_group(INTEGER, INTEGER, INTEGER, TIMESTAMPTZ);
+DROP FUNCTION IF EXISTS appointment_block(TIMESTAMPTZ, INTEGER, INTEGER, INTEGER);
+
+ALTER TABLE appointment_series DROP COLUMN IF EXISTS appointment_type_id;
+
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS appointment_type_id,
+    DROP COLUMN IF EXISTS buffer_before,
+    DROP COLUMN IF EXISTS buffer_after,
+    DROP COLUMN IF EXISTS capacity;
+
+DROP TABLE IF EXISTS appointment_types;
@@ -0,0 +1,54 @@
+-- Appointment types set the length of a visit, the time kept free around it and how many patients
+-- share one slot. A type belongs to a doctor or to every doctor of a specialization.
+CREATE TABLE appointment_types (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    doctor_id INTEGER REFERENCES doctors(id) ON DELETE CASCADE,
+    specialization_id INTEGER REFERENCES specializations(id),
+    duration INTEGER NOT NULL CHECK (duration > 0),
+    buffer_before INTEGER NOT NULL DEFAULT 0 CHECK (buffer_before >= 0),
+    buffer_after INTEGER NOT NULL DEFAULT 0 CHECK (buffer_after >= 0),
+    capacity INTEGER NOT NULL DEFAULT 1 CHECK (capacity > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((doctor_id IS NULL) <> (specialization_id IS NULL))
+);
+
+CREATE INDEX idx_appointment_types_doctor ON appointment_types(doctor_id);
+CREATE INDEX idx_appointment_types_specialization ON appointment_types(specialization_id);
+
+-- Appointments keep the buffers and capacity of their type as booked, so changing a type doesn't
+-- move existing bookings. Appointments booked before types existed have none.
+ALTER TABLE appointments
+    ADD COLUMN appointment_type_id INTEGER REFERENCES appointment_types(id) ON DELETE SET NULL,
+    ADD COLUMN buffer_before INTEGER NOT NULL DEFAULT 0 CHECK (buffer_before >= 0),
+    ADD COLUMN buffer_after INTEGER NOT NULL DEFAULT 0 CHECK (buffer_after >= 0),
+    ADD COLUMN capacity INTEGER NOT NULL DEFAULT 1 CHECK (capacity > 0);
+
+ALTER TABLE appointment_series
+    ADD COLUMN appointment_type_id INTEGER REFERENCES appointment_types(id) ON DELETE SET NULL;
+
+-- The doctor's time an appointment takes, buffers included.
+CREATE FUNCTION appointment_block(start_at TIMESTAMPTZ, minutes INTEGER, buffer_before INTEGER, buffer_after INTEGER) RETURNS TSTZRANGE
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT tstzrange(start_at - buffer_before * INTERVAL '1 minute', start_at + (minutes + buffer_after) * INTERVAL '1 minute') $$;
+
+-- Appointments of a type with room for several patients share their slot with the others of the
+-- same type at the same time; every other appointment is a group of its own.
+CREATE FUNCTION appointment_group(id INTEGER, type_id INTEGER, capacity INTEGER, start_at TIMESTAMPTZ) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT CASE
+        WHEN capacity > 1 AND type_id IS NOT NULL THEN 'type ' || type_id || ' at ' || extract(epoch FROM start_at)
+        ELSE 'appointment ' || id
+    END $$;
+
+-- Active appointments of a doctor may only share time, buffers included, with the rest of their
+-- group. How many fit in a group is checked when booking.
+ALTER TABLE appointments DROP CONSTRAINT appointments_no_overlap;
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        appointment_block(appointment_time, duration, buffer_before, buffer_after) WITH &&,
+        appointment_group(id, appointment_type_id, capacity, appointment_time) WITH <>
+    ) WHERE (status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'held'));
@@ -0,0 +1,13 @@
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_room_no_overlap;
+
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS location_id,
+    DROP COLUMN IF EXISTS room_id;
+
+ALTER TABLE doctor_schedule_rules
+    DROP COLUMN IF EXISTS location_id,
+    DROP COLUMN IF EXISTS room_id;
+
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS locations;
+DROP TABLE IF EXISTS clinics;
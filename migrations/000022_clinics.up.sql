@@ -0,0 +1,52 @@
+-- A clinic runs one or more locations, each with its own rooms.
+CREATE TABLE clinics (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE locations (
+    id SERIAL PRIMARY KEY,
+    clinic_id INTEGER NOT NULL REFERENCES clinics(id),
+    name VARCHAR(100) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE rooms (
+    id SERIAL PRIMARY KEY,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_locations_clinic ON locations(clinic_id);
+CREATE INDEX idx_rooms_location ON rooms(location_id);
+
+-- A doctor works at a location, and optionally in one of its rooms, on the days of a schedule
+-- rule. Rules of older schedule versions lose them when the location or room is removed.
+ALTER TABLE doctor_schedule_rules
+    ADD COLUMN location_id INTEGER REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN room_id INTEGER REFERENCES rooms(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_doctor_schedule_rules_location ON doctor_schedule_rules(location_id);
+CREATE INDEX idx_doctor_schedule_rules_room ON doctor_schedule_rules(room_id);
+
+-- Appointments keep the location and room of the rule of their day as booked.
+ALTER TABLE appointments
+    ADD COLUMN location_id INTEGER REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN room_id INTEGER REFERENCES rooms(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_appointments_location ON appointments(location_id);
+
+-- Two doctors' active appointments may not share a room, buffers included. Appointments of one
+-- doctor are kept apart by appointments_no_overlap.
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_room_no_overlap EXCLUDE USING gist (
+        room_id WITH =,
+        appointment_block(appointment_time, duration, buffer_before, buffer_after) WITH &&,
+        doctor_id WITH <>
+    ) WHERE (room_id IS NOT NULL AND status IN ('scheduled', 'confirmed', 'checked_in', 'in_progress', 'held'));
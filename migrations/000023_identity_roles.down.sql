@@ -0,0 +1 @@
+ALTER TABLE user_identities DROP COLUMN IF EXISTS role_from_groups;
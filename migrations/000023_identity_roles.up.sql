@@ -0,0 +1,3 @@
+-- Whether the user's role came from their provider groups. Only those roles follow the groups on
+-- later sign-ins; roles set here, and those of accounts linked before this column, stay put.
+ALTER TABLE user_identities ADD COLUMN role_from_groups BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+ALTER TABLE appointment_reschedules
+    DROP COLUMN IF EXISTS previous_location_id,
+    DROP COLUMN IF EXISTS previous_room_id,
+    DROP COLUMN IF EXISTS location_id,
+    DROP COLUMN IF EXISTS room_id;
@@ -0,0 +1,7 @@
+-- Reschedules record the location and room the appointment was at and moved to, like its doctor
+-- and time. Rows from before this migration have none.
+ALTER TABLE appointment_reschedules
+    ADD COLUMN previous_location_id INTEGER REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN previous_room_id INTEGER REFERENCES rooms(id) ON DELETE SET NULL,
+    ADD COLUMN location_id INTEGER REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN room_id INTEGER REFERENCES rooms(id) ON DELETE SET NULL;
@@ -22,6 +22,20 @@ func Prefork(prefork bool) Option {
 	}
 }
 
+// ProxyHeader -.
+func ProxyHeader(header string) Option {
+	return func(s *Server) {
+		s.proxyHeader = header
+	}
+}
+
+// TrustedProxies -.
+func TrustedProxies(proxies []string) Option {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
 // ReadTimeout -.
 func ReadTimeout(timeout time.Duration) Option {
 	return func(s *Server) {
@@ -22,6 +22,8 @@ type Server struct {
 
 	address         string
 	prefork         bool
+	proxyHeader     string
+	trustedProxies  []string
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
 	shutdownTimeout time.Duration
@@ -49,6 +51,10 @@ func New(opts ...Option) *Server {
 		WriteTimeout: s.writeTimeout,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
+		// The proxy header is only believed from trusted proxies; with none, it is ignored.
+		ProxyHeader:             s.proxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          s.trustedProxies,
 	})
 
 	s.App = app
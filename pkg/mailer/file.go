@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// File - appends every message to a file instead of sending it.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile -.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Send -.
+func (f *File) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("mailer - File - Send - os.OpenFile: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("mailer - File - Send - fmt.Fprintf: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/dostonshernazarov/doctor-appointment/pkg/logger"
+)
+
+// Log - logs every message instead of sending it.
+type Log struct {
+	l logger.Interface
+}
+
+// NewLog -.
+func NewLog(l logger.Interface) *Log {
+	return &Log{l: l}
+}
+
+// Send -.
+func (m *Log) Send(_ context.Context, msg Message) error {
+	m.l.Info("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+
+	return nil
+}
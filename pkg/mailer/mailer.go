@@ -0,0 +1,16 @@
+// Package mailer sends email through SMTP, or writes it to a file or log for local development.
+package mailer
+
+import "context"
+
+// Message - a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer -.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
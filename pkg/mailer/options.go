@@ -0,0 +1,12 @@
+package mailer
+
+// SMTPOption -.
+type SMTPOption func(*SMTP)
+
+// Auth -.
+func Auth(username, password string) SMTPOption {
+	return func(s *SMTP) {
+		s.username = username
+		s.password = password
+	}
+}
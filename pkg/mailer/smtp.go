@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTP - sends mail through an SMTP server, using STARTTLS when the server offers it.
+type SMTP struct {
+	address  string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTP -.
+func NewSMTP(host, port, from string, opts ...SMTPOption) *SMTP {
+	s := &SMTP{
+		address: net.JoinHostPort(host, port),
+		host:    host,
+		from:    from,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Send -.
+func (s *SMTP) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	err := smtp.SendMail(s.address, auth, s.from, []string{msg.To}, s.format(msg))
+	if err != nil {
+		return fmt.Errorf("mailer - SMTP - Send - smtp.SendMail: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SMTP) format(msg Message) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + s.from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
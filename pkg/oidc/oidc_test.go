@@ -0,0 +1,185 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/dostonshernazarov/doctor-appointment/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	_clientID     = "clinic"
+	_clientSecret = "s3cret"
+	_redirectURL  = "http://localhost:8070/v1/auth/oidc/callback"
+)
+
+func newTestProvider(t *testing.T) *oidctest.Provider {
+	t.Helper()
+
+	idp := oidctest.NewProvider(_clientID, _clientSecret)
+	t.Cleanup(idp.Close)
+
+	return idp
+}
+
+func TestSignIn(t *testing.T) {
+	idp := newTestProvider(t)
+	idp.SignInAs(map[string]any{
+		"sub":            "staff-42",
+		"email":          "jane@hospital.example",
+		"email_verified": true,
+		"name":           "Jane Doe",
+		"roles":          []string{"physicians", "staff"},
+	})
+
+	p := New(idp.URL, _clientID, _clientSecret, _redirectURL, GroupsClaim("roles"))
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallenge(verifier))
+	require.NoError(t, err)
+
+	code, state, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	idToken, err := p.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	claims, err := p.VerifyIDToken(ctx, idToken, "nonce-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, Claims{
+		Issuer:        idp.URL,
+		Subject:       "staff-42",
+		Email:         "jane@hospital.example",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+		Groups:        []string{"physicians", "staff"},
+	}, claims)
+}
+
+func TestExchange(t *testing.T) {
+	idp := newTestProvider(t)
+	idp.SignInAs(map[string]any{"sub": "staff-42"})
+	ctx := context.Background()
+
+	authorize := func(p *Provider) (string, string) {
+		verifier, err := NewCodeVerifier()
+		require.NoError(t, err)
+
+		authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallenge(verifier))
+		require.NoError(t, err)
+
+		code, _, err := idp.Authorize(authURL)
+		require.NoError(t, err)
+
+		return code, verifier
+	}
+
+	t.Run("another verifier", func(t *testing.T) {
+		p := New(idp.URL, _clientID, _clientSecret, _redirectURL)
+		code, _ := authorize(p)
+
+		other, err := NewCodeVerifier()
+		require.NoError(t, err)
+
+		_, err = p.Exchange(ctx, code, other)
+		assert.ErrorContains(t, err, "invalid_grant")
+	})
+
+	t.Run("code used twice", func(t *testing.T) {
+		p := New(idp.URL, _clientID, _clientSecret, _redirectURL)
+		code, verifier := authorize(p)
+
+		_, err := p.Exchange(ctx, code, verifier)
+		require.NoError(t, err)
+
+		_, err = p.Exchange(ctx, code, verifier)
+		assert.ErrorContains(t, err, "invalid_grant")
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		p := New(idp.URL, _clientID, "wrong", _redirectURL)
+		code, verifier := authorize(p)
+
+		_, err := p.Exchange(ctx, code, verifier)
+		assert.ErrorContains(t, err, "invalid_client")
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newTestProvider(t)
+	p := New(idp.URL, _clientID, _clientSecret, _redirectURL)
+	ctx := context.Background()
+
+	with := func(k string, v any) map[string]any {
+		claims := idp.IDTokenClaims("nonce-1")
+		claims["sub"] = "staff-42"
+		claims[k] = v
+
+		return claims
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	foreign := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(with("sub", "staff-42")))
+	foreign.Header["kid"] = "oidctest-1"
+	foreignToken, err := foreign.SignedString(otherKey)
+	require.NoError(t, err)
+
+	symmetricToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(with("sub", "staff-42"))).
+		SignedString([]byte(_clientSecret))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"valid", idp.Sign(with("sub", "staff-42")), true},
+		{"audience list", idp.Sign(with("aud", []string{_clientID})), true},
+		{"another issuer", idp.Sign(with("iss", "https://idp.example")), false},
+		{"another client", idp.Sign(with("aud", "other")), false},
+		{"another authorized party", idp.Sign(with("aud", []string{_clientID, "other"})), false},
+		{"expired", idp.Sign(with("exp", time.Now().Add(-time.Hour).Unix())), false},
+		{"issued in the future", idp.Sign(with("iat", time.Now().Add(time.Hour).Unix())), false},
+		{"wrong nonce", idp.Sign(with("nonce", "nonce-2")), false},
+		{"no subject", idp.Sign(with("sub", "")), false},
+		{"signed with another key", foreignToken, false},
+		{"signed with the client secret", symmetricToken, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(ctx, tt.token, "nonce-1")
+
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newTestProvider(t)
+
+	_, err := New(idp.URL+"/tenant", _clientID, _clientSecret, _redirectURL).AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.Error(t, err)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636, appendix B.
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
@@ -0,0 +1,221 @@
+// Package oidctest provides an OpenID Connect provider for tests, in the manner of httptest.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Provider - an OpenID Connect provider that signs in whoever it is told to, checking the client,
+// redirect URL and PKCE verifier the way a real one would.
+type Provider struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	key *rsa.PrivateKey
+	kid string
+
+	mu     sync.Mutex
+	codes  map[string]authorization
+	claims jwt.MapClaims
+	next   int
+}
+
+type authorization struct {
+	redirectURL, nonce, challenge string
+	claims                        jwt.MapClaims
+}
+
+// NewProvider starts a provider for the client. The caller should call Close when finished.
+func NewProvider(clientID, clientSecret string) *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: generating a key: %v", err))
+	}
+
+	p := &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		kid:          "oidctest-1",
+		codes:        map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+
+	p.Server = httptest.NewServer(mux)
+
+	return p
+}
+
+// SignInAs sets the claims, such as sub, email and groups, of the user the provider signs in.
+func (p *Provider) SignInAs(claims map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.claims = claims
+}
+
+// Authorize follows the authorization URL as the user's browser would and returns the code and
+// state the provider redirects back with.
+func (p *Provider) Authorize(authURL string) (code, state string, err error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("oidctest: authorization failed with status %d", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return callback.Query().Get("code"), callback.Query().Get("state"), nil
+}
+
+// Sign returns an ID token with the claims, signed with the provider's key.
+func (p *Provider) Sign(claims map[string]any) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	token.Header["kid"] = p.kid
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: signing a token: %v", err))
+	}
+
+	return signed
+}
+
+// IDTokenClaims returns the claims of a valid ID token for the client with the nonce, which the
+// caller may change before signing it.
+func (p *Provider) IDTokenClaims(nonce string) map[string]any {
+	now := time.Now()
+
+	return map[string]any{
+		"iss":   p.URL,
+		"aud":   p.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/keys",
+	})
+}
+
+func (p *Provider) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": p.kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if q.Get("response_type") != "code" || q.Get("client_id") != p.ClientID || q.Get("redirect_uri") == "" ||
+		q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+
+		return
+	}
+
+	p.mu.Lock()
+	p.next++
+	code := fmt.Sprintf("code-%d", p.next)
+	p.codes[code] = authorization{
+		redirectURL: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		claims:      p.claims,
+	}
+	p.mu.Unlock()
+
+	redirect := q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+
+	if id != p.ClientID || secret != p.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+
+		return
+	}
+
+	code := r.PostFormValue("code")
+
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != auth.redirectURL {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+
+		return
+	}
+
+	claims := p.IDTokenClaims(auth.nonce)
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "oidctest-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.Sign(claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,27 @@
+package oidc
+
+import "net/http"
+
+// Option -.
+type Option func(*Provider)
+
+// Scopes replaces the requested scopes, openid, email and profile by default.
+func Scopes(scopes ...string) Option {
+	return func(p *Provider) {
+		p.scopes = scopes
+	}
+}
+
+// GroupsClaim sets the ID token claim that lists the user's groups, groups by default.
+func GroupsClaim(name string) Option {
+	return func(p *Provider) {
+		p.groupsClaim = name
+	}
+}
+
+// HTTPClient -.
+func HTTPClient(c *http.Client) Option {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
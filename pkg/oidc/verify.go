@@ -0,0 +1,303 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// _signingMethods are the algorithms ID tokens are accepted with. Symmetric ones are left out on
+// purpose: the client secret must not be usable to forge a token.
+var _signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384"}
+
+// rawClaims keeps the claims as JSON so the groups claim can be read by whatever name it has.
+// Registered claims are checked in VerifyIDToken, with leeway for clock skew.
+type rawClaims map[string]json.RawMessage
+
+func (rawClaims) Valid() error {
+	return nil
+}
+
+type idTokenClaims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce"`
+	Email           string   `json:"email"`
+	EmailVerified   bool     `json:"email_verified"`
+	Name            string   `json:"name"`
+}
+
+// audience - the aud claim, a string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+
+	*a = many
+
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, aud := range a {
+		if aud == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyIDToken checks the ID token's signature against the provider's keys, that it was issued
+// by the provider for this client and hasn't expired, and that it carries the nonce the sign-in
+// started with. Errors wrap ErrInvalidIDToken.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	parser := &jwt.Parser{ValidMethods: _signingMethods}
+	claims := rawClaims{}
+
+	_, err = parser.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key, err := p.key(ctx, d.JWKSURI, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA:
+			if _, ok := key.(*rsa.PublicKey); ok {
+				return key, nil
+			}
+		case *jwt.SigningMethodECDSA:
+			if _, ok := key.(*ecdsa.PublicKey); ok {
+				return key, nil
+			}
+		}
+
+		return nil, fmt.Errorf("key %q does not match algorithm %s", kid, t.Method.Alg())
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var std idTokenClaims
+
+	b, err := json.Marshal(claims)
+	if err == nil {
+		err = json.Unmarshal(b, &std)
+	}
+
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	now := time.Now()
+
+	switch {
+	case strings.TrimSuffix(std.Issuer, "/") != p.issuer:
+		return Claims{}, fmt.Errorf("%w: issued by %q", ErrInvalidIDToken, std.Issuer)
+	case !std.Audience.contains(p.clientID):
+		return Claims{}, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	case len(std.Audience) > 1 && std.AuthorizedParty != p.clientID:
+		return Claims{}, fmt.Errorf("%w: authorized party %q", ErrInvalidIDToken, std.AuthorizedParty)
+	case std.ExpiresAt == 0 || now.Add(-_defaultLeeway).After(time.Unix(std.ExpiresAt, 0)):
+		return Claims{}, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case now.Add(_defaultLeeway).Before(time.Unix(std.IssuedAt, 0)):
+		return Claims{}, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	case std.Nonce == "" || std.Nonce != nonce:
+		return Claims{}, fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	case std.Subject == "":
+		return Claims{}, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	return Claims{
+		Issuer:        p.issuer,
+		Subject:       std.Subject,
+		Email:         std.Email,
+		EmailVerified: std.EmailVerified,
+		Name:          std.Name,
+		Groups:        groups(claims[p.groupsClaim]),
+	}, nil
+}
+
+// groups reads a groups claim given as an array of strings or a single string.
+func groups(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(raw, &many); err == nil {
+		return many
+	}
+
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil && one != "" {
+		return []string{one}
+	}
+
+	return nil
+}
+
+// key returns the provider's public key with the id, fetching the keys again if it's unknown,
+// as the provider may have rotated them.
+func (p *Provider) key(ctx context.Context, jwksURI, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	keys, err := p.fetchKeys(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = keys
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	// A provider with a single key may leave kid out.
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("oidc - fetchKeys - http.NewRequestWithContext: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("oidc - fetchKeys - p.do: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc - fetchKeys - status %d", status)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// _minRSABits is the smallest RSA modulus accepted for signing or verification.
+const _minRSABits = 2048
+
+var (
+	// ErrUnknownKey is returned for a token whose kid isn't in the key set.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrUnsupportedKey is returned for PEM blocks that aren't RSA or Ed25519 keys.
+	ErrUnsupportedKey = errors.New("unsupported key: use RSA (at least 2048 bits) or Ed25519")
+)
+
+// Key - a key tokens are verified with and, if Private is set, signed with.
+// RSA keys use RS256, Ed25519 keys EdDSA and HMAC secrets HS256.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// NewHMACKey returns a shared secret key. It is never published in the JWKS.
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+}
+
+// NewKey returns the key for an RSA or Ed25519 private or public key.
+func NewKey(id string, key any) (Key, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < _minRSABits {
+			return Key{}, ErrUnsupportedKey
+		}
+
+		return Key{ID: id, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < _minRSABits {
+			return Key{}, ErrUnsupportedKey
+		}
+
+		return Key{ID: id, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return Key{ID: id, Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return Key{ID: id, Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	default:
+		return Key{}, ErrUnsupportedKey
+	}
+}
+
+// LoadKey reads an RSA or Ed25519 key from a PEM file: a private key (PKCS #1 or #8), which can
+// sign, or a public key (PKIX), which only verifies.
+func LoadKey(id, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("auth - LoadKey - os.ReadFile: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("auth - LoadKey: %s: no PEM block", path)
+	}
+
+	var key any
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("auth - LoadKey: %s: %w", path, ErrUnsupportedKey)
+	}
+
+	if err != nil {
+		return Key{}, fmt.Errorf("auth - LoadKey: %s: %w", path, err)
+	}
+
+	k, err := NewKey(id, key)
+	if err != nil {
+		return Key{}, fmt.Errorf("auth - LoadKey: %s: %w", path, err)
+	}
+
+	return k, nil
+}
+
+// KeySet - the keys tokens are verified with, one of which signs new tokens. Keeping the
+// previous keys in the set lets tokens they signed run out while a new key takes over.
+type KeySet struct {
+	signing Key
+	keys    map[string]Key
+}
+
+// NewKeySet returns the set of keys, signing with the one whose ID is signingKeyID.
+func NewKeySet(signingKeyID string, keys ...Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]Key, len(keys))}
+
+	for _, key := range keys {
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("auth - NewKeySet: duplicate key id %q", key.ID)
+		}
+
+		set.keys[key.ID] = key
+	}
+
+	signing, ok := set.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("auth - NewKeySet: signing key %q: %w", signingKeyID, ErrUnknownKey)
+	}
+
+	if signing.Private == nil {
+		return nil, fmt.Errorf("auth - NewKeySet: signing key %q has no private key", signingKeyID)
+	}
+
+	set.signing = signing
+
+	return set, nil
+}
+
+// LoadKeySet loads the PEM files in paths, keyed by key id. See LoadKey.
+func LoadKeySet(signingKeyID string, paths map[string]string) (*KeySet, error) {
+	keys := make([]Key, 0, len(paths))
+
+	for id, path := range paths {
+		key, err := LoadKey(id, path)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(signingKeyID, keys...)
+}
+
+// JWK - a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS - a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, sorted by id. Shared secrets are left out.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range s.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+
+	return jwks
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const _opaqueTokenBytes = 32
+
+// GenerateOpaqueToken returns a random token for refresh, password reset and similar links.
+// Only its HashOpaqueToken should be stored.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, _opaqueTokenBytes)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("auth - GenerateOpaqueToken - rand.Read: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken returns the hex SHA-256 of the token.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -8,15 +8,20 @@ import (
 )
 
 type Claims struct {
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	UserID    int    `json:"user_id"`
+	SessionID int    `json:"sid"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
 	jwt.StandardClaims
 }
 
-func GenerateJWTToken(email, role, secret string, expiration time.Duration) (string, error) {
+// GenerateJWTToken signs an access token with the signing key, naming it in the kid header.
+func (s *KeySet) GenerateJWTToken(userID, sessionID int, email, role string, expiration time.Duration) (string, error) {
 	claims := &Claims{
-		Email: email,
-		Role:  role,
+		UserID:    userID,
+		SessionID: sessionID,
+		Email:     email,
+		Role:      role,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(expiration).Unix(),
 			IssuedAt:  time.Now().Unix(),
@@ -24,16 +29,28 @@ func GenerateJWTToken(email, role, secret string, expiration time.Duration) (str
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+
+	return token.SignedString(s.signing.Private)
 }
 
-func ParseToken(tokenString, secret string) (*Claims, error) {
+// ParseToken verifies the token with the key its kid header names. The key also fixes the
+// algorithm, so a token can't pick a weaker one.
+func (s *KeySet) ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
+
+		return key.Public, nil
 	})
 
 	if err != nil {
@@ -0,0 +1,27 @@
+package totp
+
+import "time"
+
+// Option -.
+type Option func(*Generator)
+
+// Digits -.
+func Digits(n int) Option {
+	return func(g *Generator) {
+		g.digits = n
+	}
+}
+
+// Period -.
+func Period(d time.Duration) Option {
+	return func(g *Generator) {
+		g.period = d
+	}
+}
+
+// WithAlgorithm -.
+func WithAlgorithm(a Algorithm) Option {
+	return func(g *Generator) {
+		g.algorithm = a
+	}
+}
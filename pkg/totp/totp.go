@@ -0,0 +1,179 @@
+// Package totp implements time-based one-time passwords (RFC 6238) as used by authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // HMAC-SHA1 is what RFC 6238 and authenticator apps default to
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Algorithm - the HMAC hash codes are computed with.
+type Algorithm string
+
+// Algorithms.
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+const (
+	_defaultDigits    = 6
+	_defaultPeriod    = 30 * time.Second
+	_defaultAlgorithm = SHA1
+
+	_secretBytes = 20
+)
+
+// _encoding is the unpadded base32 authenticator apps expect secrets in.
+var _encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Generator -.
+type Generator struct {
+	digits    int
+	period    time.Duration
+	algorithm Algorithm
+}
+
+// New returns a generator of 6-digit HMAC-SHA1 codes changing every 30 seconds, unless told otherwise.
+func New(opts ...Option) *Generator {
+	g := &Generator{
+		digits:    _defaultDigits,
+		period:    _defaultPeriod,
+		algorithm: _defaultAlgorithm,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// GenerateSecret returns a random 160-bit secret, base32 encoded.
+func GenerateSecret() (string, error) {
+	b := make([]byte, _secretBytes)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("totp - GenerateSecret - rand.Read: %w", err)
+	}
+
+	return _encoding.EncodeToString(b), nil
+}
+
+// Step returns the number of periods between the Unix epoch and t.
+func (g *Generator) Step(t time.Time) int64 {
+	return t.Unix() / int64(g.period/time.Second)
+}
+
+// Code returns the code for the base32 secret at t.
+func (g *Generator) Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return g.code(key, g.Step(t))
+}
+
+// Validate checks the code against the base32 secret at t and up to skew periods either side,
+// allowing for clock drift. It returns the step the code belongs to, so callers can refuse
+// codes that were used before.
+func (g *Generator) Validate(code, secret string, t time.Time, skew int) (int64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != g.digits {
+		return 0, false
+	}
+
+	now := g.Step(t)
+	for i := -int64(skew); i <= int64(skew); i++ {
+		want, err := g.code(key, now+i)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return now + i, true
+		}
+	}
+
+	return 0, false
+}
+
+// URI returns the otpauth:// URI authenticator apps enroll from, usually shown as a QR code.
+func (g *Generator) URI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", string(g.algorithm))
+	query.Set("digits", strconv.Itoa(g.digits))
+	query.Set("period", strconv.Itoa(int(g.period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// code computes the HOTP value (RFC 4226) of the key for the counter.
+func (g *Generator) code(key []byte, counter int64) (string, error) {
+	newHash, err := g.hash()
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(newHash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < g.digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", g.digits, value%mod), nil
+}
+
+func (g *Generator) hash() (func() hash.Hash, error) {
+	switch g.algorithm {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("totp - unsupported algorithm %q", g.algorithm)
+	}
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := _encoding.DecodeString(strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "="))
+	if err != nil {
+		return nil, fmt.Errorf("totp - decodeSecret: %w", err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,84 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The test vectors of RFC 6238, appendix B.
+func TestCodeRFC6238(t *testing.T) {
+	secrets := map[Algorithm]string{
+		SHA1:   "12345678901234567890",
+		SHA256: "12345678901234567890123456789012",
+		SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	tests := []struct {
+		unix int64
+		want map[Algorithm]string
+	}{
+		{59, map[Algorithm]string{SHA1: "94287082", SHA256: "46119246", SHA512: "90693936"}},
+		{1111111109, map[Algorithm]string{SHA1: "07081804", SHA256: "68084774", SHA512: "25091201"}},
+		{1111111111, map[Algorithm]string{SHA1: "14050471", SHA256: "67062674", SHA512: "99943326"}},
+		{1234567890, map[Algorithm]string{SHA1: "89005924", SHA256: "91819424", SHA512: "93441116"}},
+		{2000000000, map[Algorithm]string{SHA1: "69279037", SHA256: "90698825", SHA512: "38618901"}},
+		{20000000000, map[Algorithm]string{SHA1: "65353130", SHA256: "77737706", SHA512: "47863826"}},
+	}
+
+	for _, tt := range tests {
+		for algorithm, want := range tt.want {
+			g := New(Digits(8), WithAlgorithm(algorithm))
+			secret := base32.StdEncoding.EncodeToString([]byte(secrets[algorithm]))
+
+			got, err := g.Code(secret, time.Unix(tt.unix, 0))
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "%s at %d", algorithm, tt.unix)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	g := New()
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := g.Code(secret, now)
+	require.NoError(t, err)
+	assert.Equal(t, "050471", code)
+
+	step, ok := g.Validate(code, secret, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, g.Step(now), step)
+
+	step, ok = g.Validate(code, secret, now.Add(30*time.Second), 1)
+	assert.True(t, ok, "previous period within skew")
+	assert.Equal(t, g.Step(now), step)
+
+	_, ok = g.Validate(code, secret, now.Add(90*time.Second), 1)
+	assert.False(t, ok, "outside skew")
+
+	_, ok = g.Validate("000000", secret, now, 1)
+	assert.False(t, ok)
+
+	_, ok = g.Validate("05047", secret, now, 1)
+	assert.False(t, ok, "wrong length")
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	_, err = New().Code(secret, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	got := New().URI("Clinic", "jane@example.com", "JBSWY3DPEHPK3PXP")
+
+	assert.Equal(t, "otpauth://totp/Clinic:jane@example.com?algorithm=SHA1&digits=6&issuer=Clinic&period=30&secret=JBSWY3DPEHPK3PXP", got)
+}